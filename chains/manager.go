@@ -211,6 +211,9 @@ type ManagerConfig struct {
 
 	FrontierPollFrequency   time.Duration
 	ConsensusAppConcurrency int
+	// Max number of bytes of blocks with unknown parents that each snowman
+	// chain keeps pending issuance. If 0, the bytes are not limited.
+	ConsensusMaxOrphanBytes int
 
 	// Max Time to spend fetching a container and its
 	// ancestors when responding to a GetAncestors
@@ -824,6 +827,7 @@ func (m *manager) createAvalancheChain(
 		ConnectedValidators: connectedValidators,
 		Params:              consensusParams,
 		Consensus:           snowmanConsensus,
+		MaxOrphanBytes:      m.ConsensusMaxOrphanBytes,
 	}
 	var snowmanEngine common.Engine
 	snowmanEngine, err = smeng.New(snowmanEngineConfig)
@@ -1170,6 +1174,7 @@ func (m *manager) createSnowmanChain(
 		Params:              consensusParams,
 		Consensus:           consensus,
 		PartialSync:         m.PartialSyncPrimaryNetwork && ctx.ChainID == constants.PlatformChainID,
+		MaxOrphanBytes:      m.ConsensusMaxOrphanBytes,
 	}
 	var engine common.Engine
 	engine, err = smeng.New(engineConfig)
@@ -1219,6 +1219,8 @@ func GetNodeConfig(v *viper.Viper) (node.Config, error) {
 	if nodeConfig.ConsensusAppConcurrency <= 0 {
 		return node.Config{}, fmt.Errorf("%s must be > 0", ConsensusAppConcurrencyKey)
 	}
+	nodeConfig.ConsensusGossipVerificationConcurrency = int(v.GetUint(ConsensusGossipVerificationConcurrencyKey))
+	nodeConfig.ConsensusMaxOrphanBytes = int(v.GetUint(ConsensusMaxOrphanBytesKey))
 
 	nodeConfig.UseCurrentHeight = v.GetBool(ProposerVMUseCurrentHeightKey)
 
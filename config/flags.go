@@ -181,6 +181,8 @@ func addNodeFlags(fs *pflag.FlagSet) {
 
 	// Router
 	fs.Uint(ConsensusAppConcurrencyKey, constants.DefaultConsensusAppConcurrency, "Maximum number of goroutines to use when handling App messages on a chain")
+	fs.Uint(ConsensusGossipVerificationConcurrencyKey, constants.DefaultConsensusGossipVerificationConcurrency, "Maximum number of gossiped transactions to verify concurrently across all chains. If 0, verification is not limited")
+	fs.Uint(ConsensusMaxOrphanBytesKey, constants.DefaultConsensusMaxOrphanBytes, "Maximum number of bytes of blocks with unknown parents that are kept pending issuance on a chain. Once exceeded, the oldest are dropped. If 0, the bytes are not limited")
 	fs.Duration(ConsensusShutdownTimeoutKey, constants.DefaultConsensusShutdownTimeout, "Timeout before killing an unresponsive chain")
 	fs.Duration(ConsensusFrontierPollFrequencyKey, constants.DefaultFrontierPollFrequency, "Frequency of polling for new consensus frontiers")
 
@@ -137,6 +137,8 @@ const (
 	HealthAPIEnabledKey                                = "api-health-enabled"
 	MeterVMsEnabledKey                                 = "meter-vms-enabled"
 	ConsensusAppConcurrencyKey                         = "consensus-app-concurrency"
+	ConsensusGossipVerificationConcurrencyKey          = "consensus-gossip-verification-concurrency"
+	ConsensusMaxOrphanBytesKey                         = "consensus-max-orphan-bytes"
 	ConsensusShutdownTimeoutKey                        = "consensus-shutdown-timeout"
 	ConsensusFrontierPollFrequencyKey                  = "consensus-frontier-poll-frequency"
 	ProposerVMUseCurrentHeightKey                      = "proposervm-use-current-height"
@@ -0,0 +1,138 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package p2p
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	capturedAppGossip byte = iota
+	capturedAppRequest
+
+	// capturedHeaderLen is the length of the header preceding the bytes of
+	// a captured message: the message type, the sending node, and the
+	// length of the message.
+	capturedHeaderLen = 1 + ids.NodeIDLen + 4
+)
+
+var (
+	_ Handler = (*CaptureHandler)(nil)
+
+	ErrInvalidCapture = errors.New("invalid capture")
+)
+
+// NewCaptureHandler returns a handler that records the raw bytes of every
+// inbound AppGossip and AppRequest message to [w] before passing the message
+// to [handler]. Captured messages can be fed back into a handler with Replay.
+//
+// Each message is written as a 1 byte message type, the ID of the sending
+// node, a 4 byte big-endian length, and the message bytes.
+func NewCaptureHandler(handler Handler, w io.Writer, log logging.Logger) *CaptureHandler {
+	return &CaptureHandler{
+		handler: handler,
+		w:       w,
+		log:     log,
+	}
+}
+
+type CaptureHandler struct {
+	handler Handler
+	log     logging.Logger
+
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (c *CaptureHandler) AppGossip(ctx context.Context, nodeID ids.NodeID, gossipBytes []byte) {
+	c.capture(capturedAppGossip, nodeID, gossipBytes)
+	c.handler.AppGossip(ctx, nodeID, gossipBytes)
+}
+
+func (c *CaptureHandler) AppRequest(ctx context.Context, nodeID ids.NodeID, deadline time.Time, requestBytes []byte) ([]byte, error) {
+	c.capture(capturedAppRequest, nodeID, requestBytes)
+	return c.handler.AppRequest(ctx, nodeID, deadline, requestBytes)
+}
+
+func (c *CaptureHandler) CrossChainAppRequest(ctx context.Context, chainID ids.ID, deadline time.Time, requestBytes []byte) ([]byte, error) {
+	return c.handler.CrossChainAppRequest(ctx, chainID, deadline, requestBytes)
+}
+
+// capture writes a message to the capture. Failing to capture a message does
+// not prevent it from being handled.
+func (c *CaptureHandler) capture(messageType byte, nodeID ids.NodeID, msgBytes []byte) {
+	record := make([]byte, capturedHeaderLen, capturedHeaderLen+len(msgBytes))
+	record[0] = messageType
+	copy(record[1:], nodeID[:])
+	binary.BigEndian.PutUint32(record[1+ids.NodeIDLen:], uint32(len(msgBytes)))
+	record = append(record, msgBytes...)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, err := c.w.Write(record); err != nil {
+		c.log.Warn("failed to capture message",
+			zap.Stringer("nodeID", nodeID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Replay feeds the messages captured by a CaptureHandler from [r] into
+// [handler] in the order they were captured. Requests are handled with the
+// deadline of [ctx], if any, and their responses are discarded.
+//
+// Returns the number of messages that were replayed.
+func Replay(ctx context.Context, r io.Reader, handler Handler) (int, error) {
+	var (
+		deadline, _ = ctx.Deadline()
+		header      [capturedHeaderLen]byte
+		numReplayed int
+	)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return numReplayed, nil
+			}
+			return numReplayed, fmt.Errorf("%w: failed to read header: %w", ErrInvalidCapture, err)
+		}
+
+		messageType := header[0]
+		nodeID, err := ids.ToNodeID(header[1 : 1+ids.NodeIDLen])
+		if err != nil {
+			return numReplayed, err
+		}
+		msgLen := binary.BigEndian.Uint32(header[1+ids.NodeIDLen:])
+		if msgLen > constants.DefaultMaxMessageSize {
+			return numReplayed, fmt.Errorf("%w: message length %d exceeds maximum %d", ErrInvalidCapture, msgLen, constants.DefaultMaxMessageSize)
+		}
+
+		msgBytes := make([]byte, msgLen)
+		if _, err := io.ReadFull(r, msgBytes); err != nil {
+			return numReplayed, fmt.Errorf("%w: failed to read message: %w", ErrInvalidCapture, err)
+		}
+
+		switch messageType {
+		case capturedAppGossip:
+			handler.AppGossip(ctx, nodeID, msgBytes)
+		case capturedAppRequest:
+			_, _ = handler.AppRequest(ctx, nodeID, deadline, msgBytes)
+		default:
+			return numReplayed, fmt.Errorf("%w: unknown message type %d", ErrInvalidCapture, messageType)
+		}
+		numReplayed++
+	}
+}
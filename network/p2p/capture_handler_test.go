@@ -0,0 +1,126 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+type capturedMessage struct {
+	messageType byte
+	nodeID      ids.NodeID
+	msgBytes    []byte
+}
+
+// newRecordingHandler returns a handler that appends every handled message to
+// [messages].
+func newRecordingHandler(messages *[]capturedMessage) Handler {
+	return TestHandler{
+		AppGossipF: func(_ context.Context, nodeID ids.NodeID, gossipBytes []byte) {
+			*messages = append(*messages, capturedMessage{
+				messageType: capturedAppGossip,
+				nodeID:      nodeID,
+				msgBytes:    gossipBytes,
+			})
+		},
+		AppRequestF: func(_ context.Context, nodeID ids.NodeID, _ time.Time, requestBytes []byte) ([]byte, error) {
+			*messages = append(*messages, capturedMessage{
+				messageType: capturedAppRequest,
+				nodeID:      nodeID,
+				msgBytes:    requestBytes,
+			})
+			return nil, nil
+		},
+	}
+}
+
+func TestCaptureHandlerReplay(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var (
+		capture  bytes.Buffer
+		handled  []capturedMessage
+		replayed []capturedMessage
+		nodeID0  = ids.GenerateTestNodeID()
+		nodeID1  = ids.GenerateTestNodeID()
+	)
+	handler := NewCaptureHandler(newRecordingHandler(&handled), &capture, logging.NoLog{})
+
+	handler.AppGossip(ctx, nodeID0, []byte("gossip"))
+	_, err := handler.AppRequest(ctx, nodeID1, time.Time{}, []byte("request"))
+	require.NoError(err)
+	handler.AppGossip(ctx, nodeID1, []byte{})
+	_, err = handler.CrossChainAppRequest(ctx, ids.GenerateTestID(), time.Time{}, []byte("not captured"))
+	require.NoError(err)
+
+	numReplayed, err := Replay(ctx, &capture, newRecordingHandler(&replayed))
+	require.NoError(err)
+	require.Equal(3, numReplayed)
+	require.Equal(handled, replayed)
+}
+
+func TestReplayInvalidCapture(t *testing.T) {
+	var validCapture bytes.Buffer
+	handler := NewCaptureHandler(NoOpHandler{}, &validCapture, logging.NoLog{})
+	handler.AppGossip(context.Background(), ids.GenerateTestNodeID(), []byte("gossip"))
+	validBytes := validCapture.Bytes()
+
+	unknownType := bytes.Clone(validBytes)
+	unknownType[0] = capturedAppRequest + 1
+
+	tests := []struct {
+		name                string
+		capture             []byte
+		expectedNumReplayed int
+		expectedErr         error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:                "valid",
+			capture:             validBytes,
+			expectedNumReplayed: 1,
+		},
+		{
+			name:        "truncated header",
+			capture:     validBytes[:capturedHeaderLen-1],
+			expectedErr: ErrInvalidCapture,
+		},
+		{
+			name:        "truncated message",
+			capture:     validBytes[:len(validBytes)-1],
+			expectedErr: ErrInvalidCapture,
+		},
+		{
+			name:                "trailing truncated message",
+			capture:             append(bytes.Clone(validBytes), validBytes[:len(validBytes)-1]...),
+			expectedNumReplayed: 1,
+			expectedErr:         ErrInvalidCapture,
+		},
+		{
+			name:        "unknown message type",
+			capture:     unknownType,
+			expectedErr: ErrInvalidCapture,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			numReplayed, err := Replay(context.Background(), bytes.NewReader(tt.capture), NoOpHandler{})
+			require.ErrorIs(err, tt.expectedErr)
+			require.Equal(tt.expectedNumReplayed, numReplayed)
+		})
+	}
+}
@@ -50,6 +50,12 @@ type Client struct {
 	options       *clientOptions
 }
 
+// SampleNodes returns up to [limit] nodes that AppRequestAny may send requests
+// to.
+func (c *Client) SampleNodes(ctx context.Context, limit int) []ids.NodeID {
+	return c.options.nodeSampler.Sample(ctx, limit)
+}
+
 // AppRequestAny issues an AppRequest to an arbitrary node decided by Client.
 // If a specific node needs to be requested, use AppRequest instead.
 // See AppRequest for more docs.
@@ -58,7 +64,7 @@ func (c *Client) AppRequestAny(
 	appRequestBytes []byte,
 	onResponse AppResponseCallback,
 ) error {
-	sampled := c.options.nodeSampler.Sample(ctx, 1)
+	sampled := c.SampleNodes(ctx, 1)
 	if len(sampled) != 1 {
 		return ErrNoPeers
 	}
@@ -5,13 +5,52 @@ package gossip
 
 import (
 	"crypto/rand"
+	"errors"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/bloom"
+	"github.com/ava-labs/avalanchego/utils/logging"
 )
 
+// bloomHashSeedBytes is the number of bytes used to marshal each hash seed of
+// a bloom filter, in addition to the one byte that encodes the number of hash
+// seeds.
+const bloomHashSeedBytes = 8
+
+// minBloomFilterBytes is the size of the smallest marshalled bloom filter,
+// which has a single hash seed and a single byte of entries.
+const minBloomFilterBytes = 1 + bloomHashSeedBytes + 1
+
+var (
+	ErrInvalidMaxBloomFilterSize       = errors.New("max bloom filter size is too small")
+	ErrInvalidFalsePositiveProbability = errors.New("target false positive probability must be positive")
+)
+
+// BloomFilterOption configures BloomFilter
+type BloomFilterOption interface {
+	apply(filter *BloomFilter)
+}
+
+type bloomFilterOptionFunc func(filter *BloomFilter)
+
+func (o bloomFilterOptionFunc) apply(filter *BloomFilter) {
+	o(filter)
+}
+
+// WithMaxBloomFilterSize limits the marshalled size of the bloom filter to
+// [maxBytes]. If the bloom filter would otherwise exceed [maxBytes], it is
+// clamped to [maxBytes], accepting a higher false positive probability, and a
+// warning is logged.
+func WithMaxBloomFilterSize(log logging.Logger, maxBytes int) BloomFilterOption {
+	return bloomFilterOptionFunc(func(filter *BloomFilter) {
+		filter.log = log
+		filter.maxBytes = maxBytes
+	})
+}
+
 // NewBloomFilter returns a new instance of a bloom filter with at least [minTargetElements] elements
 // anticipated at any moment, and a false positive probability of [targetFalsePositiveProbability]. If the
 // false positive probability exceeds [resetFalsePositiveProbability], the bloom filter will be reset.
@@ -24,6 +63,7 @@ func NewBloomFilter(
 	minTargetElements int,
 	targetFalsePositiveProbability,
 	resetFalsePositiveProbability float64,
+	options ...BloomFilterOption,
 ) (*BloomFilter, error) {
 	metrics, err := bloom.NewMetrics(namespace, registerer)
 	if err != nil {
@@ -36,6 +76,13 @@ func NewBloomFilter(
 
 		metrics: metrics,
 	}
+	for _, option := range options {
+		option.apply(filter)
+	}
+	if filter.maxBytes != 0 && filter.maxBytes < minBloomFilterBytes {
+		return nil, ErrInvalidMaxBloomFilterSize
+	}
+
 	err = resetBloomFilter(
 		filter,
 		minTargetElements,
@@ -45,15 +92,54 @@ func NewBloomFilter(
 	return filter, err
 }
 
+// EstimateBloomFilter returns the parameters of the bloom filter that
+// NewBloomFilter would return for the same arguments, without allocating it.
+// [numHashes] is the number of hash seeds, [numBytes] is the marshalled size
+// of the bloom filter and [resetAt] is the number of elements after which it
+// breaches [resetFalsePositiveProbability] and is reset.
+func EstimateBloomFilter(
+	minTargetElements int,
+	targetFalsePositiveProbability,
+	resetFalsePositiveProbability float64,
+	options ...BloomFilterOption,
+) (numHashes int, numBytes int, resetAt int, err error) {
+	if targetFalsePositiveProbability <= 0 {
+		return 0, 0, 0, ErrInvalidFalsePositiveProbability
+	}
+
+	filter := &BloomFilter{}
+	for _, option := range options {
+		option.apply(filter)
+	}
+	if filter.maxBytes != 0 && filter.maxBytes < minBloomFilterBytes {
+		return 0, 0, 0, ErrInvalidMaxBloomFilterSize
+	}
+
+	numHashes, numEntries, maxCount, _ := bloomFilterParameters(
+		minTargetElements,
+		targetFalsePositiveProbability,
+		resetFalsePositiveProbability,
+		filter.maxBytes,
+	)
+	return numHashes, bloomFilterSize(numHashes, numEntries), maxCount, nil
+}
+
 type BloomFilter struct {
 	minTargetElements              int
 	targetFalsePositiveProbability float64
 	resetFalsePositiveProbability  float64
 
+	// maxBytes, if non-zero, is the maximum marshalled size of the bloom
+	// filter.
+	log      logging.Logger
+	maxBytes int
+
 	metrics *bloom.Metrics
 
-	maxCount int
-	bloom    *bloom.Filter
+	numHashes  int
+	numEntries int
+	maxCount   int
+	bloom      *bloom.Filter
 	// salt is provided to eventually unblock collisions in Bloom. It's possible
 	// that conflicting Gossipable items collide in the bloom filter, so a salt
 	// is generated to eventually resolve collisions.
@@ -79,6 +165,30 @@ func (b *BloomFilter) Marshal() ([]byte, []byte) {
 	return bloomBytes, salt[:]
 }
 
+// Count returns the number of elements added to the bloom filter since it was
+// last reset.
+func (b *BloomFilter) Count() int {
+	return b.bloom.Count()
+}
+
+// MaxCount returns the number of elements after which the bloom filter breaches
+// the reset false positive probability.
+func (b *BloomFilter) MaxCount() int {
+	return b.maxCount
+}
+
+// FalsePositiveProbability returns the estimated false positive probability of
+// the bloom filter given the number of elements added since it was last reset.
+func (b *BloomFilter) FalsePositiveProbability() float64 {
+	return bloom.EstimateFalsePositiveProbability(b.numHashes, b.numEntries, b.Count())
+}
+
+// NeedsReset returns true if the bloom filter has breached the reset false
+// positive probability.
+func (b *BloomFilter) NeedsReset() bool {
+	return b.Count() > b.maxCount
+}
+
 // ResetBloomFilterIfNeeded resets a bloom filter if it breaches [targetFalsePositiveProbability].
 //
 // If [targetElements] exceeds [minTargetElements], the size of the bloom filter will grow to maintain
@@ -89,7 +199,7 @@ func ResetBloomFilterIfNeeded(
 	bloomFilter *BloomFilter,
 	targetElements int,
 ) (bool, error) {
-	if bloomFilter.bloom.Count() <= bloomFilter.maxCount {
+	if !bloomFilter.NeedsReset() {
 		return false, nil
 	}
 
@@ -103,29 +213,168 @@ func ResetBloomFilterIfNeeded(
 	return err == nil, err
 }
 
+// ResetBloomFilter resets a bloom filter regardless of its false positive
+// probability. This allows the caller to remove stale elements from the bloom
+// filter by re-adding the elements that should remain.
+//
+// The bloom filter is sized for the larger of [targetElements] and
+// [minTargetElements].
+func ResetBloomFilter(
+	bloomFilter *BloomFilter,
+	targetElements int,
+) error {
+	targetElements = max(bloomFilter.minTargetElements, targetElements)
+	return resetBloomFilter(
+		bloomFilter,
+		targetElements,
+		bloomFilter.targetFalsePositiveProbability,
+		bloomFilter.resetFalsePositiveProbability,
+	)
+}
+
 func resetBloomFilter(
 	bloomFilter *BloomFilter,
 	targetElements int,
 	targetFalsePositiveProbability,
 	resetFalsePositiveProbability float64,
 ) error {
-	numHashes, numEntries := bloom.OptimalParameters(
+	reset, err := prepareBloomFilterReset(
+		bloomFilter,
 		targetElements,
 		targetFalsePositiveProbability,
+		resetFalsePositiveProbability,
 	)
-	newBloom, err := bloom.New(numHashes, numEntries)
 	if err != nil {
 		return err
 	}
+	ApplyBloomFilterReset(bloomFilter, reset)
+	return nil
+}
+
+// BloomFilterReset is an empty bloom filter that replaces the contents of a
+// BloomFilter once it is applied. Elements can be added to the reset before
+// it is applied, which allows a bloom filter to be repopulated without
+// blocking concurrent access to the bloom filter being replaced.
+type BloomFilterReset struct {
+	numHashes  int
+	numEntries int
+	maxCount   int
+	bloom      *bloom.Filter
+	salt       ids.ID
+}
+
+// Add adds [gossipable] to the reset. It is not safe to call concurrently.
+func (r *BloomFilterReset) Add(gossipable Gossipable) {
+	h := gossipable.GossipID()
+	bloom.Add(r.bloom, h[:], r.salt[:])
+}
+
+// PrepareBloomFilterReset returns a reset of [bloomFilter] that is sized for
+// the larger of [targetElements] and [minTargetElements]. [bloomFilter] isn't
+// modified until the reset is applied with ApplyBloomFilterReset.
+func PrepareBloomFilterReset(
+	bloomFilter *BloomFilter,
+	targetElements int,
+) (*BloomFilterReset, error) {
+	targetElements = max(bloomFilter.minTargetElements, targetElements)
+	return prepareBloomFilterReset(
+		bloomFilter,
+		targetElements,
+		bloomFilter.targetFalsePositiveProbability,
+		bloomFilter.resetFalsePositiveProbability,
+	)
+}
+
+// ApplyBloomFilterReset replaces the contents of [bloomFilter] with [reset].
+// The reset must not be modified after it is applied.
+func ApplyBloomFilterReset(bloomFilter *BloomFilter, reset *BloomFilterReset) {
+	bloomFilter.numHashes = reset.numHashes
+	bloomFilter.numEntries = reset.numEntries
+	bloomFilter.maxCount = reset.maxCount
+	bloomFilter.bloom = reset.bloom
+	bloomFilter.salt = reset.salt
+
+	bloomFilter.metrics.Reset(reset.bloom, reset.maxCount)
+}
+
+func prepareBloomFilterReset(
+	bloomFilter *BloomFilter,
+	targetElements int,
+	targetFalsePositiveProbability,
+	resetFalsePositiveProbability float64,
+) (*BloomFilterReset, error) {
+	numHashes, numEntries, maxCount, size := bloomFilterParameters(
+		targetElements,
+		targetFalsePositiveProbability,
+		resetFalsePositiveProbability,
+		bloomFilter.maxBytes,
+	)
+	if maxBytes := bloomFilter.maxBytes; maxBytes != 0 && size > maxBytes {
+		bloomFilter.log.Warn("clamping bloom filter size",
+			zap.Int("targetElements", targetElements),
+			zap.Float64("targetFalsePositiveProbability", targetFalsePositiveProbability),
+			zap.Int("size", size),
+			zap.Int("maxSize", maxBytes),
+		)
+	}
+
+	newBloom, err := bloom.New(numHashes, numEntries)
+	if err != nil {
+		return nil, err
+	}
 	var newSalt ids.ID
 	if _, err := rand.Read(newSalt[:]); err != nil {
-		return err
+		return nil, err
+	}
+	return &BloomFilterReset{
+		numHashes:  numHashes,
+		numEntries: numEntries,
+		maxCount:   maxCount,
+		bloom:      newBloom,
+		salt:       newSalt,
+	}, nil
+}
+
+// bloomFilterParameters returns the number of hashes, the number of entries
+// and the max count of a bloom filter sized for [targetElements]. If [maxBytes]
+// is non-zero, the bloom filter is clamped to [maxBytes]. [size] is the
+// marshalled size of the bloom filter before it was clamped.
+func bloomFilterParameters(
+	targetElements int,
+	targetFalsePositiveProbability,
+	resetFalsePositiveProbability float64,
+	maxBytes int,
+) (numHashes int, numEntries int, maxCount int, size int) {
+	numHashes, numEntries = bloom.OptimalParameters(
+		targetElements,
+		targetFalsePositiveProbability,
+	)
+	maxCount = bloom.EstimateCount(numHashes, numEntries, resetFalsePositiveProbability)
+
+	size = bloomFilterSize(numHashes, numEntries)
+	if maxBytes == 0 || size <= maxBytes {
+		return numHashes, numEntries, maxCount, size
 	}
 
-	bloomFilter.maxCount = bloom.EstimateCount(numHashes, numEntries, resetFalsePositiveProbability)
-	bloomFilter.bloom = newBloom
-	bloomFilter.salt = newSalt
+	// The hash seeds are marshalled along with the entries, so the number of
+	// hashes is capped to leave room for at least one entry.
+	numHashes = bloom.OptimalHashes(maxBytes-bloomFilterSize(1, 0), targetElements)
+	for numHashes > 1 && bloomFilterSize(numHashes, 1) > maxBytes {
+		numHashes--
+	}
+	numEntries = maxBytes - bloomFilterSize(numHashes, 0)
+	// The clamped filter breaches the reset false positive probability sooner.
+	// It isn't reset until it holds [targetElements] so that it isn't reset
+	// repeatedly.
+	maxCount = max(
+		bloom.EstimateCount(numHashes, numEntries, resetFalsePositiveProbability),
+		targetElements,
+	)
+	return numHashes, numEntries, maxCount, size
+}
 
-	bloomFilter.metrics.Reset(newBloom, bloomFilter.maxCount)
-	return nil
+// bloomFilterSize returns the marshalled size of a bloom filter with
+// [numHashes] hash seeds and [numEntries] bytes of entries.
+func bloomFilterSize(numHashes, numEntries int) int {
+	return 1 + numHashes*bloomHashSeedBytes + numEntries
 }
@@ -4,6 +4,7 @@
 package gossip
 
 import (
+	"bytes"
 	"slices"
 	"testing"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
 )
 
 func TestBloomFilterRefresh(t *testing.T) {
@@ -106,3 +108,163 @@ func TestBloomFilterRefresh(t *testing.T) {
 		})
 	}
 }
+
+type bufferWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*bufferWriteCloser) Close() error {
+	return nil
+}
+
+func TestBloomFilterMaxSize(t *testing.T) {
+	require := require.New(t)
+
+	const maxBytes = 1024
+	var logs bufferWriteCloser
+	log := logging.NewLogger("", logging.NewWrappedCore(logging.Warn, &logs, logging.Plain.ConsoleEncoder()))
+
+	// The filter is sized within the limit, so it isn't clamped.
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 10, 0.01, 0.05, WithMaxBloomFilterSize(log, maxBytes))
+	require.NoError(err)
+	bloomBytes, _ := bloom.Marshal()
+	require.Less(len(bloomBytes), maxBytes)
+	require.Zero(logs.Len())
+
+	// The filter would exceed the limit, so it is clamped with a warning.
+	bloom, err = NewBloomFilter(prometheus.NewRegistry(), "", 100_000, 0.0001, 0.001, WithMaxBloomFilterSize(log, maxBytes))
+	require.NoError(err)
+	bloomBytes, _ = bloom.Marshal()
+	require.Len(bloomBytes, maxBytes)
+	require.Contains(logs.String(), "clamping bloom filter size")
+
+	// The clamped filter isn't reset until it holds the targeted number of
+	// elements.
+	require.Equal(100_000, bloom.MaxCount())
+
+	// Resets are clamped as well.
+	logs.Reset()
+	require.NoError(ResetBloomFilter(bloom, 200_000))
+	bloomBytes, _ = bloom.Marshal()
+	require.Len(bloomBytes, maxBytes)
+	require.Contains(logs.String(), "clamping bloom filter size")
+
+	_, err = NewBloomFilter(prometheus.NewRegistry(), "", 10, 0.01, 0.05, WithMaxBloomFilterSize(log, minBloomFilterBytes-1))
+	require.ErrorIs(err, ErrInvalidMaxBloomFilterSize)
+}
+
+func TestBloomFilterReset(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 10, .01, .05)
+	require.NoError(err)
+
+	var (
+		oldTx = &testTx{id: ids.GenerateTestID()}
+		newTx = &testTx{id: ids.GenerateTestID()}
+	)
+	bloom.Add(oldTx)
+	_, saltBefore := bloom.Marshal()
+
+	// The bloom filter is unchanged until the reset is applied.
+	reset, err := PrepareBloomFilterReset(bloom, 100)
+	require.NoError(err)
+	reset.Add(newTx)
+	require.True(bloom.Has(oldTx))
+	require.False(bloom.Has(newTx))
+	require.Equal(1, bloom.Count())
+
+	ApplyBloomFilterReset(bloom, reset)
+	require.False(bloom.Has(oldTx))
+	require.True(bloom.Has(newTx))
+	require.Equal(1, bloom.Count())
+	// The bloom filter was also reset when it was created.
+	require.Equal(2.0, testutil.ToFloat64(bloom.metrics.ResetCount))
+
+	_, saltAfter := bloom.Marshal()
+	require.NotEqual(saltBefore, saltAfter)
+}
+
+func TestEstimateBloomFilter(t *testing.T) {
+	tests := []struct {
+		name                           string
+		minTargetElements              int
+		targetFalsePositiveProbability float64
+		resetFalsePositiveProbability  float64
+		maxBytes                       int
+	}{
+		{
+			name:                           "small",
+			minTargetElements:              10,
+			targetFalsePositiveProbability: 0.01,
+			resetFalsePositiveProbability:  0.05,
+		},
+		{
+			name:                           "large",
+			minTargetElements:              8 * 1024,
+			targetFalsePositiveProbability: 0.01,
+			resetFalsePositiveProbability:  0.05,
+		},
+		{
+			name:                           "low false positive probability",
+			minTargetElements:              1000,
+			targetFalsePositiveProbability: 0.0001,
+			resetFalsePositiveProbability:  0.001,
+		},
+		{
+			name:                           "never reset",
+			minTargetElements:              1000,
+			targetFalsePositiveProbability: 0.01,
+			resetFalsePositiveProbability:  1,
+		},
+		{
+			name:                           "clamped",
+			minTargetElements:              100_000,
+			targetFalsePositiveProbability: 0.0001,
+			resetFalsePositiveProbability:  0.001,
+			maxBytes:                       1024,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var options []BloomFilterOption
+			if tt.maxBytes != 0 {
+				options = append(options, WithMaxBloomFilterSize(logging.NoLog{}, tt.maxBytes))
+			}
+
+			numHashes, numBytes, resetAt, err := EstimateBloomFilter(
+				tt.minTargetElements,
+				tt.targetFalsePositiveProbability,
+				tt.resetFalsePositiveProbability,
+				options...,
+			)
+			require.NoError(err)
+
+			bloom, err := NewBloomFilter(
+				prometheus.NewRegistry(),
+				"",
+				tt.minTargetElements,
+				tt.targetFalsePositiveProbability,
+				tt.resetFalsePositiveProbability,
+				options...,
+			)
+			require.NoError(err)
+			bloomBytes, _ := bloom.Marshal()
+			require.Equal(bloom.numHashes, numHashes)
+			require.Len(bloomBytes, numBytes)
+			require.Equal(bloom.MaxCount(), resetAt)
+		})
+	}
+}
+
+func TestEstimateBloomFilterInvalid(t *testing.T) {
+	require := require.New(t)
+
+	_, _, _, err := EstimateBloomFilter(10, 0, 0.05)
+	require.ErrorIs(err, ErrInvalidFalsePositiveProbability)
+
+	_, _, _, err = EstimateBloomFilter(10, 0.01, 0.05, WithMaxBloomFilterSize(logging.NoLog{}, minBloomFilterBytes-1))
+	require.ErrorIs(err, ErrInvalidMaxBloomFilterSize)
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import "github.com/ava-labs/avalanchego/ids"
+
+// bundle groups [gossipables] into bundles of gossipables that depend on each
+// other, as reported by [dependencies]. Within a bundle, every gossipable
+// follows the gossipables it depends on. Dependencies on gossipables that are
+// not in [gossipables] are ignored, so no bundle depends on another bundle.
+//
+// Bundles are ordered by the first of their gossipables in [gossipables].
+func bundle[T Gossipable](gossipables []T, dependencies func(T) []ids.ID) [][]T {
+	indices := make(map[ids.ID]int, len(gossipables))
+	for i, gossipable := range gossipables {
+		indices[gossipable.GossipID()] = i
+	}
+
+	// Record the dependencies of each gossipable on other gossipables and
+	// union the gossipables that depend on each other.
+	var (
+		dependsOn = make([][]int, len(gossipables))
+		parents   = make([]int, len(gossipables))
+	)
+	for i := range parents {
+		parents[i] = i
+	}
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+	for i, gossipable := range gossipables {
+		for _, dependencyID := range dependencies(gossipable) {
+			j, ok := indices[dependencyID]
+			if !ok || i == j {
+				continue
+			}
+			dependsOn[i] = append(dependsOn[i], j)
+			parents[find(i)] = find(j)
+		}
+	}
+
+	// Topologically sort the gossipables so that every gossipable follows its
+	// dependencies.
+	var (
+		visited = make([]bool, len(gossipables))
+		order   = make([]int, 0, len(gossipables))
+		visit   func(i int)
+	)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, j := range dependsOn[i] {
+			visit(j)
+		}
+		order = append(order, i)
+	}
+	for i := range gossipables {
+		visit(i)
+	}
+
+	var (
+		bundleIndices = make(map[int]int)
+		bundles       [][]T
+	)
+	for _, i := range order {
+		root := find(i)
+		bundleIndex, ok := bundleIndices[root]
+		if !ok {
+			bundleIndex = len(bundles)
+			bundleIndices[root] = bundleIndex
+			bundles = append(bundles, nil)
+		}
+		bundles[bundleIndex] = append(bundles[bundleIndex], gossipables[i])
+	}
+	return bundles
+}
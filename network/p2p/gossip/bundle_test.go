@@ -0,0 +1,224 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+)
+
+var errMissingDependency = errors.New("missing dependency")
+
+// testDependencies maps a tx to the txs it depends on
+type testDependencies map[ids.ID][]ids.ID
+
+func (d testDependencies) of(tx *testTx) []ids.ID {
+	return d[tx.id]
+}
+
+// requireSelfConsistentBundles asserts that every tx in [bundles] follows the
+// txs it depends on and that no bundle depends on another bundle.
+func requireSelfConsistentBundles(require *require.Assertions, dependencies testDependencies, bundles [][]*testTx) {
+	bundleIndices := make(map[ids.ID]int)
+	for i, bundle := range bundles {
+		for _, tx := range bundle {
+			bundleIndices[tx.id] = i
+		}
+	}
+
+	for i, bundle := range bundles {
+		applied := set.Set[ids.ID]{}
+		for _, tx := range bundle {
+			for _, dependencyID := range dependencies.of(tx) {
+				dependencyBundle, ok := bundleIndices[dependencyID]
+				if !ok {
+					continue
+				}
+				require.Equal(i, dependencyBundle)
+				require.True(applied.Contains(dependencyID))
+			}
+			applied.Add(tx.id)
+		}
+	}
+}
+
+func TestBundle(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		parent     = &testTx{id: ids.GenerateTestID()}
+		child      = &testTx{id: ids.GenerateTestID()}
+		grandchild = &testTx{id: ids.GenerateTestID()}
+		sibling    = &testTx{id: ids.GenerateTestID()}
+		unrelated  = &testTx{id: ids.GenerateTestID()}
+		orphan     = &testTx{id: ids.GenerateTestID()}
+
+		dependencies = testDependencies{
+			child.id:      {parent.id},
+			grandchild.id: {child.id, parent.id},
+			sibling.id:    {parent.id},
+			orphan.id:     {ids.GenerateTestID()},
+		}
+	)
+
+	bundles := bundle(
+		[]*testTx{grandchild, unrelated, sibling, child, parent, orphan},
+		dependencies.of,
+	)
+	require.Equal(
+		[][]*testTx{
+			{parent, child, grandchild, sibling},
+			{unrelated},
+			{orphan},
+		},
+		bundles,
+	)
+	requireSelfConsistentBundles(require, dependencies, bundles)
+}
+
+func TestAppResponseBundles(t *testing.T) {
+	require := require.New(t)
+
+	bundles := [][][]byte{
+		{{1}, {2}},
+		{{3}},
+	}
+	responseBytes, err := MarshalAppResponseBundles(bundles)
+	require.NoError(err)
+
+	parsedBundles, err := ParseAppResponseBundles(responseBytes)
+	require.NoError(err)
+	require.Equal(bundles, parsedBundles)
+
+	// Receivers that don't support bundles see every item in order
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Equal([][]byte{{1}, {2}, {3}}, gossip)
+
+	// Responses without bundles are parsed as bundles of a single item
+	responseBytes, err = MarshalAppResponse(gossip)
+	require.NoError(err)
+	parsedBundles, err = ParseAppResponseBundles(responseBytes)
+	require.NoError(err)
+	require.Equal([][][]byte{{{1}}, {{2}}, {{3}}}, parsedBundles)
+}
+
+// dependentSet only adds txs whose dependencies were already added
+type dependentSet struct {
+	*testSet
+	dependencies testDependencies
+	added        []*testTx
+}
+
+func (d *dependentSet) Add(tx *testTx) error {
+	for _, dependencyID := range d.dependencies.of(tx) {
+		if !d.Has(dependencyID) {
+			return errMissingDependency
+		}
+	}
+	if err := d.testSet.Add(tx); err != nil {
+		return err
+	}
+	d.added = append(d.added, tx)
+	return nil
+}
+
+func TestPullGossipDependencyBundles(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	newSet := func(dependencies testDependencies) *dependentSet {
+		bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+		require.NoError(err)
+		return &dependentSet{
+			testSet: &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			},
+			dependencies: dependencies,
+		}
+	}
+
+	var (
+		parent       = &testTx{id: ids.GenerateTestID()}
+		child        = &testTx{id: ids.GenerateTestID()}
+		grandchild   = &testTx{id: ids.GenerateTestID()}
+		unrelated    = &testTx{id: ids.GenerateTestID()}
+		dependencies = testDependencies{
+			child.id:      {parent.id},
+			grandchild.id: {child.id},
+		}
+	)
+
+	serverSet := newSet(nil)
+	for _, tx := range []*testTx{grandchild, child, unrelated, parent} {
+		require.NoError(serverSet.Add(tx))
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		serverSet,
+		metrics,
+		1024,
+		WithDependencyBundles[*testTx](dependencies.of),
+	)
+
+	clientSet := newSet(dependencies)
+	requestBytes, err := MarshalAppRequest(clientSet.GetFilter())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	bundleBytes, err := ParseAppResponseBundles(responseBytes)
+	require.NoError(err)
+	bundles := make([][]*testTx, len(bundleBytes))
+	for i, bundle := range bundleBytes {
+		for _, bytes := range bundle {
+			tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+			require.NoError(err)
+			bundles[i] = append(bundles[i], tx)
+		}
+	}
+	require.Len(bundles, 2)
+	requireSelfConsistentBundles(require, dependencies, bundles)
+
+	// Every bundle is applied in order without being rejected
+	gossiper := NewPullGossiper[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		clientSet,
+		nil,
+		metrics,
+		1,
+	)
+	gossiper.handleResponse(ctx, ids.EmptyNodeID, responseBytes, nil)
+	require.ElementsMatch([]*testTx{parent, child, grandchild, unrelated}, clientSet.added)
+	require.Equal(
+		[]*testTx{parent, child, grandchild},
+		filterTxs(clientSet.added, set.Of(parent.id, child.id, grandchild.id)),
+	)
+}
+
+// filterTxs returns the txs in [txs] with an ID in [txIDs], in order.
+func filterTxs(txs []*testTx, txIDs set.Set[ids.ID]) []*testTx {
+	var filtered []*testTx
+	for _, tx := range txs {
+		if txIDs.Contains(tx.id) {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
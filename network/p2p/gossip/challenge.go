@@ -0,0 +1,196 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/linked"
+
+	mathrand "math/rand"
+)
+
+// challengeLen is the number of bytes in a challenge
+const challengeLen = 8
+
+var (
+	ErrInvalidChallengeMaxPeers  = errors.New("max challenged peers must be positive")
+	ErrInvalidChallengeFrequency = errors.New("challenge frequency must be positive")
+
+	errUnexpectedChallengeEcho = errors.New("unexpected challenge echo")
+	errMismatchedChallengeEcho = errors.New("mismatched challenge echo")
+	errMissingChallengeEcho    = errors.New("missing challenge echo")
+)
+
+// ReplayChallengeParams configures challenging peers to echo a random nonce in
+// their next pull request. A peer that doesn't echo the most recent challenge
+// it was sent is likely replaying a stale request or is misbehaving.
+type ReplayChallengeParams struct {
+	// MaxPeers is the maximum number of peers with an outstanding challenge
+	// that are tracked. Once exceeded, the challenge of the least recently
+	// challenged peer is forgotten.
+	MaxPeers int
+	// Frequency is the inverse of the probability that a response to a peer
+	// without an outstanding challenge includes a challenge. If 1, every such
+	// response includes a challenge.
+	Frequency int
+	// Peers, if non-nil, also bounds the peers with an outstanding challenge
+	// together with the other structures sharing it.
+	Peers *PeerLRU
+}
+
+func (p *ReplayChallengeParams) Verify() error {
+	switch {
+	case p.MaxPeers <= 0:
+		return ErrInvalidChallengeMaxPeers
+	case p.Frequency <= 0:
+		return ErrInvalidChallengeFrequency
+	default:
+		return nil
+	}
+}
+
+func newChallenger(params ReplayChallengeParams) *challenger {
+	c := &challenger{
+		params:      params,
+		outstanding: linked.NewHashmap[ids.NodeID, []byte](),
+	}
+	if params.Peers != nil {
+		params.Peers.OnEvict(c.evict)
+	}
+	return c
+}
+
+// challenger tracks the challenges sent to peers that haven't been echoed yet.
+type challenger struct {
+	params ReplayChallengeParams
+
+	lock        sync.Mutex
+	outstanding *linked.Hashmap[ids.NodeID, []byte]
+}
+
+// verify checks that [echo], received from [nodeID], matches the outstanding
+// challenge of [nodeID]. The outstanding challenge is cleared regardless of
+// whether it matched.
+func (c *challenger) verify(nodeID ids.NodeID, echo []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	challenge, ok := c.outstanding.Get(nodeID)
+	switch {
+	case !ok && len(echo) == 0:
+		return nil
+	case !ok:
+		return errUnexpectedChallengeEcho
+	}
+
+	c.outstanding.Delete(nodeID)
+	switch {
+	case len(echo) == 0:
+		return errMissingChallengeEcho
+	case !bytes.Equal(challenge, echo):
+		return errMismatchedChallengeEcho
+	default:
+		return nil
+	}
+}
+
+// challenge returns a new challenge to send to [nodeID], or nil if [nodeID]
+// shouldn't be challenged. Any outstanding challenge of [nodeID] is replaced.
+func (c *challenger) challenge(nodeID ids.NodeID) ([]byte, error) {
+	if mathrand.Intn(c.params.Frequency) != 0 { // #nosec G404
+		return nil, nil
+	}
+
+	challenge := make([]byte, challengeLen)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	if c.params.Peers != nil {
+		c.params.Peers.Touch(nodeID)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.outstanding.Delete(nodeID)
+	if c.outstanding.Len() >= c.params.MaxPeers {
+		oldestNodeID, _, _ := c.outstanding.Oldest()
+		c.outstanding.Delete(oldestNodeID)
+	}
+	c.outstanding.Put(nodeID, challenge)
+	return challenge, nil
+}
+
+// evict forgets the outstanding challenge of [nodeID].
+func (c *challenger) evict(nodeID ids.NodeID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.outstanding.Delete(nodeID)
+}
+
+func newChallengeEchoes(maxPeers int) *challengeEchoes {
+	return &challengeEchoes{
+		maxPeers:   maxPeers,
+		challenges: linked.NewHashmap[ids.NodeID, []byte](),
+	}
+}
+
+// challengeEchoes tracks the most recent challenge received from each peer
+// that hasn't been echoed yet.
+type challengeEchoes struct {
+	maxPeers int
+	// peers, if non-nil, also bounds the peers whose challenges are tracked.
+	peers *PeerLRU
+
+	lock       sync.Mutex
+	challenges *linked.Hashmap[ids.NodeID, []byte]
+}
+
+// put records [challenge] to be echoed in the next request to [nodeID].
+func (c *challengeEchoes) put(nodeID ids.NodeID, challenge []byte) {
+	if c.peers != nil {
+		c.peers.Touch(nodeID)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.challenges.Delete(nodeID)
+	if c.challenges.Len() >= c.maxPeers {
+		oldestNodeID, _, _ := c.challenges.Oldest()
+		c.challenges.Delete(oldestNodeID)
+	}
+	c.challenges.Put(nodeID, challenge)
+}
+
+// sharePeers bounds the peers whose challenges are tracked by [peers].
+func (c *challengeEchoes) sharePeers(peers *PeerLRU) {
+	c.peers = peers
+	peers.OnEvict(c.evict)
+}
+
+// evict forgets the challenge received from [nodeID].
+func (c *challengeEchoes) evict(nodeID ids.NodeID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.challenges.Delete(nodeID)
+}
+
+// pop returns the challenge to echo in the next request to [nodeID], if any.
+func (c *challengeEchoes) pop(nodeID ids.NodeID) []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	challenge, _ := c.challenges.Get(nodeID)
+	c.challenges.Delete(nodeID)
+	return challenge
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/set"
+)
+
+var (
+	_ Set[*testTx] = (*CombinedSet[*testTx])(nil)
+
+	ErrDuplicateGossipable = errors.New("gossipable is already in a combined set")
+
+	errUnknownSet = errors.New("gossipable classified into an unknown set")
+)
+
+// NewCombinedSet returns a set that gossips the gossipables of all of [sets]
+// as if they were a single set. Gossipables are added to the set at the index
+// returned by [classify]. [bloom] is advertised as the filter of the combined
+// set. It is updated with the gossipables added through the combined set, and
+// is rebuilt from the gossipables in [sets] once it needs to be reset, so
+// gossipables should only be added to [sets] through the combined set.
+func NewCombinedSet[T Gossipable](
+	bloom *BloomFilter,
+	classify func(gossipable T) int,
+	sets ...Set[T],
+) *CombinedSet[T] {
+	return &CombinedSet[T]{
+		sets:     sets,
+		classify: classify,
+		bloom:    bloom,
+	}
+}
+
+// CombinedSet allows a single handler to gossip from multiple sets, such as
+// mempools of txs with different priorities.
+type CombinedSet[T Gossipable] struct {
+	sets     []Set[T]
+	classify func(gossipable T) int
+
+	// lock is held while adding gossipables, so that concurrent additions of
+	// the same gossipable can't both pass the duplicate check, and guards
+	// bloom.
+	lock  sync.Mutex
+	bloom *BloomFilter
+}
+
+// Add adds [gossipable] to the set it is classified into, unless it is already
+// in any of the sets.
+func (c *CombinedSet[T]) Add(gossipable T) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	gossipID := gossipable.GossipID()
+	if c.Has(gossipID) {
+		return fmt.Errorf("%w: %s", ErrDuplicateGossipable, gossipID)
+	}
+
+	index := c.classify(gossipable)
+	if index < 0 || index >= len(c.sets) {
+		return fmt.Errorf("%w: %d", errUnknownSet, index)
+	}
+	if err := c.sets[index].Add(gossipable); err != nil {
+		return err
+	}
+
+	c.bloom.Add(gossipable)
+	if !c.bloom.NeedsReset() {
+		return nil
+	}
+
+	// The bloom filter is sized for and populated with the same gossipables,
+	// even if the sets are modified while they are iterated over.
+	var gossipables []T
+	c.Iterate(func(gossipable T) bool {
+		gossipables = append(gossipables, gossipable)
+		return true
+	})
+	if _, err := ResetBloomFilterIfNeeded(c.bloom, len(gossipables)); err != nil {
+		return err
+	}
+	for _, gossipable := range gossipables {
+		c.bloom.Add(gossipable)
+	}
+	return nil
+}
+
+func (c *CombinedSet[_]) Has(gossipID ids.ID) bool {
+	for _, s := range c.sets {
+		if s.Has(gossipID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Iterate iterates over the gossipables of every set, in the order the sets
+// were provided, until [f] returns false. A gossipable that is in multiple sets
+// is only iterated over once.
+func (c *CombinedSet[T]) Iterate(f func(gossipable T) bool) {
+	var (
+		seen set.Set[ids.ID]
+		done bool
+	)
+	for _, s := range c.sets {
+		s.Iterate(func(gossipable T) bool {
+			gossipID := gossipable.GossipID()
+			if seen.Contains(gossipID) {
+				return true
+			}
+			seen.Add(gossipID)
+
+			done = !f(gossipable)
+			return !done
+		})
+		if done {
+			return
+		}
+	}
+}
+
+func (c *CombinedSet[_]) GetFilter() ([]byte, []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.bloom.Marshal()
+}
@@ -0,0 +1,196 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/bloom"
+)
+
+func TestCombinedSet(t *testing.T) {
+	require := require.New(t)
+
+	newSet := func() *testSet {
+		bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+		require.NoError(err)
+		return &testSet{
+			txs:   make(map[ids.ID]*testTx),
+			bloom: bloom,
+		}
+	}
+	var (
+		standardSet = newSet()
+		prioritySet = newSet()
+	)
+
+	combinedBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	// Txs with an odd first byte are priority txs, and txs with a first byte
+	// of 2 aren't in any set.
+	combinedSet := NewCombinedSet[*testTx](
+		combinedBloom,
+		func(tx *testTx) int {
+			if tx.id[0] == 2 {
+				return 2
+			}
+			return int(tx.id[0] % 2)
+		},
+		standardSet,
+		prioritySet,
+	)
+
+	var (
+		standardTx = &testTx{id: ids.ID{0}}
+		priorityTx = &testTx{id: ids.ID{1}}
+		unknownTx  = &testTx{id: ids.ID{2}}
+		// overlappingTx was added to both of the sets without going through
+		// the combined set.
+		overlappingTx = &testTx{id: ids.ID{4}}
+	)
+	require.NoError(standardSet.Add(overlappingTx))
+	require.NoError(prioritySet.Add(overlappingTx))
+
+	// Txs are added to the set they are classified into.
+	require.NoError(combinedSet.Add(standardTx))
+	require.NoError(combinedSet.Add(priorityTx))
+	require.True(standardSet.Has(standardTx.id))
+	require.False(standardSet.Has(priorityTx.id))
+	require.True(prioritySet.Has(priorityTx.id))
+	require.False(prioritySet.Has(standardTx.id))
+
+	err = combinedSet.Add(unknownTx)
+	require.ErrorIs(err, errUnknownSet)
+	require.False(combinedSet.Has(unknownTx.id))
+
+	// Txs in any of the sets aren't added again, even if they would be
+	// classified into a different set.
+	err = combinedSet.Add(overlappingTx)
+	require.ErrorIs(err, ErrDuplicateGossipable)
+	err = combinedSet.Add(standardTx)
+	require.ErrorIs(err, ErrDuplicateGossipable)
+
+	for _, tx := range []*testTx{standardTx, priorityTx, overlappingTx} {
+		require.True(combinedSet.Has(tx.id))
+	}
+
+	// Every tx is iterated over once, even if it is in both sets.
+	var iterated []ids.ID
+	combinedSet.Iterate(func(tx *testTx) bool {
+		iterated = append(iterated, tx.id)
+		return true
+	})
+	require.ElementsMatch(
+		[]ids.ID{standardTx.id, priorityTx.id, overlappingTx.id},
+		iterated,
+	)
+
+	// Iteration stops across all sets.
+	iterated = nil
+	combinedSet.Iterate(func(tx *testTx) bool {
+		iterated = append(iterated, tx.id)
+		return false
+	})
+	require.Len(iterated, 1)
+
+	// The filter includes the txs added through the combined set.
+	filterBytes, saltBytes := combinedSet.GetFilter()
+	filter, err := bloom.Parse(filterBytes)
+	require.NoError(err)
+	for _, tx := range []*testTx{standardTx, priorityTx} {
+		require.True(bloom.Contains(filter, tx.id[:], saltBytes))
+	}
+}
+
+func TestCombinedSetBloomFilterReset(t *testing.T) {
+	require := require.New(t)
+
+	setBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: setBloom,
+	}
+
+	combinedBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 10, 0.01, 0.05)
+	require.NoError(err)
+	combinedSet := NewCombinedSet[*testTx](
+		combinedBloom,
+		func(*testTx) int {
+			return 0
+		},
+		set,
+	)
+
+	// Once the filter is reset, it is repopulated with every tx in the sets.
+	txs := make([]*testTx, 100)
+	for i := range txs {
+		txs[i] = &testTx{id: ids.GenerateTestID()}
+		require.NoError(combinedSet.Add(txs[i]))
+	}
+	require.Greater(combinedBloom.MaxCount(), 10)
+	for _, tx := range txs {
+		require.True(combinedBloom.Has(tx))
+	}
+}
+
+func TestCombinedSetConcurrentAdd(t *testing.T) {
+	require := require.New(t)
+
+	newSet := func() *testSet {
+		bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+		require.NoError(err)
+		return &testSet{
+			txs:   make(map[ids.ID]*testTx),
+			bloom: bloom,
+		}
+	}
+
+	combinedBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	// Every addition is classified into a different set than the last, so the
+	// sets themselves can't reject a duplicate.
+	var numClassified atomic.Int64
+	combinedSet := NewCombinedSet[*testTx](
+		combinedBloom,
+		func(*testTx) int {
+			return int(numClassified.Add(1) % 2)
+		},
+		newSet(),
+		newSet(),
+	)
+
+	const numAdds = 10
+	var (
+		tx   = &testTx{id: ids.GenerateTestID()}
+		wg   sync.WaitGroup
+		errs = make(chan error, numAdds)
+	)
+	for i := 0; i < numAdds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- combinedSet.Add(tx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// Only a single addition succeeds.
+	var numAdded int
+	for err := range errs {
+		if err == nil {
+			numAdded++
+			continue
+		}
+		require.ErrorIs(err, ErrDuplicateGossipable)
+	}
+	require.Equal(1, numAdded)
+}
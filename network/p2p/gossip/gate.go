@@ -0,0 +1,169 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+var (
+	_ p2p.Handler = (*GatedHandler)(nil)
+	_ Gossiper    = GatedGossiper{}
+
+	ErrGossipGated = errors.New("gossip is gated")
+)
+
+// Gate stops gossip from being processed while it is closed, such as while
+// the node is state syncing and is unable to verify gossip. The zero value is
+// an open gate.
+type Gate struct {
+	lock   sync.Mutex
+	closed bool
+	onOpen []func()
+}
+
+// Close stops gossip from being processed until the gate is opened.
+func (g *Gate) Close() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.closed = true
+}
+
+// Open resumes processing gossip. Gossip queued while the gate was closed is
+// handled asynchronously, as Open may be called while holding locks that are
+// needed to handle it, such as the engine lock.
+func (g *Gate) Open() {
+	g.lock.Lock()
+	wasClosed := g.closed
+	g.closed = false
+	onOpen := g.onOpen
+	g.lock.Unlock()
+
+	if !wasClosed {
+		return
+	}
+	for _, f := range onOpen {
+		go f()
+	}
+}
+
+// IsOpen returns true if gossip should be processed.
+func (g *Gate) IsOpen() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return !g.closed
+}
+
+// onOpened registers [f] to be called in a new goroutine whenever the gate is
+// opened after being closed.
+func (g *Gate) onOpened(f func()) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.onOpen = append(g.onOpen, f)
+}
+
+type queuedGossip struct {
+	nodeID      ids.NodeID
+	gossipBytes []byte
+}
+
+// NewGatedHandler returns a handler that only forwards messages to [handler]
+// while [gate] is open. While [gate] is closed, requests are refused and up to
+// [maxQueuedGossip] gossip messages are queued to be handled once [gate] is
+// opened. Any additional gossip messages are dropped.
+func NewGatedHandler(
+	log logging.Logger,
+	handler p2p.Handler,
+	gate *Gate,
+	maxQueuedGossip int,
+) *GatedHandler {
+	h := &GatedHandler{
+		Handler:         handler,
+		log:             log,
+		gate:            gate,
+		maxQueuedGossip: maxQueuedGossip,
+	}
+	gate.onOpened(h.flush)
+	return h
+}
+
+type GatedHandler struct {
+	p2p.Handler
+
+	log             logging.Logger
+	gate            *Gate
+	maxQueuedGossip int
+
+	lock   sync.Mutex
+	queued []queuedGossip
+}
+
+func (g *GatedHandler) AppGossip(ctx context.Context, nodeID ids.NodeID, gossipBytes []byte) {
+	if g.gate.IsOpen() {
+		g.Handler.AppGossip(ctx, nodeID, gossipBytes)
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if len(g.queued) >= g.maxQueuedGossip {
+		g.log.Debug("dropping gossip",
+			zap.String("reason", "gossip is gated"),
+			zap.Stringer("nodeID", nodeID),
+		)
+		return
+	}
+	g.queued = append(g.queued, queuedGossip{
+		nodeID:      nodeID,
+		gossipBytes: gossipBytes,
+	})
+}
+
+func (g *GatedHandler) AppRequest(ctx context.Context, nodeID ids.NodeID, deadline time.Time, requestBytes []byte) ([]byte, error) {
+	if !g.gate.IsOpen() {
+		return nil, ErrGossipGated
+	}
+
+	return g.Handler.AppRequest(ctx, nodeID, deadline, requestBytes)
+}
+
+// flush handles the gossip that was queued while the gate was closed.
+func (g *GatedHandler) flush() {
+	g.lock.Lock()
+	queued := g.queued
+	g.queued = nil
+	g.lock.Unlock()
+
+	for _, gossip := range queued {
+		g.Handler.AppGossip(context.Background(), gossip.nodeID, gossip.gossipBytes)
+	}
+}
+
+// GatedGossiper only calls [Gossip] while [Gate] is open
+type GatedGossiper struct {
+	Gossiper
+
+	Gate *Gate
+}
+
+func (g GatedGossiper) Gossip(ctx context.Context) error {
+	if !g.Gate.IsOpen() {
+		return nil
+	}
+
+	return g.Gossiper.Gossip(ctx)
+}
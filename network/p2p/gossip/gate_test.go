@@ -0,0 +1,106 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+func TestGatedHandler(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var (
+		gossiped = make(chan []byte, 3)
+		response = []byte("response")
+		handler  = &p2p.TestHandler{
+			AppGossipF: func(_ context.Context, _ ids.NodeID, gossipBytes []byte) {
+				gossiped <- gossipBytes
+			},
+			AppRequestF: func(context.Context, ids.NodeID, time.Time, []byte) ([]byte, error) {
+				return response, nil
+			},
+		}
+		gate         = &Gate{}
+		gatedHandler = NewGatedHandler(logging.NoLog{}, handler, gate, 2)
+		numGossiped  = 0
+		gossiper     = GatedGossiper{
+			Gossiper: &TestGossiper{
+				GossipF: func(context.Context) error {
+					numGossiped++
+					return nil
+				},
+			},
+			Gate: gate,
+		}
+	)
+
+	// While syncing, gossip is queued up to the limit and requests aren't
+	// served.
+	gate.Close()
+	for i := byte(0); i < 3; i++ {
+		gatedHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{i})
+	}
+	require.Empty(gossiped)
+	_, err := gatedHandler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, nil)
+	require.ErrorIs(err, ErrGossipGated)
+	require.NoError(gossiper.Gossip(ctx))
+	require.Zero(numGossiped)
+
+	// Once synced, the queued gossip is handled and gossip resumes.
+	gate.Open()
+	require.Equal([]byte{0}, <-gossiped)
+	require.Equal([]byte{1}, <-gossiped)
+
+	gatedHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{3})
+	require.Equal([]byte{3}, <-gossiped)
+	got, err := gatedHandler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, nil)
+	require.NoError(err)
+	require.Equal(response, got)
+	require.NoError(gossiper.Gossip(ctx))
+	require.Equal(1, numGossiped)
+}
+
+func TestGateOpenDoesNotWait(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// lock is held by the caller of Open and is needed to handle gossip, as
+	// the engine lock is needed to verify txs.
+	var (
+		lock     sync.Mutex
+		gossiped = make(chan []byte, 1)
+		handler  = &p2p.TestHandler{
+			AppGossipF: func(_ context.Context, _ ids.NodeID, gossipBytes []byte) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				gossiped <- gossipBytes
+			},
+		}
+		gate         = &Gate{}
+		gatedHandler = NewGatedHandler(logging.NoLog{}, handler, gate, 1)
+	)
+
+	gate.Close()
+	gatedHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{0})
+
+	// Open returns without handling the queued gossip, which is handled once
+	// the lock is released.
+	lock.Lock()
+	gate.Open()
+	require.Empty(gossiped)
+	lock.Unlock()
+
+	require.Equal([]byte{0}, <-gossiped)
+}
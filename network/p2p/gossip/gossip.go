@@ -22,6 +22,9 @@ import (
 	"github.com/ava-labs/avalanchego/utils/buffer"
 	"github.com/ava-labs/avalanchego/utils/logging"
 	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+
+	dto "github.com/prometheus/client_model/go"
 )
 
 const (
@@ -63,6 +66,7 @@ var (
 	ErrInvalidDiscardedSize     = errors.New("discarded size cannot be negative")
 	ErrInvalidTargetGossipSize  = errors.New("target gossip size cannot be negative")
 	ErrInvalidRegossipFrequency = errors.New("re-gossip frequency cannot be negative")
+	ErrInvalidMaxGossipLifetime = errors.New("max gossip lifetime cannot be negative")
 
 	errEmptySetCantAdd = errors.New("empty set can not add")
 )
@@ -88,9 +92,27 @@ type Metrics struct {
 	sentBytes               *prometheus.CounterVec
 	receivedCount           *prometheus.CounterVec
 	receivedBytes           *prometheus.CounterVec
+	skippedCount            *prometheus.CounterVec
+	sampleMode              *prometheus.CounterVec
 	tracking                *prometheus.GaugeVec
 	trackingLifetimeAverage prometheus.Gauge
 	topValidators           *prometheus.GaugeVec
+	fanout                  *prometheus.GaugeVec
+	challengeFailures       prometheus.Counter
+	unmarshalFailures       *prometheus.CounterVec
+	uncompressedBytes       prometheus.Counter
+	compressedBytes         prometheus.Counter
+	setSize                 prometheus.Gauge
+	emptyGossip             prometheus.Counter
+
+	// versions are the versions of gossip recorded by the version metrics,
+	// which are only non-nil if the metrics were created with
+	// NewVersionedMetrics.
+	versions             set.Set[string]
+	sentVersionCount     *prometheus.CounterVec
+	sentVersionBytes     *prometheus.CounterVec
+	receivedVersionCount *prometheus.CounterVec
+	receivedVersionBytes *prometheus.CounterVec
 }
 
 // NewMetrics returns a common set of metrics
@@ -119,6 +141,16 @@ func NewMetrics(
 			Name:      "gossip_received_bytes",
 			Help:      "amount of gossip received (bytes)",
 		}, metricLabels),
+		skippedCount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_skipped_count",
+			Help:      "amount of gossip messages skipped due to the peer's version (n)",
+		}, metricLabels),
+		sampleMode: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_sample_mode_count",
+			Help:      "number of gossip rounds that sent a sample or all of the known gossip (n)",
+		}, sampleModeLabels),
 		tracking: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "gossip_tracking",
@@ -134,19 +166,116 @@ func NewMetrics(
 			Name:      "top_validators",
 			Help:      "number of validators gossipables are sent to due to stake",
 		}, metricLabels),
+		fanout: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gossip_fanout",
+			Help:      "number of peers the most recent push gossip was sent to",
+		}, metricLabels),
+		challengeFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_challenge_failures",
+			Help:      "number of pull requests that didn't echo the outstanding challenge of the requester (n)",
+		}),
+		unmarshalFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_unmarshal_failures",
+			Help:      "number of received gossip messages and gossipables that failed to be unmarshalled (n)",
+		}, metricLabels),
+		uncompressedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_response_uncompressed_bytes",
+			Help:      "size of pull gossip responses before they were compressed (bytes)",
+		}),
+		compressedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_response_compressed_bytes",
+			Help:      "size of pull gossip responses after they were compressed (bytes)",
+		}),
+		setSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gossip_set_size",
+			Help:      "number of gossipables in the set being gossiped (n)",
+		}),
+		emptyGossip: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_empty_count",
+			Help:      "number of received gossip messages that contained no gossip (n)",
+		}),
 	}
 	err := utils.Err(
 		metrics.Register(m.sentCount),
 		metrics.Register(m.sentBytes),
 		metrics.Register(m.receivedCount),
 		metrics.Register(m.receivedBytes),
+		metrics.Register(m.skippedCount),
+		metrics.Register(m.sampleMode),
 		metrics.Register(m.tracking),
 		metrics.Register(m.trackingLifetimeAverage),
 		metrics.Register(m.topValidators),
+		metrics.Register(m.fanout),
+		metrics.Register(m.challengeFailures),
+		metrics.Register(m.unmarshalFailures),
+		metrics.Register(m.uncompressedBytes),
+		metrics.Register(m.compressedBytes),
+		metrics.Register(m.setSize),
+		metrics.Register(m.emptyGossip),
 	)
 	return m, err
 }
 
+// MetricsSummary is a snapshot of the lifetime gossip totals tracked by
+// Metrics, across both push and pull gossip.
+type MetricsSummary struct {
+	SentCount     uint64
+	SentBytes     uint64
+	ReceivedCount uint64
+	ReceivedBytes uint64
+}
+
+// Summary returns the lifetime totals of gossip sent and received.
+func (m Metrics) Summary() (MetricsSummary, error) {
+	sentCount, err := sumCounter(m.sentCount)
+	if err != nil {
+		return MetricsSummary{}, err
+	}
+	sentBytes, err := sumCounter(m.sentBytes)
+	if err != nil {
+		return MetricsSummary{}, err
+	}
+	receivedCount, err := sumCounter(m.receivedCount)
+	if err != nil {
+		return MetricsSummary{}, err
+	}
+	receivedBytes, err := sumCounter(m.receivedBytes)
+	if err != nil {
+		return MetricsSummary{}, err
+	}
+	return MetricsSummary{
+		SentCount:     sentCount,
+		SentBytes:     sentBytes,
+		ReceivedCount: receivedCount,
+		ReceivedBytes: receivedBytes,
+	}, nil
+}
+
+// sumCounter returns the sum of the push and pull values of [counter].
+func sumCounter(counter *prometheus.CounterVec) (uint64, error) {
+	var sum uint64
+	for _, labels := range []prometheus.Labels{pushLabels, pullLabels} {
+		metric, err := counter.GetMetricWith(labels)
+		if err != nil {
+			return 0, err
+		}
+
+		var value dto.Metric
+		if err := metric.Write(&value); err != nil {
+			return 0, err
+		}
+		sum += uint64(value.GetCounter().GetValue())
+	}
+	return sum, nil
+}
+
 func (v ValidatorGossiper) Gossip(ctx context.Context) error {
 	if !v.Validators.Has(ctx, v.NodeID) {
 		return nil
@@ -162,8 +291,9 @@ func NewPullGossiper[T Gossipable](
 	client *p2p.Client,
 	metrics Metrics,
 	pollSize int,
+	options ...PullGossiperOption[T],
 ) *PullGossiper[T] {
-	return &PullGossiper[T]{
+	p := &PullGossiper[T]{
 		log:        log,
 		marshaller: marshaller,
 		set:        set,
@@ -171,19 +301,116 @@ func NewPullGossiper[T Gossipable](
 		metrics:    metrics,
 		pollSize:   pollSize,
 	}
+	for _, option := range options {
+		option.apply(p)
+	}
+	if p.echoes != nil && p.peers != nil {
+		p.echoes.sharePeers(p.peers)
+	}
+	return p
+}
+
+// PullGossiperOption configures PullGossiper
+type PullGossiperOption[T Gossipable] interface {
+	apply(p *PullGossiper[T])
+}
+
+type pullGossiperOptionFunc[T Gossipable] func(p *PullGossiper[T])
+
+func (o pullGossiperOptionFunc[T]) apply(p *PullGossiper[T]) {
+	o(p)
+}
+
+// WithCompressedFilter compresses the bloom filter sent in requests. This
+// should only be enabled once all peers support compressed filters.
+func WithCompressedFilter[T Gossipable]() PullGossiperOption[T] {
+	return pullGossiperOptionFunc[T](func(p *PullGossiper[T]) {
+		p.compressFilter = true
+	})
+}
+
+// WithCompressedResponses asks peers to compress their responses. Peers that
+// don't support compressed responses respond uncompressed.
+func WithCompressedResponses[T Gossipable]() PullGossiperOption[T] {
+	return pullGossiperOptionFunc[T](func(p *PullGossiper[T]) {
+		p.compressResponses = true
+	})
+}
+
+// WithMaxResponseSize drops responses that are larger than [maxResponseSize]
+// bytes before they are unmarshalled, which bounds the memory a peer that
+// ignores the target response size can cause to be allocated. If
+// [maxResponseSize] is 0, responses of any size are handled.
+func WithMaxResponseSize[T Gossipable](maxResponseSize int) PullGossiperOption[T] {
+	return pullGossiperOptionFunc[T](func(p *PullGossiper[T]) {
+		p.maxResponseSize = maxResponseSize
+	})
+}
+
+// WithChallengeEcho echoes the challenges included in responses in the next
+// request sent to the responder. Challenges are remembered for up to
+// [maxPeers] peers.
+func WithChallengeEcho[T Gossipable](maxPeers int) PullGossiperOption[T] {
+	return pullGossiperOptionFunc[T](func(p *PullGossiper[T]) {
+		p.echoes = newChallengeEchoes(maxPeers)
+	})
+}
+
+// WithPeerLRU bounds the peers whose challenges are echoed together with the
+// other structures sharing [peers]. This has no effect unless challenges are
+// echoed.
+func WithPeerLRU[T Gossipable](peers *PeerLRU) PullGossiperOption[T] {
+	return pullGossiperOptionFunc[T](func(p *PullGossiper[T]) {
+		p.peers = peers
+	})
 }
 
 type PullGossiper[T Gossipable] struct {
-	log        logging.Logger
-	marshaller Marshaller[T]
-	set        Set[T]
-	client     *p2p.Client
-	metrics    Metrics
-	pollSize   int
+	log            logging.Logger
+	marshaller     Marshaller[T]
+	set            Set[T]
+	client         *p2p.Client
+	metrics        Metrics
+	pollSize       int
+	compressFilter bool
+	// compressResponses is set if peers are asked to compress their responses.
+	compressResponses bool
+	// maxResponseSize, if non-zero, is the size of the largest response that
+	// is handled.
+	maxResponseSize int
+
+	// echoes, if non-nil, holds the challenges to echo to each peer.
+	echoes *challengeEchoes
+	// peers, if non-nil, bounds the peers tracked by echoes.
+	peers *PeerLRU
 }
 
 func (p *PullGossiper[_]) Gossip(ctx context.Context) error {
-	msgBytes, err := MarshalAppRequest(p.set.GetFilter())
+	var flags uint32
+	if p.compressFilter {
+		flags |= compressedFilterFlag
+	}
+	if p.compressResponses {
+		flags |= compressedResponseFlag
+	}
+	filter, salt := p.set.GetFilter()
+
+	if p.echoes != nil {
+		// Requests are built for each peer, so that they include the
+		// challenge of the peer.
+		for _, nodeID := range p.client.SampleNodes(ctx, p.pollSize) {
+			msgBytes, err := marshalAppRequest(filter, salt, flags, p.echoes.pop(nodeID))
+			if err != nil {
+				return err
+			}
+			if err := p.client.AppRequest(ctx, set.Of(nodeID), msgBytes, p.handleResponse); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	msgBytes, err := marshalAppRequest(filter, salt, flags, nil)
 	if err != nil {
 		return err
 	}
@@ -213,40 +440,66 @@ func (p *PullGossiper[_]) handleResponse(
 		return
 	}
 
-	gossip, err := ParseAppResponse(responseBytes)
+	response, err := parseAppResponse(responseBytes, p.maxResponseSize)
+	if err != nil {
+		p.metrics.unmarshalFailures.With(pullLabels).Inc()
+		p.log.Debug(
+			"failed to unmarshal gossip response",
+			zap.Stringer("nodeID", nodeID),
+			zap.Error(err),
+		)
+		return
+	}
+	bundles, err := responseBundles(response)
 	if err != nil {
+		p.metrics.unmarshalFailures.With(pullLabels).Inc()
 		p.log.Debug("failed to unmarshal gossip response", zap.Error(err))
 		return
 	}
+	if p.echoes != nil && len(response.Challenge) > 0 {
+		p.echoes.put(nodeID, response.Challenge)
+	}
 
-	receivedBytes := 0
-	for _, bytes := range gossip {
-		receivedBytes += len(bytes)
-
-		gossipable, err := p.marshaller.UnmarshalGossip(bytes)
-		if err != nil {
-			p.log.Debug(
-				"failed to unmarshal gossip",
-				zap.Stringer("nodeID", nodeID),
-				zap.Error(err),
-			)
-			continue
+	var (
+		receivedCount = 0
+		receivedBytes = 0
+	)
+	for _, bundle := range bundles {
+		receivedCount += len(bundle)
+		for _, bytes := range bundle {
+			receivedBytes += len(bytes)
 		}
 
-		gossipID := gossipable.GossipID()
-		p.log.Debug(
-			"received gossip",
-			zap.Stringer("nodeID", nodeID),
-			zap.Stringer("id", gossipID),
-		)
-		if err := p.set.Add(gossipable); err != nil {
+		// Gossipables in a bundle are added in order, so that dependencies
+		// are added before their dependents. A gossipable that fails to be
+		// added doesn't stop the rest of the bundle from being added, as
+		// the gossipables after it don't necessarily depend on it.
+		for _, bytes := range bundle {
+			gossipable, err := p.marshaller.UnmarshalGossip(bytes)
+			if err != nil {
+				p.metrics.unmarshalFailures.With(pullLabels).Inc()
+				p.log.Debug(
+					"failed to unmarshal gossip",
+					zap.Stringer("nodeID", nodeID),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			gossipID := gossipable.GossipID()
 			p.log.Debug(
-				"failed to add gossip to the known set",
+				"received gossip",
 				zap.Stringer("nodeID", nodeID),
 				zap.Stringer("id", gossipID),
-				zap.Error(err),
 			)
-			continue
+			if err := addFromPeer(p.set, nodeID, gossipable); err != nil {
+				p.log.Debug(
+					"failed to add gossip to the known set",
+					zap.Stringer("nodeID", nodeID),
+					zap.Stringer("id", gossipID),
+					zap.Error(err),
+				)
+			}
 		}
 	}
 
@@ -262,10 +515,82 @@ func (p *PullGossiper[_]) handleResponse(
 		return
 	}
 
-	receivedCountMetric.Add(float64(len(gossip)))
+	receivedCountMetric.Add(float64(receivedCount))
 	receivedBytesMetric.Add(float64(receivedBytes))
 }
 
+// PushGossiperOption configures PushGossiper
+type PushGossiperOption[T Gossipable] interface {
+	apply(p *PushGossiper[T])
+}
+
+type pushGossiperOptionFunc[T Gossipable] func(p *PushGossiper[T])
+
+func (o pushGossiperOptionFunc[T]) apply(p *PushGossiper[T]) {
+	o(p)
+}
+
+// WithPriorityGossipParams configures the PushGossiper to vary the number of
+// peers that a gossipable is initially pushed to based on its priority.
+// [priority] returns the priority of a gossipable and [gossipParams] maps a
+// priority to the branching factor to use for it. Gossipables with a priority
+// that isn't in [gossipParams] use the default gossip params.
+func WithPriorityGossipParams[T Gossipable](
+	priority func(T) int,
+	gossipParams map[int]BranchingFactor,
+) PushGossiperOption[T] {
+	return pushGossiperOptionFunc[T](func(p *PushGossiper[T]) {
+		p.priority = priority
+		p.priorityGossipParams = gossipParams
+	})
+}
+
+// WithPushSampling pushes only a sample of the queued gossipables once the set
+// grows beyond [params.Threshold]. Gossipables that are not sampled are
+// deferred as if they had been gossiped, so they may be sampled again once
+// they are eligible to be regossiped.
+func WithPushSampling[T Gossipable](params SamplingParams[T]) PushGossiperOption[T] {
+	return pushGossiperOptionFunc[T](func(p *PushGossiper[T]) {
+		p.sampling = &params
+	})
+}
+
+// WithMaxGossipLifetime stops pushing a gossipable once [maxLifetime] has
+// passed since it was first added, even if it remains in the set. The
+// gossipable is still served to pull requests, but will not be pushed again
+// if it is re-added.
+func WithMaxGossipLifetime[T Gossipable](maxLifetime time.Duration) PushGossiperOption[T] {
+	return pushGossiperOptionFunc[T](func(p *PushGossiper[T]) {
+		p.maxGossipLifetime = maxLifetime
+	})
+}
+
+// WithConnectedPeers bounds the fan-out reported by the PushGossiper by the
+// number of currently connected [peers].
+func WithConnectedPeers[T Gossipable](peers *p2p.Peers) PushGossiperOption[T] {
+	return pushGossiperOptionFunc[T](func(p *PushGossiper[T]) {
+		p.peers = peers
+	})
+}
+
+// WithPushFilters skips pushing gossip to the peers that [filters] reports
+// already have it. The filter of the set is advertised to the peers that
+// gossip is pushed to at most once every [advertiseFrequency], so that they
+// can avoid pushing gossip we already have. If [advertiseFrequency] is 0, the
+// filter of the set is never advertised.
+//
+// If the connected peers are provided with WithConnectedPeers, the validators,
+// non-validators, and peers that gossip is pushed to are sampled from them, so
+// that gossip is filtered for every peer it is pushed to. Otherwise, gossip is
+// only filtered for the peers that are selected by stake, as the remaining
+// peers are sampled when the gossip is sent.
+func WithPushFilters[T Gossipable](filters *PushFilters, advertiseFrequency time.Duration) PushGossiperOption[T] {
+	return pushGossiperOptionFunc[T](func(p *PushGossiper[T]) {
+		p.pushFilters = filters
+		p.advertiseFrequency = advertiseFrequency
+	})
+}
+
 // NewPushGossiper returns an instance of PushGossiper
 func NewPushGossiper[T Gossipable](
 	marshaller Marshaller[T],
@@ -278,6 +603,7 @@ func NewPushGossiper[T Gossipable](
 	discardedSize int,
 	targetGossipSize int,
 	maxRegossipFrequency time.Duration,
+	options ...PushGossiperOption[T],
 ) (*PushGossiper[T], error) {
 	if err := gossipParams.Verify(); err != nil {
 		return nil, fmt.Errorf("invalid gossip params: %w", err)
@@ -294,7 +620,7 @@ func NewPushGossiper[T Gossipable](
 		return nil, ErrInvalidRegossipFrequency
 	}
 
-	return &PushGossiper[T]{
+	p := &PushGossiper[T]{
 		marshaller:           marshaller,
 		set:                  mempool,
 		validators:           validators,
@@ -309,7 +635,26 @@ func NewPushGossiper[T Gossipable](
 		toGossip:   buffer.NewUnboundedDeque[T](0),
 		toRegossip: buffer.NewUnboundedDeque[T](0),
 		discarded:  &cache.LRU[ids.ID, struct{}]{Size: discardedSize},
-	}, nil
+		expired:    &cache.LRU[ids.ID, struct{}]{Size: discardedSize},
+	}
+	for _, option := range options {
+		option.apply(p)
+	}
+
+	for priority, params := range p.priorityGossipParams {
+		if err := params.Verify(); err != nil {
+			return nil, fmt.Errorf("invalid gossip params for priority %d: %w", priority, err)
+		}
+	}
+	if p.maxGossipLifetime < 0 {
+		return nil, ErrInvalidMaxGossipLifetime
+	}
+	if p.sampling != nil {
+		if err := p.sampling.Verify(); err != nil {
+			return nil, fmt.Errorf("invalid sampling params: %w", err)
+		}
+	}
+	return p, nil
 }
 
 // PushGossiper broadcasts gossip to peers randomly in the network
@@ -324,6 +669,31 @@ type PushGossiper[T Gossipable] struct {
 	regossipParams       BranchingFactor
 	targetGossipSize     int
 	maxRegossipFrequency time.Duration
+	// maxGossipLifetime, if non-zero, is the duration after a gossipable is
+	// added after which it is no longer pushed.
+	maxGossipLifetime time.Duration
+
+	clock mockable.Clock
+
+	// priority, if non-nil, is used to select the gossip params from
+	// priorityGossipParams when a gossipable is pushed for the first time.
+	priority             func(T) int
+	priorityGossipParams map[int]BranchingFactor
+
+	// sampling, if non-nil, limits pushes to a sample of the queued
+	// gossipables under load.
+	sampling *SamplingParams[T]
+
+	// peers, if non-nil, is used to bound the reported fan-out by the number
+	// of connected peers.
+	peers *p2p.Peers
+
+	// pushFilters, if non-nil, is used to skip pushing gossip to peers that
+	// already have it. If advertiseFrequency is non-zero, the filter of the
+	// set is advertised at most once every advertiseFrequency.
+	pushFilters        *PushFilters
+	advertiseFrequency time.Duration
+	lastAdvertised     time.Time
 
 	lock         sync.Mutex
 	tracking     map[ids.ID]*tracking
@@ -331,6 +701,8 @@ type PushGossiper[T Gossipable] struct {
 	toGossip     buffer.Deque[T]
 	toRegossip   buffer.Deque[T]
 	discarded    *cache.LRU[ids.ID, struct{}] // discarded attempts to avoid overgossiping transactions that are frequently dropped
+	expired      *cache.LRU[ids.ID, struct{}] // gossipables that exceeded maxGossipLifetime and should not be pushed again
+	fanout       int                          // number of peers the most recent push was sent to
 }
 
 type BranchingFactor struct {
@@ -373,7 +745,7 @@ type tracking struct {
 // Gossip flushes any queued gossipables.
 func (p *PushGossiper[T]) Gossip(ctx context.Context) error {
 	var (
-		now         = time.Now()
+		now         = p.clock.Time()
 		nowUnixNano = float64(now.UnixNano())
 	)
 
@@ -387,10 +759,16 @@ func (p *PushGossiper[T]) Gossip(ctx context.Context) error {
 		return nil
 	}
 
+	sampled := p.sampling != nil && p.sampling.shouldSample()
+	if p.sampling != nil {
+		p.metrics.observeSampleMode(pushType, sampled)
+	}
+
 	if err := p.gossip(
 		ctx,
 		now,
-		p.gossipParams,
+		sampled,
+		p.initialGossipParams,
 		p.toGossip,
 		p.toRegossip,
 		&cache.Empty[ids.ID, struct{}]{}, // Don't mark dropped unsent transactions as discarded
@@ -402,7 +780,10 @@ func (p *PushGossiper[T]) Gossip(ctx context.Context) error {
 	if err := p.gossip(
 		ctx,
 		now,
-		p.regossipParams,
+		sampled,
+		func(T) BranchingFactor {
+			return p.regossipParams
+		},
 		p.toRegossip,
 		p.toRegossip,
 		p.discarded, // Mark dropped sent transactions as discarded
@@ -413,10 +794,23 @@ func (p *PushGossiper[T]) Gossip(ctx context.Context) error {
 	return nil
 }
 
+// initialGossipParams returns the branching factor to use when pushing
+// [gossipable] for the first time.
+func (p *PushGossiper[T]) initialGossipParams(gossipable T) BranchingFactor {
+	if p.priority == nil {
+		return p.gossipParams
+	}
+	if params, ok := p.priorityGossipParams[p.priority(gossipable)]; ok {
+		return params
+	}
+	return p.gossipParams
+}
+
 func (p *PushGossiper[T]) gossip(
 	ctx context.Context,
 	now time.Time,
-	gossipParams BranchingFactor,
+	sampled bool,
+	gossipParams func(T) BranchingFactor,
 	toGossip buffer.Deque[T],
 	toRegossip buffer.Deque[T],
 	discarded cache.Cacher[ids.ID, struct{}],
@@ -424,10 +818,17 @@ func (p *PushGossiper[T]) gossip(
 ) error {
 	var (
 		sentBytes                   = 0
-		gossip                      = make([][]byte, 0, defaultGossipableCount)
+		numGossip                   = 0
+		gossip                      = make(map[BranchingFactor][][]byte)
+		gossipIDs                   = make(map[BranchingFactor][]ids.ID)
+		nowUnixNano                 = float64(now.UnixNano())
 		maxLastGossipTimeToRegossip = now.Add(-p.maxRegossipFrequency)
 	)
 
+	if sampled {
+		p.sample(now, maxLastGossipTimeToRegossip, toGossip, toRegossip, discarded)
+	}
+
 	for sentBytes < p.targetGossipSize {
 		gossipable, ok := toGossip.PopLeft()
 		if !ok {
@@ -444,6 +845,14 @@ func (p *PushGossiper[T]) gossip(
 			continue
 		}
 
+		// Stop pushing the gossipable if it has been pushed for too long.
+		if p.isExpired(tracking, nowUnixNano) {
+			delete(p.tracking, gossipID)
+			p.addedTimeSum -= tracking.addedTime
+			p.expired.Put(gossipID, struct{}{})
+			continue
+		}
+
 		// Ensure we don't attempt to send a gossipable too frequently.
 		if maxLastGossipTimeToRegossip.Before(tracking.lastGossiped) {
 			// Put the gossipable on the front of the queue to keep items sorted
@@ -459,25 +868,20 @@ func (p *PushGossiper[T]) gossip(
 			return err
 		}
 
-		gossip = append(gossip, bytes)
+		params := gossipParams(gossipable)
+		gossip[params] = append(gossip[params], bytes)
+		gossipIDs[params] = append(gossipIDs[params], gossipID)
+		numGossip++
 		sentBytes += len(bytes)
 		toRegossip.PushRight(gossipable)
 		tracking.lastGossiped = now
 	}
 
 	// If there is nothing to gossip, we can exit early.
-	if len(gossip) == 0 {
+	if numGossip == 0 {
 		return nil
 	}
 
-	// Send gossipables to peers
-	msgBytes, err := MarshalAppGossip(gossip)
-	if err != nil {
-		return err
-	}
-
-	validatorsByStake := p.validators.Top(ctx, gossipParams.StakePercentage)
-
 	sentCountMetric, err := p.metrics.sentCount.GetMetricWith(pushLabels)
 	if err != nil {
 		return fmt.Errorf("failed to get sent count metric: %w", err)
@@ -490,27 +894,232 @@ func (p *PushGossiper[T]) gossip(
 	if err != nil {
 		return fmt.Errorf("failed to get top validators metric: %w", err)
 	}
-	sentCountMetric.Add(float64(len(gossip)))
+	fanoutMetric, err := p.metrics.fanout.GetMetricWith(metricsLabels)
+	if err != nil {
+		return fmt.Errorf("failed to get fanout metric: %w", err)
+	}
+	sentCountMetric.Add(float64(numGossip))
 	sentBytesMetric.Add(float64(sentBytes))
-	topValidatorsMetric.Set(float64(len(validatorsByStake)))
 
-	return p.client.AppGossip(
-		ctx,
-		common.SendConfig{
+	// Gossipables that share the same branching factor are sent together.
+	var (
+		numTopValidators = 0
+		fanout           = 0
+	)
+	for params, gossip := range gossip {
+		filter, salt := p.filterToAdvertise(now)
+		msgBytes, err := MarshalAppGossipWithFilter(gossip, filter, salt)
+		if err != nil {
+			return err
+		}
+
+		validatorsByStake := p.validators.Top(ctx, params.StakePercentage)
+		numTopValidators = max(numTopValidators, len(validatorsByStake))
+		fanout = max(fanout, p.fanoutOf(params, len(validatorsByStake)))
+
+		sendConfig := common.SendConfig{
 			NodeIDs:       set.Of(validatorsByStake...),
-			Validators:    gossipParams.Validators,
-			NonValidators: gossipParams.NonValidators,
-			Peers:         gossipParams.Peers,
-		},
-		msgBytes,
+			Validators:    params.Validators,
+			NonValidators: params.NonValidators,
+			Peers:         params.Peers,
+		}
+		if p.pushFilters != nil {
+			if p.peers != nil {
+				p.sampleTargets(ctx, &sendConfig)
+			}
+			err := p.pushFiltered(ctx, sendConfig.NodeIDs, gossipIDs[params], gossip, filter, salt)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = p.client.AppGossip(ctx, sendConfig, msgBytes)
+		if err != nil {
+			return err
+		}
+	}
+	topValidatorsMetric.Set(float64(numTopValidators))
+	fanoutMetric.Set(float64(fanout))
+	p.fanout = fanout
+	return nil
+}
+
+// sampleTargets samples the validators, non-validators, and peers requested by
+// [config] from the connected peers that aren't already in config.NodeIDs, and
+// adds them to config.NodeIDs. This allows the gossip that the sampled peers
+// are known to have to be filtered. Otherwise, the peers would be sampled when
+// the gossip is sent, which may select peers that are known to have it.
+func (p *PushGossiper[_]) sampleTargets(ctx context.Context, config *common.SendConfig) {
+	var (
+		validators       = set.Of(p.validators.Top(ctx, 1)...)
+		numValidators    = config.Validators
+		numNonValidators = config.NonValidators
+		numPeers         = config.Peers
 	)
+	for _, nodeID := range p.peers.Sample(p.peers.Len()) {
+		if config.NodeIDs.Contains(nodeID) {
+			continue
+		}
+
+		isValidator := validators.Contains(nodeID)
+		switch {
+		case numPeers > 0:
+			numPeers--
+		case isValidator && numValidators > 0:
+			numValidators--
+		case !isValidator && numNonValidators > 0:
+			numNonValidators--
+		default:
+			continue
+		}
+		config.NodeIDs.Add(nodeID)
+	}
+
+	config.Validators = 0
+	config.NonValidators = 0
+	config.Peers = 0
+}
+
+// pushFiltered pushes to each of [nodeIDs] that is known to have some of
+// [gossip] only the gossip it doesn't have, and removes it from [nodeIDs].
+// [gossipIDs] are the IDs of [gossip].
+func (p *PushGossiper[_]) pushFiltered(
+	ctx context.Context,
+	nodeIDs set.Set[ids.NodeID],
+	gossipIDs []ids.ID,
+	gossip [][]byte,
+	filter []byte,
+	salt []byte,
+) error {
+	for nodeID := range nodeIDs {
+		var unknown [][]byte
+		for i, gossipID := range gossipIDs {
+			if !p.pushFilters.has(nodeID, gossipID) {
+				unknown = append(unknown, gossip[i])
+			}
+		}
+		numKnown := len(gossip) - len(unknown)
+		if numKnown == 0 {
+			continue
+		}
+
+		nodeIDs.Remove(nodeID)
+		p.pushFilters.numFiltered.Add(float64(numKnown))
+		if len(unknown) == 0 {
+			continue
+		}
+
+		msgBytes, err := MarshalAppGossipWithFilter(unknown, filter, salt)
+		if err != nil {
+			return err
+		}
+		err = p.client.AppGossip(
+			ctx,
+			common.SendConfig{
+				NodeIDs: set.Of(nodeID),
+			},
+			msgBytes,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterToAdvertise returns the filter of the set to advertise in pushed
+// gossip, or nil if the filter was advertised too recently.
+func (p *PushGossiper[_]) filterToAdvertise(now time.Time) ([]byte, []byte) {
+	if p.pushFilters == nil || p.advertiseFrequency <= 0 || now.Sub(p.lastAdvertised) < p.advertiseFrequency {
+		return nil, nil
+	}
+	p.lastAdvertised = now
+	return p.set.GetFilter()
+}
+
+// fanoutOf returns the number of peers that gossip sent with [params] to
+// [numTopValidators] validators selected by stake targets.
+func (p *PushGossiper[_]) fanoutOf(params BranchingFactor, numTopValidators int) int {
+	fanout := numTopValidators + params.Validators + params.NonValidators + params.Peers
+	if p.peers != nil {
+		fanout = min(fanout, p.peers.Len())
+	}
+	return fanout
+}
+
+// CurrentFanout returns the number of peers that the most recent push was
+// sent to. If gossipables were pushed with different branching factors, the
+// largest fan-out is reported.
+func (p *PushGossiper[_]) CurrentFanout() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.fanout
+}
+
+// sample replaces the gossipables at the front of [toGossip] that are eligible
+// to be gossiped with a sample of them. Gossipables that are not sampled are
+// moved to [toRegossip] as if they were just gossiped.
+func (p *PushGossiper[T]) sample(
+	now time.Time,
+	maxLastGossipTimeToRegossip time.Time,
+	toGossip buffer.Deque[T],
+	toRegossip buffer.Deque[T],
+	discarded cache.Cacher[ids.ID, struct{}],
+) {
+	var candidates []T
+	for {
+		gossipable, ok := toGossip.PopLeft()
+		if !ok {
+			break
+		}
+
+		gossipID := gossipable.GossipID()
+		tracking := p.tracking[gossipID]
+		if !p.set.Has(gossipID) {
+			delete(p.tracking, gossipID)
+			p.addedTimeSum -= tracking.addedTime
+			discarded.Put(gossipID, struct{}{}) // Cache that the item was dropped
+			continue
+		}
+
+		if p.isExpired(tracking, float64(now.UnixNano())) {
+			delete(p.tracking, gossipID)
+			p.addedTimeSum -= tracking.addedTime
+			p.expired.Put(gossipID, struct{}{})
+			continue
+		}
+
+		if maxLastGossipTimeToRegossip.Before(tracking.lastGossiped) {
+			toGossip.PushLeft(gossipable)
+			break
+		}
+		candidates = append(candidates, gossipable)
+	}
+
+	sampled, unsampled := p.sampling.sample(candidates)
+	for _, gossipable := range unsampled {
+		p.tracking[gossipable.GossipID()].lastGossiped = now
+		toRegossip.PushRight(gossipable)
+	}
+	// Push the sample onto the front of the queue in reverse order so that
+	// the highest priority gossipables are sent first.
+	for i := len(sampled) - 1; i >= 0; i-- {
+		toGossip.PushLeft(sampled[i])
+	}
+}
+
+// isExpired returns true if the gossipable tracked by [tracking] has exceeded
+// maxGossipLifetime.
+func (p *PushGossiper[_]) isExpired(tracking *tracking, nowUnixNano float64) bool {
+	return p.maxGossipLifetime > 0 && nowUnixNano-tracking.addedTime >= float64(p.maxGossipLifetime)
 }
 
 // Add enqueues new gossipables to be pushed. If a gossiable is already tracked,
 // it is not added again.
 func (p *PushGossiper[T]) Add(gossipables ...T) {
 	var (
-		now         = time.Now()
+		now         = p.clock.Time()
 		nowUnixNano = float64(now.UnixNano())
 	)
 
@@ -526,6 +1135,9 @@ func (p *PushGossiper[T]) Add(gossipables ...T) {
 		if _, ok := p.tracking[gossipID]; ok {
 			continue
 		}
+		if _, ok := p.expired.Get(gossipID); ok {
+			continue
+		}
 
 		tracking := &tracking{
 			addedTime: nowUnixNano,
@@ -542,6 +1154,45 @@ func (p *PushGossiper[T]) Add(gossipables ...T) {
 	}
 }
 
+// PushStatus is the push gossip state of a single gossipable.
+type PushStatus struct {
+	// Tracked is true if the gossipable is queued to be pushed or regossiped.
+	Tracked bool
+	// Sent is true if the gossipable has been pushed at least once.
+	Sent bool
+	// InCooldown is true if the gossipable was pushed too recently to be
+	// regossiped.
+	InCooldown bool
+	// Discarded is true if the gossipable was recently dropped from the set
+	// after being pushed, so it will not be pushed as new if it is re-added.
+	Discarded bool
+	// Expired is true if the gossipable exceeded the max gossip lifetime, so
+	// it will not be pushed again.
+	Expired bool
+}
+
+// Status returns the push gossip state of [gossipID].
+func (p *PushGossiper[_]) Status(gossipID ids.ID) PushStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, discarded := p.discarded.Get(gossipID)
+	_, expired := p.expired.Get(gossipID)
+	status := PushStatus{
+		Discarded: discarded,
+		Expired:   expired,
+	}
+	tracking, ok := p.tracking[gossipID]
+	if !ok {
+		return status
+	}
+
+	status.Tracked = true
+	status.Sent = !tracking.lastGossiped.IsZero()
+	status.InCooldown = status.Sent && p.clock.Time().Sub(tracking.lastGossiped) < p.maxRegossipFrequency
+	return status
+}
+
 func (p *PushGossiper[_]) updateMetrics(nowUnixNano float64) {
 	var (
 		numUnsent       = float64(p.toGossip.Len())
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/proto"
@@ -184,6 +185,195 @@ func TestGossiperGossip(t *testing.T) {
 	}
 }
 
+func TestPullGossiperChallengeEcho(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	responseSender := &common.FakeSender{
+		SentAppResponse: make(chan []byte, 1),
+	}
+	responseNetwork, err := p2p.NewNetwork(logging.NoLog{}, responseSender, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	responseBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	responseSet := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: responseBloom,
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		responseSet,
+		metrics,
+		units.MiB,
+		WithReplayChallenges[*testTx](ReplayChallengeParams{
+			MaxPeers:  1,
+			Frequency: 1, // challenge every response
+		}),
+	)
+	require.NoError(responseNetwork.AddHandler(0x0, handler))
+
+	requestSender := &common.FakeSender{
+		SentAppRequest: make(chan []byte, 1),
+	}
+	requestNetwork, err := p2p.NewNetwork(logging.NoLog{}, requestSender, prometheus.NewRegistry(), "")
+	require.NoError(err)
+	require.NoError(requestNetwork.Connected(ctx, ids.EmptyNodeID, nil))
+
+	requestBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestSet := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: requestBloom,
+	}
+	gossiper := NewPullGossiper[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		requestSet,
+		requestNetwork.NewClient(0x0),
+		metrics,
+		1,
+		WithChallengeEcho[*testTx](1),
+	)
+
+	// Every request after the first echoes the challenge of the previous
+	// response.
+	for requestID := uint32(1); requestID < 10; requestID += 2 {
+		require.NoError(gossiper.Gossip(ctx))
+		require.NoError(responseNetwork.AppRequest(ctx, ids.EmptyNodeID, requestID, time.Time{}, <-requestSender.SentAppRequest))
+		require.NoError(requestNetwork.AppResponse(ctx, ids.EmptyNodeID, requestID, <-responseSender.SentAppResponse))
+	}
+	require.Zero(testutil.ToFloat64(metrics.challengeFailures))
+}
+
+func TestPullGossiperMaxResponseSize(t *testing.T) {
+	tx := &testTx{id: ids.GenerateTestID()}
+	responseBytes, err := MarshalAppResponse([][]byte{tx.id[:]})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		maxResponseSize int
+		expectedAdded   bool
+	}{
+		{
+			name:            "no max response size",
+			maxResponseSize: 0,
+			expectedAdded:   true,
+		},
+		{
+			name:            "response at max size",
+			maxResponseSize: len(responseBytes),
+			expectedAdded:   true,
+		},
+		{
+			name:            "response larger than max size",
+			maxResponseSize: len(responseBytes) - 1,
+			expectedAdded:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			network, err := p2p.NewNetwork(logging.NoLog{}, &common.FakeSender{}, prometheus.NewRegistry(), "")
+			require.NoError(err)
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+
+			gossiper := NewPullGossiper[*testTx](
+				logging.NoLog{},
+				testMarshaller{},
+				set,
+				network.NewClient(0x0),
+				metrics,
+				1,
+				WithMaxResponseSize[*testTx](tt.maxResponseSize),
+			)
+			gossiper.handleResponse(context.Background(), ids.EmptyNodeID, responseBytes, nil)
+			require.Equal(tt.expectedAdded, set.Has(tx.id))
+		})
+	}
+}
+
+func TestPullGossiperUnmarshalFailures(t *testing.T) {
+	require := require.New(t)
+
+	network, err := p2p.NewNetwork(logging.NoLog{}, &common.FakeSender{}, prometheus.NewRegistry(), "")
+	require.NoError(err)
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	gossiper := NewPullGossiper[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		network.NewClient(0x0),
+		metrics,
+		1,
+	)
+
+	// A response that can't be parsed
+	gossiper.handleResponse(context.Background(), ids.EmptyNodeID, []byte{0xff}, nil)
+	require.Equal(1.0, testutil.ToFloat64(metrics.unmarshalFailures.With(pullLabels)))
+
+	// A response with a gossipable that can't be unmarshalled
+	responseBytes, err := MarshalAppResponse([][]byte{{1, 2, 3}})
+	require.NoError(err)
+	gossiper.handleResponse(context.Background(), ids.EmptyNodeID, responseBytes, nil)
+	require.Equal(2.0, testutil.ToFloat64(metrics.unmarshalFailures.With(pullLabels)))
+	require.Zero(testutil.ToFloat64(metrics.unmarshalFailures.With(pushLabels)))
+}
+
+func TestPullGossiperBundleFailures(t *testing.T) {
+	require := require.New(t)
+
+	network, err := p2p.NewNetwork(logging.NoLog{}, &common.FakeSender{}, prometheus.NewRegistry(), "")
+	require.NoError(err)
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	gossiper := NewPullGossiper[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		network.NewClient(0x0),
+		metrics,
+		1,
+	)
+
+	// Gossipables that follow a gossipable that couldn't be added are still
+	// added, as they don't necessarily depend on it.
+	tx := &testTx{id: ids.GenerateTestID()}
+	responseBytes, err := MarshalAppResponseBundles([][][]byte{{{1, 2, 3}, tx.id[:]}})
+	require.NoError(err)
+	gossiper.handleResponse(context.Background(), ids.EmptyNodeID, responseBytes, nil)
+	require.Equal(1.0, testutil.ToFloat64(metrics.unmarshalFailures.With(pullLabels)))
+	require.True(set.Has(tx.id))
+}
+
 func TestEvery(*testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	calls := 0
@@ -234,6 +424,39 @@ func TestValidatorGossiper(t *testing.T) {
 	require.Equal(2, calls)
 }
 
+func TestMetricsSummary(t *testing.T) {
+	require := require.New(t)
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	summary, err := metrics.Summary()
+	require.NoError(err)
+	require.Zero(summary)
+
+	metrics.sentCount.With(pushLabels).Add(2)
+	metrics.sentCount.With(pullLabels).Add(3)
+	metrics.sentBytes.With(pushLabels).Add(20)
+	metrics.sentBytes.With(pullLabels).Add(30)
+	metrics.receivedCount.With(pushLabels).Add(4)
+	metrics.receivedBytes.With(pullLabels).Add(40)
+
+	// Gauges are not included in the lifetime totals
+	metrics.tracking.With(unsentLabels).Set(100)
+
+	summary, err = metrics.Summary()
+	require.NoError(err)
+	require.Equal(
+		MetricsSummary{
+			SentCount:     5,
+			SentBytes:     50,
+			ReceivedCount: 4,
+			ReceivedBytes: 40,
+		},
+		summary,
+	)
+}
+
 func TestPushGossiperNew(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -242,6 +465,7 @@ func TestPushGossiperNew(t *testing.T) {
 		discardedSize        int
 		targetGossipSize     int
 		maxRegossipFrequency time.Duration
+		maxGossipLifetime    time.Duration
 		expected             error
 	}{
 		{
@@ -353,6 +577,17 @@ func TestPushGossiperNew(t *testing.T) {
 			maxRegossipFrequency: -1,
 			expected:             ErrInvalidRegossipFrequency,
 		},
+		{
+			name: "invalid max gossip lifetime",
+			gossipParams: BranchingFactor{
+				Validators: 1,
+			},
+			regossipParams: BranchingFactor{
+				Validators: 1,
+			},
+			maxGossipLifetime: -1,
+			expected:          ErrInvalidMaxGossipLifetime,
+		},
 	}
 
 	for _, tt := range tests {
@@ -368,6 +603,7 @@ func TestPushGossiperNew(t *testing.T) {
 				tt.discardedSize,
 				tt.targetGossipSize,
 				tt.maxRegossipFrequency,
+				WithMaxGossipLifetime[*testTx](tt.maxGossipLifetime),
 			)
 			require.ErrorIs(t, err, tt.expected)
 		})
@@ -610,3 +846,332 @@ type testValidatorSet struct {
 func (t testValidatorSet) Has(_ context.Context, nodeID ids.NodeID) bool {
 	return t.validators.Contains(nodeID)
 }
+
+// recordingSender records the SendConfig of every AppGossip message sent
+type recordingSender struct {
+	common.FakeSender
+
+	lock    sync.Mutex
+	configs []common.SendConfig
+}
+
+func (r *recordingSender) SendAppGossip(_ context.Context, config common.SendConfig, _ []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.configs = append(r.configs, config)
+	return nil
+}
+
+func TestPushGossiperPriorityGossipParams(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	sender := &recordingSender{}
+	network, err := p2p.NewNetwork(
+		logging.NoLog{},
+		sender,
+		prometheus.NewRegistry(),
+		"",
+	)
+	require.NoError(err)
+	client := network.NewClient(0)
+	validators := p2p.NewValidators(
+		&p2p.Peers{},
+		logging.NoLog{},
+		constants.PrimaryNetworkID,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 1, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		time.Hour,
+	)
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	const highPriority = 1
+	var (
+		defaultParams = BranchingFactor{
+			Validators: 1,
+		}
+		highPriorityParams = BranchingFactor{
+			Validators: 10,
+		}
+	)
+	gossiper, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		FullSet[*testTx]{},
+		validators,
+		client,
+		metrics,
+		defaultParams,
+		defaultParams,
+		0, // the discarded cache size doesn't matter for this test
+		units.MiB,
+		time.Hour,
+		WithPriorityGossipParams[*testTx](
+			func(tx *testTx) int {
+				return int(tx.id[0])
+			},
+			map[int]BranchingFactor{
+				highPriority: highPriorityParams,
+			},
+		),
+	)
+	require.NoError(err)
+
+	gossiper.Add(
+		&testTx{id: ids.ID{0}},
+		&testTx{id: ids.ID{highPriority}},
+	)
+	require.NoError(gossiper.Gossip(ctx))
+
+	// The high priority tx should have been sent in a separate message to
+	// more peers.
+	require.Len(sender.configs, 2)
+	require.ElementsMatch(
+		[]int{
+			defaultParams.Validators,
+			highPriorityParams.Validators,
+		},
+		[]int{
+			sender.configs[0].Validators,
+			sender.configs[1].Validators,
+		},
+	)
+}
+
+func TestPushGossiperMaxGossipLifetime(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	sender := &common.FakeSender{
+		SentAppGossip: make(chan []byte, 1),
+	}
+	network, err := p2p.NewNetwork(
+		logging.NoLog{},
+		sender,
+		prometheus.NewRegistry(),
+		"",
+	)
+	require.NoError(err)
+	client := network.NewClient(0)
+	validators := p2p.NewValidators(
+		&p2p.Peers{},
+		logging.NoLog{},
+		constants.PrimaryNetworkID,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 1, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		time.Hour,
+	)
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	const (
+		regossipFrequency = time.Second
+		maxGossipLifetime = time.Minute
+	)
+	gossiper, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		set,
+		validators,
+		client,
+		metrics,
+		BranchingFactor{
+			Validators: 1,
+		},
+		BranchingFactor{
+			Validators: 1,
+		},
+		0, // the discarded cache size doesn't matter for this test
+		units.MiB,
+		regossipFrequency,
+		WithMaxGossipLifetime[*testTx](maxGossipLifetime),
+	)
+	require.NoError(err)
+
+	requirePushed := func(expected bool) {
+		select {
+		case <-sender.SentAppGossip:
+			require.True(expected, "unexpectedly sent gossip message")
+		default:
+			require.False(expected, "expected gossip message to be sent")
+		}
+	}
+
+	startTime := time.Unix(0, 0)
+	gossiper.clock.Set(startTime)
+
+	tx := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx))
+	gossiper.Add(tx)
+	require.NoError(gossiper.Gossip(ctx))
+	requirePushed(true)
+
+	// The tx is regossiped until it exceeds the max gossip lifetime
+	gossiper.clock.Set(startTime.Add(maxGossipLifetime - regossipFrequency))
+	require.NoError(gossiper.Gossip(ctx))
+	requirePushed(true)
+
+	gossiper.clock.Set(startTime.Add(maxGossipLifetime))
+	require.NoError(gossiper.Gossip(ctx))
+	requirePushed(false)
+	require.Equal(PushStatus{Expired: true}, gossiper.Status(tx.id))
+
+	// Re-adding the tx doesn't cause it to be pushed again
+	gossiper.Add(tx)
+	gossiper.clock.Set(startTime.Add(2 * maxGossipLifetime))
+	require.NoError(gossiper.Gossip(ctx))
+	requirePushed(false)
+
+	// The tx is still served to pull requests
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+	)
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Equal([][]byte{tx.id[:]}, gossip)
+}
+
+func TestPushGossiperCurrentFanout(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	network, err := p2p.NewNetwork(
+		logging.NoLog{},
+		&common.FakeSender{},
+		prometheus.NewRegistry(),
+		"",
+	)
+	require.NoError(err)
+	client := network.NewClient(0)
+
+	validatorSet := make(map[ids.NodeID]*validators.GetValidatorOutput)
+	for i := 0; i < 4; i++ {
+		nodeID := ids.GenerateTestNodeID()
+		validatorSet[nodeID] = &validators.GetValidatorOutput{
+			NodeID: nodeID,
+			Weight: 1,
+		}
+	}
+	validators := p2p.NewValidators(
+		network.Peers,
+		logging.NoLog{},
+		constants.PrimaryNetworkID,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 1, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return validatorSet, nil
+			},
+		},
+		time.Hour,
+	)
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	params := BranchingFactor{
+		StakePercentage: .5,
+		Validators:      1,
+		Peers:           2,
+	}
+	gossiper, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		FullSet[*testTx]{},
+		validators,
+		client,
+		metrics,
+		params,
+		params,
+		0, // the discarded cache size doesn't matter for this test
+		units.MiB,
+		0, // regossip on every round
+		WithConnectedPeers[*testTx](network.Peers),
+	)
+	require.NoError(err)
+
+	// Nothing has been pushed yet
+	require.Zero(gossiper.CurrentFanout())
+
+	gossiper.Add(&testTx{id: ids.GenerateTestID()})
+	tests := []struct {
+		numPeers       int
+		expectedFanout int
+	}{
+		{
+			numPeers:       0,
+			expectedFanout: 0,
+		},
+		{
+			numPeers:       3,
+			expectedFanout: 3,
+		},
+		{
+			numPeers:       10,
+			expectedFanout: 5, // 2 top validators + 1 validator + 2 peers
+		},
+	}
+	for _, test := range tests {
+		for network.Peers.Len() < test.numPeers {
+			require.NoError(network.Connected(ctx, ids.GenerateTestNodeID(), nil))
+		}
+		require.NoError(gossiper.Gossip(ctx))
+		require.Equal(test.expectedFanout, gossiper.CurrentFanout())
+	}
+}
+
+func TestPushGossiperInvalidPriorityGossipParams(t *testing.T) {
+	_, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		FullSet[*testTx]{},
+		nil,
+		nil,
+		Metrics{},
+		BranchingFactor{
+			Validators: 1,
+		},
+		BranchingFactor{
+			Validators: 1,
+		},
+		0,
+		0,
+		0,
+		WithPriorityGossipParams[*testTx](
+			func(*testTx) int {
+				return 0
+			},
+			map[int]BranchingFactor{
+				1: {},
+			},
+		),
+	)
+	require.ErrorIs(t, err, ErrInvalidNumToGossip)
+}
@@ -29,3 +29,66 @@ type Set[T Gossipable] interface {
 	// corresponding salt.
 	GetFilter() (bloom []byte, salt []byte)
 }
+
+// SnapshotSet is a Set that can be iterated over without holding its lock. If
+// a Set implements SnapshotSet, pull requests are served with
+// IterateSnapshot, so that marshalling the served gossipables doesn't block
+// modifications of the set.
+type SnapshotSet[T Gossipable] interface {
+	Set[T]
+	// IterateSnapshot iterates over a point-in-time copy of the set until [f]
+	// returns false.
+	IterateSnapshot(f func(gossipable T) bool)
+}
+
+// PeerAwareSet is a Set that is told which peer a gossipable was received
+// from. If a Set implements PeerAwareSet, AddFromPeer is called instead of Add
+// when handling gossip received from a peer.
+type PeerAwareSet[T Gossipable] interface {
+	Set[T]
+	// AddFromPeer adds a Gossipable that was received from [nodeID] to the
+	// set. Returns an error if gossipable was not added.
+	AddFromPeer(nodeID ids.NodeID, gossipable T) error
+}
+
+// addFromPeer adds [gossipable], which was received from [nodeID], to [set].
+func addFromPeer[T Gossipable](set Set[T], nodeID ids.NodeID, gossipable T) error {
+	if set, ok := set.(PeerAwareSet[T]); ok {
+		return set.AddFromPeer(nodeID, gossipable)
+	}
+	return set.Add(gossipable)
+}
+
+// BatchPeerAwareSet is a PeerAwareSet that adds a batch of gossipables
+// received from a peer at once. If a Set implements BatchPeerAwareSet,
+// AddBatchFromPeer is called with all of the gossipables in a single push
+// gossip message.
+type BatchPeerAwareSet[T Gossipable] interface {
+	PeerAwareSet[T]
+	// AddBatchFromPeer adds gossipables that were received from [nodeID] to
+	// the set. Returns an error for each gossipable that was not added,
+	// aligned with [gossipables].
+	AddBatchFromPeer(nodeID ids.NodeID, gossipables []T) []error
+}
+
+// addBatchFromPeer adds [gossipables], which were received from [nodeID], to
+// [set]. The returned errors are aligned with [gossipables].
+func addBatchFromPeer[T Gossipable](set Set[T], nodeID ids.NodeID, gossipables []T) []error {
+	if set, ok := set.(BatchPeerAwareSet[T]); ok {
+		return set.AddBatchFromPeer(nodeID, gossipables)
+	}
+
+	errs := make([]error, len(gossipables))
+	for i, gossipable := range gossipables {
+		errs[i] = addFromPeer(set, nodeID, gossipable)
+	}
+	return errs
+}
+
+// Prioritizer ranks gossipables, such as by the fee they pay, so that the
+// gossipables with the highest priority are served to pull requests first.
+type Prioritizer[T Gossipable] interface {
+	// Priority returns the priority of [gossipable]. Gossipables with a
+	// higher priority are served first.
+	Priority(gossipable T) uint64
+}
@@ -4,19 +4,224 @@
 package gossip
 
 import (
+	"cmp"
 	"context"
-	"fmt"
+	"errors"
+	"slices"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/trace"
 	"github.com/ava-labs/avalanchego/utils/bloom"
+	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+	"github.com/ava-labs/avalanchego/version"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
-var _ p2p.Handler = (*Handler[*testTx])(nil)
+// MaxResponseSize is the hard maximum number of bytes of gossip included in a
+// response, regardless of the configured target response size. It leaves room
+// within the maximum message size for the encoding of the response.
+const MaxResponseSize = constants.DefaultMaxMessageSize / 2
+
+// abandonCheckFrequency is the number of gossipables iterated over between
+// checks of whether a request was abandoned.
+const abandonCheckFrequency = 64
+
+var (
+	_ p2p.Handler = (*Handler[*testTx])(nil)
+
+	errPeerVersionTooOld = errors.New("peer version too old")
+)
+
+// HandlerOption configures Handler
+type HandlerOption[T Gossipable] interface {
+	apply(handler *Handler[T])
+}
+
+type handlerOptionFunc[T Gossipable] func(handler *Handler[T])
+
+func (o handlerOptionFunc[T]) apply(handler *Handler[T]) {
+	o(handler)
+}
+
+// WithMinPeerVersion skips gossip messages from peers that connected with a
+// version before [minVersion]. Peers that are not connected are skipped as
+// well.
+func WithMinPeerVersion[T Gossipable](peers *p2p.Peers, minVersion *version.Application) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.peers = peers
+		handler.minPeerVersion = minVersion
+	})
+}
+
+// WithPeerStats records the gossip activity of each peer in [stats].
+func WithPeerStats[T Gossipable](stats *PeerStatsTracker) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.peerStats = stats
+	})
+}
+
+// WithServeSampling responds to pull requests with only a sample of the set
+// once the set grows beyond [params.Threshold]. [params] must be valid.
+func WithServeSampling[T Gossipable](params SamplingParams[T]) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.sampling = &params
+	})
+}
+
+// WithAdaptiveResponseSize reduces the target response size for peers whose
+// responses are not expected to arrive before the deadline of their requests.
+func WithAdaptiveResponseSize[T Gossipable](params AdaptiveResponseSizeParams) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.responseSizer = newResponseSizer(params)
+	})
+}
+
+// WithDependencyBundles responds to pull requests with gossipables grouped into
+// bundles of gossipables that depend on each other, so that the requester can
+// apply each bundle in order. [dependencies] returns the IDs of the
+// gossipables that a gossipable depends on.
+func WithDependencyBundles[T Gossipable](dependencies func(T) []ids.ID) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.dependencies = dependencies
+	})
+}
+
+// WithStatusHints annotates each gossipable returned to a pull request with a
+// hint of its [status], if the requester asks for status hints.
+func WithStatusHints[T Gossipable](status func(T) Status) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.status = status
+	})
+}
+
+// WithReplayChallenges occasionally includes a challenge in responses to pull
+// requests that the requester must echo in its next request. Requests that
+// fail to echo the outstanding challenge of the requester are recorded, as the
+// requester may be replaying stale requests. [params] must be valid.
+func WithReplayChallenges[T Gossipable](params ReplayChallengeParams) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.challenger = newChallenger(params)
+	})
+}
+
+// WithConflicts includes the known conflicts of each gossipable in responses
+// to pull requests, so that the requester learns the full set of conflicting
+// gossipables at once. [conflicts] returns the gossipables that conflict with
+// a gossipable and are not in the set.
+func WithConflicts[T Gossipable](conflicts func(T) []T) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.conflicts = conflicts
+	})
+}
+
+// WithSnapshot serves pull requests from [snapshot] rather than from the set,
+// so that serving requests doesn't contend with modifications of the set.
+func WithSnapshot[T Gossipable](snapshot *Snapshot[T]) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.snapshot = snapshot
+	})
+}
+
+// WithResponseFilter only includes the gossipables for which [include] returns
+// true in responses to pull requests, such as to exclude gossipables that were
+// issued locally and are already pushed to peers. If [include] is nil, every
+// gossipable is included.
+func WithResponseFilter[T Gossipable](include func(T) bool) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.include = include
+	})
+}
+
+// WithMaxAge excludes gossipables that were added to the set more than
+// [maxAge] ago from responses to pull requests, as they are unlikely to still
+// be useful to the requester. [addedTime] returns when a gossipable was added
+// to the set, or false if it isn't known, in which case the gossipable is
+// included. Excluded gossipables don't count towards the target response size.
+func WithMaxAge[T Gossipable](addedTime func(T) (time.Time, bool), maxAge time.Duration) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.addedTime = addedTime
+		handler.maxAge = maxAge
+	})
+}
+
+// WithTargetResponseItems bounds responses to pull requests to
+// [targetResponseItems] gossipables, in addition to the target response size.
+// Sets of many small gossipables may otherwise respond with a large number of
+// gossipables that are expensive for the requester to process. If 0, the
+// number of gossipables is unlimited.
+func WithTargetResponseItems[T Gossipable](targetResponseItems int) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.targetResponseItems = targetResponseItems
+	})
+}
+
+// WithReceivedPushFilters records the gossip pushed to us and the filters
+// advertised by the peers that pushed it in [filters], so that a PushGossiper
+// sharing [filters] avoids pushing gossip back to peers that already have it.
+func WithReceivedPushFilters[T Gossipable](filters *PushFilters) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.pushFilters = filters
+	})
+}
+
+// WithResponseCompression compresses responses that are larger than
+// [threshold] bytes, if the requester supports compressed responses.
+func WithResponseCompression[T Gossipable](threshold int) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.compressResponses = true
+		handler.compressionThreshold = threshold
+	})
+}
+
+// WithOnInvalidGossip calls [onInvalidGossip] with the number of gossipables
+// that failed to be unmarshalled or added to the set whenever at least
+// [minFailureRatio] of the gossipables pushed by a peer fail, so that peers
+// that persistently push junk can be down-ranked or throttled.
+func WithOnInvalidGossip[T Gossipable](
+	minFailureRatio float64,
+	onInvalidGossip func(nodeID ids.NodeID, count int),
+) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.minFailureRatio = minFailureRatio
+		handler.onInvalidGossip = onInvalidGossip
+	})
+}
+
+// WithMarshalCache serves pull requests with the marshalled bytes of
+// gossipables in [cache], rather than marshalling them for every request.
+func WithMarshalCache[T Gossipable](cache *MarshalCache) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.marshalCache = cache
+	})
+}
+
+// WithTracer records a span for every pull request served and every push of
+// gossip received with [tracer].
+func WithTracer[T Gossipable](tracer trace.Tracer) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.tracer = tracer
+	})
+}
+
+// WithPrioritizer responds to pull requests with the gossipables that
+// [prioritizer] ranks highest first, so that they are included even if the
+// response is full before every gossipable is served. Gossipables of the same
+// priority are served in the order of the set.
+func WithPrioritizer[T Gossipable](prioritizer Prioritizer[T]) HandlerOption[T] {
+	return handlerOptionFunc[T](func(handler *Handler[T]) {
+		handler.prioritizer = prioritizer
+	})
+}
 
 func NewHandler[T Gossipable](
 	log logging.Logger,
@@ -24,103 +229,605 @@ func NewHandler[T Gossipable](
 	set Set[T],
 	metrics Metrics,
 	targetResponseSize int,
+	options ...HandlerOption[T],
 ) *Handler[T] {
-	return &Handler[T]{
+	h := &Handler[T]{
 		Handler:            p2p.NoOpHandler{},
 		log:                log,
 		marshaller:         marshaller,
 		set:                set,
 		metrics:            metrics,
 		targetResponseSize: targetResponseSize,
+		tracer:             trace.Noop,
 	}
+	for _, option := range options {
+		option.apply(h)
+	}
+	if versioner, ok := marshaller.(GossipVersioner); ok && metrics.versioned() {
+		h.versioner = versioner
+	}
+	return h
 }
 
 type Handler[T Gossipable] struct {
 	p2p.Handler
 	marshaller         Marshaller[T]
 	log                logging.Logger
+	clock              mockable.Clock
 	set                Set[T]
 	metrics            Metrics
 	targetResponseSize int
+	// targetResponseItems, if non-zero, is the number of gossipables after
+	// which a response is considered full.
+	targetResponseItems int
+
+	// If [minPeerVersion] is non-nil, messages from peers with a version
+	// before it are skipped.
+	peers          *p2p.Peers
+	minPeerVersion *version.Application
+
+	// peerStats, if non-nil, records the gossip activity of each peer.
+	peerStats *PeerStatsTracker
+
+	// sampling, if non-nil, limits responses to a sample of the set under
+	// load.
+	sampling *SamplingParams[T]
+
+	// responseSizer, if non-nil, reduces the target response size of peers
+	// whose responses are late.
+	responseSizer *responseSizer
+
+	// dependencies, if non-nil, is used to group responses into bundles of
+	// gossipables that depend on each other.
+	dependencies func(T) []ids.ID
+
+	// conflicts, if non-nil, returns the conflicts of a gossipable to include
+	// in responses after the gossipable.
+	conflicts func(T) []T
+
+	// status, if non-nil, returns the status hint of a gossipable to include
+	// in responses to requesters that ask for status hints.
+	status func(T) Status
+
+	// challenger, if non-nil, challenges requesters to echo a nonce in their
+	// next request.
+	challenger *challenger
+
+	// snapshot, if non-nil, is iterated over to serve pull requests instead
+	// of the set.
+	snapshot *Snapshot[T]
+
+	// include, if non-nil, returns true if a gossipable should be included in
+	// responses to pull requests.
+	include func(T) bool
+
+	// addedTime, if non-nil, returns when a gossipable was added to the set.
+	// Gossipables that were added more than maxAge ago aren't included in
+	// responses to pull requests.
+	addedTime func(T) (time.Time, bool)
+	maxAge    time.Duration
+
+	// pushFilters, if non-nil, records the gossip known by the peers that
+	// push gossip to us.
+	pushFilters *PushFilters
+
+	// compressResponses is set if responses larger than compressionThreshold
+	// are compressed for requesters that support compressed responses.
+	compressResponses    bool
+	compressionThreshold int
+
+	// onInvalidGossip, if non-nil, is called when at least minFailureRatio of
+	// the gossipables pushed by a peer fail to be added.
+	onInvalidGossip func(nodeID ids.NodeID, count int)
+	minFailureRatio float64
+
+	// marshalCache, if non-nil, caches the marshalled bytes of the gossipables
+	// served to pull requests.
+	marshalCache *MarshalCache
+
+	// versioner, if non-nil, reports the versions of the gossip that is
+	// served and received to record it in the version metrics.
+	versioner GossipVersioner
+
+	// prioritizer, if non-nil, orders responses to pull requests by the
+	// priority of the gossipables.
+	prioritizer Prioritizer[T]
+
+	// tracer records the handling of requests and gossip. By default, nothing
+	// is recorded.
+	tracer trace.Tracer
 }
 
-func (h Handler[T]) AppRequest(_ context.Context, _ ids.NodeID, _ time.Time, requestBytes []byte) ([]byte, error) {
-	filter, salt, err := ParseAppRequest(requestBytes)
+// shouldSkip returns true if messages from [nodeID] should not be processed
+// because of the version of the peer.
+func (h Handler[_]) shouldSkip(nodeID ids.NodeID, labels prometheus.Labels) bool {
+	if h.minPeerVersion == nil {
+		return false
+	}
+
+	nodeVersion, ok := h.peers.Version(nodeID)
+	if ok && nodeVersion != nil && !nodeVersion.Before(h.minPeerVersion) {
+		return false
+	}
+
+	h.log.Debug("skipping gossip message",
+		zap.Stringer("nodeID", nodeID),
+		zap.Stringer("nodeVersion", nodeVersion),
+		zap.Stringer("minVersion", h.minPeerVersion),
+	)
+
+	skippedCountMetric, err := h.metrics.skippedCount.GetMetricWith(labels)
+	if err != nil {
+		h.log.Error("failed to get skipped count metric", zap.Error(err))
+		return true
+	}
+	skippedCountMetric.Inc()
+	return true
+}
+
+// iterate iterates over the gossipables that are served to pull requests.
+func (h Handler[T]) iterate(f func(gossipable T) bool) {
+	if h.include != nil {
+		iterateIncluded := f
+		f = func(gossipable T) bool {
+			return !h.include(gossipable) || iterateIncluded(gossipable)
+		}
+	}
+
+	if h.addedTime != nil {
+		var (
+			minAddedTime = h.clock.Time().Add(-h.maxAge)
+			iterateFresh = f
+		)
+		f = func(gossipable T) bool {
+			addedTime, ok := h.addedTime(gossipable)
+			return (ok && addedTime.Before(minAddedTime)) || iterateFresh(gossipable)
+		}
+	}
+
+	if h.snapshot != nil {
+		h.snapshot.Iterate(f)
+		return
+	}
+	if set, ok := h.set.(SnapshotSet[T]); ok {
+		set.IterateSnapshot(f)
+		return
+	}
+	h.set.Iterate(f)
+}
+
+func (h Handler[T]) AppRequest(ctx context.Context, nodeID ids.NodeID, deadline time.Time, requestBytes []byte) ([]byte, error) {
+	ctx, span := h.tracer.Start(ctx, "gossipHandler.AppRequest", oteltrace.WithAttributes(
+		attribute.Stringer("nodeID", nodeID),
+		attribute.Int("requestLen", len(requestBytes)),
+	))
+	defer span.End()
+
+	if h.shouldSkip(nodeID, pullLabels) {
+		return nil, errPeerVersionTooOld
+	}
+
+	request, err := parseAppRequest(requestBytes)
 	if err != nil {
 		return nil, err
 	}
+	var (
+		filter = request.filter
+		salt   = request.salt
+	)
 
-	responseSize := 0
-	gossipBytes := make([][]byte, 0)
-	h.set.Iterate(func(gossipable T) bool {
-		gossipID := gossipable.GossipID()
+	var challenge []byte
+	if h.challenger != nil {
+		if err := h.challenger.verify(nodeID, request.challengeEcho); err != nil {
+			h.log.Debug("failed replay challenge",
+				zap.Stringer("nodeID", nodeID),
+				zap.Error(err),
+			)
+			h.metrics.challengeFailures.Inc()
+			if h.peerStats != nil {
+				h.peerStats.update(nodeID, func(stats *GossipPeerStats) {
+					stats.NumChallengeFailures++
+				})
+			}
+		}
 
-		// filter out what the requesting peer already knows about
-		if bloom.Contains(filter, gossipID[:], salt[:]) {
-			return true
+		challenge, err = h.challenger.challenge(nodeID)
+		if err != nil {
+			return nil, err
 		}
+	}
 
+	targetResponseSize := h.targetResponseSize
+	if h.responseSizer != nil {
+		targetResponseSize = h.responseSizer.targetResponseSize(nodeID, h.targetResponseSize)
+	}
+
+	var (
+		responseSize = 0
+		// response is marshalled as gossipables are appended, so that the
+		// gossipables aren't copied again once the response is complete.
+		response appResponseWriter
+		// statuses is non-nil if the status hints of the gossip are included
+		// in the response.
+		statuses []Status
+		// served is non-nil if the gossip in the response is recorded by
+		// version once the response is complete.
+		served [][]byte
+		// exceededMax is set if a gossipable was not added to the response
+		// because it would have exceeded MaxResponseSize.
+		exceededMax bool
+	)
+	if h.status != nil && request.flags&statusHintsFlag != 0 {
+		statuses = make([]Status, 0)
+	}
+	if h.versioner != nil {
+		served = make([][]byte, 0)
+	}
+	appendGossip := func(gossipable T) bool {
 		var bytes []byte
-		bytes, err = h.marshaller.MarshalGossip(gossipable)
+		bytes, err = marshal(h.marshalCache, h.marshaller, gossipable)
 		if err != nil {
 			return false
 		}
 
+		// Never exceed the hard maximum response size, regardless of the
+		// target response size. As responseSize <= MaxResponseSize, the
+		// subtraction can't overflow.
+		if len(bytes) > MaxResponseSize-responseSize {
+			exceededMax = true
+			return false
+		}
+
 		// check that this doesn't exceed our maximum configured target response
 		// size
-		gossipBytes = append(gossipBytes, bytes)
+		response.appendGossip(bytes)
 		responseSize += len(bytes)
+		if statuses != nil {
+			statuses = append(statuses, h.status(gossipable))
+		}
+		if served != nil {
+			served = append(served, bytes)
+		}
 
-		return responseSize <= h.targetResponseSize
-	})
+		if h.targetResponseItems > 0 && response.numGossip >= h.targetResponseItems {
+			return false
+		}
+		return responseSize <= targetResponseSize
+	}
+
+	// newConflicts returns the conflicts of [gossipable] that the requesting
+	// peer doesn't know about and that haven't already been included.
+	var includedConflicts set.Set[ids.ID]
+	newConflicts := func(gossipable T) []T {
+		if h.conflicts == nil {
+			return nil
+		}
+
+		var conflicts []T
+		for _, conflict := range h.conflicts(gossipable) {
+			gossipID := conflict.GossipID()
+			if includedConflicts.Contains(gossipID) || bloom.Contains(filter, gossipID[:], salt[:]) {
+				continue
+			}
+			includedConflicts.Add(gossipID)
+			conflicts = append(conflicts, conflict)
+		}
+		return conflicts
+	}
+	appendWithConflicts := func(gossipable T) bool {
+		if !appendGossip(gossipable) {
+			return false
+		}
+		for _, conflict := range newConflicts(gossipable) {
+			if !appendGossip(conflict) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// abandoned is called for every gossipable that is iterated over, and
+	// periodically reports if the requester has abandoned the request, in
+	// which case iteration stops and whatever was gathered is returned.
+	var numIterated int
+	abandoned := func() bool {
+		numIterated++
+		if numIterated%abandonCheckFrequency != 1 {
+			return false
+		}
+		return ctx.Err() != nil || (!deadline.IsZero() && !h.clock.Time().Before(deadline))
+	}
+
+	sampled := h.sampling != nil && h.sampling.shouldSample()
+	if h.sampling != nil {
+		h.metrics.observeSampleMode(pullType, sampled)
+	}
+
+	var bundleSizes []int
+	if sampled || h.dependencies != nil || h.prioritizer != nil {
+		var candidates []T
+		h.iterate(func(gossipable T) bool {
+			if abandoned() {
+				return false
+			}
+
+			gossipID := gossipable.GossipID()
+
+			// filter out what the requesting peer already knows about
+			if !bloom.Contains(filter, gossipID[:], salt[:]) {
+				candidates = append(candidates, gossipable)
+			}
+			return true
+		})
+
+		if sampled {
+			candidates, _ = h.sampling.sample(candidates)
+		}
+		if h.prioritizer != nil {
+			sortByPriority(h.prioritizer.Priority, candidates)
+		}
+
+		if h.dependencies == nil {
+			for _, gossipable := range candidates {
+				if !appendWithConflicts(gossipable) {
+					break
+				}
+			}
+		} else {
+			// Bundles are only sent in full, so a response may exceed the
+			// target response size by more than a single gossipable.
+		bundles:
+			for _, bundle := range bundle(candidates, h.dependencies) {
+				var (
+					full            = false
+					bundleStart     = response.mark()
+					bundleStartSize = responseSize
+				)
+				for _, gossipable := range bundle {
+					full = !appendGossip(gossipable) || full
+					if err != nil || exceededMax {
+						break
+					}
+				}
+				if err != nil {
+					break
+				}
+				if exceededMax {
+					// Drop the partially added bundle
+					response.truncate(bundleStart)
+					responseSize = bundleStartSize
+					if statuses != nil {
+						statuses = statuses[:bundleStart.numGossip]
+					}
+					if served != nil {
+						served = served[:bundleStart.numGossip]
+					}
+					break
+				}
+
+				bundleSizes = append(bundleSizes, len(bundle))
+				if full {
+					break
+				}
+
+				// Conflicts are sent as their own bundles. The requester fails
+				// to add all but one of a set of conflicts, which would stop it
+				// from applying the rest of a bundle.
+				for _, gossipable := range bundle {
+					for _, conflict := range newConflicts(gossipable) {
+						full = !appendGossip(conflict)
+						if err != nil || exceededMax {
+							break bundles
+						}
+
+						bundleSizes = append(bundleSizes, 1)
+						if full {
+							break bundles
+						}
+					}
+				}
+			}
+		}
+	} else {
+		h.iterate(func(gossipable T) bool {
+			if abandoned() {
+				return false
+			}
+
+			gossipID := gossipable.GossipID()
+
+			// filter out what the requesting peer already knows about
+			if bloom.Contains(filter, gossipID[:], salt[:]) {
+				return true
+			}
+			return appendWithConflicts(gossipable)
+		})
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	if h.responseSizer != nil {
+		h.responseSizer.observe(nodeID, deadline, responseSize, h.targetResponseSize)
+	}
+
+	// Failing to update the metrics shouldn't prevent the gossip from being
+	// served.
+	h.observeSent(response.numGossip, responseSize)
+	h.observeSetSize()
+	if served != nil {
+		tally := make(versionTally)
+		for _, bytes := range served {
+			tally.add(h.versioner, h.metrics.versions, bytes)
+		}
+		tally.observe(h.metrics.sentVersionCount, h.metrics.sentVersionBytes, pullType)
+	}
+
+	if h.peerStats != nil {
+		h.peerStats.update(nodeID, func(stats *GossipPeerStats) {
+			stats.SentCount += uint64(response.numGossip)
+			stats.SentBytes += uint64(responseSize)
+		})
+	}
+
+	responseBytes := response.marshal(bundleSizes, statuses, challenge)
+	span.SetAttributes(
+		attribute.Int("numGossip", response.numGossip),
+		attribute.Int("gossipLen", responseSize),
+		attribute.Int("responseLen", len(responseBytes)),
+	)
+	if !h.compressResponses || request.flags&compressedResponseFlag == 0 || len(responseBytes) <= h.compressionThreshold {
+		return responseBytes, nil
+	}
+
+	compressedBytes, err := compressAppResponse(responseBytes)
+	if err != nil {
+		return nil, err
+	}
+	// Responses that don't compress well are sent as they are.
+	if len(compressedBytes) >= len(responseBytes) {
+		return responseBytes, nil
+	}
+	h.metrics.uncompressedBytes.Add(float64(len(responseBytes)))
+	h.metrics.compressedBytes.Add(float64(len(compressedBytes)))
+	return compressedBytes, nil
+}
+
+// sortByPriority stably sorts [gossipables] from the highest to the lowest
+// [priority]. The priority of each gossipable is only computed once, rather
+// than on every comparison, so that priorities that change over time, such as
+// those decayed by age, are compared consistently.
+func sortByPriority[T Gossipable](priority func(T) uint64, gossipables []T) {
+	priorities := make([]uint64, len(gossipables))
+	order := make([]int, len(gossipables))
+	for i, gossipable := range gossipables {
+		priorities[i] = priority(gossipable)
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(priorities[b], priorities[a])
+	})
+
+	sorted := make([]T, len(gossipables))
+	for i, j := range order {
+		sorted[i] = gossipables[j]
+	}
+	copy(gossipables, sorted)
+}
+
+// observeSent records that [count] gossipables of [size] bytes were sent in
+// response to a pull gossip request.
+func (h Handler[T]) observeSent(count int, size int) {
 	sentCountMetric, err := h.metrics.sentCount.GetMetricWith(pullLabels)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sent count metric: %w", err)
+		h.log.Error("failed to get sent count metric", zap.Error(err))
+		return
 	}
 
 	sentBytesMetric, err := h.metrics.sentBytes.GetMetricWith(pullLabels)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sent bytes metric: %w", err)
+		h.log.Error("failed to get sent bytes metric", zap.Error(err))
+		return
 	}
 
-	sentCountMetric.Add(float64(len(gossipBytes)))
-	sentBytesMetric.Add(float64(responseSize))
+	sentCountMetric.Add(float64(count))
+	sentBytesMetric.Add(float64(size))
+}
 
-	return MarshalAppResponse(gossipBytes)
+// observeSetSize records the size of the set, if the set reports its size.
+func (h Handler[T]) observeSetSize() {
+	if set, ok := h.set.(SizedSet[T]); ok {
+		h.metrics.setSize.Set(float64(set.Len()))
+	}
 }
 
-func (h Handler[_]) AppGossip(_ context.Context, nodeID ids.NodeID, gossipBytes []byte) {
-	gossip, err := ParseAppGossip(gossipBytes)
+func (h Handler[T]) AppGossip(ctx context.Context, nodeID ids.NodeID, gossipBytes []byte) {
+	_, span := h.tracer.Start(ctx, "gossipHandler.AppGossip", oteltrace.WithAttributes(
+		attribute.Stringer("nodeID", nodeID),
+		attribute.Int("gossipLen", len(gossipBytes)),
+	))
+	defer span.End()
+
+	if h.shouldSkip(nodeID, pushLabels) {
+		return
+	}
+
+	gossip, filter, salt, err := ParseAppGossipWithFilter(gossipBytes)
 	if err != nil {
+		h.metrics.unmarshalFailures.With(pushLabels).Inc()
 		h.log.Debug("failed to unmarshal gossip", zap.Error(err))
 		return
 	}
+	if h.pushFilters != nil && filter != nil {
+		h.pushFilters.advertise(nodeID, filter, salt)
+	}
+	// A message may only advertise a filter, so there is nothing to add.
+	if len(gossip) == 0 {
+		h.metrics.emptyGossip.Inc()
+		span.SetAttributes(attribute.Int("numGossip", 0))
+		return
+	}
 
-	receivedBytes := 0
+	var (
+		receivedBytes        int
+		numAdded, numDropped uint64
+		gossipables          = make([]T, 0, len(gossip))
+	)
 	for _, bytes := range gossip {
 		receivedBytes += len(bytes)
 		gossipable, err := h.marshaller.UnmarshalGossip(bytes)
 		if err != nil {
+			h.metrics.unmarshalFailures.With(pushLabels).Inc()
 			h.log.Debug("failed to unmarshal gossip",
 				zap.Stringer("nodeID", nodeID),
 				zap.Error(err),
 			)
+			numDropped++
 			continue
 		}
 
-		if err := h.set.Add(gossipable); err != nil {
+		// The peer has the gossipable even if we fail to add it.
+		if h.pushFilters != nil {
+			if err := h.pushFilters.receive(nodeID, gossipable.GossipID()); err != nil {
+				h.log.Error("failed to record received gossip", zap.Error(err))
+			}
+		}
+		gossipables = append(gossipables, gossipable)
+	}
+
+	for i, err := range addBatchFromPeer(h.set, nodeID, gossipables) {
+		if err != nil {
 			h.log.Debug(
 				"failed to add gossip to the known set",
 				zap.Stringer("nodeID", nodeID),
-				zap.Stringer("id", gossipable.GossipID()),
+				zap.Stringer("id", gossipables[i].GossipID()),
 				zap.Error(err),
 			)
+			numDropped++
+			continue
 		}
+		numAdded++
+	}
+	h.observeSetSize()
+
+	span.SetAttributes(
+		attribute.Int("numGossip", len(gossip)),
+		attribute.Int64("numAdded", int64(numAdded)),
+		attribute.Int64("numDropped", int64(numDropped)),
+	)
+
+	if h.onInvalidGossip != nil && numDropped > 0 && float64(numDropped) >= h.minFailureRatio*float64(len(gossip)) {
+		h.onInvalidGossip(nodeID, int(numDropped))
+	}
+
+	if h.peerStats != nil {
+		h.peerStats.update(nodeID, func(stats *GossipPeerStats) {
+			stats.ReceivedCount += uint64(len(gossip))
+			stats.ReceivedBytes += uint64(receivedBytes)
+			stats.NumAdded += numAdded
+			stats.NumDropped += numDropped
+		})
 	}
 
 	receivedCountMetric, err := h.metrics.receivedCount.GetMetricWith(pushLabels)
@@ -137,4 +844,12 @@ func (h Handler[_]) AppGossip(_ context.Context, nodeID ids.NodeID, gossipBytes
 
 	receivedCountMetric.Add(float64(len(gossip)))
 	receivedBytesMetric.Add(float64(receivedBytes))
+
+	if h.versioner != nil {
+		tally := make(versionTally)
+		for _, bytes := range gossip {
+			tally.add(h.versioner, h.metrics.versions, bytes)
+		}
+		tally.observe(h.metrics.receivedVersionCount, h.metrics.receivedVersionBytes, pushType)
+	}
 }
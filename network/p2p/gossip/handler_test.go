@@ -0,0 +1,1498 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/proto/pb/sdk"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/version"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestHandlerMinPeerVersion(t *testing.T) {
+	minVersion := &version.Application{
+		Name:  version.Client,
+		Major: 1,
+		Minor: 11,
+		Patch: 3,
+	}
+
+	tests := []struct {
+		name        string
+		peerVersion *version.Application
+		connected   bool
+		expectedErr error
+	}{
+		{
+			name: "below min version",
+			peerVersion: &version.Application{
+				Name:  version.Client,
+				Major: 1,
+				Minor: 11,
+				Patch: 2,
+			},
+			connected:   true,
+			expectedErr: errPeerVersionTooOld,
+		},
+		{
+			name:        "at min version",
+			peerVersion: minVersion,
+			connected:   true,
+		},
+		{
+			name: "above min version",
+			peerVersion: &version.Application{
+				Name:  version.Client,
+				Major: 1,
+				Minor: 12,
+				Patch: 0,
+			},
+			connected: true,
+		},
+		{
+			name:        "not connected",
+			connected:   false,
+			expectedErr: errPeerVersionTooOld,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := context.Background()
+			nodeID := ids.GenerateTestNodeID()
+
+			network, err := p2p.NewNetwork(logging.NoLog{}, &common.FakeSender{}, prometheus.NewRegistry(), "")
+			require.NoError(err)
+			if tt.connected {
+				require.NoError(network.Connected(ctx, nodeID, tt.peerVersion))
+			}
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				testMarshaller{},
+				set,
+				metrics,
+				1024,
+				WithMinPeerVersion[*testTx](network.Peers, minVersion),
+			)
+
+			tx := &testTx{id: ids.GenerateTestID()}
+			gossipBytes, err := MarshalAppGossip([][]byte{tx.id[:]})
+			require.NoError(err)
+
+			handler.AppGossip(ctx, nodeID, gossipBytes)
+			require.Equal(tt.expectedErr == nil, set.Has(tx.id))
+
+			requestBytes, err := MarshalAppRequest(bloom.Marshal())
+			require.NoError(err)
+
+			_, err = handler.AppRequest(ctx, nodeID, time.Time{}, requestBytes)
+			require.ErrorIs(err, tt.expectedErr)
+
+			expectedSkipped := 0.0
+			if tt.expectedErr != nil {
+				expectedSkipped = 1
+			}
+			require.Equal(expectedSkipped, testutil.ToFloat64(metrics.skippedCount.With(pushLabels)))
+			require.Equal(expectedSkipped, testutil.ToFloat64(metrics.skippedCount.With(pullLabels)))
+		})
+	}
+}
+
+func TestHandlerReplayCapturedTraffic(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	newHandler := func() (*Handler[*testTx], *testSet) {
+		bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+		require.NoError(err)
+		set := &testSet{
+			txs:   make(map[ids.ID]*testTx),
+			bloom: bloom,
+		}
+
+		metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+		require.NoError(err)
+		return NewHandler[*testTx](
+			logging.NoLog{},
+			testMarshaller{},
+			set,
+			metrics,
+			1024,
+		), set
+	}
+
+	var (
+		capture        bytes.Buffer
+		handler, set   = newHandler()
+		captureHandler = p2p.NewCaptureHandler(handler, &capture, logging.NoLog{})
+		nodeID         = ids.GenerateTestNodeID()
+		tx0            = &testTx{id: ids.GenerateTestID()}
+		tx1            = &testTx{id: ids.GenerateTestID()}
+	)
+
+	gossipBytes, err := MarshalAppGossip([][]byte{tx0.id[:], tx1.id[:]})
+	require.NoError(err)
+	captureHandler.AppGossip(ctx, nodeID, gossipBytes)
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	_, err = captureHandler.AppRequest(ctx, nodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	// Malformed traffic is captured as well
+	captureHandler.AppGossip(ctx, nodeID, []byte{1, 2, 3})
+	_, err = captureHandler.AppRequest(ctx, nodeID, time.Time{}, []byte{1, 2, 3})
+	require.Error(err) //nolint:forbidigo // returns a proto error
+
+	replayHandler, replaySet := newHandler()
+	require.NotPanics(func() {
+		numReplayed, err := p2p.Replay(ctx, &capture, replayHandler)
+		require.NoError(err)
+		require.Equal(4, numReplayed)
+	})
+	require.Equal(set.txs, replaySet.txs)
+}
+
+func TestHandlerAdaptiveResponseSize(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	for i := 0; i < 100; i++ {
+		require.NoError(set.Add(&testTx{id: ids.GenerateTestID()}))
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	const (
+		targetResponseSize = 32 * ids.IDLen
+		minResponseSize    = 4 * ids.IDLen
+	)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		targetResponseSize,
+		WithAdaptiveResponseSize[*testTx](AdaptiveResponseSizeParams{
+			MaxPeers:           1,
+			MinResponseSize:    minResponseSize,
+			SendBytesPerSecond: ids.IDLen, // one tx per second
+		}),
+	)
+	now := time.Unix(0, 0)
+	handler.clock.Set(now)
+	handler.responseSizer.clock.Set(now)
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+
+	numResponseTxs := func(nodeID ids.NodeID, deadline time.Time) int {
+		responseBytes, err := handler.AppRequest(ctx, nodeID, deadline, requestBytes)
+		require.NoError(err)
+		gossip, err := ParseAppResponse(responseBytes)
+		require.NoError(err)
+		return len(gossip)
+	}
+
+	// A chronically slow peer can't receive more than a few txs before the
+	// deadline, so its responses get progressively smaller.
+	var (
+		slowNodeID   = ids.GenerateTestNodeID()
+		slowDeadline = now.Add(2 * time.Second)
+		expectedTxs  = []int{33, 17, 9, 5, 5}
+	)
+	for _, expected := range expectedTxs {
+		require.Equal(expected, numResponseTxs(slowNodeID, slowDeadline))
+	}
+	require.Equal(minResponseSize, handler.responseSizer.targetResponseSize(slowNodeID, targetResponseSize))
+
+	// A peer that receives its responses in time gets the default size.
+	fastNodeID := ids.GenerateTestNodeID()
+	fastDeadline := now.Add(time.Minute)
+	require.Equal(33, numResponseTxs(fastNodeID, fastDeadline))
+	require.Equal(33, numResponseTxs(fastNodeID, fastDeadline))
+
+	// Once the slow peer meets its deadlines, its response size recovers.
+	for i := 0; i < responseSizeGrowthDivisor; i++ {
+		numResponseTxs(slowNodeID, fastDeadline)
+	}
+	require.Equal(33, numResponseTxs(slowNodeID, fastDeadline))
+}
+
+// peerAwareTestSet records the peer that each gossipable was received from
+type peerAwareTestSet struct {
+	*testSet
+	senders map[ids.ID]ids.NodeID
+}
+
+func (p *peerAwareTestSet) AddFromPeer(nodeID ids.NodeID, tx *testTx) error {
+	p.senders[tx.id] = nodeID
+	return p.Add(tx)
+}
+
+func TestHandlerPeerAwareSet(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &peerAwareTestSet{
+		testSet: &testSet{
+			txs:   make(map[ids.ID]*testTx),
+			bloom: bloom,
+		},
+		senders: make(map[ids.ID]ids.NodeID),
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		1024,
+	)
+
+	var (
+		nodeID = ids.GenerateTestNodeID()
+		tx     = &testTx{id: ids.GenerateTestID()}
+	)
+	gossipBytes, err := MarshalAppGossip([][]byte{tx.id[:]})
+	require.NoError(err)
+	handler.AppGossip(context.Background(), nodeID, gossipBytes)
+
+	require.True(set.Has(tx.id))
+	require.Equal(map[ids.ID]ids.NodeID{tx.id: nodeID}, set.senders)
+}
+
+func TestHandlerUnmarshalFailures(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		1024,
+	)
+
+	// A message that can't be parsed
+	nodeID := ids.GenerateTestNodeID()
+	handler.AppGossip(context.Background(), nodeID, []byte{0xff})
+	require.Equal(1.0, testutil.ToFloat64(metrics.unmarshalFailures.With(pushLabels)))
+
+	// A message with a gossipable that can't be unmarshalled
+	tx := &testTx{id: ids.GenerateTestID()}
+	gossipBytes, err := MarshalAppGossip([][]byte{{1, 2, 3}, tx.id[:]})
+	require.NoError(err)
+	handler.AppGossip(context.Background(), nodeID, gossipBytes)
+	require.Equal(2.0, testutil.ToFloat64(metrics.unmarshalFailures.With(pushLabels)))
+	require.Zero(testutil.ToFloat64(metrics.unmarshalFailures.With(pullLabels)))
+	require.True(set.Has(tx.id))
+}
+
+func TestHandlerOnInvalidGossip(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	type invalidGossip struct {
+		nodeID ids.NodeID
+		count  int
+	}
+	var invalid []invalidGossip
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		1024,
+		WithOnInvalidGossip[*testTx](0.5, func(nodeID ids.NodeID, count int) {
+			invalid = append(invalid, invalidGossip{
+				nodeID: nodeID,
+				count:  count,
+			})
+		}),
+	)
+
+	// Txs that are already in the set fail to be added.
+	knownTxs := make([][]byte, 3)
+	for i := range knownTxs {
+		tx := &testTx{id: ids.GenerateTestID()}
+		require.NoError(set.Add(tx))
+		knownTxs[i] = tx.id[:]
+	}
+
+	// Gossip with few failures isn't reported.
+	nodeID := ids.GenerateTestNodeID()
+	newTx0 := ids.GenerateTestID()
+	newTx1 := ids.GenerateTestID()
+	gossipBytes, err := MarshalAppGossip([][]byte{knownTxs[0], newTx0[:], newTx1[:]})
+	require.NoError(err)
+	handler.AppGossip(context.Background(), nodeID, gossipBytes)
+	require.Empty(invalid)
+
+	// Gossip where every gossipable fails is reported.
+	gossipBytes, err = MarshalAppGossip(append(knownTxs, []byte{1, 2, 3}))
+	require.NoError(err)
+	handler.AppGossip(context.Background(), nodeID, gossipBytes)
+	require.Equal(
+		[]invalidGossip{
+			{
+				nodeID: nodeID,
+				count:  4,
+			},
+		},
+		invalid,
+	)
+}
+
+// sizedMarshaller marshals every tx to [size] bytes
+type sizedMarshaller struct {
+	testMarshaller
+	bytes []byte
+}
+
+func (s sizedMarshaller) MarshalGossip(*testTx) ([]byte, error) {
+	return s.bytes, nil
+}
+
+func TestHandlerMaxResponseSize(t *testing.T) {
+	tests := []struct {
+		name               string
+		targetResponseSize int
+		gossipableSize     int
+		dependencies       func(*testTx) []ids.ID
+		expectedNumGossip  int
+	}{
+		{
+			name:               "max target response size",
+			targetResponseSize: math.MaxInt,
+			gossipableSize:     MaxResponseSize / 4,
+			expectedNumGossip:  4,
+		},
+		{
+			name:               "gossipable larger than max response size",
+			targetResponseSize: math.MaxInt,
+			gossipableSize:     MaxResponseSize + 1,
+			expectedNumGossip:  0,
+		},
+		{
+			name:               "bundle larger than max response size",
+			targetResponseSize: math.MaxInt,
+			gossipableSize:     MaxResponseSize / 4,
+			dependencies: func(tx *testTx) []ids.ID {
+				// Every tx depends on the zero tx, forming a single bundle
+				return []ids.ID{{}}
+			},
+			expectedNumGossip: 0,
+		},
+		{
+			name:               "negative target response size",
+			targetResponseSize: math.MinInt,
+			gossipableSize:     1,
+			expectedNumGossip:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			require.NoError(set.Add(&testTx{}))
+			for i := 0; i < 16; i++ {
+				require.NoError(set.Add(&testTx{id: ids.GenerateTestID()}))
+			}
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+
+			var options []HandlerOption[*testTx]
+			if tt.dependencies != nil {
+				options = append(options, WithDependencyBundles[*testTx](tt.dependencies))
+			}
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				sizedMarshaller{
+					bytes: make([]byte, tt.gossipableSize),
+				},
+				set,
+				metrics,
+				tt.targetResponseSize,
+				options...,
+			)
+
+			emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+			require.NoError(err)
+			responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+			require.NoError(err)
+
+			gossip, err := ParseAppResponse(responseBytes)
+			require.NoError(err)
+			require.Len(gossip, tt.expectedNumGossip)
+
+			responseSize := 0
+			for _, bytes := range gossip {
+				responseSize += len(bytes)
+			}
+			require.LessOrEqual(responseSize, MaxResponseSize)
+		})
+	}
+}
+
+func TestHandlerTargetResponseItems(t *testing.T) {
+	const (
+		numGossip      = 100
+		gossipableSize = 10
+	)
+
+	tests := []struct {
+		name                string
+		targetResponseSize  int
+		targetResponseItems int
+		expectedNumGossip   int
+	}{
+		{
+			name:                "unlimited items",
+			targetResponseSize:  math.MaxInt,
+			targetResponseItems: 0,
+			expectedNumGossip:   numGossip,
+		},
+		{
+			name:                "item limit before size limit",
+			targetResponseSize:  50 * gossipableSize,
+			targetResponseItems: 5,
+			expectedNumGossip:   5,
+		},
+		{
+			name:                "size limit before item limit",
+			targetResponseSize:  10 * gossipableSize,
+			targetResponseItems: 50,
+			expectedNumGossip:   11, // The target size is exceeded by a gossipable
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			for i := 0; i < numGossip; i++ {
+				require.NoError(set.Add(&testTx{id: ids.GenerateTestID()}))
+			}
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				sizedMarshaller{
+					bytes: make([]byte, gossipableSize),
+				},
+				set,
+				metrics,
+				tt.targetResponseSize,
+				WithTargetResponseItems[*testTx](tt.targetResponseItems),
+			)
+
+			emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+			require.NoError(err)
+			responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+			require.NoError(err)
+
+			gossip, err := ParseAppResponse(responseBytes)
+			require.NoError(err)
+			require.Len(gossip, tt.expectedNumGossip)
+		})
+	}
+}
+
+// cancellingMarshaller cancels a request once [cancelAfter] gossipables were
+// marshalled.
+type cancellingMarshaller struct {
+	testMarshaller
+	numMarshalled *int
+	cancelAfter   int
+	cancel        context.CancelFunc
+}
+
+func (c cancellingMarshaller) MarshalGossip(tx *testTx) ([]byte, error) {
+	*c.numMarshalled++
+	if *c.numMarshalled == c.cancelAfter {
+		c.cancel()
+	}
+	return c.testMarshaller.MarshalGossip(tx)
+}
+
+func TestHandlerAbandonedRequest(t *testing.T) {
+	const numGossip = 4 * abandonCheckFrequency
+
+	tests := []struct {
+		name              string
+		cancelled         bool
+		cancelAfter       int
+		deadline          time.Time
+		expectedNumGossip int
+	}{
+		{
+			name:              "active request",
+			expectedNumGossip: numGossip,
+		},
+		{
+			name:              "cancelled request",
+			cancelled:         true,
+			expectedNumGossip: 0,
+		},
+		{
+			name:              "expired deadline",
+			deadline:          time.Now().Add(-time.Second),
+			expectedNumGossip: 0,
+		},
+		{
+			name:              "cancelled while iterating",
+			cancelAfter:       abandonCheckFrequency + 1,
+			expectedNumGossip: 2 * abandonCheckFrequency,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			for i := 0; i < numGossip; i++ {
+				require.NoError(set.Add(&testTx{id: ids.GenerateTestID()}))
+			}
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				cancellingMarshaller{
+					numMarshalled: new(int),
+					cancelAfter:   tt.cancelAfter,
+					cancel:        cancel,
+				},
+				set,
+				metrics,
+				math.MaxInt,
+			)
+
+			emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+			require.NoError(err)
+			responseBytes, err := handler.AppRequest(ctx, ids.EmptyNodeID, tt.deadline, requestBytes)
+			require.NoError(err)
+
+			gossip, err := ParseAppResponse(responseBytes)
+			require.NoError(err)
+			require.Len(gossip, tt.expectedNumGossip)
+		})
+	}
+}
+
+func TestHandlerConflicts(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	var (
+		tx0 = &testTx{id: ids.ID{0}}
+		tx1 = &testTx{id: ids.ID{1}}
+		// conflict0 conflicts with tx0, conflict01 conflicts with both txs,
+		// and knownConflict conflicts with tx0 but is known by the requester.
+		conflict0     = &testTx{id: ids.ID{2}}
+		conflict01    = &testTx{id: ids.ID{3}}
+		knownConflict = &testTx{id: ids.ID{4}}
+		conflicts     = map[ids.ID][]*testTx{
+			tx0.id: {conflict0, conflict01, knownConflict},
+			tx1.id: {conflict01},
+		}
+	)
+	require.NoError(set.Add(tx0))
+	require.NoError(set.Add(tx1))
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithConflicts(func(tx *testTx) []*testTx {
+			return conflicts[tx.id]
+		}),
+	)
+
+	requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requesterBloom.Add(knownConflict)
+	requestBytes, err := MarshalAppRequest(requesterBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	got := make([]*testTx, len(gossip))
+	for i, bytes := range gossip {
+		got[i], err = testMarshaller{}.UnmarshalGossip(bytes)
+		require.NoError(err)
+	}
+
+	// Conflicts are only included once, after the first gossipable they
+	// conflict with.
+	require.ElementsMatch([]*testTx{tx0, tx1, conflict0, conflict01}, got)
+	if got[0].id == tx0.id {
+		require.ElementsMatch([]*testTx{conflict0, conflict01}, got[1:3])
+	} else {
+		require.Equal([]*testTx{tx1, conflict01, tx0, conflict0}, got)
+	}
+}
+
+func TestHandlerStatusHints(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	var (
+		pendingTx  = &testTx{id: ids.ID{0}}
+		acceptedTx = &testTx{id: ids.ID{1}}
+		unknownTx  = &testTx{id: ids.ID{2}}
+		statuses   = map[ids.ID]Status{
+			pendingTx.id:  StatusPending,
+			acceptedTx.id: StatusAccepted,
+		}
+	)
+	require.NoError(set.Add(pendingTx))
+	require.NoError(set.Add(acceptedTx))
+	require.NoError(set.Add(unknownTx))
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithStatusHints(func(tx *testTx) Status {
+			return statuses[tx.id]
+		}),
+	)
+
+	requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+
+	// Status hints are only included if they are asked for.
+	requestBytes, err := MarshalAppRequest(requesterBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+	gossip, gotStatuses, err := ParseAppResponseWithStatusHints(responseBytes)
+	require.NoError(err)
+	require.Len(gossip, 3)
+	require.Nil(gotStatuses)
+
+	requestBytes, err = MarshalAppRequestWithStatusHints(requesterBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err = handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+	gossip, gotStatuses, err = ParseAppResponseWithStatusHints(responseBytes)
+	require.NoError(err)
+	require.Len(gossip, 3)
+	require.Len(gotStatuses, 3)
+	for i, bytes := range gossip {
+		tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+		require.NoError(err)
+		require.Equal(statuses[tx.id], gotStatuses[i])
+	}
+
+	// Peers that don't support status hints parse the response as usual.
+	gossip, err = ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Len(gossip, 3)
+}
+
+func TestHandlerReplayChallenges(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	peerStats := NewPeerStatsTracker(2)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithPeerStats[*testTx](peerStats),
+		WithReplayChallenges[*testTx](ReplayChallengeParams{
+			MaxPeers:  1,
+			Frequency: 1, // challenge every response
+		}),
+	)
+
+	requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	filter, salt := requesterBloom.Marshal()
+
+	nodeID := ids.GenerateTestNodeID()
+	request := func(echo []byte) []byte {
+		requestBytes, err := marshalAppRequest(filter, salt, 0, echo)
+		require.NoError(err)
+		responseBytes, err := handler.AppRequest(context.Background(), nodeID, time.Time{}, requestBytes)
+		require.NoError(err)
+
+		response := &sdk.PullGossipResponse{}
+		require.NoError(proto.Unmarshal(responseBytes, response))
+		require.Len(response.Challenge, challengeLen)
+		return response.Challenge
+	}
+	requireFailures := func(expected uint64) {
+		require.Equal(float64(expected), testutil.ToFloat64(metrics.challengeFailures))
+		require.Equal(expected, peerStats.PeerStats()[nodeID].NumChallengeFailures)
+	}
+
+	// Peers without an outstanding challenge aren't expected to echo one.
+	challenge := request(nil)
+	requireFailures(0)
+
+	// Echoing the challenge passes.
+	newChallenge := request(challenge)
+	requireFailures(0)
+
+	// Replaying a stale challenge fails.
+	challenge = request(challenge)
+	requireFailures(1)
+	require.NotEqual(newChallenge, challenge)
+
+	// Not echoing an outstanding challenge fails.
+	challenge = request(nil)
+	requireFailures(2)
+
+	// A challenge is only accepted once.
+	request(challenge)
+	requireFailures(2)
+
+	// Challenges are forgotten once too many peers are challenged, so peers
+	// whose challenges were forgotten fail if they echo it.
+	challengeBytes, err := marshalAppRequest(filter, salt, 0, nil)
+	require.NoError(err)
+	_, err = handler.AppRequest(context.Background(), ids.GenerateTestNodeID(), time.Time{}, challengeBytes)
+	require.NoError(err)
+	request(challenge)
+	requireFailures(3)
+}
+
+func TestHandlerSnapshot(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	const refreshInterval = time.Minute
+	snapshot, err := NewSnapshot[*testTx](set, refreshInterval, prometheus.NewRegistry(), "")
+	require.NoError(err)
+	now := time.Now()
+	snapshot.clock.Set(now)
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithSnapshot(snapshot),
+	)
+
+	requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(requesterBloom.Marshal())
+	require.NoError(err)
+	requireServed := func(expected ...*testTx) {
+		responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+		require.NoError(err)
+		gossip, err := ParseAppResponse(responseBytes)
+		require.NoError(err)
+
+		served := make([]*testTx, 0, len(gossip))
+		for _, bytes := range gossip {
+			tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+			require.NoError(err)
+			served = append(served, tx)
+		}
+		require.ElementsMatch(expected, served)
+	}
+
+	tx0 := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx0))
+	requireServed(tx0)
+	require.Zero(testutil.ToFloat64(snapshot.staleness))
+
+	// Txs added after the snapshot was taken aren't served until the snapshot
+	// is refreshed.
+	tx1 := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx1))
+	snapshot.clock.Set(now.Add(refreshInterval - 1))
+	requireServed(tx0)
+	require.Equal(float64(refreshInterval-1), testutil.ToFloat64(snapshot.staleness))
+
+	snapshot.clock.Set(now.Add(refreshInterval))
+	requireServed(tx0, tx1)
+	require.Zero(testutil.ToFloat64(snapshot.staleness))
+}
+
+// snapshotTestSet counts the number of snapshots of the set that are iterated
+// over.
+type snapshotTestSet struct {
+	*testSet
+
+	numSnapshots int
+}
+
+func (s *snapshotTestSet) IterateSnapshot(f func(*testTx) bool) {
+	s.numSnapshots++
+	s.Iterate(f)
+}
+
+func TestHandlerSnapshotSet(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &snapshotTestSet{
+		testSet: &testSet{
+			txs:   make(map[ids.ID]*testTx),
+			bloom: bloom,
+		},
+	}
+	tx := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx))
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+	)
+
+	requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(requesterBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Equal([][]byte{tx.id[:]}, gossip)
+	require.Equal(1, set.numSnapshots)
+}
+
+func TestHandlerResponseFilter(t *testing.T) {
+	var (
+		localTx  = &testTx{id: ids.GenerateTestID()}
+		remoteTx = &testTx{id: ids.GenerateTestID()}
+	)
+	tests := []struct {
+		name     string
+		include  func(*testTx) bool
+		expected []*testTx
+	}{
+		{
+			name:     "nil filter",
+			include:  nil,
+			expected: []*testTx{localTx, remoteTx},
+		},
+		{
+			name: "exclude local tx",
+			include: func(tx *testTx) bool {
+				return tx.id != localTx.id
+			},
+			expected: []*testTx{remoteTx},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			require.NoError(set.Add(localTx))
+			require.NoError(set.Add(remoteTx))
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				testMarshaller{},
+				set,
+				metrics,
+				units.MiB,
+				WithResponseFilter(tt.include),
+			)
+
+			requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			requestBytes, err := MarshalAppRequest(requesterBloom.Marshal())
+			require.NoError(err)
+			responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+			require.NoError(err)
+			gossip, err := ParseAppResponse(responseBytes)
+			require.NoError(err)
+
+			served := make([]*testTx, 0, len(gossip))
+			for _, bytes := range gossip {
+				tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+				require.NoError(err)
+				served = append(served, tx)
+			}
+			require.ElementsMatch(tt.expected, served)
+		})
+	}
+}
+
+func TestHandlerResponseCompression(t *testing.T) {
+	tests := []struct {
+		name               string
+		options            []HandlerOption[*testTx]
+		flags              uint32
+		expectedCompressed bool
+	}{
+		{
+			name:  "compression disabled",
+			flags: compressedResponseFlag,
+		},
+		{
+			name:    "requester doesn't support compression",
+			options: []HandlerOption[*testTx]{WithResponseCompression[*testTx](0)},
+		},
+		{
+			name:    "response below threshold",
+			options: []HandlerOption[*testTx]{WithResponseCompression[*testTx](units.MiB)},
+			flags:   compressedResponseFlag,
+		},
+		{
+			name:               "response above threshold",
+			options:            []HandlerOption[*testTx]{WithResponseCompression[*testTx](0)},
+			flags:              compressedResponseFlag,
+			expectedCompressed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			// Mostly zero IDs are compressible
+			for i := 1; i <= 100; i++ {
+				require.NoError(set.Add(&testTx{id: ids.ID{byte(i)}}))
+			}
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				testMarshaller{},
+				set,
+				metrics,
+				units.MiB,
+				tt.options...,
+			)
+
+			requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			filter, salt := requesterBloom.Marshal()
+			requestBytes, err := marshalAppRequest(filter, salt, tt.flags, nil)
+			require.NoError(err)
+			responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+			require.NoError(err)
+			require.Equal(tt.expectedCompressed, responseBytes[0] == compressedResponsePrefix)
+
+			gossip, err := ParseAppResponse(responseBytes)
+			require.NoError(err)
+			require.Len(gossip, 100)
+
+			if !tt.expectedCompressed {
+				require.Zero(testutil.ToFloat64(metrics.uncompressedBytes))
+				require.Zero(testutil.ToFloat64(metrics.compressedBytes))
+				return
+			}
+			uncompressedBytes, err := MarshalAppResponse(gossip)
+			require.NoError(err)
+			require.Equal(float64(len(uncompressedBytes)), testutil.ToFloat64(metrics.uncompressedBytes))
+			require.Equal(float64(len(responseBytes)), testutil.ToFloat64(metrics.compressedBytes))
+			require.Less(len(responseBytes), len(uncompressedBytes))
+		})
+	}
+}
+
+func TestHandlerAppRequestMetricsFailure(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	tx := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx))
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	// The sent metrics don't have the expected labels, so they can't be
+	// updated.
+	metrics.sentCount = prometheus.NewCounterVec(prometheus.CounterOpts{}, nil)
+	metrics.sentBytes = prometheus.NewCounterVec(prometheus.CounterOpts{}, nil)
+
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+	)
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Len(gossip, 1)
+
+	gotTx, err := testMarshaller{}.UnmarshalGossip(gossip[0])
+	require.NoError(err)
+	require.Equal(tx.id, gotTx.id)
+}
+
+// recordingTracer records the spans it starts in memory
+type recordingTracer struct {
+	oteltrace.Tracer
+}
+
+func (recordingTracer) Close() error {
+	return nil
+}
+
+func TestHandlerTracing(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	servedTx := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(servedTx))
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := recordingTracer{
+		Tracer: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(""),
+	}
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithTracer[*testTx](tracer),
+	)
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	nodeID := ids.GenerateTestNodeID()
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(ctx, nodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	pushedTx := &testTx{id: ids.GenerateTestID()}
+	gossipBytes, err := MarshalAppGossip([][]byte{pushedTx.id[:], {1, 2, 3}})
+	require.NoError(err)
+	handler.AppGossip(ctx, nodeID, gossipBytes)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(spans, 3)
+
+	requestSpan := spans[0]
+	require.Equal("gossipHandler.AppRequest", requestSpan.Name())
+	require.Equal(parent.SpanContext().SpanID(), requestSpan.Parent().SpanID())
+	require.ElementsMatch(
+		[]attribute.KeyValue{
+			attribute.String("nodeID", nodeID.String()),
+			attribute.Int("requestLen", len(requestBytes)),
+			attribute.Int("numGossip", 1),
+			attribute.Int("gossipLen", len(servedTx.id)),
+			attribute.Int("responseLen", len(responseBytes)),
+		},
+		requestSpan.Attributes(),
+	)
+
+	gossipSpan := spans[1]
+	require.Equal("gossipHandler.AppGossip", gossipSpan.Name())
+	require.Equal(parent.SpanContext().SpanID(), gossipSpan.Parent().SpanID())
+	require.ElementsMatch(
+		[]attribute.KeyValue{
+			attribute.String("nodeID", nodeID.String()),
+			attribute.Int("gossipLen", len(gossipBytes)),
+			attribute.Int("numGossip", 2),
+			attribute.Int64("numAdded", 1),
+			attribute.Int64("numDropped", 1),
+		},
+		gossipSpan.Attributes(),
+	)
+}
+
+// idPrioritizer prioritizes txs by the first byte of their ID and counts the
+// number of priorities it computed
+type idPrioritizer struct {
+	numPriorities int
+}
+
+func (p *idPrioritizer) Priority(tx *testTx) uint64 {
+	p.numPriorities++
+	return uint64(tx.id[0])
+}
+
+func TestHandlerPrioritizer(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(set.Add(&testTx{id: ids.ID{byte(i)}}))
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	prioritizer := &idPrioritizer{}
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithPrioritizer[*testTx](prioritizer),
+		WithTargetResponseItems[*testTx](3),
+	)
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	// Only the highest priority txs fit in the response, in order of priority.
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	expectedIDs := []ids.ID{{9}, {8}, {7}}
+	require.Len(gossip, len(expectedIDs))
+	for i, expectedID := range expectedIDs {
+		require.Equal(expectedID[:], gossip[i])
+	}
+
+	// The priority of each tx is only computed once.
+	require.Equal(len(set.txs), prioritizer.numPriorities)
+}
+
+func TestHandlerSetSizeMetric(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	tx0 := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx0))
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+	)
+	require.Zero(testutil.ToFloat64(metrics.setSize))
+
+	// The size of the set is recorded once pushed gossip is added.
+	tx1 := &testTx{id: ids.GenerateTestID()}
+	gossipBytes, err := MarshalAppGossip([][]byte{tx1.id[:]})
+	require.NoError(err)
+	handler.AppGossip(context.Background(), ids.EmptyNodeID, gossipBytes)
+	require.Equal(float64(2), testutil.ToFloat64(metrics.setSize))
+
+	// The size of the set is recorded when serving a pull request.
+	delete(set.txs, tx0.id)
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	_, err = handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+	require.Equal(float64(1), testutil.ToFloat64(metrics.setSize))
+}
+
+func TestHandlerMaxAge(t *testing.T) {
+	require := require.New(t)
+
+	const maxAge = time.Minute
+	var (
+		now       = time.Unix(1_000_000, 0)
+		addedTime = make(map[ids.ID]time.Time)
+		freshTxs  = []*testTx{
+			{id: ids.GenerateTestID()},
+			{id: ids.GenerateTestID()},
+		}
+		unknownTx = &testTx{id: ids.GenerateTestID()}
+	)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	for i := 0; i < 10; i++ {
+		staleTx := &testTx{id: ids.GenerateTestID()}
+		require.NoError(set.Add(staleTx))
+		addedTime[staleTx.id] = now.Add(-maxAge - time.Second)
+	}
+	for _, freshTx := range freshTxs {
+		require.NoError(set.Add(freshTx))
+		addedTime[freshTx.id] = now.Add(-maxAge)
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		// Both fresh txs fit in the response only if the stale txs don't
+		// count towards the target response size.
+		ids.IDLen,
+		WithMaxAge(
+			func(tx *testTx) (time.Time, bool) {
+				added, ok := addedTime[tx.id]
+				return added, ok
+			},
+			maxAge,
+		),
+	)
+	handler.clock.Set(now)
+
+	request := func() []ids.ID {
+		requesterBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+		require.NoError(err)
+		requestBytes, err := MarshalAppRequest(requesterBloom.Marshal())
+		require.NoError(err)
+		responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+		require.NoError(err)
+		gossip, err := ParseAppResponse(responseBytes)
+		require.NoError(err)
+
+		served := make([]ids.ID, 0, len(gossip))
+		for _, bytes := range gossip {
+			tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+			require.NoError(err)
+			served = append(served, tx.id)
+		}
+		return served
+	}
+
+	// Only the txs that aren't older than the max age are served.
+	require.ElementsMatch([]ids.ID{freshTxs[0].id, freshTxs[1].id}, request())
+
+	// Txs whose age isn't known are served.
+	for _, freshTx := range freshTxs {
+		delete(set.txs, freshTx.id)
+	}
+	require.NoError(set.Add(unknownTx))
+	require.Equal([]ids.ID{unknownTx.id}, request())
+
+	// Once the fresh txs age out, they aren't served either.
+	for _, freshTx := range freshTxs {
+		require.NoError(set.Add(freshTx))
+	}
+	handler.clock.Set(now.Add(time.Second))
+	require.Equal([]ids.ID{unknownTx.id}, request())
+}
+
+func TestHandlerEmptyGossip(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+		onAdd: func(*testTx) {
+			require.FailNow("unexpected add")
+		},
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+	)
+
+	// An empty message is counted, but is otherwise ignored.
+	gossipBytes, err := MarshalAppGossip(nil)
+	require.NoError(err)
+	handler.AppGossip(context.Background(), ids.GenerateTestNodeID(), gossipBytes)
+	require.Equal(1.0, testutil.ToFloat64(metrics.emptyGossip))
+	require.Zero(testutil.CollectAndCount(metrics.receivedCount))
+	require.Zero(testutil.ToFloat64(metrics.unmarshalFailures.With(pushLabels)))
+
+	// A message that can't be parsed isn't counted as empty.
+	handler.AppGossip(context.Background(), ids.GenerateTestNodeID(), []byte{0xff})
+	require.Equal(1.0, testutil.ToFloat64(metrics.emptyGossip))
+	require.Equal(1.0, testutil.ToFloat64(metrics.unmarshalFailures.With(pushLabels)))
+}
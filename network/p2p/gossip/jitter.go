@@ -0,0 +1,77 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+var (
+	ErrInvalidJitterFrequency = errors.New("jitter frequency must be positive")
+	ErrInvalidJitter          = errors.New("jitter must be in the range [0, 1)")
+)
+
+// NewJitteredInterval returns intervals of [frequency] that are randomized by
+// up to [jitter] of [frequency] in either direction, using [source] as the
+// source of randomness.
+func NewJitteredInterval(
+	frequency time.Duration,
+	jitter float64,
+	source rand.Source,
+) (*JitteredInterval, error) {
+	switch {
+	case frequency <= 0:
+		return nil, ErrInvalidJitterFrequency
+	case jitter < 0 || jitter >= 1:
+		return nil, ErrInvalidJitter
+	}
+	return &JitteredInterval{
+		frequency: frequency,
+		jitter:    jitter,
+		rand:      rand.New(source), // #nosec G404
+	}, nil
+}
+
+// JitteredInterval randomizes the interval between rounds of gossip so that
+// nodes started at the same time don't gossip in synchronized bursts.
+//
+// JitteredInterval is not safe for concurrent use.
+type JitteredInterval struct {
+	frequency time.Duration
+	jitter    float64
+	rand      *rand.Rand
+}
+
+// Next returns the next interval, which is uniformly distributed in
+// [frequency * (1 - jitter), frequency * (1 + jitter)).
+func (j *JitteredInterval) Next() time.Duration {
+	offset := j.jitter * (2*j.rand.Float64() - 1)
+	return time.Duration(float64(j.frequency) * (1 + offset))
+}
+
+// EveryWithJitter calls [Gossip] after every interval returned by [interval].
+func EveryWithJitter(ctx context.Context, log logging.Logger, gossiper Gossiper, interval *JitteredInterval) {
+	timer := time.NewTimer(interval.Next())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := gossiper.Gossip(ctx); err != nil {
+				log.Warn("failed to gossip", zap.Error(err))
+			}
+			timer.Reset(interval.Next())
+		case <-ctx.Done():
+			log.Debug("shutting down gossip")
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/utils/set"
+)
+
+func TestJitteredInterval(t *testing.T) {
+	require := require.New(t)
+
+	const (
+		frequency = time.Second
+		jitter    = 0.1
+	)
+	interval, err := NewJitteredInterval(frequency, jitter, rand.NewSource(0))
+	require.NoError(err)
+
+	var intervals set.Set[time.Duration]
+	for i := 0; i < 100; i++ {
+		next := interval.Next()
+		require.GreaterOrEqual(next, 900*time.Millisecond)
+		require.Less(next, 1100*time.Millisecond)
+		intervals.Add(next)
+	}
+	require.Greater(intervals.Len(), 1)
+}
+
+func TestJitteredIntervalNoJitter(t *testing.T) {
+	require := require.New(t)
+
+	interval, err := NewJitteredInterval(time.Second, 0, rand.NewSource(0))
+	require.NoError(err)
+	for i := 0; i < 10; i++ {
+		require.Equal(time.Second, interval.Next())
+	}
+}
+
+func TestNewJitteredIntervalInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		frequency   time.Duration
+		jitter      float64
+		expectedErr error
+	}{
+		{
+			name:        "zero frequency",
+			jitter:      0.1,
+			expectedErr: ErrInvalidJitterFrequency,
+		},
+		{
+			name:        "negative jitter",
+			frequency:   time.Second,
+			jitter:      -0.1,
+			expectedErr: ErrInvalidJitter,
+		},
+		{
+			name:        "jitter too large",
+			frequency:   time.Second,
+			jitter:      1,
+			expectedErr: ErrInvalidJitter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJitteredInterval(tt.frequency, tt.jitter, rand.NewSource(0))
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"errors"
+
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+var ErrInvalidMarshalCacheSize = errors.New("marshal cache size must be positive")
+
+// NewMarshalCache returns a cache of the marshalled bytes of up to [size]
+// gossipables.
+func NewMarshalCache(size int) (*MarshalCache, error) {
+	if size <= 0 {
+		return nil, ErrInvalidMarshalCacheSize
+	}
+	return &MarshalCache{
+		marshalled: &cache.LRU[ids.ID, []byte]{Size: size},
+	}, nil
+}
+
+// MarshalCache remembers the marshalled bytes of recently served gossipables,
+// so that gossipables served to many peers are only marshalled once. It must
+// only be used for gossipables that are immutable once they have a GossipID.
+//
+// Gossipables should be evicted from the cache once they are removed from the
+// set, so that the cache doesn't hold on to gossipables that won't be served
+// again.
+type MarshalCache struct {
+	marshalled *cache.LRU[ids.ID, []byte]
+}
+
+// MarshalObserver is optionally implemented by a Marshaller that needs to
+// observe every gossipable it would have marshalled. Gossipables served from a
+// MarshalCache aren't passed to MarshalGossip, so they are passed to
+// OnCachedMarshal instead.
+type MarshalObserver[T Gossipable] interface {
+	// OnCachedMarshal is called with a gossipable whose cached marshalled
+	// bytes are being served.
+	OnCachedMarshal(gossipable T)
+}
+
+// Evict removes the marshalled bytes of the gossipable with [gossipID] from
+// the cache.
+func (m *MarshalCache) Evict(gossipID ids.ID) {
+	m.marshalled.Evict(gossipID)
+}
+
+// Len returns the number of gossipables in the cache.
+func (m *MarshalCache) Len() int {
+	return m.marshalled.Len()
+}
+
+// marshal returns the marshalled bytes of [gossipable], marshalling it with
+// [marshaller] if it isn't cached.
+func marshal[T Gossipable](m *MarshalCache, marshaller Marshaller[T], gossipable T) ([]byte, error) {
+	if m == nil {
+		return marshaller.MarshalGossip(gossipable)
+	}
+
+	gossipID := gossipable.GossipID()
+	if bytes, ok := m.marshalled.Get(gossipID); ok {
+		if observer, ok := marshaller.(MarshalObserver[T]); ok {
+			observer.OnCachedMarshal(gossipable)
+		}
+		return bytes, nil
+	}
+
+	bytes, err := marshaller.MarshalGossip(gossipable)
+	if err != nil {
+		return nil, err
+	}
+	m.marshalled.Put(gossipID, bytes)
+	return bytes, nil
+}
@@ -0,0 +1,186 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/units"
+)
+
+// countingMarshaller counts the number of times each tx is marshalled and
+// served from the cache
+type countingMarshaller struct {
+	testMarshaller
+	numMarshalled map[ids.ID]int
+	numCached     map[ids.ID]int
+}
+
+func (c *countingMarshaller) MarshalGossip(tx *testTx) ([]byte, error) {
+	c.numMarshalled[tx.id]++
+	return c.testMarshaller.MarshalGossip(tx)
+}
+
+func (c *countingMarshaller) OnCachedMarshal(tx *testTx) {
+	c.numCached[tx.id]++
+}
+
+// allocatingMarshaller marshals every tx into a newly allocated [size] bytes
+type allocatingMarshaller struct {
+	testMarshaller
+	size int
+}
+
+func (a allocatingMarshaller) MarshalGossip(tx *testTx) ([]byte, error) {
+	bytes := make([]byte, a.size)
+	copy(bytes, tx.id[:])
+	return bytes, nil
+}
+
+func TestNewMarshalCacheInvalidSize(t *testing.T) {
+	_, err := NewMarshalCache(0)
+	require.ErrorIs(t, err, ErrInvalidMarshalCacheSize)
+}
+
+func TestHandlerMarshalCache(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	txs := []*testTx{
+		{id: ids.GenerateTestID()},
+		{id: ids.GenerateTestID()},
+		{id: ids.GenerateTestID()},
+	}
+	for _, tx := range txs {
+		require.NoError(set.Add(tx))
+	}
+
+	marshalCache, err := NewMarshalCache(len(txs))
+	require.NoError(err)
+	marshaller := &countingMarshaller{
+		numMarshalled: make(map[ids.ID]int),
+		numCached:     make(map[ids.ID]int),
+	}
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		marshaller,
+		set,
+		metrics,
+		units.MiB,
+		WithMarshalCache[*testTx](marshalCache),
+	)
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	request := func() {
+		responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+		require.NoError(err)
+		gossip, err := ParseAppResponse(responseBytes)
+		require.NoError(err)
+		require.Len(gossip, len(set.txs))
+	}
+
+	// Txs are only marshalled the first time they are served, but the
+	// marshaller still observes every time they are served.
+	request()
+	request()
+	require.Equal(len(txs), marshalCache.Len())
+	for _, tx := range txs {
+		require.Equal(1, marshaller.numMarshalled[tx.id])
+		require.Equal(1, marshaller.numCached[tx.id])
+	}
+
+	// Removed txs are evicted from the cache.
+	delete(set.txs, txs[0].id)
+	marshalCache.Evict(txs[0].id)
+	require.Equal(len(txs)-1, marshalCache.Len())
+
+	// Txs that are added back are marshalled again.
+	set.txs[txs[0].id] = txs[0]
+	request()
+	require.Equal(len(txs), marshalCache.Len())
+	require.Equal(2, marshaller.numMarshalled[txs[0].id])
+	for _, tx := range txs[1:] {
+		require.Equal(1, marshaller.numMarshalled[tx.id])
+	}
+
+	// The cache is bounded.
+	tx := &testTx{id: ids.GenerateTestID()}
+	require.NoError(set.Add(tx))
+	request()
+	require.Equal(len(txs), marshalCache.Len())
+}
+
+func BenchmarkHandlerMarshalCache(b *testing.B) {
+	const (
+		numTxs = 1000
+		txSize = 256
+	)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", numTxs, 0.01, 0.05)
+	require.NoError(b, err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	for i := 0; i < numTxs; i++ {
+		require.NoError(b, set.Add(&testTx{id: ids.GenerateTestID()}))
+	}
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", numTxs, 0.01, 0.05)
+	require.NoError(b, err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(b, err)
+
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(b, err)
+
+			var options []HandlerOption[*testTx]
+			if cached {
+				marshalCache, err := NewMarshalCache(numTxs)
+				require.NoError(b, err)
+				options = append(options, WithMarshalCache[*testTx](marshalCache))
+			}
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				allocatingMarshaller{
+					size: txSize,
+				},
+				set,
+				metrics,
+				units.MiB,
+				options...,
+			)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -4,51 +4,405 @@
 package gossip
 
 import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/proto/pb/sdk"
 	"github.com/ava-labs/avalanchego/utils/bloom"
+	"github.com/ava-labs/avalanchego/utils/compression"
+	"github.com/ava-labs/avalanchego/utils/constants"
+)
+
+const (
+	// compressedFilterFlag is set in the flags of a request if its filter is
+	// zstd compressed. Peers that don't support compressed filters fail to
+	// parse the compressed filter and reject the request.
+	compressedFilterFlag uint32 = 1
+	// statusHintsFlag is set in the flags of a request if the requester asks
+	// for the status hints of the returned gossip. Peers that don't support
+	// status hints ignore it.
+	statusHintsFlag uint32 = 2
+	// compressedResponseFlag is set in the flags of a request if the requester
+	// supports zstd compressed responses. Peers that don't support compressed
+	// responses ignore it.
+	compressedResponseFlag uint32 = 4
+
+	// compressedResponsePrefix is the first byte of a zstd compressed
+	// response. As protobuf field numbers start at 1, a marshalled response
+	// never starts with it, so uncompressed responses are parsed as before.
+	compressedResponsePrefix byte = 0
+)
+
+// Status is a hint of the status of a gossipable, as known by the peer that
+// returned it.
+type Status byte
+
+const (
+	// StatusUnknown is reported if the peer doesn't know the status of the
+	// gossipable.
+	StatusUnknown Status = iota
+	// StatusPending is reported if the gossipable hasn't been accepted yet.
+	StatusPending
+	// StatusAccepted is reported if the gossipable was recently accepted.
+	StatusAccepted
 )
 
 func MarshalAppRequest(filter, salt []byte) ([]byte, error) {
+	return marshalAppRequest(filter, salt, 0, nil)
+}
+
+// MarshalCompressedAppRequest marshals a request with a zstd compressed
+// filter. This should only be used if the receiving peers are known to
+// support compressed filters.
+func MarshalCompressedAppRequest(filter, salt []byte) ([]byte, error) {
+	return marshalAppRequest(filter, salt, compressedFilterFlag, nil)
+}
+
+// MarshalAppRequestWithStatusHints marshals a request that asks for the status
+// hints of the returned gossip.
+func MarshalAppRequestWithStatusHints(filter, salt []byte) ([]byte, error) {
+	return marshalAppRequest(filter, salt, statusHintsFlag, nil)
+}
+
+// marshalAppRequest marshals a request with [flags] that echoes
+// [challengeEcho]. If [flags] includes compressedFilterFlag, [filter] is
+// compressed.
+func marshalAppRequest(filter, salt []byte, flags uint32, challengeEcho []byte) ([]byte, error) {
+	if flags&compressedFilterFlag != 0 {
+		compressor, err := newFilterCompressor()
+		if err != nil {
+			return nil, err
+		}
+		filter, err = compressor.Compress(filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	request := &sdk.PullGossipRequest{
-		Filter: filter,
-		Salt:   salt,
+		Filter:        filter,
+		Salt:          salt,
+		Flags:         flags,
+		ChallengeEcho: challengeEcho,
 	}
 	return proto.Marshal(request)
 }
 
 func ParseAppRequest(bytes []byte) (*bloom.ReadFilter, ids.ID, error) {
+	request, err := parseAppRequest(bytes)
+	if err != nil {
+		return nil, ids.Empty, err
+	}
+	return request.filter, request.salt, nil
+}
+
+// appRequest is a parsed request
+type appRequest struct {
+	filter        *bloom.ReadFilter
+	salt          ids.ID
+	flags         uint32
+	challengeEcho []byte
+}
+
+func parseAppRequest(bytes []byte) (*appRequest, error) {
 	request := &sdk.PullGossipRequest{}
 	if err := proto.Unmarshal(bytes, request); err != nil {
-		return nil, ids.Empty, err
+		return nil, err
 	}
 
 	salt, err := ids.ToID(request.Salt)
 	if err != nil {
-		return nil, ids.Empty, err
+		return nil, err
+	}
+
+	filterBytes := request.Filter
+	if request.Flags&compressedFilterFlag != 0 {
+		compressor, err := newFilterCompressor()
+		if err != nil {
+			return nil, err
+		}
+		filterBytes, err = compressor.Decompress(filterBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := bloom.Parse(filterBytes)
+	if err != nil {
+		return nil, err
 	}
+	return &appRequest{
+		filter:        filter,
+		salt:          salt,
+		flags:         request.Flags,
+		challengeEcho: request.ChallengeEcho,
+	}, nil
+}
 
-	filter, err := bloom.Parse(request.Filter)
-	return filter, salt, err
+// newFilterCompressor returns the compressor used for filters. A filter can't
+// be larger than a message, which bounds the size of decompressed filters.
+func newFilterCompressor() (compression.Compressor, error) {
+	return compression.NewZstdCompressor(constants.DefaultMaxMessageSize)
+}
+
+var (
+	errInvalidBundleSizes = errors.New("bundle sizes don't match the number of gossip items")
+	errInvalidStatuses    = errors.New("statuses don't match the number of gossip items")
+	errResponseTooLarge   = errors.New("response too large")
+)
+
+// compressAppResponse compresses a marshalled response and prefixes it with
+// compressedResponsePrefix.
+func compressAppResponse(responseBytes []byte) ([]byte, error) {
+	compressor, err := compression.NewZstdCompressor(constants.DefaultMaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := compressor.Compress(responseBytes)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{compressedResponsePrefix}, compressed...), nil
+}
+
+// decompressAppResponse returns the marshalled response of [bytes], which are
+// decompressed if they are prefixed with compressedResponsePrefix. Compressed
+// responses that decompress to more than [maxSize] bytes are rejected.
+func decompressAppResponse(bytes []byte, maxSize int) ([]byte, error) {
+	if len(bytes) == 0 || bytes[0] != compressedResponsePrefix {
+		return bytes, nil
+	}
+
+	compressor, err := compression.NewZstdCompressor(int64(maxSize))
+	if err != nil {
+		return nil, err
+	}
+	return compressor.Decompress(bytes[1:])
 }
 
 func MarshalAppResponse(gossip [][]byte) ([]byte, error) {
-	return proto.Marshal(&sdk.PullGossipResponse{
-		Gossip: gossip,
-	})
+	return marshalAppResponse(gossip, nil, nil, nil)
+}
+
+// marshalAppResponse marshals a response of gossip that is grouped into
+// bundles of [bundleSizes] consecutive items, if [bundleSizes] is non-nil,
+// along with the status hint of each item, if [statuses] is non-nil, and a
+// [challenge] for the requester to echo.
+func marshalAppResponse(gossip [][]byte, bundleSizes []int, statuses []Status, challenge []byte) ([]byte, error) {
+	response := &sdk.PullGossipResponse{
+		Gossip:    gossip,
+		Challenge: challenge,
+	}
+	if bundleSizes != nil {
+		response.BundleSizes = make([]uint32, len(bundleSizes))
+		for i, size := range bundleSizes {
+			response.BundleSizes[i] = uint32(size)
+		}
+	}
+	if statuses != nil {
+		response.Statuses = make([]byte, len(statuses))
+		for i, status := range statuses {
+			response.Statuses[i] = byte(status)
+		}
+	}
+	return proto.Marshal(response)
+}
+
+// Field numbers of sdk.PullGossipResponse
+const (
+	responseGossipField protowire.Number = iota + 1
+	responseBundleSizesField
+	responseStatusesField
+	responseChallengeField
+)
+
+// appResponseWriter marshals a response incrementally, writing each gossipable
+// directly into the response as it is appended rather than buffering the
+// gossipables and copying them again once the response is marshalled. The
+// marshalled response is identical to the one returned by marshalAppResponse.
+//
+// The response grows as gossipables are appended, so that small responses
+// don't hold on to memory sized for the target response size.
+type appResponseWriter struct {
+	bytes     []byte
+	numGossip int
+}
+
+// appResponseMark is a point of an appResponseWriter that can be truncated
+// back to.
+type appResponseMark struct {
+	numBytes  int
+	numGossip int
+}
+
+func (w *appResponseWriter) appendGossip(gossip []byte) {
+	w.bytes = protowire.AppendTag(w.bytes, responseGossipField, protowire.BytesType)
+	w.bytes = protowire.AppendBytes(w.bytes, gossip)
+	w.numGossip++
+}
+
+// mark returns the current point of the response.
+func (w *appResponseWriter) mark() appResponseMark {
+	return appResponseMark{
+		numBytes:  len(w.bytes),
+		numGossip: w.numGossip,
+	}
+}
+
+// truncate removes the gossipables appended after [mark].
+func (w *appResponseWriter) truncate(mark appResponseMark) {
+	w.bytes = w.bytes[:mark.numBytes]
+	w.numGossip = mark.numGossip
+}
+
+// marshal returns the response with the appended gossipables, grouped into
+// bundles of [bundleSizes], along with [statuses] and [challenge], as
+// described by marshalAppResponse.
+func (w *appResponseWriter) marshal(bundleSizes []int, statuses []Status, challenge []byte) []byte {
+	if len(bundleSizes) > 0 {
+		var packedSize int
+		for _, size := range bundleSizes {
+			packedSize += protowire.SizeVarint(uint64(uint32(size)))
+		}
+		w.bytes = protowire.AppendTag(w.bytes, responseBundleSizesField, protowire.BytesType)
+		w.bytes = protowire.AppendVarint(w.bytes, uint64(packedSize))
+		for _, size := range bundleSizes {
+			w.bytes = protowire.AppendVarint(w.bytes, uint64(uint32(size)))
+		}
+	}
+	if len(statuses) > 0 {
+		w.bytes = protowire.AppendTag(w.bytes, responseStatusesField, protowire.BytesType)
+		w.bytes = protowire.AppendVarint(w.bytes, uint64(len(statuses)))
+		for _, status := range statuses {
+			w.bytes = append(w.bytes, byte(status))
+		}
+	}
+	if len(challenge) > 0 {
+		w.bytes = protowire.AppendTag(w.bytes, responseChallengeField, protowire.BytesType)
+		w.bytes = protowire.AppendBytes(w.bytes, challenge)
+	}
+	return w.bytes
 }
 
 func ParseAppResponse(bytes []byte) ([][]byte, error) {
+	response, err := parseAppResponse(bytes, 0)
+	if err != nil {
+		return nil, err
+	}
+	return response.Gossip, nil
+}
+
+// parseAppResponse parses a response, rejecting it without unmarshalling it if
+// it is larger than [maxSize] bytes, either as received or once decompressed.
+// If [maxSize] is 0, responses of up to the maximum message size are parsed.
+func parseAppResponse(bytes []byte, maxSize int) (*sdk.PullGossipResponse, error) {
+	if maxSize > 0 && len(bytes) > maxSize {
+		return nil, fmt.Errorf("%w: %d > %d", errResponseTooLarge, len(bytes), maxSize)
+	}
+	if maxSize <= 0 {
+		maxSize = constants.DefaultMaxMessageSize
+	}
+
+	bytes, err := decompressAppResponse(bytes, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
 	response := &sdk.PullGossipResponse{}
-	err := proto.Unmarshal(bytes, response)
-	return response.Gossip, err
+	return response, proto.Unmarshal(bytes, response)
+}
+
+// ParseAppResponseWithStatusHints parses a response along with the status hint
+// of each item. If the response doesn't include status hints, nil statuses are
+// returned.
+func ParseAppResponseWithStatusHints(bytes []byte) ([][]byte, []Status, error) {
+	response, err := parseAppResponse(bytes, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(response.Statuses) == 0 {
+		return response.Gossip, nil, nil
+	}
+	if len(response.Statuses) != len(response.Gossip) {
+		return nil, nil, errInvalidStatuses
+	}
+
+	statuses := make([]Status, len(response.Statuses))
+	for i, status := range response.Statuses {
+		statuses[i] = Status(status)
+	}
+	return response.Gossip, statuses, nil
+}
+
+// MarshalAppResponseBundles marshals a response of gossip that is grouped into
+// dependency ordered bundles. Receivers that don't support bundles parse the
+// response as the concatenation of the bundles.
+func MarshalAppResponseBundles(bundles [][][]byte) ([]byte, error) {
+	var (
+		gossip      [][]byte
+		bundleSizes = make([]int, len(bundles))
+	)
+	for i, bundle := range bundles {
+		gossip = append(gossip, bundle...)
+		bundleSizes[i] = len(bundle)
+	}
+	return marshalAppResponse(gossip, bundleSizes, nil, nil)
+}
+
+// ParseAppResponseBundles parses a response into its bundles. If the response
+// isn't grouped into bundles, every item is returned as its own bundle.
+func ParseAppResponseBundles(bytes []byte) ([][][]byte, error) {
+	response, err := parseAppResponse(bytes, 0)
+	if err != nil {
+		return nil, err
+	}
+	return responseBundles(response)
+}
+
+// responseBundles returns the bundles of a parsed response
+func responseBundles(response *sdk.PullGossipResponse) ([][][]byte, error) {
+	if len(response.BundleSizes) == 0 {
+		bundles := make([][][]byte, len(response.Gossip))
+		for i, gossip := range response.Gossip {
+			bundles[i] = [][]byte{gossip}
+		}
+		return bundles, nil
+	}
+
+	var (
+		bundles   = make([][][]byte, len(response.BundleSizes))
+		remaining = response.Gossip
+	)
+	for i, size := range response.BundleSizes {
+		if uint64(size) > uint64(len(remaining)) {
+			return nil, errInvalidBundleSizes
+		}
+		bundles[i] = remaining[:size]
+		remaining = remaining[size:]
+	}
+	if len(remaining) != 0 {
+		return nil, errInvalidBundleSizes
+	}
+	return bundles, nil
 }
 
 func MarshalAppGossip(gossip [][]byte) ([]byte, error) {
+	return MarshalAppGossipWithFilter(gossip, nil, nil)
+}
+
+// MarshalAppGossipWithFilter marshals pushed gossip that advertises [filter],
+// a bloom filter of the gossip the sender already knows, salted with [salt].
+// Receivers may skip pushing gossip in the filter to the sender. Receivers
+// that don't support filters ignore it.
+func MarshalAppGossipWithFilter(gossip [][]byte, filter, salt []byte) ([]byte, error) {
 	return proto.Marshal(&sdk.PushGossip{
 		Gossip: gossip,
+		Filter: filter,
+		Salt:   salt,
 	})
 }
 
@@ -57,3 +411,27 @@ func ParseAppGossip(bytes []byte) ([][]byte, error) {
 	err := proto.Unmarshal(bytes, msg)
 	return msg.Gossip, err
 }
+
+// ParseAppGossipWithFilter parses pushed gossip along with the filter
+// advertised by the sender. If the sender didn't advertise a valid filter, a
+// nil filter is returned and all gossip should be pushed to the sender. An
+// invalid filter doesn't invalidate the gossip it was advertised with.
+func ParseAppGossipWithFilter(bytes []byte) ([][]byte, *bloom.ReadFilter, ids.ID, error) {
+	msg := &sdk.PushGossip{}
+	if err := proto.Unmarshal(bytes, msg); err != nil {
+		return nil, nil, ids.Empty, err
+	}
+	if len(msg.Filter) == 0 {
+		return msg.Gossip, nil, ids.Empty, nil
+	}
+
+	salt, err := ids.ToID(msg.Salt)
+	if err != nil {
+		return msg.Gossip, nil, ids.Empty, nil
+	}
+	filter, err := bloom.Parse(msg.Filter)
+	if err != nil {
+		return msg.Gossip, nil, ids.Empty, nil
+	}
+	return msg.Gossip, filter, salt, nil
+}
@@ -0,0 +1,304 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/proto/pb/sdk"
+	"github.com/ava-labs/avalanchego/utils/compression"
+)
+
+// newSparseBloomFilter returns a bloom filter sized for many more elements
+// than it contains
+func newSparseBloomFilter(t testing.TB) *BloomFilter {
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 8*1024, 0.01, 0.05)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		bloom.Add(&testTx{id: ids.GenerateTestID()})
+	}
+	return bloom
+}
+
+func TestMarshalAppRequest(t *testing.T) {
+	tests := []struct {
+		name              string
+		marshalAppRequest func(filter, salt []byte) ([]byte, error)
+	}{
+		{
+			name:              "uncompressed",
+			marshalAppRequest: MarshalAppRequest,
+		},
+		{
+			name:              "compressed",
+			marshalAppRequest: MarshalCompressedAppRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom := newSparseBloomFilter(t)
+			filterBytes, saltBytes := bloom.Marshal()
+			requestBytes, err := tt.marshalAppRequest(filterBytes, saltBytes)
+			require.NoError(err)
+
+			filter, salt, err := ParseAppRequest(requestBytes)
+			require.NoError(err)
+			require.Equal(filterBytes, filter.Marshal())
+			require.Equal(saltBytes, salt[:])
+		})
+	}
+}
+
+func TestParseCompressedAppRequestWithoutFlag(t *testing.T) {
+	require := require.New(t)
+
+	bloom := newSparseBloomFilter(t)
+	requestBytes, err := MarshalCompressedAppRequest(bloom.Marshal())
+	require.NoError(err)
+
+	// Peers that don't support compressed filters ignore the flags
+	request := &sdk.PullGossipRequest{}
+	require.NoError(proto.Unmarshal(requestBytes, request))
+	request.Flags = 0
+	requestBytes, err = proto.Marshal(request)
+	require.NoError(err)
+
+	_, _, err = ParseAppRequest(requestBytes)
+	require.Error(err) //nolint:forbidigo // the error depends on the compressed bytes
+}
+
+func TestAppResponseCompression(t *testing.T) {
+	gossip := [][]byte{
+		make([]byte, 1024),
+		make([]byte, 1024),
+	}
+	uncompressedBytes, err := MarshalAppResponseBundles([][][]byte{gossip})
+	require.NoError(t, err)
+	compressedBytes, err := compressAppResponse(uncompressedBytes)
+	require.NoError(t, err)
+	require.Less(t, len(compressedBytes), len(uncompressedBytes))
+
+	tests := []struct {
+		name          string
+		responseBytes []byte
+	}{
+		{
+			name:          "uncompressed",
+			responseBytes: uncompressedBytes,
+		},
+		{
+			name:          "compressed",
+			responseBytes: compressedBytes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			parsedGossip, err := ParseAppResponse(tt.responseBytes)
+			require.NoError(err)
+			require.Equal(gossip, parsedGossip)
+
+			bundles, err := ParseAppResponseBundles(tt.responseBytes)
+			require.NoError(err)
+			require.Equal([][][]byte{gossip}, bundles)
+
+			response, err := parseAppResponse(tt.responseBytes, len(uncompressedBytes))
+			require.NoError(err)
+			require.Equal(gossip, response.Gossip)
+		})
+	}
+}
+
+func TestParseCompressedAppResponseTooLarge(t *testing.T) {
+	require := require.New(t)
+
+	responseBytes, err := MarshalAppResponse([][]byte{make([]byte, 1024)})
+	require.NoError(err)
+	compressedBytes, err := compressAppResponse(responseBytes)
+	require.NoError(err)
+
+	// The size of the decompressed response is limited as well
+	_, err = parseAppResponse(compressedBytes, len(responseBytes)-1)
+	require.ErrorIs(err, compression.ErrDecompressedMsgTooLarge)
+}
+
+func TestMarshalAppGossipWithFilter(t *testing.T) {
+	require := require.New(t)
+
+	gossip := [][]byte{{1}, {2}}
+	bloom := newSparseBloomFilter(t)
+	filterBytes, saltBytes := bloom.Marshal()
+	gossipBytes, err := MarshalAppGossipWithFilter(gossip, filterBytes, saltBytes)
+	require.NoError(err)
+
+	parsedGossip, filter, salt, err := ParseAppGossipWithFilter(gossipBytes)
+	require.NoError(err)
+	require.Equal(gossip, parsedGossip)
+	require.Equal(filterBytes, filter.Marshal())
+	require.Equal(saltBytes, salt[:])
+
+	// Peers that don't support filters ignore it
+	parsedGossip, err = ParseAppGossip(gossipBytes)
+	require.NoError(err)
+	require.Equal(gossip, parsedGossip)
+
+	// Peers that don't advertise a filter should be pushed everything
+	gossipBytes, err = MarshalAppGossip(gossip)
+	require.NoError(err)
+	parsedGossip, filter, _, err = ParseAppGossipWithFilter(gossipBytes)
+	require.NoError(err)
+	require.Equal(gossip, parsedGossip)
+	require.Nil(filter)
+
+	// Invalid filters are ignored, but the gossip is still parsed
+	for _, invalid := range []struct {
+		filterBytes []byte
+		saltBytes   []byte
+	}{
+		{
+			filterBytes: []byte{1},
+			saltBytes:   saltBytes,
+		},
+		{
+			filterBytes: filterBytes,
+			saltBytes:   []byte{1},
+		},
+	} {
+		gossipBytes, err = MarshalAppGossipWithFilter(gossip, invalid.filterBytes, invalid.saltBytes)
+		require.NoError(err)
+		parsedGossip, filter, _, err = ParseAppGossipWithFilter(gossipBytes)
+		require.NoError(err)
+		require.Equal(gossip, parsedGossip)
+		require.Nil(filter)
+	}
+}
+
+func BenchmarkMarshalAppRequest(b *testing.B) {
+	bloom := newSparseBloomFilter(b)
+	filterBytes, saltBytes := bloom.Marshal()
+
+	uncompressedBytes, err := MarshalAppRequest(filterBytes, saltBytes)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	var compressedBytes []byte
+	for i := 0; i < b.N; i++ {
+		compressedBytes, err = MarshalCompressedAppRequest(filterBytes, saltBytes)
+		require.NoError(b, err)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(len(uncompressedBytes)), "uncompressed-bytes")
+	b.ReportMetric(float64(len(compressedBytes)), "compressed-bytes")
+	b.ReportMetric(float64(len(uncompressedBytes)-len(compressedBytes)), "saved-bytes")
+}
+
+func TestParseAppResponseTooLarge(t *testing.T) {
+	require := require.New(t)
+
+	gossip := [][]byte{{1, 2, 3}}
+	responseBytes, err := MarshalAppResponse(gossip)
+	require.NoError(err)
+
+	response, err := parseAppResponse(responseBytes, len(responseBytes))
+	require.NoError(err)
+	require.Equal(gossip, response.Gossip)
+
+	_, err = parseAppResponse(responseBytes, len(responseBytes)-1)
+	require.ErrorIs(err, errResponseTooLarge)
+}
+
+func TestAppResponseWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		gossip      [][]byte
+		bundleSizes []int
+		statuses    []Status
+		challenge   []byte
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "gossip",
+			gossip: [][]byte{{1, 2, 3}, {}, make([]byte, 300)},
+		},
+		{
+			name:        "bundles",
+			gossip:      [][]byte{{1}, {2}, {3}},
+			bundleSizes: []int{2, 1},
+		},
+		{
+			name:      "statuses and challenge",
+			gossip:    [][]byte{{1}, {2}},
+			statuses:  []Status{StatusPending, StatusAccepted},
+			challenge: []byte{4, 5, 6},
+		},
+		{
+			name:        "everything",
+			gossip:      [][]byte{{1}, {2}, {3}},
+			bundleSizes: []int{1, 2},
+			statuses:    []Status{StatusPending, StatusAccepted, StatusPending},
+			challenge:   []byte{4, 5, 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			expectedBytes, err := marshalAppResponse(tt.gossip, tt.bundleSizes, tt.statuses, tt.challenge)
+			require.NoError(err)
+
+			w := appResponseWriter{}
+			for _, gossip := range tt.gossip {
+				w.appendGossip(gossip)
+			}
+
+			// Truncated gossip isn't included in the response.
+			mark := w.mark()
+			w.appendGossip([]byte{7, 8, 9})
+			w.truncate(mark)
+			require.Equal(len(tt.gossip), w.numGossip)
+
+			responseBytes := w.marshal(tt.bundleSizes, tt.statuses, tt.challenge)
+			require.Equal(expectedBytes, responseBytes)
+		})
+	}
+}
+
+func BenchmarkAppResponseWriter(b *testing.B) {
+	gossip := make([][]byte, 1000)
+	for i := range gossip {
+		gossip[i] = make([]byte, 200)
+	}
+	b.Run("buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			gossipBytes := make([][]byte, 0)
+			for _, bytes := range gossip {
+				gossipBytes = append(gossipBytes, bytes)
+			}
+			_, err := marshalAppResponse(gossipBytes, nil, nil, nil)
+			require.NoError(b, err)
+		}
+	})
+	b.Run("streaming", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var w appResponseWriter
+			for _, bytes := range gossip {
+				w.appendGossip(bytes)
+			}
+			_ = w.marshal(nil, nil, nil)
+		}
+	})
+}
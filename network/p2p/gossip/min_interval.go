@@ -0,0 +1,74 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+var _ Gossiper = (*MinIntervalGossiper[*testTx])(nil)
+
+// SizedSet is a Set that reports the number of gossipables it holds. If the
+// Set of a Handler implements SizedSet, its size is recorded in the Handler's
+// Metrics whenever a pull request is served or pushed gossip is received.
+type SizedSet[T Gossipable] interface {
+	Set[T]
+	// Len returns the number of gossipables in the set.
+	Len() int
+}
+
+// NewMinIntervalGossiper returns a gossiper that calls [gossiper] at most once
+// every [minInterval] while [set] holds fewer than [threshold] gossipables.
+// While [set] holds at least [threshold] gossipables, or if [set] doesn't
+// implement SizedSet, every round of gossip is forwarded to [gossiper].
+func NewMinIntervalGossiper[T Gossipable](
+	gossiper Gossiper,
+	set Set[T],
+	threshold int,
+	minInterval time.Duration,
+) *MinIntervalGossiper[T] {
+	return &MinIntervalGossiper[T]{
+		gossiper:    gossiper,
+		set:         set,
+		threshold:   threshold,
+		minInterval: minInterval,
+	}
+}
+
+// MinIntervalGossiper reduces the frequency of gossip while the gossiped set
+// is small, where frequent rounds of gossip have little benefit.
+type MinIntervalGossiper[T Gossipable] struct {
+	gossiper    Gossiper
+	set         Set[T]
+	threshold   int
+	minInterval time.Duration
+	clock       mockable.Clock
+
+	lock       sync.Mutex
+	lastGossip time.Time
+}
+
+func (m *MinIntervalGossiper[T]) Gossip(ctx context.Context) error {
+	m.lock.Lock()
+	now := m.clock.Time()
+	if m.isSmall() && now.Sub(m.lastGossip) < m.minInterval {
+		m.lock.Unlock()
+		return nil
+	}
+	m.lastGossip = now
+	m.lock.Unlock()
+
+	return m.gossiper.Gossip(ctx)
+}
+
+// isSmall returns true if the set is known to hold fewer than the threshold of
+// gossipables.
+func (m *MinIntervalGossiper[T]) isSmall() bool {
+	set, ok := m.set.(SizedSet[T])
+	return ok && set.Len() < m.threshold
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+func TestMinIntervalGossiper(t *testing.T) {
+	const (
+		threshold   = 2
+		minInterval = time.Second
+	)
+
+	tests := []struct {
+		name               string
+		numTxs             int
+		hideLen            bool
+		expectedNumGossips int
+	}{
+		{
+			name:               "small set",
+			numTxs:             threshold - 1,
+			expectedNumGossips: 2,
+		},
+		{
+			name:               "set at threshold",
+			numTxs:             threshold,
+			expectedNumGossips: 4,
+		},
+		{
+			name:               "set without size",
+			numTxs:             0,
+			hideLen:            true,
+			expectedNumGossips: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			set := &testSet{
+				txs: make(map[ids.ID]*testTx),
+			}
+			for i := 0; i < tt.numTxs; i++ {
+				tx := &testTx{id: ids.GenerateTestID()}
+				set.txs[tx.id] = tx
+			}
+
+			var gossipSet Set[*testTx] = set
+			if tt.hideLen {
+				gossipSet = struct{ Set[*testTx] }{set}
+			}
+
+			numGossips := 0
+			gossiper := NewMinIntervalGossiper[*testTx](
+				&TestGossiper{
+					GossipF: func(context.Context) error {
+						numGossips++
+						return nil
+					},
+				},
+				gossipSet,
+				threshold,
+				minInterval,
+			)
+
+			// Rounds of gossip are attempted every half of the min interval
+			startTime := time.Unix(0, 0)
+			for i := 0; i < 4; i++ {
+				gossiper.clock.Set(startTime.Add(time.Duration(i) * minInterval / 2))
+				require.NoError(gossiper.Gossip(context.Background()))
+			}
+			require.Equal(tt.expectedNumGossips, numGossips)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/linked"
+)
+
+// NewPeerLRU returns a PeerLRU that tracks up to [size] distinct peers.
+func NewPeerLRU(size int, registerer prometheus.Registerer, namespace string) (*PeerLRU, error) {
+	p := &PeerLRU{
+		size:  size,
+		peers: linked.NewHashmap[ids.NodeID, struct{}](),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_peer_evictions",
+			Help:      "number of peers that were no longer tracked to stay within the limit of tracked peers (n)",
+		}),
+	}
+	return p, registerer.Register(p.evictions)
+}
+
+// PeerLRU bounds the total number of distinct peers tracked by the per-peer
+// gossip structures that share it. Once more than [size] peers are tracked,
+// the least recently active peer is evicted from every structure.
+type PeerLRU struct {
+	size      int
+	evictions prometheus.Counter
+
+	lock    sync.Mutex
+	peers   *linked.Hashmap[ids.NodeID, struct{}]
+	onEvict []func(nodeID ids.NodeID)
+}
+
+// OnEvict registers [f] to be called with every peer that is evicted. [f] is
+// not called while the lock of the PeerLRU is held.
+func (p *PeerLRU) OnEvict(f func(nodeID ids.NodeID)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.onEvict = append(p.onEvict, f)
+}
+
+// Touch marks [nodeID] as the most recently active peer. If this exceeds the
+// limit of tracked peers, the least recently active peer is evicted.
+//
+// Touch calls the eviction callbacks, so it must not be called while holding
+// a lock that is acquired by an eviction callback.
+func (p *PeerLRU) Touch(nodeID ids.NodeID) {
+	p.lock.Lock()
+	var (
+		evictedNodeID ids.NodeID
+		evicted       bool
+	)
+	if _, ok := p.peers.Get(nodeID); !ok && p.peers.Len() >= p.size {
+		evictedNodeID, _, evicted = p.peers.Oldest()
+		p.peers.Delete(evictedNodeID)
+	}
+	p.peers.Put(nodeID, struct{}{})
+	onEvict := p.onEvict
+	p.lock.Unlock()
+
+	if !evicted {
+		return
+	}
+	p.evictions.Inc()
+	for _, f := range onEvict {
+		f(evictedNodeID)
+	}
+}
+
+// Len returns the number of tracked peers.
+func (p *PeerLRU) Len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.peers.Len()
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/set"
+)
+
+func TestPeerLRUChurn(t *testing.T) {
+	require := require.New(t)
+
+	const (
+		maxPeers       = 8
+		maxPeersPerMap = 64
+		numPeers       = 1000
+	)
+	peers, err := NewPeerLRU(maxPeers, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	var (
+		peerStats = NewPeerStatsTrackerWithPeers(maxPeersPerMap, peers)
+		throttler = NewPeerStatsThrottler(
+			p2p.NewSlidingWindowThrottler(time.Minute, 0),
+			peerStats,
+		)
+		challenger = newChallenger(ReplayChallengeParams{
+			MaxPeers:  maxPeersPerMap,
+			Frequency: 1,
+			Peers:     peers,
+		})
+		echoes = newChallengeEchoes(maxPeersPerMap)
+	)
+	echoes.sharePeers(peers)
+
+	trackedPeers := func() set.Set[ids.NodeID] {
+		var tracked set.Set[ids.NodeID]
+		for nodeID := range peerStats.PeerStats() {
+			tracked.Add(nodeID)
+		}
+		it := challenger.outstanding.NewIterator()
+		for it.Next() {
+			tracked.Add(it.Key())
+		}
+		it = echoes.challenges.NewIterator()
+		for it.Next() {
+			tracked.Add(it.Key())
+		}
+		return tracked
+	}
+
+	// Each peer is tracked by a different structure so that the structures
+	// only stay within the limit because they share it.
+	for i := 0; i < numPeers; i++ {
+		nodeID := ids.GenerateTestNodeID()
+		switch i % 3 {
+		case 0:
+			require.False(throttler.Handle(nodeID))
+		case 1:
+			_, err := challenger.challenge(nodeID)
+			require.NoError(err)
+		case 2:
+			echoes.put(nodeID, []byte{1})
+		}
+
+		require.LessOrEqual(peers.Len(), maxPeers)
+		require.LessOrEqual(trackedPeers().Len(), maxPeers)
+	}
+	require.Equal(float64(numPeers-maxPeers), testutil.ToFloat64(peers.evictions))
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/linked"
+)
+
+var _ p2p.Throttler = (*peerStatsThrottler)(nil)
+
+// GossipPeerStats is the gossip activity of a single peer
+type GossipPeerStats struct {
+	// ReceivedCount and ReceivedBytes are the amount of gossip pushed to us by
+	// the peer.
+	ReceivedCount uint64
+	ReceivedBytes uint64
+	// SentCount and SentBytes are the amount of gossip sent to the peer in
+	// response to its pull requests.
+	SentCount uint64
+	SentBytes uint64
+	// NumAdded is the number of gossipables received from the peer that were
+	// added to the set.
+	NumAdded uint64
+	// NumDropped is the number of gossipables received from the peer that
+	// failed to be added to the set.
+	NumDropped uint64
+	// NumThrottled is the number of messages from the peer that were
+	// throttled.
+	NumThrottled uint64
+	// NumChallengeFailures is the number of pull requests from the peer that
+	// didn't echo the challenge it was most recently sent.
+	NumChallengeFailures uint64
+}
+
+// NewPeerStatsTracker returns a tracker of the gossip activity of up to [size]
+// peers. Once more than [size] peers are tracked, the least recently active
+// peer is evicted.
+func NewPeerStatsTracker(size int) *PeerStatsTracker {
+	return NewPeerStatsTrackerWithPeers(size, nil)
+}
+
+// NewPeerStatsTrackerWithPeers returns a tracker of the gossip activity of up
+// to [size] peers. If [peers] is non-nil, the tracked peers are also bounded
+// by the other structures sharing [peers].
+func NewPeerStatsTrackerWithPeers(size int, peers *PeerLRU) *PeerStatsTracker {
+	p := &PeerStatsTracker{
+		size:  size,
+		peers: peers,
+		stats: linked.NewHashmap[ids.NodeID, *GossipPeerStats](),
+	}
+	if peers != nil {
+		peers.OnEvict(p.evict)
+	}
+	return p
+}
+
+// PeerStatsTracker tracks the gossip activity of the most recently active
+// peers.
+type PeerStatsTracker struct {
+	size  int
+	peers *PeerLRU
+
+	lock  sync.Mutex
+	stats *linked.Hashmap[ids.NodeID, *GossipPeerStats]
+}
+
+// PeerStats returns a copy of the gossip activity of the currently tracked
+// peers.
+func (p *PeerStatsTracker) PeerStats() map[ids.NodeID]GossipPeerStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	peerStats := make(map[ids.NodeID]GossipPeerStats, p.stats.Len())
+	it := p.stats.NewIterator()
+	for it.Next() {
+		peerStats[it.Key()] = *it.Value()
+	}
+	return peerStats
+}
+
+// update applies [f] to the stats of [nodeID] and marks [nodeID] as the most
+// recently active peer.
+func (p *PeerStatsTracker) update(nodeID ids.NodeID, f func(stats *GossipPeerStats)) {
+	if p.peers != nil {
+		p.peers.Touch(nodeID)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	stats, ok := p.stats.Get(nodeID)
+	if !ok {
+		if p.stats.Len() >= p.size {
+			oldestNodeID, _, _ := p.stats.Oldest()
+			p.stats.Delete(oldestNodeID)
+		}
+		stats = &GossipPeerStats{}
+	}
+	f(stats)
+	p.stats.Put(nodeID, stats)
+}
+
+// evict stops tracking the gossip activity of [nodeID].
+func (p *PeerStatsTracker) evict(nodeID ids.NodeID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.stats.Delete(nodeID)
+}
+
+// NewPeerStatsThrottler returns a throttler that records the messages that
+// were throttled by [throttler] in [stats].
+func NewPeerStatsThrottler(throttler p2p.Throttler, stats *PeerStatsTracker) p2p.Throttler {
+	return &peerStatsThrottler{
+		throttler: throttler,
+		stats:     stats,
+	}
+}
+
+type peerStatsThrottler struct {
+	throttler p2p.Throttler
+	stats     *PeerStatsTracker
+}
+
+func (p *peerStatsThrottler) Handle(nodeID ids.NodeID) bool {
+	if p.throttler.Handle(nodeID) {
+		return true
+	}
+
+	p.stats.update(nodeID, func(stats *GossipPeerStats) {
+		stats.NumThrottled++
+	})
+	return false
+}
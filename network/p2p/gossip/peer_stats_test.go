@@ -0,0 +1,103 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+func TestPeerStatsTracker(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	peerStats := NewPeerStatsTracker(2)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		1024,
+		WithPeerStats[*testTx](peerStats),
+	)
+
+	var (
+		nodeID0 = ids.GenerateTestNodeID()
+		nodeID1 = ids.GenerateTestNodeID()
+		tx0     = &testTx{id: ids.GenerateTestID()}
+		tx1     = &testTx{id: ids.GenerateTestID()}
+	)
+
+	// nodeID0 pushes two novel txs
+	gossipBytes, err := MarshalAppGossip([][]byte{tx0.id[:], tx1.id[:]})
+	require.NoError(err)
+	handler.AppGossip(ctx, nodeID0, gossipBytes)
+
+	// nodeID1 pushes a tx that we already have
+	gossipBytes, err = MarshalAppGossip([][]byte{tx0.id[:]})
+	require.NoError(err)
+	handler.AppGossip(ctx, nodeID1, gossipBytes)
+
+	// nodeID1 pulls everything we know about
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	_, err = handler.AppRequest(ctx, nodeID1, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	// nodeID1 is throttled
+	throttler := NewPeerStatsThrottler(
+		p2p.NewSlidingWindowThrottler(time.Minute, 0),
+		peerStats,
+	)
+	require.False(throttler.Handle(nodeID1))
+
+	require.Equal(
+		map[ids.NodeID]GossipPeerStats{
+			nodeID0: {
+				ReceivedCount: 2,
+				ReceivedBytes: 2 * ids.IDLen,
+				NumAdded:      2,
+			},
+			nodeID1: {
+				ReceivedCount: 1,
+				ReceivedBytes: ids.IDLen,
+				SentCount:     2,
+				SentBytes:     2 * ids.IDLen,
+				NumDropped:    1,
+				NumThrottled:  1,
+			},
+		},
+		peerStats.PeerStats(),
+	)
+
+	// A new peer evicts the least recently active peer
+	nodeID2 := ids.GenerateTestNodeID()
+	require.False(throttler.Handle(nodeID2))
+
+	stats := peerStats.PeerStats()
+	require.Len(stats, 2)
+	require.NotContains(stats, nodeID0)
+	require.Contains(stats, nodeID1)
+	require.Equal(GossipPeerStats{NumThrottled: 1}, stats[nodeID2])
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+var (
+	_ Prioritizer[Gossipable] = (*AgeDecayedPrioritizer[Gossipable])(nil)
+
+	ErrInvalidPriorityHalfLife = errors.New("priority half-life must be positive")
+)
+
+// NewAgeDecayedPrioritizer returns a Prioritizer that halves the priority
+// reported by [prioritizer] for every [halfLife] since a gossipable was added,
+// so that fresh gossipables outrank stale gossipables with the same priority.
+//
+// [addedTime] returns when a gossipable was added, or false if it isn't known,
+// in which case its priority isn't decayed. The age of a gossipable is
+// measured with [clock].
+func NewAgeDecayedPrioritizer[T Gossipable](
+	prioritizer Prioritizer[T],
+	halfLife time.Duration,
+	addedTime func(T) (time.Time, bool),
+	clock *mockable.Clock,
+) (*AgeDecayedPrioritizer[T], error) {
+	if halfLife <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPriorityHalfLife, halfLife)
+	}
+	return &AgeDecayedPrioritizer[T]{
+		prioritizer: prioritizer,
+		halfLife:    halfLife,
+		addedTime:   addedTime,
+		clock:       clock,
+	}, nil
+}
+
+// AgeDecayedPrioritizer decays the priority of gossipables by their age.
+type AgeDecayedPrioritizer[T Gossipable] struct {
+	prioritizer Prioritizer[T]
+	halfLife    time.Duration
+	addedTime   func(T) (time.Time, bool)
+	clock       *mockable.Clock
+}
+
+// Priority returns the decayed priority of [gossipable], rounded down.
+func (p *AgeDecayedPrioritizer[T]) Priority(gossipable T) uint64 {
+	priority := p.prioritizer.Priority(gossipable)
+	addedTime, ok := p.addedTime(gossipable)
+	if !ok {
+		return priority
+	}
+
+	age := p.clock.Time().Sub(addedTime)
+	if age <= 0 {
+		return priority
+	}
+	halfLives := float64(age) / float64(p.halfLife)
+	return uint64(float64(priority) * math.Exp2(-halfLives))
+}
@@ -0,0 +1,169 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+	"github.com/ava-labs/avalanchego/utils/units"
+)
+
+var _ Prioritizer[*testTx] = constPrioritizer(0)
+
+// constPrioritizer gives every tx the same priority.
+type constPrioritizer uint64
+
+func (p constPrioritizer) Priority(*testTx) uint64 {
+	return uint64(p)
+}
+
+func TestNewAgeDecayedPrioritizerInvalidHalfLife(t *testing.T) {
+	for _, halfLife := range []time.Duration{0, -time.Second} {
+		_, err := NewAgeDecayedPrioritizer[*testTx](
+			constPrioritizer(100),
+			halfLife,
+			func(*testTx) (time.Time, bool) {
+				return time.Time{}, false
+			},
+			&mockable.Clock{},
+		)
+		require.ErrorIs(t, err, ErrInvalidPriorityHalfLife)
+	}
+}
+
+func TestAgeDecayedPrioritizer(t *testing.T) {
+	const halfLife = time.Minute
+	now := time.Now()
+
+	tests := []struct {
+		name             string
+		addedTime        time.Time
+		known            bool
+		expectedPriority uint64
+	}{
+		{
+			name:             "unknown",
+			expectedPriority: 100,
+		},
+		{
+			name:             "just added",
+			addedTime:        now,
+			known:            true,
+			expectedPriority: 100,
+		},
+		{
+			name:             "added in the future",
+			addedTime:        now.Add(time.Minute),
+			known:            true,
+			expectedPriority: 100,
+		},
+		{
+			name:             "one half-life",
+			addedTime:        now.Add(-halfLife),
+			known:            true,
+			expectedPriority: 50,
+		},
+		{
+			name:             "two half-lives",
+			addedTime:        now.Add(-2 * halfLife),
+			known:            true,
+			expectedPriority: 25,
+		},
+		{
+			name:             "rounded down",
+			addedTime:        now.Add(-halfLife / 2),
+			known:            true,
+			expectedPriority: 70,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &mockable.Clock{}
+			clock.Set(now)
+			prioritizer, err := NewAgeDecayedPrioritizer[*testTx](
+				constPrioritizer(100),
+				halfLife,
+				func(*testTx) (time.Time, bool) {
+					return tt.addedTime, tt.known
+				},
+				clock,
+			)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedPriority, prioritizer.Priority(&testTx{}))
+		})
+	}
+}
+
+// Pull requests are served with an older gossipable of the same base priority
+// after a newer one.
+func TestHandlerAgeDecayedPrioritizer(t *testing.T) {
+	require := require.New(t)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+
+	var (
+		now   = time.Now()
+		added = make(map[ids.ID]time.Time)
+		clock = &mockable.Clock{}
+	)
+	clock.Set(now)
+	for i := 0; i < 10; i++ {
+		tx := &testTx{id: ids.ID{byte(i)}}
+		require.NoError(set.Add(tx))
+		// The tx with the highest ID is the oldest.
+		added[tx.id] = now.Add(-time.Duration(i) * time.Minute)
+	}
+
+	prioritizer, err := NewAgeDecayedPrioritizer[*testTx](
+		constPrioritizer(1000),
+		time.Minute,
+		func(tx *testTx) (time.Time, bool) {
+			addedTime, ok := added[tx.id]
+			return addedTime, ok
+		},
+		clock,
+	)
+	require.NoError(err)
+
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		testMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+		WithPrioritizer[*testTx](prioritizer),
+		WithTargetResponseItems[*testTx](3),
+	)
+
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	// Only the newest txs fit in the response, newest first.
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	expectedIDs := []ids.ID{{0}, {1}, {2}}
+	require.Len(gossip, len(expectedIDs))
+	for i, expectedID := range expectedIDs {
+		require.Equal(expectedID[:], gossip[i])
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/bloom"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+// receivedFalsePositiveProbability is the target false positive probability of
+// the filter of recently received gossip. A false positive skips pushing
+// gossip to a peer that doesn't have it, which is recovered by pull gossip.
+const receivedFalsePositiveProbability = .01
+
+var (
+	ErrInvalidPushFilterPeers = errors.New("number of push filter peers must be positive")
+	ErrInvalidPushFilterTTL   = errors.New("push filter ttl must be positive")
+)
+
+// NewPushFilters returns a tracker of the gossip known by up to [maxPeers]
+// peers. The filter advertised by a peer is used for [ttl] after it is
+// received. Gossip pushed to us by a peer is remembered for up to [ttl] in a
+// filter sized for [expectedReceived] pushed gossipables.
+func NewPushFilters(
+	maxPeers int,
+	ttl time.Duration,
+	expectedReceived int,
+	registerer prometheus.Registerer,
+	namespace string,
+) (*PushFilters, error) {
+	switch {
+	case maxPeers <= 0:
+		return nil, ErrInvalidPushFilterPeers
+	case ttl <= 0:
+		return nil, ErrInvalidPushFilterTTL
+	}
+
+	numHashes, numEntries := bloom.OptimalParameters(expectedReceived, receivedFalsePositiveProbability)
+	p := &PushFilters{
+		ttl:         ttl,
+		numHashes:   numHashes,
+		numEntries:  numEntries,
+		maxReceived: max(expectedReceived, 1),
+		advertised:  &cache.LRU[ids.NodeID, *advertisedFilter]{Size: maxPeers},
+		numFiltered: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_push_filtered",
+			Help:      "number of gossipables not pushed to a peer that was known to have them (n)",
+		}),
+	}
+	if err := p.resetReceived(); err != nil {
+		return nil, err
+	}
+	return p, registerer.Register(p.numFiltered)
+}
+
+// PushFilters tracks the gossip that peers are known to have, so that gossip
+// isn't pushed to peers that already have it. A peer is known to have a
+// gossipable if it pushed the gossipable to us recently, or if the filter the
+// peer most recently advertised contains the gossipable. Peers that never
+// advertised a filter are pushed all gossip they didn't push to us.
+type PushFilters struct {
+	clock       mockable.Clock
+	ttl         time.Duration
+	numHashes   int
+	numEntries  int
+	maxReceived int
+	numFiltered prometheus.Counter
+
+	lock       sync.Mutex
+	advertised *cache.LRU[ids.NodeID, *advertisedFilter]
+	// received is a short-lived filter of the gossip pushed to us, keyed by
+	// the peer that pushed it.
+	received      *bloom.Filter
+	receivedSalt  ids.ID
+	receivedSince time.Time
+}
+
+type advertisedFilter struct {
+	filter         *bloom.ReadFilter
+	salt           ids.ID
+	advertisedTime time.Time
+}
+
+// advertise records [filter], salted with [salt], as the gossip known by
+// [nodeID].
+func (p *PushFilters) advertise(nodeID ids.NodeID, filter *bloom.ReadFilter, salt ids.ID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.advertised.Put(nodeID, &advertisedFilter{
+		filter:         filter,
+		salt:           salt,
+		advertisedTime: p.clock.Time(),
+	})
+}
+
+// receive records that [nodeID] pushed [gossipID] to us.
+func (p *PushFilters) receive(nodeID ids.NodeID, gossipID ids.ID) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.resetReceivedIfNeeded(); err != nil {
+		return err
+	}
+	bloom.Add(p.received, receivedKey(nodeID, gossipID), p.receivedSalt[:])
+	return nil
+}
+
+// has returns true if [nodeID] is known to have [gossipID].
+func (p *PushFilters) has(nodeID ids.NodeID, gossipID ids.ID) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if bloom.Contains(p.received, receivedKey(nodeID, gossipID), p.receivedSalt[:]) {
+		return true
+	}
+
+	advertised, ok := p.advertised.Get(nodeID)
+	if !ok {
+		return false
+	}
+	if p.clock.Time().Sub(advertised.advertisedTime) >= p.ttl {
+		p.advertised.Evict(nodeID)
+		return false
+	}
+	return bloom.Contains(advertised.filter, gossipID[:], advertised.salt[:])
+}
+
+// resetReceivedIfNeeded forgets the received gossip once it is older than the
+// ttl, or once the filter is full.
+//
+// Assumes [p.lock] is held.
+func (p *PushFilters) resetReceivedIfNeeded() error {
+	if p.clock.Time().Sub(p.receivedSince) < p.ttl && p.received.Count() < p.maxReceived {
+		return nil
+	}
+	return p.resetReceived()
+}
+
+// resetReceived replaces the filter of received gossip with an empty filter.
+//
+// Assumes [p.lock] is held, or that [p] isn't shared yet.
+func (p *PushFilters) resetReceived() error {
+	received, err := bloom.New(p.numHashes, p.numEntries)
+	if err != nil {
+		return err
+	}
+	var salt ids.ID
+	if _, err := rand.Read(salt[:]); err != nil {
+		return err
+	}
+
+	p.received = received
+	p.receivedSalt = salt
+	p.receivedSince = p.clock.Time()
+	return nil
+}
+
+func receivedKey(nodeID ids.NodeID, gossipID ids.ID) []byte {
+	key := make([]byte, 0, ids.NodeIDLen+ids.IDLen)
+	key = append(key, nodeID[:]...)
+	return append(key, gossipID[:]...)
+}
@@ -0,0 +1,346 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/units"
+)
+
+func TestNewPushFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxPeers    int
+		ttl         time.Duration
+		expectedErr error
+	}{
+		{
+			name:     "valid",
+			maxPeers: 1,
+			ttl:      time.Second,
+		},
+		{
+			name:        "invalid max peers",
+			maxPeers:    0,
+			ttl:         time.Second,
+			expectedErr: ErrInvalidPushFilterPeers,
+		},
+		{
+			name:        "invalid ttl",
+			maxPeers:    1,
+			ttl:         0,
+			expectedErr: ErrInvalidPushFilterTTL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPushFilters(tt.maxPeers, tt.ttl, 16, prometheus.NewRegistry(), "")
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestPushFilters(t *testing.T) {
+	require := require.New(t)
+
+	const ttl = time.Minute
+	filters, err := NewPushFilters(1, ttl, 16, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	var (
+		startTime  = time.Unix(0, 0)
+		nodeID     = ids.GenerateTestNodeID()
+		otherNode  = ids.GenerateTestNodeID()
+		receivedID = ids.GenerateTestID()
+		knownID    = ids.GenerateTestID()
+		unknownID  = ids.GenerateTestID()
+	)
+	filters.clock.Set(startTime)
+	require.NoError(filters.resetReceived())
+
+	// Gossip pushed by a peer is known by that peer only
+	require.NoError(filters.receive(nodeID, receivedID))
+	require.True(filters.has(nodeID, receivedID))
+	require.False(filters.has(otherNode, receivedID))
+
+	// Gossip in the advertised filter is known by the advertising peer
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 16, .01, .05)
+	require.NoError(err)
+	bloom.Add(&testTx{id: knownID})
+	filterBytes, saltBytes := bloom.Marshal()
+	gossipBytes, err := MarshalAppGossipWithFilter(nil, filterBytes, saltBytes)
+	require.NoError(err)
+	_, filter, salt, err := ParseAppGossipWithFilter(gossipBytes)
+	require.NoError(err)
+	filters.advertise(nodeID, filter, salt)
+	require.True(filters.has(nodeID, knownID))
+	require.False(filters.has(nodeID, unknownID))
+	require.False(filters.has(otherNode, knownID))
+
+	// Both are forgotten after the ttl
+	filters.clock.Set(startTime.Add(ttl))
+	require.NoError(filters.receive(otherNode, unknownID))
+	require.False(filters.has(nodeID, receivedID))
+	require.False(filters.has(nodeID, knownID))
+	require.True(filters.has(otherNode, unknownID))
+}
+
+func TestPushFiltersReduceDuplicatePushes(t *testing.T) {
+	tests := []struct {
+		name                string
+		usePushFilters      bool
+		expectedNumReceived int
+	}{
+		{
+			name:                "without push filters",
+			usePushFilters:      false,
+			expectedNumReceived: 3,
+		},
+		{
+			name:                "with push filters",
+			usePushFilters:      true,
+			expectedNumReceived: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := context.Background()
+
+			type node struct {
+				set         *testSet
+				handler     *Handler[*testTx]
+				gossiper    *PushGossiper[*testTx]
+				pushFilters *PushFilters
+				numReceived int
+			}
+
+			var (
+				nodeIDs = []ids.NodeID{
+					ids.GenerateTestNodeID(),
+					ids.GenerateTestNodeID(),
+				}
+				nodes  = make(map[ids.NodeID]*node)
+				prefix = p2p.ProtocolPrefix(0)
+			)
+			newNode := func(nodeID ids.NodeID) *node {
+				sender := &common.SenderTest{
+					T: t,
+					SendAppGossipF: func(ctx context.Context, config common.SendConfig, gossipBytes []byte) error {
+						gossipBytes = gossipBytes[len(prefix):]
+						for peerID := range config.NodeIDs {
+							gossip, err := ParseAppGossip(gossipBytes)
+							require.NoError(err)
+
+							peer := nodes[peerID]
+							peer.numReceived += len(gossip)
+							peer.handler.AppGossip(ctx, nodeID, gossipBytes)
+						}
+						return nil
+					},
+				}
+				network, err := p2p.NewNetwork(logging.NoLog{}, sender, prometheus.NewRegistry(), "")
+				require.NoError(err)
+
+				// Every other node is a validator
+				validatorSet := make(map[ids.NodeID]*validators.GetValidatorOutput)
+				for _, peerID := range nodeIDs {
+					if peerID == nodeID {
+						continue
+					}
+					validatorSet[peerID] = &validators.GetValidatorOutput{
+						NodeID: peerID,
+						Weight: 1,
+					}
+				}
+				validators := p2p.NewValidators(
+					network.Peers,
+					logging.NoLog{},
+					constants.PrimaryNetworkID,
+					&validators.TestState{
+						GetCurrentHeightF: func(context.Context) (uint64, error) {
+							return 1, nil
+						},
+						GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+							return validatorSet, nil
+						},
+					},
+					time.Hour,
+				)
+
+				bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+				require.NoError(err)
+				set := &testSet{
+					txs:   make(map[ids.ID]*testTx),
+					bloom: bloom,
+				}
+				metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+				require.NoError(err)
+
+				var (
+					pushFilters      *PushFilters
+					handlerOptions   []HandlerOption[*testTx]
+					gossiperOptions  []PushGossiperOption[*testTx]
+					pushGossipParams = BranchingFactor{
+						StakePercentage: 1,
+						Validators:      1,
+					}
+				)
+				if tt.usePushFilters {
+					pushFilters, err = NewPushFilters(10, time.Minute, 1000, prometheus.NewRegistry(), "")
+					require.NoError(err)
+					handlerOptions = append(handlerOptions, WithReceivedPushFilters[*testTx](pushFilters))
+					gossiperOptions = append(gossiperOptions, WithPushFilters[*testTx](pushFilters, time.Nanosecond))
+				}
+				gossiper, err := NewPushGossiper[*testTx](
+					testMarshaller{},
+					set,
+					validators,
+					network.NewClient(0),
+					metrics,
+					pushGossipParams,
+					pushGossipParams,
+					0, // the discarded cache size doesn't matter for this test
+					units.MiB,
+					time.Hour,
+					gossiperOptions...,
+				)
+				require.NoError(err)
+
+				return &node{
+					set:         set,
+					handler:     NewHandler[*testTx](logging.NoLog{}, testMarshaller{}, set, metrics, units.MiB, handlerOptions...),
+					gossiper:    gossiper,
+					pushFilters: pushFilters,
+				}
+			}
+			for _, nodeID := range nodeIDs {
+				nodes[nodeID] = newNode(nodeID)
+			}
+
+			var (
+				a = nodes[nodeIDs[0]]
+				b = nodes[nodeIDs[1]]
+
+				// Pushed from b to a
+				pushedTx = &testTx{id: ids.GenerateTestID()}
+				// Known by both a and b, but never pushed
+				sharedTx = &testTx{id: ids.GenerateTestID()}
+				// Only known by a
+				newTx = &testTx{id: ids.GenerateTestID()}
+			)
+			require.NoError(b.set.Add(pushedTx))
+			require.NoError(b.set.Add(sharedTx))
+			require.NoError(a.set.Add(sharedTx))
+			require.NoError(a.set.Add(newTx))
+
+			b.gossiper.Add(pushedTx)
+			require.NoError(b.gossiper.Gossip(ctx))
+			require.Equal(1, a.numReceived)
+			require.True(a.set.Has(pushedTx.id))
+
+			// a pushes everything it has to b, which already has everything
+			// but newTx.
+			a.gossiper.Add(pushedTx, sharedTx, newTx)
+			require.NoError(a.gossiper.Gossip(ctx))
+			require.Equal(tt.expectedNumReceived, b.numReceived)
+			require.True(b.set.Has(newTx.id))
+
+			if tt.usePushFilters {
+				require.Equal(2.0, testutil.ToFloat64(a.pushFilters.numFiltered))
+			}
+		})
+	}
+}
+
+func TestPushFiltersSampledPeers(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var sent []common.SendConfig
+	sender := &common.SenderTest{
+		T: t,
+		SendAppGossipF: func(_ context.Context, config common.SendConfig, _ []byte) error {
+			sent = append(sent, config)
+			return nil
+		},
+	}
+	network, err := p2p.NewNetwork(logging.NoLog{}, sender, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	var (
+		knownPeer   = ids.GenerateTestNodeID()
+		unknownPeer = ids.GenerateTestNodeID()
+	)
+	require.NoError(network.Connected(ctx, knownPeer, nil))
+	require.NoError(network.Connected(ctx, unknownPeer, nil))
+
+	validators := p2p.NewValidators(
+		network.Peers,
+		logging.NoLog{},
+		constants.PrimaryNetworkID,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 1, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		time.Hour,
+	)
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	pushFilters, err := NewPushFilters(10, time.Minute, 1000, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	params := BranchingFactor{
+		Peers: 2,
+	}
+	gossiper, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		FullSet[*testTx]{},
+		validators,
+		network.NewClient(0),
+		metrics,
+		params,
+		params,
+		0, // the discarded cache size doesn't matter for this test
+		units.MiB,
+		time.Hour,
+		WithConnectedPeers[*testTx](network.Peers),
+		WithPushFilters[*testTx](pushFilters, 0),
+	)
+	require.NoError(err)
+
+	tx := &testTx{id: ids.GenerateTestID()}
+	require.NoError(pushFilters.receive(knownPeer, tx.id))
+
+	// The sampled peers are filtered, so the tx is only pushed to the peer
+	// that doesn't have it, and no other peers are sampled when it is sent.
+	gossiper.Add(tx)
+	require.NoError(gossiper.Gossip(ctx))
+	require.Equal(
+		[]common.SendConfig{
+			{
+				NodeIDs: set.Of(unknownPeer),
+			},
+		},
+		sent,
+	)
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/linked"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+// responseSizeGrowthDivisor determines how quickly the target response size
+// of a peer recovers after its responses start meeting their deadlines again.
+// Each response that meets its deadline grows the target response size by
+// 1/responseSizeGrowthDivisor of the default target response size.
+const responseSizeGrowthDivisor = 8
+
+// AdaptiveResponseSizeParams configures shrinking the target response size
+// for peers whose responses are not expected to arrive before the deadline of
+// their requests.
+type AdaptiveResponseSizeParams struct {
+	// MaxPeers is the maximum number of peers with a reduced target response
+	// size that are tracked. Once exceeded, the least recently active peer
+	// reverts to the default target response size.
+	MaxPeers int
+	// MinResponseSize is the smallest target response size a peer can be
+	// reduced to.
+	MinResponseSize int
+	// SendBytesPerSecond is the estimated rate at which responses are sent.
+	// It is used to estimate when a response will arrive. If 0, responses
+	// are assumed to arrive as soon as they are built.
+	SendBytesPerSecond float64
+}
+
+func newResponseSizer(params AdaptiveResponseSizeParams) *responseSizer {
+	return &responseSizer{
+		params: params,
+		sizes:  linked.NewHashmap[ids.NodeID, int](),
+	}
+}
+
+// responseSizer tracks the target response size of peers whose responses
+// have missed their deadlines.
+type responseSizer struct {
+	params AdaptiveResponseSizeParams
+	clock  mockable.Clock
+
+	lock  sync.Mutex
+	sizes *linked.Hashmap[ids.NodeID, int]
+}
+
+// targetResponseSize returns the target response size of [nodeID], which is
+// [defaultSize] unless it has been reduced.
+func (r *responseSizer) targetResponseSize(nodeID ids.NodeID, defaultSize int) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if size, ok := r.sizes.Get(nodeID); ok {
+		return size
+	}
+	return defaultSize
+}
+
+// observe records whether a response of [responseSize] bytes to [nodeID],
+// which finished being built now, is expected to arrive before [deadline].
+// The target response size of [nodeID] is halved if the response is expected
+// to be late and grown otherwise.
+func (r *responseSizer) observe(
+	nodeID ids.NodeID,
+	deadline time.Time,
+	responseSize int,
+	defaultSize int,
+) {
+	var sendDuration time.Duration
+	if r.params.SendBytesPerSecond > 0 {
+		sendDuration = time.Duration(float64(responseSize) / r.params.SendBytesPerSecond * float64(time.Second))
+	}
+	expectedArrival := r.clock.Time().Add(sendDuration)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	size, ok := r.sizes.Get(nodeID)
+	if !ok {
+		size = defaultSize
+	}
+
+	if expectedArrival.After(deadline) {
+		size = max(r.params.MinResponseSize, size/2)
+	} else {
+		size += min(defaultSize/responseSizeGrowthDivisor, defaultSize-size)
+	}
+
+	if size >= defaultSize {
+		r.sizes.Delete(nodeID)
+		return
+	}
+
+	if !ok && r.sizes.Len() >= r.params.MaxPeers {
+		oldestNodeID, _, _ := r.sizes.Oldest()
+		r.sizes.Delete(oldestNodeID)
+	}
+	r.sizes.Put(nodeID, size)
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/utils/sampler"
+	"github.com/ava-labs/avalanchego/utils/set"
+)
+
+const (
+	modeLabel   = "mode"
+	sampledMode = "sampled"
+	fullMode    = "full"
+)
+
+var (
+	sampleModeLabels = []string{typeLabel, modeLabel}
+
+	ErrInvalidSampleSize      = errors.New("sample size function must be provided")
+	ErrInvalidSampleThreshold = errors.New("sample threshold cannot be negative")
+	ErrInvalidTopFraction     = errors.New("top fraction must be in the range [0, 1]")
+	ErrInvalidRandomFraction  = errors.New("random fraction must be in the range [0, 1]")
+)
+
+// SamplingParams configures gossiping only a sample of the known gossipables
+// once the set grows beyond a threshold. This bounds the bandwidth spent on
+// gossip under extreme load while still propagating the most important
+// gossipables.
+type SamplingParams[T Gossipable] struct {
+	// Size returns the current number of gossipables in the set.
+	Size func() int
+	// Threshold is the size of the set above which only a sample is gossiped.
+	Threshold int
+	// Priority returns the priority of a gossipable, such as its fee. If nil,
+	// all gossipables are considered to have the same priority.
+	Priority func(T) uint64
+	// TopFraction is the fraction of candidates with the highest priority
+	// that are always included in a sample.
+	TopFraction float64
+	// RandomFraction is the fraction of the remaining candidates that are
+	// included in a sample uniformly at random.
+	RandomFraction float64
+}
+
+func (s *SamplingParams[_]) Verify() error {
+	switch {
+	case s.Size == nil:
+		return ErrInvalidSampleSize
+	case s.Threshold < 0:
+		return ErrInvalidSampleThreshold
+	case s.TopFraction < 0 || s.TopFraction > 1:
+		return ErrInvalidTopFraction
+	case s.RandomFraction < 0 || s.RandomFraction > 1:
+		return ErrInvalidRandomFraction
+	default:
+		return nil
+	}
+}
+
+// shouldSample returns true if the set is large enough that only a sample
+// should be gossiped.
+func (s *SamplingParams[_]) shouldSample() bool {
+	return s.Size() > s.Threshold
+}
+
+// sample splits [candidates] into the gossipables that should be gossiped and
+// the gossipables that should not. The sampled gossipables are ordered with
+// the highest priority gossipables first, followed by the randomly sampled
+// gossipables.
+func (s *SamplingParams[T]) sample(candidates []T) ([]T, []T) {
+	candidates = slices.Clone(candidates)
+	if s.Priority != nil {
+		sortByPriority(s.Priority, candidates)
+	}
+
+	var (
+		numTop    = int(s.TopFraction * float64(len(candidates)))
+		top       = candidates[:numTop]
+		rest      = candidates[numTop:]
+		numRandom = int(s.RandomFraction * float64(len(rest)))
+		uniform   = sampler.NewUniform()
+	)
+	uniform.Initialize(uint64(len(rest)))
+	indices, err := uniform.Sample(numRandom)
+	if err != nil {
+		// This should never happen as numRandom <= len(rest)
+		return candidates, nil
+	}
+
+	selected := set.Of(indices...)
+	sampled := make([]T, numTop, numTop+numRandom)
+	copy(sampled, top)
+	unsampled := make([]T, 0, len(rest)-numRandom)
+	for i, gossipable := range rest {
+		if selected.Contains(uint64(i)) {
+			sampled = append(sampled, gossipable)
+		} else {
+			unsampled = append(unsampled, gossipable)
+		}
+	}
+	return sampled, unsampled
+}
+
+func (m Metrics) observeSampleMode(gossipType string, sampled bool) {
+	mode := fullMode
+	if sampled {
+		mode = sampledMode
+	}
+	m.sampleMode.With(prometheus.Labels{
+		typeLabel: gossipType,
+		modeLabel: mode,
+	}).Inc()
+}
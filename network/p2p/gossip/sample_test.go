@@ -0,0 +1,383 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/proto/pb/sdk"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+	"github.com/ava-labs/avalanchego/utils/units"
+)
+
+const numLoadTxs = 100
+
+// newLoadTxs returns [numLoadTxs] txs where the priority of the i-th tx is i.
+func newLoadTxs() []*testTx {
+	txs := make([]*testTx, numLoadTxs)
+	for i := range txs {
+		txs[i] = &testTx{id: ids.ID{byte(i)}}
+	}
+	return txs
+}
+
+func testTxPriority(tx *testTx) uint64 {
+	return uint64(tx.id[0])
+}
+
+func newLoadSamplingParams(size int) SamplingParams[*testTx] {
+	return SamplingParams[*testTx]{
+		Size: func() int {
+			return size
+		},
+		Threshold:      numLoadTxs / 2,
+		Priority:       testTxPriority,
+		TopFraction:    .1,
+		RandomFraction: .2,
+	}
+}
+
+// requireLoadSample asserts that [sample] contains the highest priority txs,
+// highest priority first, followed by a random sample of the remaining txs.
+func requireLoadSample(require *require.Assertions, sample []*testTx) {
+	const (
+		numTop    = numLoadTxs / 10
+		numRandom = (numLoadTxs - numTop) / 5
+	)
+	require.Len(sample, numTop+numRandom)
+	for i, tx := range sample[:numTop] {
+		require.Equal(uint64(numLoadTxs-1-i), testTxPriority(tx))
+	}
+	for _, tx := range sample[numTop:] {
+		require.Less(testTxPriority(tx), uint64(numLoadTxs-numTop))
+	}
+}
+
+func TestSamplingParamsVerify(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      SamplingParams[*testTx]
+		expectedErr error
+	}{
+		{
+			name:   "valid",
+			params: newLoadSamplingParams(0),
+		},
+		{
+			name: "no size",
+			params: SamplingParams[*testTx]{
+				Threshold: 1,
+			},
+			expectedErr: ErrInvalidSampleSize,
+		},
+		{
+			name: "negative threshold",
+			params: SamplingParams[*testTx]{
+				Size:      func() int { return 0 },
+				Threshold: -1,
+			},
+			expectedErr: ErrInvalidSampleThreshold,
+		},
+		{
+			name: "top fraction too large",
+			params: SamplingParams[*testTx]{
+				Size:        func() int { return 0 },
+				TopFraction: 1.1,
+			},
+			expectedErr: ErrInvalidTopFraction,
+		},
+		{
+			name: "negative random fraction",
+			params: SamplingParams[*testTx]{
+				Size:           func() int { return 0 },
+				RandomFraction: -.1,
+			},
+			expectedErr: ErrInvalidRandomFraction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.params.Verify(), tt.expectedErr)
+		})
+	}
+}
+
+func TestSamplingParamsSample(t *testing.T) {
+	require := require.New(t)
+
+	txs := newLoadTxs()
+	params := newLoadSamplingParams(numLoadTxs)
+	require.True(params.shouldSample())
+
+	sampled, unsampled := params.sample(txs)
+	requireLoadSample(require, sampled)
+	require.Len(unsampled, len(txs)-len(sampled))
+	require.ElementsMatch(txs, append(sampled, unsampled...))
+}
+
+func TestHandlerServeSampling(t *testing.T) {
+	tests := []struct {
+		name            string
+		size            int
+		expectedSampled bool
+	}{
+		{
+			name:            "below threshold",
+			size:            numLoadTxs / 2,
+			expectedSampled: false,
+		},
+		{
+			name:            "above threshold",
+			size:            numLoadTxs,
+			expectedSampled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			txs := newLoadTxs()
+			for _, tx := range txs {
+				require.NoError(set.Add(tx))
+			}
+
+			metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+			require.NoError(err)
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				testMarshaller{},
+				set,
+				metrics,
+				units.MiB,
+				WithServeSampling[*testTx](newLoadSamplingParams(tt.size)),
+			)
+
+			emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+			require.NoError(err)
+			responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+			require.NoError(err)
+
+			gossip, err := ParseAppResponse(responseBytes)
+			require.NoError(err)
+			got := make([]*testTx, 0, len(gossip))
+			for _, bytes := range gossip {
+				tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+				require.NoError(err)
+				got = append(got, tx)
+			}
+
+			expectedSampledCount, expectedFullCount := 0.0, 1.0
+			if tt.expectedSampled {
+				requireLoadSample(require, got)
+				expectedSampledCount, expectedFullCount = 1, 0
+			} else {
+				require.ElementsMatch(txs, got)
+			}
+			require.Equal(expectedSampledCount, testutil.ToFloat64(metrics.sampleMode.With(prometheus.Labels{
+				typeLabel: pullType,
+				modeLabel: sampledMode,
+			})))
+			require.Equal(expectedFullCount, testutil.ToFloat64(metrics.sampleMode.With(prometheus.Labels{
+				typeLabel: pullType,
+				modeLabel: fullMode,
+			})))
+		})
+	}
+}
+
+func TestPushGossiperSampling(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	sender := &common.FakeSender{
+		SentAppGossip: make(chan []byte, 1),
+	}
+	network, err := p2p.NewNetwork(
+		logging.NoLog{},
+		sender,
+		prometheus.NewRegistry(),
+		"",
+	)
+	require.NoError(err)
+	client := network.NewClient(0)
+	validators := p2p.NewValidators(
+		&p2p.Peers{},
+		logging.NoLog{},
+		constants.PrimaryNetworkID,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 1, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		time.Hour,
+	)
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	gossiper, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		FullSet[*testTx]{},
+		validators,
+		client,
+		metrics,
+		BranchingFactor{
+			Validators: 1,
+		},
+		BranchingFactor{
+			Validators: 1,
+		},
+		0, // the discarded cache size doesn't matter for this test
+		units.MiB,
+		time.Hour,
+		WithPushSampling[*testTx](newLoadSamplingParams(numLoadTxs)),
+	)
+	require.NoError(err)
+
+	gossiper.Add(newLoadTxs()...)
+	require.NoError(gossiper.Gossip(ctx))
+
+	// remove the handler prefix
+	sentMsg := <-sender.SentAppGossip
+	msg := &sdk.PushGossip{}
+	require.NoError(proto.Unmarshal(sentMsg[1:], msg))
+
+	got := make([]*testTx, 0, len(msg.Gossip))
+	for _, bytes := range msg.Gossip {
+		tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+		require.NoError(err)
+		got = append(got, tx)
+	}
+	requireLoadSample(require, got)
+
+	// The txs that weren't sampled are deferred until they can be regossiped.
+	require.Equal(float64(numLoadTxs), testutil.ToFloat64(metrics.tracking.With(sentLabels)))
+	require.Zero(testutil.ToFloat64(metrics.tracking.With(unsentLabels)))
+	require.Equal(1.0, testutil.ToFloat64(metrics.sampleMode.With(prometheus.Labels{
+		typeLabel: pushType,
+		modeLabel: sampledMode,
+	})))
+}
+
+func TestPushGossiperSamplingAgeDecayedPriority(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	sender := &common.FakeSender{
+		SentAppGossip: make(chan []byte, 1),
+	}
+	network, err := p2p.NewNetwork(
+		logging.NoLog{},
+		sender,
+		prometheus.NewRegistry(),
+		"",
+	)
+	require.NoError(err)
+	client := network.NewClient(0)
+	validators := p2p.NewValidators(
+		&p2p.Peers{},
+		logging.NoLog{},
+		constants.PrimaryNetworkID,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 1, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		time.Hour,
+	)
+	metrics, err := NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	var (
+		now     = time.Now()
+		oldTx   = &testTx{id: ids.GenerateTestID()}
+		newTx   = &testTx{id: ids.GenerateTestID()}
+		clock   = &mockable.Clock{}
+		added   = map[ids.ID]time.Time{}
+		getTime = func(tx *testTx) (time.Time, bool) {
+			addedTime, ok := added[tx.id]
+			return addedTime, ok
+		}
+	)
+	const halfLife = time.Minute
+	prioritizer, err := NewAgeDecayedPrioritizer[*testTx](
+		constPrioritizer(100),
+		halfLife,
+		getTime,
+		clock,
+	)
+	require.NoError(err)
+
+	gossiper, err := NewPushGossiper[*testTx](
+		testMarshaller{},
+		FullSet[*testTx]{},
+		validators,
+		client,
+		metrics,
+		BranchingFactor{
+			Validators: 1,
+		},
+		BranchingFactor{
+			Validators: 1,
+		},
+		0, // the discarded cache size doesn't matter for this test
+		units.MiB,
+		time.Hour,
+		WithPushSampling[*testTx](SamplingParams[*testTx]{
+			Size: func() int {
+				return 2
+			},
+			Priority:    prioritizer.Priority,
+			TopFraction: 1,
+		}),
+	)
+	require.NoError(err)
+
+	added[oldTx.id] = now
+	added[newTx.id] = now.Add(halfLife)
+	clock.Set(now.Add(halfLife))
+	gossiper.Add(oldTx)
+	gossiper.Add(newTx)
+	require.NoError(gossiper.Gossip(ctx))
+
+	// remove the handler prefix
+	sentMsg := <-sender.SentAppGossip
+	msg := &sdk.PushGossip{}
+	require.NoError(proto.Unmarshal(sentMsg[1:], msg))
+
+	// Although both txs have the same priority, the newer tx is sent first.
+	got := make([]*testTx, 0, len(msg.Gossip))
+	for _, bytes := range msg.Gossip {
+		tx, err := testMarshaller{}.UnmarshalGossip(bytes)
+		require.NoError(err)
+		got = append(got, tx)
+	}
+	require.Equal([]*testTx{newTx, oldTx}, got)
+}
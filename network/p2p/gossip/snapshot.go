@@ -0,0 +1,81 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+// NewSnapshot returns a read-only copy of [set] that is refreshed once it is
+// older than [refreshInterval].
+func NewSnapshot[T Gossipable](
+	set Set[T],
+	refreshInterval time.Duration,
+	registerer prometheus.Registerer,
+	namespace string,
+) (*Snapshot[T], error) {
+	s := &Snapshot[T]{
+		set:             set,
+		refreshInterval: refreshInterval,
+		staleness: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gossip_snapshot_staleness",
+			Help:      "age of the snapshot that was most recently used to serve a pull request (ns)",
+		}),
+	}
+	return s, registerer.Register(s.staleness)
+}
+
+// Snapshot is a periodically refreshed copy of the gossipables in a Set. Pull
+// requests can be served from a Snapshot so that serving requests doesn't
+// contend with modifications of the Set, at the cost of serving gossipables
+// that may be up to refreshInterval stale.
+type Snapshot[T Gossipable] struct {
+	set             Set[T]
+	refreshInterval time.Duration
+	staleness       prometheus.Gauge
+	clock           mockable.Clock
+
+	lock sync.Mutex
+	// gossipables is never modified once it is taken, so it can be iterated
+	// over without holding the lock.
+	gossipables []T
+	takenAt     time.Time
+}
+
+// Iterate iterates over the gossipables in the snapshot until [f] returns
+// false. If the snapshot is older than the refresh interval, it is refreshed
+// first.
+func (s *Snapshot[T]) Iterate(f func(gossipable T) bool) {
+	for _, gossipable := range s.current() {
+		if !f(gossipable) {
+			return
+		}
+	}
+}
+
+// current returns the gossipables in the snapshot, after refreshing it if it
+// is stale.
+func (s *Snapshot[T]) current() []T {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.clock.Time()
+	if s.takenAt.IsZero() || now.Sub(s.takenAt) >= s.refreshInterval {
+		gossipables := make([]T, 0, len(s.gossipables))
+		s.set.Iterate(func(gossipable T) bool {
+			gossipables = append(gossipables, gossipable)
+			return true
+		})
+		s.gossipables = gossipables
+		s.takenAt = now
+	}
+	s.staleness.Set(float64(now.Sub(s.takenAt)))
+	return s.gossipables
+}
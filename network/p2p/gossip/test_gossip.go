@@ -72,3 +72,7 @@ func (t *testSet) Iterate(f func(gossipable *testTx) bool) {
 func (t *testSet) GetFilter() ([]byte, []byte) {
 	return t.bloom.Marshal()
 }
+
+func (t *testSet) Len() int {
+	return len(t.txs)
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/utils"
+	"github.com/ava-labs/avalanchego/utils/set"
+)
+
+const (
+	versionLabel = "version"
+	// UnknownVersion is the version label of gossip whose version isn't one
+	// of the versions the metrics were created with.
+	UnknownVersion = "unknown"
+)
+
+var versionMetricLabels = []string{typeLabel, versionLabel}
+
+// GossipVersioner is optionally implemented by a Marshaller that marshals
+// gossipables with multiple versions of its encoding. If the Marshaller of a
+// Handler implements GossipVersioner, and the Handler's Metrics were created
+// with NewVersionedMetrics, the gossip served and received by the Handler is
+// additionally recorded by version.
+type GossipVersioner interface {
+	// GossipVersion returns the version of the encoding of [gossipBytes].
+	GossipVersion(gossipBytes []byte) string
+}
+
+// NewVersionedMetrics returns the metrics of NewMetrics that additionally
+// record the gossip served and received by a Handler by the version of its
+// encoding. Gossip with a version other than [versions] is recorded as
+// UnknownVersion, so that the number of versions recorded is bounded.
+func NewVersionedMetrics(
+	metrics prometheus.Registerer,
+	namespace string,
+	versions ...string,
+) (Metrics, error) {
+	m, err := NewMetrics(metrics, namespace)
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	m.versions = set.Of(versions...)
+	m.sentVersionCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gossip_sent_version_count",
+		Help:      "amount of gossip sent by the version of its encoding (n)",
+	}, versionMetricLabels)
+	m.sentVersionBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gossip_sent_version_bytes",
+		Help:      "amount of gossip sent by the version of its encoding (bytes)",
+	}, versionMetricLabels)
+	m.receivedVersionCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gossip_received_version_count",
+		Help:      "amount of gossip received by the version of its encoding (n)",
+	}, versionMetricLabels)
+	m.receivedVersionBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gossip_received_version_bytes",
+		Help:      "amount of gossip received by the version of its encoding (bytes)",
+	}, versionMetricLabels)
+	err = utils.Err(
+		metrics.Register(m.sentVersionCount),
+		metrics.Register(m.sentVersionBytes),
+		metrics.Register(m.receivedVersionCount),
+		metrics.Register(m.receivedVersionBytes),
+	)
+	return m, err
+}
+
+// versioned returns true if gossip is recorded by version.
+func (m Metrics) versioned() bool {
+	return m.sentVersionCount != nil
+}
+
+// versionTally is the amount of gossip of each version.
+type versionTally map[string]versionAmount
+
+type versionAmount struct {
+	count int
+	bytes int
+}
+
+// add records [gossipBytes] with the version reported by [versioner]. Versions
+// that aren't one of [versions] are recorded as UnknownVersion.
+func (t versionTally) add(versioner GossipVersioner, versions set.Set[string], gossipBytes []byte) {
+	version := versioner.GossipVersion(gossipBytes)
+	if !versions.Contains(version) {
+		version = UnknownVersion
+	}
+
+	amount := t[version]
+	amount.count++
+	amount.bytes += len(gossipBytes)
+	t[version] = amount
+}
+
+// observe adds the amount of gossip of each version in [tally] to [count] and
+// [bytes] with the labels of [gossipType].
+func (t versionTally) observe(count, bytes *prometheus.CounterVec, gossipType string) {
+	for version, amount := range t {
+		labels := prometheus.Labels{
+			typeLabel:    gossipType,
+			versionLabel: version,
+		}
+		count.With(labels).Add(float64(amount.count))
+		bytes.With(labels).Add(float64(amount.bytes))
+	}
+}
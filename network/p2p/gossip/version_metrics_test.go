@@ -0,0 +1,159 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/units"
+)
+
+var _ GossipVersioner = (*versionedMarshaller)(nil)
+
+// versionedMarshaller reports the first byte of a tx as its version
+type versionedMarshaller struct {
+	testMarshaller
+}
+
+func (versionedMarshaller) GossipVersion(gossipBytes []byte) string {
+	return strconv.Itoa(int(gossipBytes[0]))
+}
+
+func TestHandlerVersionMetrics(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		v1Txs = []*testTx{
+			{id: ids.ID{1, 1}},
+			{id: ids.ID{1, 2}},
+		}
+		v2Tx      = &testTx{id: ids.ID{2}}
+		unknownTx = &testTx{id: ids.ID{3}}
+		allTxs    = append([]*testTx{v2Tx, unknownTx}, v1Txs...)
+	)
+
+	bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	set := &testSet{
+		txs:   make(map[ids.ID]*testTx),
+		bloom: bloom,
+	}
+	for _, tx := range allTxs {
+		require.NoError(set.Add(tx))
+	}
+
+	metrics, err := NewVersionedMetrics(prometheus.NewRegistry(), "", "1", "2")
+	require.NoError(err)
+	handler := NewHandler[*testTx](
+		logging.NoLog{},
+		versionedMarshaller{},
+		set,
+		metrics,
+		units.MiB,
+	)
+
+	requireVersions := func(count, bytes *prometheus.CounterVec, gossipType string) {
+		for version, expectedCount := range map[string]int{
+			"1":            len(v1Txs),
+			"2":            1,
+			UnknownVersion: 1,
+		} {
+			labels := prometheus.Labels{
+				typeLabel:    gossipType,
+				versionLabel: version,
+			}
+			require.Equal(float64(expectedCount), testutil.ToFloat64(count.With(labels)))
+			require.Equal(float64(expectedCount*ids.IDLen), testutil.ToFloat64(bytes.With(labels)))
+		}
+		// Only the known versions and UnknownVersion are recorded.
+		require.Equal(3, testutil.CollectAndCount(count))
+	}
+
+	// Served gossip is recorded by version.
+	emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+	gossip, err := ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Len(gossip, len(allTxs))
+	requireVersions(metrics.sentVersionCount, metrics.sentVersionBytes, pullType)
+	require.Zero(testutil.CollectAndCount(metrics.receivedVersionCount))
+
+	// Received gossip is recorded by version, even if it isn't added.
+	gossipBytes, err := MarshalAppGossip(gossip)
+	require.NoError(err)
+	handler.AppGossip(context.Background(), ids.EmptyNodeID, gossipBytes)
+	requireVersions(metrics.receivedVersionCount, metrics.receivedVersionBytes, pushType)
+}
+
+func TestHandlerVersionMetricsDisabled(t *testing.T) {
+	tests := []struct {
+		name             string
+		marshaller       Marshaller[*testTx]
+		versionedMetrics bool
+	}{
+		{
+			name:             "unversioned marshaller",
+			marshaller:       testMarshaller{},
+			versionedMetrics: true,
+		},
+		{
+			name:             "unversioned metrics",
+			marshaller:       versionedMarshaller{},
+			versionedMetrics: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			bloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			set := &testSet{
+				txs:   make(map[ids.ID]*testTx),
+				bloom: bloom,
+			}
+			require.NoError(set.Add(&testTx{id: ids.ID{1}}))
+
+			var metrics Metrics
+			if tt.versionedMetrics {
+				metrics, err = NewVersionedMetrics(prometheus.NewRegistry(), "", "1")
+			} else {
+				metrics, err = NewMetrics(prometheus.NewRegistry(), "")
+			}
+			require.NoError(err)
+			handler := NewHandler[*testTx](
+				logging.NoLog{},
+				tt.marshaller,
+				set,
+				metrics,
+				units.MiB,
+			)
+			require.Nil(handler.versioner)
+
+			emptyBloom, err := NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+			require.NoError(err)
+			requestBytes, err := MarshalAppRequest(emptyBloom.Marshal())
+			require.NoError(err)
+			_, err = handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+			require.NoError(err)
+
+			if tt.versionedMetrics {
+				require.Zero(testutil.CollectAndCount(metrics.sentVersionCount))
+			}
+		})
+	}
+}
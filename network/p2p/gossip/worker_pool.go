@@ -0,0 +1,229 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+var (
+	_ p2p.Handler = (*PooledHandler)(nil)
+
+	ErrInvalidNumWorkers = errors.New("number of workers must be positive")
+	ErrWorkerPoolFull    = errors.New("gossip worker pool is full")
+	ErrWorkerPoolClosed  = errors.New("gossip worker pool is closed")
+)
+
+type appResponse struct {
+	responseBytes []byte
+	err           error
+}
+
+// poolWork is a message queued to be handled by a worker.
+type poolWork struct {
+	// handle handles the message.
+	handle func()
+	// drop is called instead of handle if the pool is closed before the
+	// message is handled.
+	drop func()
+}
+
+// NewPooledHandler returns a handler that handles messages with [handler] on a
+// dedicated pool of [numWorkers] goroutines, isolating the work of handling
+// gossip from the goroutines of the caller. Up to [maxQueued] messages wait
+// for a worker, after which gossip messages are dropped and requests are
+// refused with ErrWorkerPoolFull.
+//
+// Close must be called to stop the workers.
+func NewPooledHandler(
+	log logging.Logger,
+	handler p2p.Handler,
+	numWorkers int,
+	maxQueued int,
+	registerer prometheus.Registerer,
+	namespace string,
+) (*PooledHandler, error) {
+	if numWorkers <= 0 {
+		return nil, ErrInvalidNumWorkers
+	}
+
+	p := &PooledHandler{
+		Handler: handler,
+		log:     log,
+		work:    make(chan poolWork, maxQueued),
+		done:    make(chan struct{}),
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gossip_worker_pool_queue_depth",
+			Help:      "number of gossip messages waiting for a worker (n)",
+		}),
+		activeWorkers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gossip_worker_pool_active_workers",
+			Help:      "number of workers handling a gossip message (n)",
+		}),
+		numDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gossip_worker_pool_dropped",
+			Help:      "number of gossip messages dropped because the worker pool was full (n)",
+		}),
+	}
+	err := utils.Err(
+		registerer.Register(p.queueDepth),
+		registerer.Register(p.activeWorkers),
+		registerer.Register(p.numDropped),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		go p.runWorker()
+	}
+	return p, nil
+}
+
+type PooledHandler struct {
+	p2p.Handler
+
+	log           logging.Logger
+	queueDepth    prometheus.Gauge
+	activeWorkers prometheus.Gauge
+	numDropped    prometheus.Counter
+
+	// lock prevents work from being submitted after the pool is closed
+	lock   sync.RWMutex
+	closed bool
+	work   chan poolWork
+	// done is closed once the pool is closed to stop the workers
+	done chan struct{}
+}
+
+func (p *PooledHandler) AppGossip(ctx context.Context, nodeID ids.NodeID, gossipBytes []byte) {
+	submitted := p.submit(poolWork{
+		handle: func() {
+			p.Handler.AppGossip(ctx, nodeID, gossipBytes)
+		},
+		drop: func() {},
+	})
+	if !submitted {
+		p.log.Debug("dropping gossip",
+			zap.String("reason", "worker pool is full"),
+			zap.Stringer("nodeID", nodeID),
+		)
+	}
+}
+
+func (p *PooledHandler) AppRequest(ctx context.Context, nodeID ids.NodeID, deadline time.Time, requestBytes []byte) ([]byte, error) {
+	// The response is buffered so that the worker doesn't block if the
+	// request is abandoned.
+	responses := make(chan appResponse, 1)
+	submitted := p.submit(poolWork{
+		handle: func() {
+			responseBytes, err := p.Handler.AppRequest(ctx, nodeID, deadline, requestBytes)
+			responses <- appResponse{
+				responseBytes: responseBytes,
+				err:           err,
+			}
+		},
+		drop: func() {
+			responses <- appResponse{
+				err: ErrWorkerPoolClosed,
+			}
+		},
+	})
+	if !submitted {
+		return nil, ErrWorkerPoolFull
+	}
+
+	select {
+	case response := <-responses:
+		return response.responseBytes, response.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops the workers and drops the queued messages. Messages that are
+// being handled when Close is called are not waited for, as handling them may
+// require locks held by the caller of Close.
+func (p *PooledHandler) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.done)
+
+	// As no more work can be submitted, the queue is drained once it is
+	// empty. Workers may still take queued work concurrently.
+	for {
+		select {
+		case work := <-p.work:
+			p.queueDepth.Dec()
+			p.numDropped.Inc()
+			work.drop()
+		default:
+			return
+		}
+	}
+}
+
+// submit queues [work] to be handled by a worker. Returns false if [work] was
+// dropped.
+func (p *PooledHandler) submit(work poolWork) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.closed {
+		p.numDropped.Inc()
+		return false
+	}
+
+	p.queueDepth.Inc()
+	select {
+	case p.work <- work:
+		return true
+	default:
+		p.queueDepth.Dec()
+		p.numDropped.Inc()
+		return false
+	}
+}
+
+func (p *PooledHandler) runWorker() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case work := <-p.work:
+			p.queueDepth.Dec()
+			select {
+			case <-p.done:
+				// The pool was closed while the work was queued.
+				p.numDropped.Inc()
+				work.drop()
+				return
+			default:
+			}
+
+			p.activeWorkers.Inc()
+			work.handle()
+			p.activeWorkers.Dec()
+		}
+	}
+}
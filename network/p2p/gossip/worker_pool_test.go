@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gossip
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+func TestPooledHandler(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var (
+		release  = make(chan struct{})
+		gossiped = make(chan []byte, 3)
+		response = []byte("response")
+		handler  = &p2p.TestHandler{
+			AppGossipF: func(_ context.Context, _ ids.NodeID, gossipBytes []byte) {
+				<-release
+				gossiped <- gossipBytes
+			},
+			AppRequestF: func(context.Context, ids.NodeID, time.Time, []byte) ([]byte, error) {
+				return response, nil
+			},
+		}
+	)
+	pooledHandler, err := NewPooledHandler(logging.NoLog{}, handler, 2, 1, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	// Gossip is handled by the workers rather than by the caller, so the
+	// caller isn't blocked while the workers are busy.
+	for i := byte(0); i < 2; i++ {
+		pooledHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{i})
+		require.Eventually(
+			func() bool {
+				return testutil.ToFloat64(pooledHandler.activeWorkers) == float64(i+1)
+			},
+			time.Second,
+			time.Millisecond,
+		)
+	}
+
+	// Once every worker is busy, messages are queued up to the limit.
+	pooledHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{2})
+	require.Equal(1.0, testutil.ToFloat64(pooledHandler.queueDepth))
+
+	pooledHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{3})
+	_, err = pooledHandler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, nil)
+	require.ErrorIs(err, ErrWorkerPoolFull)
+	require.Equal(2.0, testutil.ToFloat64(pooledHandler.numDropped))
+
+	close(release)
+	got := make(map[byte]struct{})
+	for i := 0; i < 3; i++ {
+		gossipBytes := <-gossiped
+		got[gossipBytes[0]] = struct{}{}
+	}
+	require.Equal(map[byte]struct{}{0: {}, 1: {}, 2: {}}, got)
+
+	responseBytes, err := pooledHandler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, nil)
+	require.NoError(err)
+	require.Equal(response, responseBytes)
+
+	// Once closed, messages are no longer handled.
+	pooledHandler.Close()
+	require.Zero(testutil.ToFloat64(pooledHandler.activeWorkers))
+	_, err = pooledHandler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, nil)
+	require.ErrorIs(err, ErrWorkerPoolFull)
+}
+
+func TestPooledHandlerCloseDoesNotWait(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// lock is held by the caller of Close and is needed to handle gossip, as
+	// the engine lock is needed to verify txs.
+	var (
+		lock     sync.Mutex
+		handling = make(chan struct{}, 1)
+		handled  = make(chan []byte, 2)
+		handler  = &p2p.TestHandler{
+			AppGossipF: func(_ context.Context, _ ids.NodeID, gossipBytes []byte) {
+				handling <- struct{}{}
+				lock.Lock()
+				defer lock.Unlock()
+
+				handled <- gossipBytes
+			},
+		}
+	)
+	pooledHandler, err := NewPooledHandler(logging.NoLog{}, handler, 1, 2, prometheus.NewRegistry(), "")
+	require.NoError(err)
+
+	lock.Lock()
+	pooledHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{0})
+	<-handling
+	pooledHandler.AppGossip(ctx, ids.EmptyNodeID, []byte{1})
+	requestErrs := make(chan error, 1)
+	go func() {
+		_, err := pooledHandler.AppRequest(ctx, ids.EmptyNodeID, time.Time{}, nil)
+		requestErrs <- err
+	}()
+	require.Eventually(
+		func() bool {
+			return testutil.ToFloat64(pooledHandler.queueDepth) == 2
+		},
+		time.Second,
+		time.Millisecond,
+	)
+
+	// Close returns without waiting for the message being handled, and drops
+	// the queued messages.
+	pooledHandler.Close()
+	require.Zero(testutil.ToFloat64(pooledHandler.queueDepth))
+	require.Equal(2.0, testutil.ToFloat64(pooledHandler.numDropped))
+	require.ErrorIs(<-requestErrs, ErrWorkerPoolClosed)
+	lock.Unlock()
+
+	require.Equal([]byte{0}, <-handled)
+	require.Never(
+		func() bool {
+			return len(handled) > 0
+		},
+		50*time.Millisecond,
+		time.Millisecond,
+	)
+}
@@ -201,8 +201,8 @@ func (n *Network) CrossChainAppRequestFailed(ctx context.Context, chainID ids.ID
 	return n.router.CrossChainAppRequestFailed(ctx, chainID, requestID, appErr)
 }
 
-func (n *Network) Connected(_ context.Context, nodeID ids.NodeID, _ *version.Application) error {
-	n.Peers.add(nodeID)
+func (n *Network) Connected(_ context.Context, nodeID ids.NodeID, nodeVersion *version.Application) error {
+	n.Peers.add(nodeID, nodeVersion)
 	return nil
 }
 
@@ -241,15 +241,20 @@ func (n *Network) AddHandler(handlerID uint64, handler Handler) error {
 
 // Peers contains metadata about the current set of connected peers
 type Peers struct {
-	lock sync.RWMutex
-	set  set.SampleableSet[ids.NodeID]
+	lock     sync.RWMutex
+	set      set.SampleableSet[ids.NodeID]
+	versions map[ids.NodeID]*version.Application
 }
 
-func (p *Peers) add(nodeID ids.NodeID) {
+func (p *Peers) add(nodeID ids.NodeID, nodeVersion *version.Application) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	p.set.Add(nodeID)
+	if p.versions == nil {
+		p.versions = make(map[ids.NodeID]*version.Application)
+	}
+	p.versions[nodeID] = nodeVersion
 }
 
 func (p *Peers) remove(nodeID ids.NodeID) {
@@ -257,6 +262,7 @@ func (p *Peers) remove(nodeID ids.NodeID) {
 	defer p.lock.Unlock()
 
 	p.set.Remove(nodeID)
+	delete(p.versions, nodeID)
 }
 
 func (p *Peers) has(nodeID ids.NodeID) bool {
@@ -266,6 +272,24 @@ func (p *Peers) has(nodeID ids.NodeID) bool {
 	return p.set.Contains(nodeID)
 }
 
+// Version returns the application version that [nodeID] reported when it
+// connected. Returns false if [nodeID] is not connected.
+func (p *Peers) Version(nodeID ids.NodeID) (*version.Application, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	nodeVersion, ok := p.versions[nodeID]
+	return nodeVersion, ok
+}
+
+// Len returns the number of connected peers
+func (p *Peers) Len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.set.Len()
+}
+
 // Sample returns a pseudo-random sample of up to limit Peers
 func (p *Peers) Sample(limit int) []ids.NodeID {
 	p.lock.RLock()
@@ -166,6 +166,14 @@ type Config struct {
 	// ConsensusAppConcurrency defines the maximum number of goroutines to
 	// handle App messages per chain.
 	ConsensusAppConcurrency int `json:"consensusAppConcurrency"`
+	// ConsensusGossipVerificationConcurrency defines the maximum number of
+	// gossiped transactions that may be verified concurrently across all
+	// chains. If 0, verification is not limited.
+	ConsensusGossipVerificationConcurrency int `json:"consensusGossipVerificationConcurrency"`
+	// ConsensusMaxOrphanBytes defines the maximum number of bytes of blocks
+	// with unknown parents that each snowman chain keeps pending issuance. If
+	// 0, the bytes are not limited.
+	ConsensusMaxOrphanBytes int `json:"consensusMaxOrphanBytes"`
 
 	TrackedSubnets set.Set[ids.ID] `json:"trackedSubnets"`
 
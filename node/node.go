@@ -23,6 +23,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/ava-labs/avalanchego/api/admin"
 	"github.com/ava-labs/avalanchego/api/health"
@@ -1089,6 +1090,7 @@ func (n *Node) initChainManager(avaxAssetID ids.ID) error {
 			ChainConfigs:                            n.Config.ChainConfigs,
 			FrontierPollFrequency:                   n.Config.FrontierPollFrequency,
 			ConsensusAppConcurrency:                 n.Config.ConsensusAppConcurrency,
+			ConsensusMaxOrphanBytes:                 n.Config.ConsensusMaxOrphanBytes,
 			BootstrapMaxTimeGetAncestors:            n.Config.BootstrapMaxTimeGetAncestors,
 			BootstrapAncestorsMaxContainersSent:     n.Config.BootstrapAncestorsMaxContainersSent,
 			BootstrapAncestorsMaxContainersReceived: n.Config.BootstrapAncestorsMaxContainersReceived,
@@ -1121,6 +1123,13 @@ func (n *Node) initVMs() error {
 		vdrs = validators.NewManager()
 	}
 
+	// The gossip verification limiter is shared across all chains so that a
+	// single busy chain can't starve the others of CPU.
+	var gossipVerificationLimiter *semaphore.Weighted
+	if n.Config.ConsensusGossipVerificationConcurrency > 0 {
+		gossipVerificationLimiter = semaphore.NewWeighted(int64(n.Config.ConsensusGossipVerificationConcurrency))
+	}
+
 	// Register the VMs that Avalanche supports
 	eUpgradeTime := version.GetEUpgradeTime(n.Config.NetworkID)
 	err := utils.Err(
@@ -1156,13 +1165,15 @@ func (n *Node) initVMs() error {
 				DurangoTime:                   version.GetDurangoTime(n.Config.NetworkID),
 				EUpgradeTime:                  eUpgradeTime,
 				UseCurrentHeight:              n.Config.UseCurrentHeight,
+				GossipVerificationLimiter:     gossipVerificationLimiter,
 			},
 		}),
 		n.VMManager.RegisterFactory(context.TODO(), constants.AVMID, &avm.Factory{
 			Config: avmconfig.Config{
-				TxFee:            n.Config.TxFee,
-				CreateAssetTxFee: n.Config.CreateAssetTxFee,
-				EUpgradeTime:     eUpgradeTime,
+				TxFee:                     n.Config.TxFee,
+				CreateAssetTxFee:          n.Config.CreateAssetTxFee,
+				EUpgradeTime:              eUpgradeTime,
+				GossipVerificationLimiter: gossipVerificationLimiter,
 			},
 		}),
 		n.VMManager.RegisterFactory(context.TODO(), constants.EVMID, &coreth.Factory{}),
@@ -39,6 +39,15 @@ func (sb *binarySnowball) Preference() int {
 	return sb.preference
 }
 
+// Confidence only reports the snowflake counter if it is counting towards the
+// snowball preference.
+func (sb *binarySnowball) Confidence() int {
+	if sb.binarySnowflake.Preference() != sb.Preference() {
+		return 0
+	}
+	return sb.binarySnowflake.Confidence()
+}
+
 func (sb *binarySnowball) RecordSuccessfulPoll(choice int) {
 	sb.increasePreferenceStrength(choice)
 	sb.binarySnowflake.RecordSuccessfulPoll(choice)
@@ -62,6 +62,10 @@ func (sf *binarySnowflake) RecordUnsuccessfulPoll() {
 	sf.confidence = 0
 }
 
+func (sf *binarySnowflake) Confidence() int {
+	return sf.confidence
+}
+
 func (sf *binarySnowflake) Finalized() bool {
 	return sf.finalized
 }
@@ -36,10 +36,31 @@ type Consensus interface {
 	// instance
 	RecordUnsuccessfulPoll()
 
+	// Returns the number of consecutive successful polls that have been
+	// recorded for the current preference
+	Confidence() int
+
+	// Returns statistics about the depth of the decisions in this instance
+	DepthStats() DepthStats
+
 	// Return whether a choice has been finalized
 	Finalized() bool
 }
 
+// DepthStats describes how deep the decisions of a consensus instance are
+// nested. Each decision must be polled through all of the decisions above it,
+// so an instance with a large maximum depth relative to its number of
+// decisions is unbalanced.
+type DepthStats struct {
+	// NumDecisions is the number of decisions in the instance
+	NumDecisions int
+	// MaxDepth is the largest number of decisions from the root to a decision,
+	// including the decision itself
+	MaxDepth int
+	// AvgDepth is the average depth of the decisions
+	AvgDepth float64
+}
+
 // Factory produces Nnary and Unary decision instances
 type Factory interface {
 	NewNnary(params Parameters, choice ids.ID) Nnary
@@ -72,6 +93,9 @@ type Nnary interface {
 	// RecordUnsuccessfulPoll resets the snowflake counter of this instance
 	RecordUnsuccessfulPoll()
 
+	// Returns the current value of the snowflake counter of this instance
+	Confidence() int
+
 	// Return whether a choice has been finalized
 	Finalized() bool
 }
@@ -98,6 +122,9 @@ type Binary interface {
 	// RecordUnsuccessfulPoll resets the snowflake counter of this instance
 	RecordUnsuccessfulPoll()
 
+	// Returns the current value of the snowflake counter of this instance
+	Confidence() int
+
 	// Return whether a choice has been finalized
 	Finalized() bool
 }
@@ -121,6 +148,9 @@ type Unary interface {
 	// RecordUnsuccessfulPoll resets the snowflake counter of this instance
 	RecordUnsuccessfulPoll()
 
+	// Returns the current value of the snowflake counter of this instance
+	Confidence() int
+
 	// Return whether a choice has been finalized
 	Finalized() bool
 
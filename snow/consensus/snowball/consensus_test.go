@@ -40,6 +40,14 @@ func (*Byzantine) RecordPoll(bag.Bag[ids.ID]) bool {
 
 func (*Byzantine) RecordUnsuccessfulPoll() {}
 
+func (*Byzantine) Confidence() int {
+	return 0
+}
+
+func (*Byzantine) DepthStats() DepthStats {
+	return DepthStats{}
+}
+
 func (*Byzantine) Finalized() bool {
 	return true
 }
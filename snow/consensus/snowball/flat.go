@@ -42,3 +42,12 @@ func (f *Flat) RecordPoll(votes bag.Bag[ids.ID]) bool {
 		return false
 	}
 }
+
+// DepthStats reports the single n-ary decision of this instance.
+func (*Flat) DepthStats() DepthStats {
+	return DepthStats{
+		NumDecisions: 1,
+		MaxDepth:     1,
+		AvgDepth:     1,
+	}
+}
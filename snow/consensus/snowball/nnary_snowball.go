@@ -47,6 +47,15 @@ func (sb *nnarySnowball) Preference() ids.ID {
 	return sb.preference
 }
 
+// Confidence only reports the snowflake counter if it is counting towards the
+// snowball preference.
+func (sb *nnarySnowball) Confidence() int {
+	if sb.nnarySnowflake.Preference() != sb.Preference() {
+		return 0
+	}
+	return sb.nnarySnowflake.Confidence()
+}
+
 func (sb *nnarySnowball) RecordSuccessfulPoll(choice ids.ID) {
 	sb.increasePreferenceStrength(choice)
 	sb.nnarySnowflake.RecordSuccessfulPoll(choice)
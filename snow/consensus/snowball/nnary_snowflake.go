@@ -69,6 +69,10 @@ func (sf *nnarySnowflake) RecordUnsuccessfulPoll() {
 	sf.confidence = 0
 }
 
+func (sf *nnarySnowflake) Confidence() int {
+	return sf.confidence
+}
+
 func (sf *nnarySnowflake) Finalized() bool {
 	return sf.finalized
 }
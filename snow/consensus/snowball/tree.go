@@ -90,6 +90,36 @@ func (t *Tree) RecordUnsuccessfulPoll() {
 	t.shouldReset = true
 }
 
+func (t *Tree) Confidence() int {
+	// If a reset is pending, the next poll will reset every instance in the
+	// tree before applying any votes.
+	if t.shouldReset {
+		return 0
+	}
+	return t.node.Confidence()
+}
+
+// DepthStats reports the depths of the binary decisions in the tree. The
+// shape of the tree depends on the order choices are added, so a peer that
+// controls the choices can make the tree unbalanced.
+func (t *Tree) DepthStats() DepthStats {
+	depths := t.node.DecisionDepths(0, nil)
+	if len(depths) == 0 {
+		return DepthStats{}
+	}
+
+	stats := DepthStats{
+		NumDecisions: len(depths),
+	}
+	totalDepth := 0
+	for _, depth := range depths {
+		stats.MaxDepth = max(stats.MaxDepth, depth)
+		totalDepth += depth
+	}
+	stats.AvgDepth = float64(totalDepth) / float64(len(depths))
+	return stats
+}
+
 func (t *Tree) String() string {
 	sb := strings.Builder{}
 
@@ -132,6 +162,12 @@ type node interface {
 	// Apply the votes, reset the model if needed
 	// Returns the new node and whether the vote was successful
 	RecordPoll(votes bag.Bag[ids.ID], shouldReset bool) (newChild node, successful bool)
+	// Returns the lowest confidence along the preferred branch of this
+	// sub-tree
+	Confidence() int
+	// Appends the depths of the binary decisions in this sub-tree to [depths],
+	// where [depth] is the number of binary decisions above this node
+	DecisionDepths(depth int, depths []int) []int
 	// Returns true if consensus has been reached on this node
 	Finalized() bool
 
@@ -455,6 +491,25 @@ func (u *unaryNode) RecordPoll(votes bag.Bag[ids.ID], reset bool) (node, bool) {
 	return u, true
 }
 
+func (u *unaryNode) Confidence() int {
+	confidence := u.snow.Confidence()
+	switch {
+	case u.child == nil:
+		return confidence
+	case u.shouldReset:
+		return 0
+	default:
+		return min(confidence, u.child.Confidence())
+	}
+}
+
+func (u *unaryNode) DecisionDepths(depth int, depths []int) []int {
+	if u.child == nil {
+		return depths
+	}
+	return u.child.DecisionDepths(depth, depths)
+}
+
 func (u *unaryNode) Finalized() bool {
 	return u.snow.Finalized()
 }
@@ -566,6 +621,30 @@ func (b *binaryNode) RecordPoll(votes bag.Bag[ids.ID], reset bool) (node, bool)
 	return b, true
 }
 
+func (b *binaryNode) Confidence() int {
+	confidence := b.snow.Confidence()
+	bit := b.snow.Preference()
+	switch child := b.children[bit]; {
+	case child == nil:
+		return confidence
+	case b.shouldReset[bit]:
+		return 0
+	default:
+		return min(confidence, child.Confidence())
+	}
+}
+
+func (b *binaryNode) DecisionDepths(depth int, depths []int) []int {
+	depth++
+	depths = append(depths, depth)
+	for _, child := range b.children {
+		if child != nil {
+			depths = child.DecisionDepths(depth, depths)
+		}
+	}
+	return depths
+}
+
 func (b *binaryNode) Finalized() bool {
 	return b.snow.Finalized()
 }
@@ -57,6 +57,94 @@ func TestSnowballSingleton(t *testing.T) {
 	require.True(tree.Finalized())
 }
 
+func TestSnowballConfidence(t *testing.T) {
+	require := require.New(t)
+
+	params := Parameters{
+		K:               1,
+		AlphaPreference: 1,
+		AlphaConfidence: 1,
+		Beta:            5,
+	}
+	tree := NewTree(SnowballFactory, params, Red)
+	tree.Add(Blue)
+
+	require.Zero(tree.Confidence())
+
+	oneRed := bag.Of(Red)
+	require.True(tree.RecordPoll(oneRed))
+	require.Equal(1, tree.Confidence())
+
+	require.True(tree.RecordPoll(oneRed))
+	require.Equal(2, tree.Confidence())
+
+	tree.RecordUnsuccessfulPoll()
+	require.Zero(tree.Confidence())
+
+	require.True(tree.RecordPoll(oneRed))
+	require.Equal(1, tree.Confidence())
+
+	// Blue gains confidence, but Red is still preferred.
+	oneBlue := bag.Of(Blue)
+	require.True(tree.RecordPoll(oneBlue))
+	require.Equal(Red, tree.Preference())
+	require.Zero(tree.Confidence())
+}
+
+func TestSnowballDepthStats(t *testing.T) {
+	params := Parameters{
+		K:               1,
+		AlphaPreference: 1,
+		AlphaConfidence: 1,
+		Beta:            1,
+	}
+
+	tests := []struct {
+		name     string
+		choices  []ids.ID
+		expected DepthStats
+	}{
+		{
+			name:     "single choice",
+			choices:  []ids.ID{{}},
+			expected: DepthStats{},
+		},
+		{
+			name: "balanced",
+			choices: []ids.ID{
+				{0}, {1}, {2}, {3}, {4}, {5}, {6}, {7},
+			},
+			expected: DepthStats{
+				NumDecisions: 7,
+				MaxDepth:     3,
+				AvgDepth:     17. / 7,
+			},
+		},
+		{
+			// Each choice only conflicts with the others on its own bit, so
+			// every decision is nested below all of the previous decisions.
+			name: "skewed",
+			choices: []ids.ID{
+				{0}, {1 << 0}, {1 << 1}, {1 << 2}, {1 << 3}, {1 << 4}, {1 << 5}, {1 << 6}, {1 << 7},
+			},
+			expected: DepthStats{
+				NumDecisions: 8,
+				MaxDepth:     8,
+				AvgDepth:     4.5,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := NewTree(SnowballFactory, params, test.choices[0])
+			for _, choice := range test.choices[1:] {
+				tree.Add(choice)
+			}
+			require.Equal(t, test.expected, tree.DepthStats())
+		})
+	}
+}
+
 func TestSnowballRecordUnsuccessfulPoll(t *testing.T) {
 	require := require.New(t)
 
@@ -44,6 +44,10 @@ func (sf *unarySnowflake) RecordUnsuccessfulPoll() {
 	sf.confidence = 0
 }
 
+func (sf *unarySnowflake) Confidence() int {
+	return sf.confidence
+}
+
 func (sf *unarySnowflake) Finalized() bool {
 	return sf.finalized
 }
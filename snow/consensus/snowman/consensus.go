@@ -57,7 +57,36 @@ type Consensus interface {
 	// tracked.
 	PreferenceAtHeight(height uint64) (ids.ID, bool)
 
+	// Returns the ID of the tail of the strongly preferred sequence of
+	// decisions where every decision has been preferred with at least
+	// [minConfidence] consecutive successful polls. If the preferred child of
+	// the last accepted decision hasn't reached [minConfidence], the last
+	// accepted decision is returned.
+	StablePreference(minConfidence int) ids.ID
+
 	// RecordPoll collects the results of a network poll. Assumes all decisions
 	// have been previously added. Returns if a critical error has occurred.
 	RecordPoll(context.Context, bag.Bag[ids.ID]) error
+
+	// OnFinalized registers [f] to be called with every decision that is
+	// accepted after registration, in the order they are accepted. [f] is
+	// called asynchronously so that it never blocks consensus. If [f] falls too
+	// far behind, notifications are dropped.
+	//
+	// The returned function stops notifying [f] and releases the goroutine
+	// calling it, once the notifications that were already queued for [f]
+	// are delivered.
+	OnFinalized(f func(blkID ids.ID, height uint64)) func()
+
+	// PauseNotifications stops notifying the callbacks registered with
+	// [OnFinalized] until [ResumeNotifications] is called. This allows an
+	// indexer to reindex without receiving notifications for blocks it is
+	// about to reindex. It is safe to call concurrently with consensus.
+	PauseNotifications()
+
+	// ResumeNotifications resumes notifying the callbacks registered with
+	// [OnFinalized]. Decisions accepted while notifications were paused are
+	// notified if they were retained and have a height of at least
+	// [fromHeight].
+	ResumeNotifications(fromHeight uint64)
 }
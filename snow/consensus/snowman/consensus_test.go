@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
@@ -48,6 +49,9 @@ var (
 		RecordPollDivergedVotingWithNoConflictingBitTest,
 		RecordPollChangePreferredChainTest,
 		LastAcceptedTest,
+		StablePreferenceTest,
+		OnFinalizedTest,
+		HealthCheckDecisionDepthTest,
 		MetricsProcessingErrorTest,
 		MetricsAcceptedErrorTest,
 		MetricsRejectedErrorTest,
@@ -1093,6 +1097,177 @@ func LastAcceptedTest(t *testing.T, factory Factory) {
 	require.Equal(block2.HeightV, lastAcceptedHeight)
 }
 
+func StablePreferenceTest(t *testing.T, factory Factory) {
+	sm := factory.New()
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  3,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	require.NoError(sm.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	block1 := snowmantest.BuildChild(block0)
+	block0Conflict := snowmantest.BuildChild(snowmantest.Genesis)
+
+	require.NoError(sm.Add(context.Background(), block0))
+	require.NoError(sm.Add(context.Background(), block1))
+	require.NoError(sm.Add(context.Background(), block0Conflict))
+
+	// Without any polls, the preferred tip has no confidence.
+	require.Equal(block1.IDV, sm.Preference())
+	require.Equal(snowmantest.GenesisID, sm.StablePreference(1))
+	require.Equal(block1.IDV, sm.StablePreference(0))
+
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block1.IDV)))
+
+	require.Equal(block1.IDV, sm.StablePreference(1))
+	require.Equal(snowmantest.GenesisID, sm.StablePreference(2))
+
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block1.IDV)))
+
+	require.Equal(block1.IDV, sm.StablePreference(2))
+
+	// A vote for a conflicting block resets the confidence, so the tip is no
+	// longer reported as stable even though it is still preferred.
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block0Conflict.IDV)))
+
+	require.Equal(block1.IDV, sm.Preference())
+	require.Equal(snowmantest.GenesisID, sm.StablePreference(2))
+}
+
+func OnFinalizedTest(t *testing.T, factory Factory) {
+	sm := factory.New()
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  1,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	require.NoError(sm.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	type finalized struct {
+		blkID  ids.ID
+		height uint64
+	}
+	finalizedC := make(chan finalized, 10)
+	stop := sm.OnFinalized(func(blkID ids.ID, height uint64) {
+		finalizedC <- finalized{
+			blkID:  blkID,
+			height: height,
+		}
+	})
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	block1 := snowmantest.BuildChild(block0)
+	block2 := snowmantest.BuildChild(block1)
+	block0Conflict := snowmantest.BuildChild(snowmantest.Genesis)
+	block3 := snowmantest.BuildChild(block2)
+
+	require.NoError(sm.Add(context.Background(), block0))
+	require.NoError(sm.Add(context.Background(), block1))
+	require.NoError(sm.Add(context.Background(), block2))
+	require.NoError(sm.Add(context.Background(), block0Conflict))
+
+	// A single poll finalizes the whole chain
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block2.IDV)))
+	require.Equal(choices.Rejected, block0Conflict.Status())
+
+	require.NoError(sm.Add(context.Background(), block3))
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block3.IDV)))
+
+	for _, blk := range []*snowmantest.Block{block0, block1, block2, block3} {
+		require.Equal(
+			finalized{
+				blkID:  blk.IDV,
+				height: blk.HeightV,
+			},
+			<-finalizedC,
+		)
+	}
+	require.Empty(finalizedC)
+
+	// Once stopped, decisions are no longer notified.
+	stop()
+	stop()
+	block4 := snowmantest.BuildChild(block3)
+	require.NoError(sm.Add(context.Background(), block4))
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block4.IDV)))
+	require.Equal(choices.Accepted, block4.Status())
+	require.Empty(finalizedC)
+}
+
+func HealthCheckDecisionDepthTest(t *testing.T, factory Factory) {
+	sm := factory.New()
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  1,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   10,
+		MaxItemProcessingTime: time.Hour,
+	}
+	require.NoError(sm.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	// Each sibling only conflicts with the others on its own bit, which nests
+	// every decision below the previous decisions.
+	for _, blkID := range []ids.ID{{0}, {1 << 0}, {1 << 1}, {1 << 2}} {
+		block := snowmantest.BuildChild(snowmantest.Genesis)
+		block.IDV = blkID
+		require.NoError(sm.Add(context.Background(), block))
+	}
+
+	details, err := sm.HealthCheck(context.Background())
+	require.NoError(err)
+	require.Equal(3, details.(map[string]interface{})["maxDecisionDepth"])
+	require.Equal(4, details.(map[string]interface{})["maxChildren"])
+	require.Equal(
+		[]ids.ID{{0}, {1 << 0}, {1 << 1}, {1 << 2}},
+		details.(map[string]interface{})["lastAcceptedChildren"],
+	)
+}
+
 func MetricsProcessingErrorTest(t *testing.T, factory Factory) {
 	require := require.New(t)
 
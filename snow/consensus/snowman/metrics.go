@@ -61,6 +61,22 @@ type metrics struct {
 
 	// numSuccessfulPolls keeps track of the number of polls that succeeded
 	numSuccessfulPolls prometheus.Counter
+
+	// numBlocksWithSnowball keeps track of the number of blocks in consensus
+	// that have a snowball instance deciding between their children
+	numBlocksWithSnowball prometheus.Gauge
+	// numBlocksWithoutSnowball keeps track of the number of blocks in consensus
+	// that haven't had a child added yet, and therefore have no snowball
+	// instance
+	numBlocksWithoutSnowball prometheus.Gauge
+
+	// numDroppedNotifications keeps track of the number of accepted blocks
+	// that were not notified to a finalization observer
+	numDroppedNotifications prometheus.Counter
+
+	// oldestUndecided tracks the number of nanoseconds that the oldest
+	// undecided choice between the children of a block has been undecided
+	oldestUndecided prometheus.Gauge
 }
 
 func newMetrics(
@@ -167,12 +183,34 @@ func newMetrics(
 			Name:      "polls_failed",
 			Help:      "number of failed polls",
 		}),
+		numBlocksWithSnowball: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "blks_with_snowball",
+			Help:      "number of blocks in consensus with a snowball instance deciding between their children",
+		}),
+		numBlocksWithoutSnowball: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "blks_without_snowball",
+			Help:      "number of blocks in consensus without any children, and therefore without a snowball instance",
+		}),
+		numDroppedNotifications: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "finalization_notifications_dropped",
+			Help:      "number of accepted blocks that were not notified to a finalization observer",
+		}),
+		oldestUndecided: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "blks_oldest_undecided",
+			Help:      "time (in ns) that the oldest undecided choice between the children of a block has been undecided",
+		}),
 	}
 
 	// Initially set the metrics for the last accepted block.
 	m.maxVerifiedHeight.Set(float64(lastAcceptedHeight))
 	m.lastAcceptedHeight.Set(float64(lastAcceptedHeight))
 	m.lastAcceptedTimestamp.Set(float64(lastAcceptedTime.Unix()))
+	// The last accepted block is initially tracked without any children.
+	m.numBlocksWithoutSnowball.Set(1)
 
 	errs.Add(
 		reg.Register(m.maxVerifiedHeight),
@@ -184,6 +222,10 @@ func newMetrics(
 		reg.Register(m.blockSizeRejectedSum),
 		reg.Register(m.numSuccessfulPolls),
 		reg.Register(m.numFailedPolls),
+		reg.Register(m.numBlocksWithSnowball),
+		reg.Register(m.numBlocksWithoutSnowball),
+		reg.Register(m.numDroppedNotifications),
+		reg.Register(m.oldestUndecided),
 	)
 	return m, errs.Err
 }
@@ -268,3 +310,35 @@ func (m *metrics) SuccessfulPoll() {
 func (m *metrics) FailedPoll() {
 	m.numFailedPolls.Inc()
 }
+
+// BlockAdded is called when a block is added to consensus. Blocks are added
+// without a snowball instance.
+func (m *metrics) BlockAdded() {
+	m.numBlocksWithoutSnowball.Inc()
+}
+
+// SnowballCreated is called when a block in consensus has its first child
+// added, which creates its snowball instance.
+func (m *metrics) SnowballCreated() {
+	m.numBlocksWithoutSnowball.Dec()
+	m.numBlocksWithSnowball.Inc()
+}
+
+// BlockRemoved is called when a block is removed from consensus.
+func (m *metrics) BlockRemoved(hasSnowball bool) {
+	if hasSnowball {
+		m.numBlocksWithSnowball.Dec()
+	} else {
+		m.numBlocksWithoutSnowball.Dec()
+	}
+}
+
+func (m *metrics) DroppedNotification() {
+	m.numDroppedNotifications.Inc()
+}
+
+// OldestUndecided is called with the duration that the oldest undecided
+// choice between the children of a block has been undecided.
+func (m *metrics) OldestUndecided(duration time.Duration) {
+	m.oldestUndecided.Set(float64(duration))
+}
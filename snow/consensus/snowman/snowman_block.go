@@ -4,9 +4,17 @@
 package snowman
 
 import (
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/exp/maps"
+
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow/choices"
 	"github.com/ava-labs/avalanchego/snow/consensus/snowball"
+	"github.com/ava-labs/avalanchego/utils"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
 )
 
 // Tracks the state of a snowman block
@@ -14,9 +22,17 @@ type snowmanBlock struct {
 	// parameters to initialize the snowball instance with
 	params snowball.Parameters
 
+	// factory creates the decision instances of the snowball instance. If
+	// nil, snowball.SnowballFactory is used.
+	factory snowball.Factory
+
 	// block that this node contains. For the genesis, this value will be nil
 	blk Block
 
+	// isGenesis is set for the block that consensus was initialized with,
+	// which is defined as accepted.
+	isGenesis bool
+
 	// shouldFalter is set to true if this node, and all its descendants received
 	// less than Alpha votes
 	shouldFalter bool
@@ -30,28 +46,190 @@ type snowmanBlock struct {
 	// as their parent. If this node has not had a child issued under it, this value
 	// will be nil
 	children map[ids.ID]Block
+
+	// tieBreakSeed, if non-zero, orders the children that haven't been polled
+	// yet. Otherwise, the first child to be added is initially preferred.
+	tieBreakSeed uint64
+
+	// polled is set once a poll has been applied to sb. Until then, every
+	// child is tied.
+	polled bool
+
+	// clock, if non-nil, is used to record when sb is created. Otherwise, the
+	// system time is used.
+	clock *mockable.Clock
+
+	// firstPolledTime is the time sb was created, which is when the choice
+	// between the children of this block started.
+	firstPolledTime time.Time
 }
 
+// AddChild adds [child] as a child of this block. If [child] was already
+// added, this is a noop.
 func (n *snowmanBlock) AddChild(child Block) {
 	childID := child.ID()
 
 	// if the snowball instance is nil, this is the first child. So the instance
 	// should be initialized.
 	if n.sb == nil {
-		n.sb = snowball.NewTree(snowball.SnowballFactory, n.params, childID)
-		n.children = make(map[ids.ID]Block)
+		n.sb = n.newTree(childID)
+		n.children = map[ids.ID]Block{
+			childID: child,
+		}
+		n.firstPolledTime = n.now()
+		return
+	}
+
+	// Adding the same child to the snowball instance twice would modify the
+	// tree, so duplicate adds are ignored.
+	if _, ok := n.children[childID]; ok {
+		return
+	}
+
+	n.children[childID] = child
+	if n.tieBreakSeed != 0 && !n.polled {
+		n.resetTree()
 	} else {
 		n.sb.Add(childID)
 	}
+}
+
+// resetTree replaces the snowball instance with one where every branch
+// initially prefers the child that is ranked first by the tie break seed. This
+// must only be called before any polls have been applied to the snowball
+// instance.
+//
+// Seeding the tie break prevents a peer from choosing the initial preference
+// by controlling the order that conflicting blocks are delivered. However,
+// nodes with different seeds will initially prefer different children, which
+// may take additional polls to resolve.
+func (n *snowmanBlock) resetTree() {
+	childIDs := maps.Keys(n.children)
+	slices.SortFunc(childIDs, func(a, b ids.ID) int {
+		return a.Prefix(n.tieBreakSeed).Compare(b.Prefix(n.tieBreakSeed))
+	})
+
+	n.sb = n.newTree(childIDs[0])
+	for _, childID := range childIDs[1:] {
+		n.sb.Add(childID)
+	}
+}
 
-	n.children[childID] = child
+// newTree returns a snowball instance that initially prefers [choice].
+func (n *snowmanBlock) newTree(choice ids.ID) snowball.Consensus {
+	factory := n.factory
+	if factory == nil {
+		factory = snowball.SnowballFactory
+	}
+	return snowball.NewTree(factory, n.params, choice)
+}
+
+// NumChildren returns the number of children that have been added to this
+// block.
+func (n *snowmanBlock) NumChildren() int {
+	return len(n.children)
+}
+
+// Children returns the IDs of the children that have been added to this block,
+// in sorted order.
+func (n *snowmanBlock) Children() []ids.ID {
+	childIDs := maps.Keys(n.children)
+	utils.Sort(childIDs)
+	return childIDs
+}
+
+// PreferredChild returns the ID of the child currently preferred by the
+// snowball instance of this block. If this block has no children, false is
+// returned.
+func (n *snowmanBlock) PreferredChild() (ids.ID, bool) {
+	if n.sb == nil {
+		return ids.Empty, false
+	}
+	return n.sb.Preference(), true
+}
+
+// Finalized returns true if the snowball instance of this block has decided
+// which child to accept. If this block has no children, false is returned.
+func (n *snowmanBlock) Finalized() bool {
+	return n.sb != nil && n.sb.Finalized()
+}
+
+// UndecidedDuration returns how long the choice between the children of this
+// block has been undecided as of [now]. If this block has no children, or the
+// choice has been decided, the duration is 0. This includes the genesis, whose
+// children are decided like any other block's.
+func (n *snowmanBlock) UndecidedDuration(now time.Time) time.Duration {
+	if n.sb == nil || n.sb.Finalized() {
+		return 0
+	}
+	return now.Sub(n.firstPolledTime)
+}
+
+func (n *snowmanBlock) now() time.Time {
+	if n.clock == nil {
+		return time.Now()
+	}
+	return n.clock.Time()
+}
+
+// String returns the children of this block, sorted by ID, followed by the
+// snowball instance deciding between them, if there is one. The output only
+// depends on the set of children and the polls applied to them, so that it is
+// reproducible.
+func (n *snowmanBlock) String() string {
+	var blkID ids.ID
+	if n.blk != nil {
+		blkID = n.blk.ID()
+	}
+	str := fmt.Sprintf("SnowmanBlock(ID = %s, Children = %v)", blkID, n.Children())
+	if n.sb == nil {
+		return str
+	}
+	return fmt.Sprintf("%s\n%s", str, n.sb)
+}
+
+// Falter marks this block, and transitively its descendants, as having
+// received less than Alpha votes. The confidence of this block's children is
+// reset before the next poll is applied to it.
+func (n *snowmanBlock) Falter() {
+	n.shouldFalter = true
+}
+
+// ClearFalter clears a falter marked by Falter, once it has been applied.
+func (n *snowmanBlock) ClearFalter() {
+	n.shouldFalter = false
+}
+
+// ShouldFalter returns true if this block is pending a falter.
+func (n *snowmanBlock) ShouldFalter() bool {
+	return n.shouldFalter
+}
+
+// Confidence returns the confidence of this block's preferred child. If this
+// block has no children, or is pending a falter, the confidence is 0.
+func (n *snowmanBlock) Confidence() int {
+	if n.sb == nil || n.ShouldFalter() {
+		return 0
+	}
+	return n.sb.Confidence()
+}
+
+// DepthStats returns the depth statistics of the snowball instance deciding
+// between this block's children. If this block has no children, there are no
+// decisions.
+func (n *snowmanBlock) DepthStats() snowball.DepthStats {
+	if n.sb == nil {
+		return snowball.DepthStats{}
+	}
+	return n.sb.DepthStats()
 }
 
+// Accepted returns true if this block is the genesis or if its inner block was
+// accepted. A block without an inner block that isn't the genesis is never
+// accepted.
 func (n *snowmanBlock) Accepted() bool {
-	// if the block is nil, then this is the genesis which is defined as
-	// accepted
-	if n.blk == nil {
+	if n.isGenesis {
 		return true
 	}
-	return n.blk.Status() == choices.Accepted
+	return n.blk != nil && n.blk.Status() == choices.Accepted
 }
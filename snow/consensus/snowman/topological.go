@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -17,8 +19,13 @@ import (
 	"github.com/ava-labs/avalanchego/snow/consensus/snowball"
 	"github.com/ava-labs/avalanchego/utils/bag"
 	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
 )
 
+// maxPendingFinalized is the maximum number of accepted blocks that can be
+// queued for each finalization observer before notifications are dropped.
+const maxPendingFinalized = 1024
+
 var (
 	errDuplicateAdd            = errors.New("duplicate block add")
 	errTooManyProcessingBlocks = errors.New("too many processing blocks")
@@ -39,8 +46,32 @@ func (TopologicalFactory) New() Consensus {
 // strongly preferred branch. This tree structure amortizes network polls to
 // vote on more than just the next block.
 type Topological struct {
+	// TieBreakSeed, if non-zero, is a secret used to decide which of the
+	// processing children of a block is initially preferred before any polls
+	// have been applied to them. If zero, the first child to be added is
+	// initially preferred, which a peer can control by choosing when blocks
+	// are delivered. The seed should differ between nodes so that it can't be
+	// predicted, at the cost of nodes initially disagreeing on which child is
+	// preferred.
+	TieBreakSeed uint64
+
+	// SnowballFactory creates the decision instances used to decide between
+	// the children of each block. If nil, snowball.SnowballFactory is used.
+	SnowballFactory snowball.Factory
+
+	// PausedNotificationsBufferSize is the maximum number of accepted blocks
+	// that are retained while notifications are paused, to be notified once
+	// they are resumed. Blocks accepted after the buffer is full are never
+	// notified. If zero, no blocks accepted while notifications are paused
+	// are notified.
+	PausedNotificationsBufferSize int
+
 	metrics *metrics
 
+	// clock is used to measure how long the choices between the children of
+	// blocks have been undecided
+	clock mockable.Clock
+
 	// pollNumber is the number of times RecordPolls has been called
 	pollNumber uint64
 
@@ -78,6 +109,26 @@ type Topological struct {
 	// We use this one map instead of creating a new map
 	// during each call to [calculateInDegree].
 	kahnNodes map[ids.ID]kahnNode
+
+	// finalizationObservers are the queues of accepted blocks that are
+	// dispatched to the callbacks registered with [OnFinalized]. Observers
+	// may be registered, stopped, paused, and resumed concurrently with
+	// consensus, so they and the paused notifications are guarded by
+	// observersLock.
+	observersLock         sync.Mutex
+	finalizationObservers []chan finalizedBlock
+
+	// notificationsPaused is true if accepted blocks are being withheld from
+	// the finalization observers. pausedNotifications are the accepted blocks
+	// that were retained while notifications were paused.
+	notificationsPaused bool
+	pausedNotifications []finalizedBlock
+}
+
+// An accepted block queued for a finalization observer
+type finalizedBlock struct {
+	blkID  ids.ID
+	height uint64
 }
 
 // Used to track the kahn topological sort status
@@ -127,7 +178,13 @@ func (ts *Topological) Initialize(
 	ts.lastAcceptedID = lastAcceptedID
 	ts.lastAcceptedHeight = lastAcceptedHeight
 	ts.blocks = map[ids.ID]*snowmanBlock{
-		lastAcceptedID: {params: ts.params},
+		lastAcceptedID: {
+			params:       ts.params,
+			factory:      ts.SnowballFactory,
+			isGenesis:    true,
+			tieBreakSeed: ts.TieBreakSeed,
+			clock:        &ts.clock,
+		},
 	}
 	ts.preferredHeights = make(map[uint64]ids.ID)
 	ts.preference = lastAcceptedID
@@ -177,15 +234,37 @@ func (ts *Topological) Add(ctx context.Context, blk Block) error {
 		return nil
 	}
 
+	// If the parent is on the preferred branch, the preferred branch may
+	// switch to this block.
+	var (
+		onPreferredBranch = parentID == ts.lastAcceptedID || ts.preferredIDs.Contains(parentID)
+		oldPreferredChild ids.ID
+		hadChildren       = parentNode.sb != nil
+	)
+	if hadChildren {
+		oldPreferredChild = parentNode.sb.Preference()
+	}
+
 	// add the block as a child of its parent, and add the block to the tree
 	parentNode.AddChild(blk)
+	if !hadChildren {
+		ts.metrics.SnowballCreated()
+	}
 	ts.blocks[blkID] = &snowmanBlock{
-		params: ts.params,
-		blk:    blk,
+		params:       ts.params,
+		factory:      ts.SnowballFactory,
+		blk:          blk,
+		tieBreakSeed: ts.TieBreakSeed,
+		clock:        &ts.clock,
 	}
+	ts.metrics.BlockAdded()
 
-	// If we are extending the preference, this is the new preference
-	if ts.preference == parentID {
+	// If we are extending the preference, or this block won the tie break
+	// against the previously preferred child, this is the new preference
+	if onPreferredBranch && parentNode.sb.Preference() == blkID {
+		if hadChildren {
+			ts.removePreferredBranch(oldPreferredChild)
+		}
 		ts.preference = blkID
 		ts.preferredIDs.Add(blkID)
 		ts.preferredHeights[height] = blkID
@@ -199,6 +278,22 @@ func (ts *Topological) Add(ctx context.Context, blk Block) error {
 	return nil
 }
 
+// removePreferredBranch removes [blkID] and its preferred descendants from the
+// preferred branch.
+func (ts *Topological) removePreferredBranch(blkID ids.ID) {
+	for {
+		n := ts.blocks[blkID]
+		ts.preferredIDs.Remove(blkID)
+		delete(ts.preferredHeights, n.blk.Height())
+
+		var ok bool
+		blkID, ok = n.PreferredChild()
+		if !ok {
+			return
+		}
+	}
+}
+
 func (ts *Topological) Decided(blk Block) bool {
 	// If the block is decided, then it must have been previously issued.
 	if blk.Status().Decided() {
@@ -245,6 +340,18 @@ func (ts *Topological) PreferenceAtHeight(height uint64) (ids.ID, bool) {
 	return blkID, ok
 }
 
+func (ts *Topological) StablePreference(minConfidence int) ids.ID {
+	stableID := ts.lastAcceptedID
+	for {
+		block := ts.blocks[stableID]
+		preferredChild, ok := block.PreferredChild()
+		if !ok || block.Confidence() < minConfidence {
+			return stableID
+		}
+		stableID = preferredChild
+	}
+}
+
 // The votes bag contains at most K votes for blocks in the tree. If there is a
 // vote for a block that isn't in the tree, the vote is dropped.
 //
@@ -330,6 +437,57 @@ func (ts *Topological) RecordPoll(ctx context.Context, voteBag bag.Bag[ids.ID])
 	return nil
 }
 
+func (ts *Topological) OnFinalized(f func(blkID ids.ID, height uint64)) func() {
+	queue := make(chan finalizedBlock, maxPendingFinalized)
+	ts.observersLock.Lock()
+	ts.finalizationObservers = append(ts.finalizationObservers, queue)
+	ts.observersLock.Unlock()
+
+	go func() {
+		for blk := range queue {
+			f(blk.blkID, blk.height)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ts.observersLock.Lock()
+			defer ts.observersLock.Unlock()
+
+			// The queue is only sent on while holding observersLock, so it
+			// can't be sent on once it is removed.
+			ts.finalizationObservers = slices.DeleteFunc(
+				ts.finalizationObservers,
+				func(observer chan finalizedBlock) bool {
+					return observer == queue
+				},
+			)
+			close(queue)
+		})
+	}
+}
+
+func (ts *Topological) PauseNotifications() {
+	ts.observersLock.Lock()
+	defer ts.observersLock.Unlock()
+
+	ts.notificationsPaused = true
+}
+
+func (ts *Topological) ResumeNotifications(fromHeight uint64) {
+	ts.observersLock.Lock()
+	defer ts.observersLock.Unlock()
+
+	ts.notificationsPaused = false
+	for _, blk := range ts.pausedNotifications {
+		if blk.height >= fromHeight {
+			ts.dispatchFinalized(blk)
+		}
+	}
+	ts.pausedNotifications = nil
+}
+
 // HealthCheck returns information about the consensus health.
 func (ts *Topological) HealthCheck(context.Context) (interface{}, error) {
 	var errs []error
@@ -354,11 +512,38 @@ func (ts *Topological) HealthCheck(context.Context) (interface{}, error) {
 		errs = append(errs, err)
 	}
 
+	// The snowball instances of processing blocks are only as deep as the
+	// children added to them, so an unusually deep instance indicates that
+	// its children were chosen to unbalance it.
+	//
+	// Similarly, a block with many children indicates that many conflicting
+	// blocks were issued.
+	var (
+		now              = ts.clock.Time()
+		maxDecisionDepth = 0
+		maxChildren      = 0
+		oldestUndecided  time.Duration
+	)
+	for _, n := range ts.blocks {
+		maxDecisionDepth = max(maxDecisionDepth, n.DepthStats().MaxDepth)
+		maxChildren = max(maxChildren, n.NumChildren())
+		oldestUndecided = max(oldestUndecided, n.UndecidedDuration(now))
+	}
+	ts.metrics.OldestUndecided(oldestUndecided)
+
+	// Conflicting children of the last accepted block that remain processing
+	// indicate that consensus hasn't been able to decide between them.
+	lastAcceptedChildren := ts.blocks[ts.lastAcceptedID].Children()
+
 	return map[string]interface{}{
 		"processingBlocks":       numProcessingBlks,
+		"maxDecisionDepth":       maxDecisionDepth,
+		"maxChildren":            maxChildren,
+		"oldestUndecided":        oldestUndecided.String(),
 		"longestProcessingBlock": maxTimeProcessing.String(), // .String() is needed here to ensure a human readable format
 		"lastAcceptedID":         ts.lastAcceptedID,
 		"lastAcceptedHeight":     ts.lastAcceptedHeight,
+		"lastAcceptedChildren":   lastAcceptedChildren,
 	}, errors.Join(errs...)
 }
 
@@ -477,7 +662,7 @@ func (ts *Topological) vote(ctx context.Context, voteStack []votes) (ids.ID, err
 	// change the preferred branch.
 	if len(voteStack) == 0 {
 		lastAcceptedBlock := ts.blocks[ts.lastAcceptedID]
-		lastAcceptedBlock.shouldFalter = true
+		lastAcceptedBlock.Falter()
 
 		if numProcessing := len(ts.blocks) - 1; numProcessing > 0 {
 			ts.ctx.Log.Verbo("no progress was made after processing pending blocks",
@@ -509,7 +694,7 @@ func (ts *Topological) vote(ctx context.Context, voteStack []votes) (ids.ID, err
 
 		// keep track of transitive falters to propagate to this block's
 		// children
-		shouldTransitivelyFalter := parentBlock.shouldFalter
+		shouldTransitivelyFalter := parentBlock.ShouldFalter()
 
 		// if the block was previously marked as needing to falter, the block
 		// should falter before applying the vote
@@ -519,10 +704,11 @@ func (ts *Topological) vote(ctx context.Context, voteStack []votes) (ids.ID, err
 			)
 
 			parentBlock.sb.RecordUnsuccessfulPoll()
-			parentBlock.shouldFalter = false
+			parentBlock.ClearFalter()
 		}
 
 		// apply the votes for this snowball instance
+		parentBlock.polled = true
 		pollSuccessful = parentBlock.sb.RecordPoll(vote.votes) || pollSuccessful
 
 		// Only accept when you are finalized and a child of the last accepted
@@ -536,6 +722,7 @@ func (ts *Topological) vote(ctx context.Context, voteStack []votes) (ids.ID, err
 			// no longer voteParentID, but its child. So, voteParentID can be
 			// removed from the tree.
 			delete(ts.blocks, vote.parentID)
+			ts.metrics.BlockRemoved(true)
 		}
 
 		// If we are on the preferred branch, then the parent's preference is
@@ -583,7 +770,7 @@ func (ts *Topological) vote(ctx context.Context, voteStack []votes) (ids.ID, err
 
 				// If the child is ever voted for positively, the confidence
 				// must be reset first.
-				childBlock.shouldFalter = true
+				childBlock.Falter()
 			}
 		}
 	}
@@ -642,12 +829,16 @@ func (ts *Topological) acceptPreferredChild(ctx context.Context, n *snowmanBlock
 		ts.pollNumber,
 		len(bytes),
 	)
+	ts.notifyFinalized(pref, height)
 
 	// Because ts.blocks contains the last accepted block, we don't delete the
 	// block from the blocks map here.
 
+	// Conflicting children are rejected in order of their IDs, so that the
+	// order of rejections is reproducible.
 	rejects := make([]ids.ID, 0, len(n.children)-1)
-	for childID, child := range n.children {
+	for _, childID := range n.Children() {
+		child := n.children[childID]
 		if childID == pref {
 			// don't reject the block we just accepted
 			continue
@@ -672,6 +863,54 @@ func (ts *Topological) acceptPreferredChild(ctx context.Context, n *snowmanBlock
 	return ts.rejectTransitively(ctx, rejects)
 }
 
+// notifyFinalized queues the accepted block for every finalization observer.
+// If notifications are paused, the accepted block is retained until they are
+// resumed, if there is room to retain it.
+func (ts *Topological) notifyFinalized(blkID ids.ID, height uint64) {
+	ts.observersLock.Lock()
+	defer ts.observersLock.Unlock()
+
+	blk := finalizedBlock{
+		blkID:  blkID,
+		height: height,
+	}
+	if !ts.notificationsPaused {
+		ts.dispatchFinalized(blk)
+		return
+	}
+
+	if len(ts.pausedNotifications) >= ts.PausedNotificationsBufferSize {
+		ts.metrics.DroppedNotification()
+		ts.ctx.Log.Debug("dropping finalization notification",
+			zap.String("reason", "notifications are paused"),
+			zap.Stringer("blkID", blkID),
+			zap.Uint64("height", height),
+		)
+		return
+	}
+	ts.pausedNotifications = append(ts.pausedNotifications, blk)
+}
+
+// dispatchFinalized queues [blk] for every finalization observer without
+// blocking. If an observer's queue is full, the notification is dropped for
+// that observer.
+//
+// Assumes observersLock is held.
+func (ts *Topological) dispatchFinalized(blk finalizedBlock) {
+	for _, queue := range ts.finalizationObservers {
+		select {
+		case queue <- blk:
+		default:
+			ts.metrics.DroppedNotification()
+			ts.ctx.Log.Warn("dropping finalization notification",
+				zap.String("reason", "observer is too far behind"),
+				zap.Stringer("blkID", blk.blkID),
+				zap.Uint64("height", blk.height),
+			)
+		}
+	}
+}
+
 // Takes in a list of rejected ids and rejects all descendants of these IDs
 func (ts *Topological) rejectTransitively(ctx context.Context, rejected []ids.ID) error {
 	// the rejected array is treated as a stack, with the next element at index
@@ -685,8 +924,10 @@ func (ts *Topological) rejectTransitively(ctx context.Context, rejected []ids.ID
 		// get the rejected node, and remove it from the tree
 		rejectedNode := ts.blocks[rejectedID]
 		delete(ts.blocks, rejectedID)
+		ts.metrics.BlockRemoved(rejectedNode.sb != nil)
 
-		for childID, child := range rejectedNode.children {
+		for _, childID := range rejectedNode.Children() {
+			child := rejectedNode.children[childID]
 			ts.ctx.Log.Trace("rejecting block",
 				zap.String("reason", "rejected ancestor"),
 				zap.Stringer("blkID", childID),
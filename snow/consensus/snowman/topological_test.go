@@ -3,8 +3,811 @@
 
 package snowman
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/snow/consensus/snowball"
+	"github.com/ava-labs/avalanchego/snow/consensus/snowman/snowmantest"
+	"github.com/ava-labs/avalanchego/snow/snowtest"
+	"github.com/ava-labs/avalanchego/utils/bag"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
 
 func TestTopological(t *testing.T) {
 	runConsensusTests(t, TopologicalFactory{})
 }
+
+func TestTopologicalTieBreakSeed(t *testing.T) {
+	require := require.New(t)
+
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  3,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	newTopological := func(seed uint64) *Topological {
+		snowCtx := snowtest.Context(t, snowtest.CChainID)
+		ctx := snowtest.ConsensusContext(snowCtx)
+		ts := &Topological{
+			TieBreakSeed: seed,
+		}
+		require.NoError(ts.Initialize(
+			ctx,
+			params,
+			snowmantest.GenesisID,
+			snowmantest.GenesisHeight,
+			snowmantest.GenesisTimestamp,
+		))
+		return ts
+	}
+
+	children := make([]*snowmantest.Block, 8)
+	for i := range children {
+		children[i] = snowmantest.BuildChild(snowmantest.Genesis)
+	}
+	reversed := slices.Clone(children)
+	slices.Reverse(reversed)
+
+	for seed := uint64(1); seed <= 8; seed++ {
+		// The preferred child is independent of the order the children are
+		// added in.
+		expectedPreference := children[0].IDV
+		for _, child := range children[1:] {
+			if child.IDV.Prefix(seed).Compare(expectedPreference.Prefix(seed)) < 0 {
+				expectedPreference = child.IDV
+			}
+		}
+
+		for _, blks := range [][]*snowmantest.Block{children, reversed} {
+			ts := newTopological(seed)
+			for _, blk := range blks {
+				require.NoError(ts.Add(context.Background(), blk))
+			}
+			require.Equal(expectedPreference, ts.Preference())
+			for _, blk := range blks {
+				require.Equal(blk.IDV == expectedPreference, ts.IsPreferred(blk))
+			}
+		}
+	}
+
+	// Without a seed, the first child added is preferred.
+	ts := newTopological(0)
+	for _, blk := range reversed {
+		require.NoError(ts.Add(context.Background(), blk))
+	}
+	require.Equal(reversed[0].IDV, ts.Preference())
+}
+
+func TestTopologicalTieBreakSeedAfterPoll(t *testing.T) {
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  3,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	const seed = 1
+	ts := &Topological{
+		TieBreakSeed: seed,
+	}
+	require.NoError(ts.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	block1 := snowmantest.BuildChild(block0)
+	require.NoError(ts.Add(context.Background(), block0))
+	require.NoError(ts.Add(context.Background(), block1))
+	require.NoError(ts.RecordPoll(context.Background(), bag.Of(block1.IDV)))
+
+	// Find a conflicting block that would win the tie break
+	var block0Conflict *snowmantest.Block
+	for {
+		block0Conflict = snowmantest.BuildChild(snowmantest.Genesis)
+		if block0Conflict.IDV.Prefix(seed).Compare(block0.IDV.Prefix(seed)) < 0 {
+			break
+		}
+	}
+
+	// Once a poll has been applied, the children are no longer tied.
+	require.NoError(ts.Add(context.Background(), block0Conflict))
+	require.Equal(block1.IDV, ts.Preference())
+	require.True(ts.IsPreferred(block0))
+	require.False(ts.IsPreferred(block0Conflict))
+}
+
+func TestTopologicalTieBreakSeedSwitchesPreferredBranch(t *testing.T) {
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  3,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	const seed = 1
+	ts := &Topological{
+		TieBreakSeed: seed,
+	}
+	require.NoError(ts.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	block1 := snowmantest.BuildChild(block0)
+	require.NoError(ts.Add(context.Background(), block0))
+	require.NoError(ts.Add(context.Background(), block1))
+	require.Equal(block1.IDV, ts.Preference())
+
+	// Find a conflicting block that wins the tie break
+	var block0Conflict *snowmantest.Block
+	for {
+		block0Conflict = snowmantest.BuildChild(snowmantest.Genesis)
+		if block0Conflict.IDV.Prefix(seed).Compare(block0.IDV.Prefix(seed)) < 0 {
+			break
+		}
+	}
+
+	// The descendants of the previously preferred child are no longer
+	// preferred.
+	require.NoError(ts.Add(context.Background(), block0Conflict))
+	require.Equal(block0Conflict.IDV, ts.Preference())
+	require.True(ts.IsPreferred(block0Conflict))
+	require.False(ts.IsPreferred(block0))
+	require.False(ts.IsPreferred(block1))
+
+	preferred, ok := ts.PreferenceAtHeight(block1.HeightV)
+	require.False(ok)
+	require.Equal(ids.Empty, preferred)
+}
+
+func TestSnowmanBlockAddChildDuplicate(t *testing.T) {
+	require := require.New(t)
+
+	n := &snowmanBlock{
+		params: snowball.Parameters{
+			K:                     1,
+			AlphaPreference:       1,
+			AlphaConfidence:       1,
+			Beta:                  3,
+			ConcurrentRepolls:     1,
+			OptimalProcessing:     1,
+			MaxOutstandingItems:   1,
+			MaxItemProcessingTime: 1,
+		},
+	}
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	block1 := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(block0)
+	n.AddChild(block1)
+
+	var (
+		expectedTree       = n.sb.String()
+		expectedDepthStats = n.DepthStats()
+	)
+	n.AddChild(block1)
+	require.Len(n.children, 2)
+	require.Equal(expectedTree, n.sb.String())
+	require.Equal(expectedDepthStats, n.DepthStats())
+}
+
+func TestSnowmanBlockChildren(t *testing.T) {
+	require := require.New(t)
+
+	n := &snowmanBlock{
+		params: snowball.Parameters{
+			K:                     1,
+			AlphaPreference:       1,
+			AlphaConfidence:       1,
+			Beta:                  3,
+			ConcurrentRepolls:     1,
+			OptimalProcessing:     1,
+			MaxOutstandingItems:   1,
+			MaxItemProcessingTime: 1,
+		},
+	}
+	require.Zero(n.NumChildren())
+	require.Empty(n.Children())
+
+	// Children are reported in sorted order, regardless of the order they
+	// were added in.
+	childIDs := []ids.ID{{2}, {0}, {1}}
+	for _, childID := range childIDs {
+		child := snowmantest.BuildChild(snowmantest.Genesis)
+		child.IDV = childID
+		n.AddChild(child)
+	}
+	require.Equal(3, n.NumChildren())
+	require.Equal([]ids.ID{{0}, {1}, {2}}, n.Children())
+}
+
+func TestSnowmanBlockAccepted(t *testing.T) {
+	require := require.New(t)
+
+	// The genesis doesn't have an inner block.
+	genesis := &snowmanBlock{
+		isGenesis: true,
+	}
+	require.True(genesis.Accepted())
+
+	// A block whose inner block hasn't been set isn't treated as the genesis.
+	block := &snowmanBlock{}
+	require.False(block.Accepted())
+
+	child := snowmantest.BuildChild(snowmantest.Genesis)
+	block.blk = child
+	require.False(block.Accepted())
+
+	require.NoError(child.Accept(context.Background()))
+	require.True(block.Accepted())
+}
+
+func TestSnowmanBlockString(t *testing.T) {
+	require := require.New(t)
+
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  3,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	childIDs := []ids.ID{{2}, {0}, {1}}
+	newBlock := func(childIDs []ids.ID) *snowmanBlock {
+		n := &snowmanBlock{
+			params: params,
+			// Seeding the tie break makes the snowball instance independent
+			// of the order that children are added in.
+			tieBreakSeed: 1,
+		}
+		for _, childID := range childIDs {
+			child := snowmantest.BuildChild(snowmantest.Genesis)
+			child.IDV = childID
+			n.AddChild(child)
+		}
+		return n
+	}
+
+	n := newBlock(nil)
+	require.Equal(
+		fmt.Sprintf("SnowmanBlock(ID = %s, Children = [])", ids.Empty),
+		n.String(),
+	)
+
+	// The output is the same regardless of the order that the children were
+	// added in.
+	n = newBlock(childIDs)
+	expected := n.String()
+	require.True(strings.HasPrefix(expected, fmt.Sprintf(
+		"SnowmanBlock(ID = %s, Children = [%s %s %s])\n",
+		ids.Empty,
+		ids.ID{0},
+		ids.ID{1},
+		ids.ID{2},
+	)))
+	for i := 0; i < 10; i++ {
+		shuffledIDs := slices.Clone(childIDs)
+		rand.Shuffle(len(shuffledIDs), func(i, j int) {
+			shuffledIDs[i], shuffledIDs[j] = shuffledIDs[j], shuffledIDs[i]
+		})
+		require.Equal(expected, newBlock(shuffledIDs).String())
+	}
+}
+
+// countingFactory counts the unary instances created by the wrapped factory.
+type countingFactory struct {
+	snowball.Factory
+
+	numUnary int
+}
+
+func (f *countingFactory) NewUnary(params snowball.Parameters) snowball.Unary {
+	f.numUnary++
+	return f.Factory.NewUnary(params)
+}
+
+func TestSnowmanBlockAddChildFactory(t *testing.T) {
+	require := require.New(t)
+
+	factory := &countingFactory{
+		Factory: snowball.SnowflakeFactory,
+	}
+	n := &snowmanBlock{
+		params: snowball.Parameters{
+			K:                     1,
+			AlphaPreference:       1,
+			AlphaConfidence:       1,
+			Beta:                  3,
+			ConcurrentRepolls:     1,
+			OptimalProcessing:     1,
+			MaxOutstandingItems:   1,
+			MaxItemProcessingTime: 1,
+		},
+		factory: factory,
+	}
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(block0)
+	require.Equal(1, factory.numUnary)
+
+	// The tree's decisions are produced by the factory, so they should be
+	// snowflake rather than snowball instances.
+	block1 := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(block1)
+
+	expected := snowball.NewTree(snowball.SnowflakeFactory, n.params, block0.ID())
+	expected.Add(block1.ID())
+	require.Equal(expected.String(), n.sb.String())
+}
+
+func TestTopologicalSnowballFactory(t *testing.T) {
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  3,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	factory := &countingFactory{
+		Factory: snowball.SnowballFactory,
+	}
+	sm := &Topological{
+		SnowballFactory: factory,
+	}
+	require.NoError(sm.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	block := snowmantest.BuildChild(snowmantest.Genesis)
+	require.NoError(sm.Add(context.Background(), block))
+	require.Equal(1, factory.numUnary)
+
+	child := snowmantest.BuildChild(block)
+	require.NoError(sm.Add(context.Background(), child))
+	require.Equal(2, factory.numUnary)
+}
+
+func TestTopologicalSubnetParameters(t *testing.T) {
+	primaryNetworkParams := snowball.DefaultParameters
+	subnetParams := snowball.Parameters{
+		K:                     5,
+		AlphaPreference:       3,
+		AlphaConfidence:       4,
+		Beta:                  8,
+		ConcurrentRepolls:     2,
+		OptimalProcessing:     10,
+		MaxOutstandingItems:   100,
+		MaxItemProcessingTime: time.Minute,
+	}
+
+	tests := []struct {
+		name        string
+		params      snowball.Parameters
+		expectedErr error
+	}{
+		{
+			name:   "primary network",
+			params: primaryNetworkParams,
+		},
+		{
+			name:   "subnet override",
+			params: subnetParams,
+		},
+		{
+			name: "alpha exceeds k",
+			params: func() snowball.Parameters {
+				params := subnetParams
+				params.AlphaConfidence = params.K + 1
+				return params
+			}(),
+			expectedErr: snowball.ErrParametersInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			snowCtx := snowtest.Context(t, snowtest.CChainID)
+			ctx := snowtest.ConsensusContext(snowCtx)
+			sm := &Topological{}
+			err := sm.Initialize(
+				ctx,
+				tt.params,
+				snowmantest.GenesisID,
+				snowmantest.GenesisHeight,
+				snowmantest.GenesisTimestamp,
+			)
+			require.ErrorIs(err, tt.expectedErr)
+			if err != nil {
+				return
+			}
+
+			// Every block is decided using the parameters of its subnet.
+			block := snowmantest.BuildChild(snowmantest.Genesis)
+			require.NoError(sm.Add(context.Background(), block))
+			require.Equal(tt.params, sm.blocks[snowmantest.GenesisID].params)
+			require.Equal(tt.params, sm.blocks[block.ID()].params)
+		})
+	}
+}
+
+func TestSnowmanBlockUndecidedDuration(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		startTime = time.Unix(0, 0)
+		clock     = &mockable.Clock{}
+	)
+	clock.Set(startTime)
+
+	// The genesis has no block, and has no undecided choice until a child is
+	// added.
+	n := &snowmanBlock{
+		params: snowball.Parameters{
+			K:                     1,
+			AlphaPreference:       1,
+			AlphaConfidence:       1,
+			Beta:                  1,
+			ConcurrentRepolls:     1,
+			OptimalProcessing:     1,
+			MaxOutstandingItems:   1,
+			MaxItemProcessingTime: 1,
+		},
+		clock: clock,
+	}
+	require.Zero(n.UndecidedDuration(startTime.Add(time.Second)))
+
+	child := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(child)
+	require.Equal(time.Second, n.UndecidedDuration(startTime.Add(time.Second)))
+
+	votes := bag.Of(child.ID())
+	require.True(n.sb.RecordPoll(votes))
+	require.Zero(n.UndecidedDuration(startTime.Add(2 * time.Second)))
+}
+
+func TestSnowmanBlockPreferredChild(t *testing.T) {
+	require := require.New(t)
+
+	// The snowball instance isn't initialized until a child is added.
+	n := &snowmanBlock{
+		params: snowball.Parameters{
+			K:                     1,
+			AlphaPreference:       1,
+			AlphaConfidence:       1,
+			Beta:                  2,
+			ConcurrentRepolls:     1,
+			OptimalProcessing:     1,
+			MaxOutstandingItems:   1,
+			MaxItemProcessingTime: 1,
+		},
+	}
+	preferredChild, ok := n.PreferredChild()
+	require.False(ok)
+	require.Equal(ids.Empty, preferredChild)
+	require.False(n.Finalized())
+
+	child0 := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(child0)
+	preferredChild, ok = n.PreferredChild()
+	require.True(ok)
+	require.Equal(child0.ID(), preferredChild)
+	require.False(n.Finalized())
+
+	child1 := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(child1)
+
+	votes := bag.Of(child1.ID())
+	require.True(n.sb.RecordPoll(votes))
+	preferredChild, ok = n.PreferredChild()
+	require.True(ok)
+	require.Equal(child1.ID(), preferredChild)
+	require.False(n.Finalized())
+
+	require.True(n.sb.RecordPoll(votes))
+	require.True(n.Finalized())
+}
+
+func TestSnowmanBlockFalter(t *testing.T) {
+	require := require.New(t)
+
+	n := &snowmanBlock{
+		params: snowball.Parameters{
+			K:                     1,
+			AlphaPreference:       1,
+			AlphaConfidence:       1,
+			Beta:                  2,
+			ConcurrentRepolls:     1,
+			OptimalProcessing:     1,
+			MaxOutstandingItems:   1,
+			MaxItemProcessingTime: 1,
+		},
+	}
+	require.False(n.ShouldFalter())
+
+	child := snowmantest.BuildChild(snowmantest.Genesis)
+	n.AddChild(child)
+	require.True(n.sb.RecordPoll(bag.Of(child.ID())))
+	require.Equal(1, n.Confidence())
+
+	// The confidence is reported as reset while a falter is pending.
+	n.Falter()
+	require.True(n.ShouldFalter())
+	require.Zero(n.Confidence())
+
+	// Marking an already faltering block is a noop.
+	n.Falter()
+	require.True(n.ShouldFalter())
+
+	n.ClearFalter()
+	require.False(n.ShouldFalter())
+	require.Equal(1, n.Confidence())
+
+	// Clearing a block that isn't faltering is a noop.
+	n.ClearFalter()
+	require.False(n.ShouldFalter())
+}
+
+func TestTopologicalOldestUndecided(t *testing.T) {
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  2,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: time.Hour,
+	}
+	sm := &Topological{}
+	require.NoError(sm.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	startTime := time.Unix(0, 0)
+	sm.clock.Set(startTime)
+
+	oldestUndecided := func() time.Duration {
+		details, err := sm.HealthCheck(context.Background())
+		require.NoError(err)
+
+		duration := time.Duration(testutil.ToFloat64(sm.metrics.oldestUndecided))
+		require.Equal(duration.String(), details.(map[string]interface{})["oldestUndecided"])
+		return duration
+	}
+	require.Zero(oldestUndecided())
+
+	block := snowmantest.BuildChild(snowmantest.Genesis)
+	require.NoError(sm.Add(context.Background(), block))
+
+	// The age increases until the block is accepted
+	for i := 1; i <= 3; i++ {
+		sm.clock.Set(startTime.Add(time.Duration(i) * time.Second))
+		require.Equal(time.Duration(i)*time.Second, oldestUndecided())
+
+		if i == 2 {
+			require.NoError(sm.RecordPoll(context.Background(), bag.Of(block.ID())))
+			require.Equal(choices.Processing, block.Status())
+		}
+	}
+
+	require.NoError(sm.RecordPoll(context.Background(), bag.Of(block.ID())))
+	require.Equal(choices.Accepted, block.Status())
+	require.Zero(oldestUndecided())
+}
+
+func TestTopologicalSnowballMetrics(t *testing.T) {
+	require := require.New(t)
+
+	snowCtx := snowtest.Context(t, snowtest.CChainID)
+	ctx := snowtest.ConsensusContext(snowCtx)
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  1,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+	ts := &Topological{}
+	require.NoError(ts.Initialize(
+		ctx,
+		params,
+		snowmantest.GenesisID,
+		snowmantest.GenesisHeight,
+		snowmantest.GenesisTimestamp,
+	))
+
+	requireNumBlocks := func(withSnowball, withoutSnowball int) {
+		require.Equal(float64(withSnowball), testutil.ToFloat64(ts.metrics.numBlocksWithSnowball))
+		require.Equal(float64(withoutSnowball), testutil.ToFloat64(ts.metrics.numBlocksWithoutSnowball))
+	}
+
+	// Only the last accepted block is tracked initially.
+	requireNumBlocks(0, 1)
+
+	block0 := snowmantest.BuildChild(snowmantest.Genesis)
+	require.NoError(ts.Add(context.Background(), block0))
+	requireNumBlocks(1, 1)
+
+	block1 := snowmantest.BuildChild(snowmantest.Genesis)
+	require.NoError(ts.Add(context.Background(), block1))
+	requireNumBlocks(1, 2)
+
+	block2 := snowmantest.BuildChild(block1)
+	require.NoError(ts.Add(context.Background(), block2))
+	requireNumBlocks(2, 2)
+
+	// Accepting block0 removes the genesis block and rejects block1 and block2.
+	require.NoError(ts.RecordPoll(context.Background(), bag.Of(block0.IDV)))
+	require.Equal(choices.Accepted, block0.Status())
+	require.Equal(choices.Rejected, block2.Status())
+	requireNumBlocks(0, 1)
+}
+
+func TestTopologicalPauseNotifications(t *testing.T) {
+	params := snowball.Parameters{
+		K:                     1,
+		AlphaPreference:       1,
+		AlphaConfidence:       1,
+		Beta:                  1,
+		ConcurrentRepolls:     1,
+		OptimalProcessing:     1,
+		MaxOutstandingItems:   1,
+		MaxItemProcessingTime: 1,
+	}
+
+	tests := []struct {
+		name       string
+		bufferSize int
+		fromHeight uint64
+		// expectedResumed are the indices of the blocks accepted while paused
+		// that are expected to be notified after resuming.
+		expectedResumed []int
+		// expectedDropped is the number of blocks accepted while paused that
+		// didn't fit in the buffer.
+		expectedDropped int
+	}{
+		{
+			name:            "skipped",
+			bufferSize:      0,
+			fromHeight:      0,
+			expectedResumed: nil,
+			expectedDropped: 3,
+		},
+		{
+			name:            "buffered",
+			bufferSize:      3,
+			fromHeight:      0,
+			expectedResumed: []int{0, 1, 2},
+		},
+		{
+			name:            "buffered from height",
+			bufferSize:      3,
+			fromHeight:      snowmantest.GenesisHeight + 3,
+			expectedResumed: []int{1, 2},
+		},
+		{
+			name:            "buffer full",
+			bufferSize:      2,
+			fromHeight:      0,
+			expectedResumed: []int{0, 1},
+			expectedDropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			snowCtx := snowtest.Context(t, snowtest.CChainID)
+			ctx := snowtest.ConsensusContext(snowCtx)
+			ts := &Topological{
+				PausedNotificationsBufferSize: tt.bufferSize,
+			}
+			require.NoError(ts.Initialize(
+				ctx,
+				params,
+				snowmantest.GenesisID,
+				snowmantest.GenesisHeight,
+				snowmantest.GenesisTimestamp,
+			))
+
+			finalizedC := make(chan uint64, 10)
+			ts.OnFinalized(func(_ ids.ID, height uint64) {
+				finalizedC <- height
+			})
+
+			accept := func(blk *snowmantest.Block) {
+				require.NoError(ts.Add(context.Background(), blk))
+				require.NoError(ts.RecordPoll(context.Background(), bag.Of(blk.IDV)))
+				require.Equal(choices.Accepted, blk.Status())
+			}
+
+			block0 := snowmantest.BuildChild(snowmantest.Genesis)
+			accept(block0)
+			require.Equal(block0.HeightV, <-finalizedC)
+
+			ts.PauseNotifications()
+			paused := make([]*snowmantest.Block, 3)
+			parent := block0
+			for i := range paused {
+				paused[i] = snowmantest.BuildChild(parent)
+				accept(paused[i])
+				parent = paused[i]
+			}
+
+			ts.ResumeNotifications(tt.fromHeight)
+			block1 := snowmantest.BuildChild(parent)
+			accept(block1)
+
+			for _, i := range tt.expectedResumed {
+				require.Equal(paused[i].HeightV, <-finalizedC)
+			}
+			require.Equal(block1.HeightV, <-finalizedC)
+			require.Empty(finalizedC)
+			require.Equal(float64(tt.expectedDropped), testutil.ToFloat64(ts.metrics.numDroppedNotifications))
+		})
+	}
+}
@@ -25,4 +25,9 @@ type Config struct {
 	Params              snowball.Parameters
 	Consensus           snowman.Consensus
 	PartialSync         bool
+
+	// MaxOrphanBytes is the maximum number of bytes of blocks that can be
+	// pending issuance while waiting for an unknown parent. Once exceeded, the
+	// oldest of these blocks are dropped. If 0, the size isn't limited.
+	MaxOrphanBytes int
 }
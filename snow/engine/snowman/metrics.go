@@ -23,6 +23,7 @@ type metrics struct {
 	numBlocked                            prometheus.Gauge
 	numBlockers                           prometheus.Gauge
 	numNonVerifieds                       prometheus.Gauge
+	numOrphansEvicted                     prometheus.Counter
 	numBuilt                              prometheus.Counter
 	numBuildsFailed                       prometheus.Counter
 	numUselessPutBytes                    prometheus.Counter
@@ -66,6 +67,11 @@ func newMetrics(namespace string, reg prometheus.Registerer) (*metrics, error) {
 			Name:      "non_verified_blks",
 			Help:      "Number of non-verified blocks in the memory",
 		}),
+		numOrphansEvicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orphans_evicted",
+			Help:      "Number of blocks with unknown parents that were dropped due to the orphan size limit",
+		}),
 		numBuilt: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "blks_built",
@@ -151,6 +157,7 @@ func newMetrics(namespace string, reg prometheus.Registerer) (*metrics, error) {
 		reg.Register(m.numBlocked),
 		reg.Register(m.numBlockers),
 		reg.Register(m.numNonVerifieds),
+		reg.Register(m.numOrphansEvicted),
 		reg.Register(m.numBuilt),
 		reg.Register(m.numBuildsFailed),
 		reg.Register(m.numUselessPutBytes),
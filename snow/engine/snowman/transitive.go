@@ -26,6 +26,7 @@ import (
 	"github.com/ava-labs/avalanchego/utils/bag"
 	"github.com/ava-labs/avalanchego/utils/bimap"
 	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/linked"
 	"github.com/ava-labs/avalanchego/utils/logging"
 	"github.com/ava-labs/avalanchego/utils/math"
 	"github.com/ava-labs/avalanchego/utils/set"
@@ -69,6 +70,12 @@ type Transitive struct {
 	// Block ID --> Block
 	pending map[ids.ID]snowman.Block
 
+	// pending blocks whose parent was unknown when they were issued, ordered
+	// from oldest to newest. Bounded by MaxOrphanBytes.
+	// Block ID --> Block
+	orphans     *linked.Hashmap[ids.ID, snowman.Block]
+	orphanBytes int
+
 	// Block ID --> Parent ID
 	nonVerifieds ancestor.Tree
 
@@ -141,6 +148,7 @@ func New(config Config) (*Transitive, error) {
 		AppHandler:                  config.VM,
 		Connector:                   config.VM,
 		pending:                     make(map[ids.ID]snowman.Block),
+		orphans:                     linked.NewHashmap[ids.ID, snowman.Block](),
 		nonVerifieds:                ancestor.NewTree(),
 		nonVerifiedCache:            nonVerifiedCache,
 		acceptedFrontiers:           acceptedFrontiers,
@@ -846,7 +854,9 @@ func (t *Transitive) issue(
 
 	// block on the parent if needed
 	parentID := blk.Parent()
-	if parent, err := t.getBlock(ctx, parentID); err != nil || !(t.Consensus.Decided(parent) || t.Consensus.Processing(parentID)) {
+	parent, err := t.getBlock(ctx, parentID)
+	parentUnknown := err != nil
+	if parentUnknown || !(t.Consensus.Decided(parent) || t.Consensus.Processing(parentID)) {
 		t.Ctx.Log.Verbo("block waiting for parent to be issued",
 			zap.Stringer("blkID", blkID),
 			zap.Stringer("parentID", parentID),
@@ -855,9 +865,50 @@ func (t *Transitive) issue(
 	}
 
 	t.blocked.Register(ctx, i)
+	if parentUnknown && !i.abandoned {
+		t.addOrphan(ctx, blk)
+	}
 	return t.errs.Err
 }
 
+// addOrphan tracks [blk] as waiting for its unknown parent. If the orphans exceed
+// MaxOrphanBytes, the oldest orphans are abandoned.
+func (t *Transitive) addOrphan(ctx context.Context, blk snowman.Block) {
+	t.orphans.Put(blk.ID(), blk)
+	t.orphanBytes += len(blk.Bytes())
+
+	for t.MaxOrphanBytes > 0 && t.orphanBytes > t.MaxOrphanBytes {
+		oldestID, oldest, ok := t.orphans.Oldest()
+		if !ok {
+			return
+		}
+
+		t.Ctx.Log.Debug("dropping orphan block",
+			zap.Stringer("blkID", oldestID),
+			zap.Stringer("parentID", oldest.Parent()),
+			zap.Int("orphanBytes", t.orphanBytes),
+		)
+		t.metrics.numOrphansEvicted.Inc()
+
+		// Abandoning the missing parent abandons every block waiting on it,
+		// which removes them from the orphans.
+		t.blocked.Abandon(ctx, oldest.Parent())
+
+		// Ensure progress even if the orphan wasn't removed by abandoning its
+		// parent.
+		t.removeOrphan(oldestID)
+	}
+}
+
+func (t *Transitive) removeOrphan(blkID ids.ID) {
+	blk, ok := t.orphans.Get(blkID)
+	if !ok {
+		return
+	}
+	t.orphans.Delete(blkID)
+	t.orphanBytes -= len(blk.Bytes())
+}
+
 // Request that [vdr] send us block [blkID]
 func (t *Transitive) sendRequest(
 	ctx context.Context,
@@ -1064,7 +1115,9 @@ func (t *Transitive) pendingContains(blkID ids.ID) bool {
 }
 
 func (t *Transitive) removeFromPending(blk snowman.Block) {
-	delete(t.pending, blk.ID())
+	blkID := blk.ID()
+	delete(t.pending, blkID)
+	t.removeOrphan(blkID)
 }
 
 func (t *Transitive) addToNonVerifieds(blk snowman.Block) {
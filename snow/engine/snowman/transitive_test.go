@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ava-labs/avalanchego/cache"
@@ -1003,6 +1004,46 @@ func TestEngineBlockingChitRequest(t *testing.T) {
 	require.Empty(te.blocked)
 }
 
+func TestEngineMaxOrphanBytes(t *testing.T) {
+	require := require.New(t)
+
+	config := DefaultConfig(t)
+	config.MaxOrphanBytes = 2 * ids.IDLen
+	_, _, sender, vm, te := setup(t, config)
+
+	sender.Default(true)
+	sender.SendGetF = func(context.Context, ids.NodeID, uint32, ids.ID) {}
+
+	vm.GetBlockF = func(_ context.Context, blkID ids.ID) (snowman.Block, error) {
+		if blkID == snowmantest.GenesisID {
+			return snowmantest.Genesis, nil
+		}
+		return nil, errUnknownBlock
+	}
+
+	orphans := make([]*snowmantest.Block, 3)
+	for i := range orphans {
+		missingBlk := snowmantest.BuildChild(snowmantest.Genesis)
+		orphans[i] = snowmantest.BuildChild(missingBlk)
+		require.NoError(te.issue(
+			context.Background(),
+			te.Ctx.NodeID,
+			orphans[i],
+			false,
+			te.metrics.issued.WithLabelValues(unknownSource),
+		))
+	}
+
+	// The oldest orphan should have been evicted to respect the limit.
+	require.NotContains(te.pending, orphans[0].ID())
+	require.Contains(te.pending, orphans[1].ID())
+	require.Contains(te.pending, orphans[2].ID())
+	require.Equal(2, te.orphans.Len())
+	require.Equal(2*ids.IDLen, te.orphanBytes)
+	require.Len(te.blocked, 2)
+	require.Equal(float64(1), testutil.ToFloat64(te.metrics.numOrphansEvicted))
+}
+
 func TestEngineBlockingChitResponse(t *testing.T) {
 	require := require.New(t)
 
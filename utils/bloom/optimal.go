@@ -109,3 +109,23 @@ func EstimateCount(numHashes, numEntries int, falsePositiveProbability float64)
 	}
 	return int(count)
 }
+
+// EstimateFalsePositiveProbability estimates the probability of false positives
+// of a bloom filter with [numHashes] and [numEntries] after [count] additions.
+// This is the inverse of EstimateCount.
+//
+// It is guaranteed to return a value in the range [0, 1].
+func EstimateFalsePositiveProbability(numHashes, numEntries, count int) float64 {
+	switch {
+	case numHashes < minHashes:
+		return 1
+	case numEntries < minEntries:
+		return 1
+	case count <= 0:
+		return 0
+	}
+
+	numBits := float64(numEntries) * bitsPerByte
+	exp := 1 - math.Exp(-float64(numHashes)*float64(count)/numBits)
+	return math.Pow(exp, float64(numHashes))
+}
@@ -180,6 +180,64 @@ func TestEstimateEntries(t *testing.T) {
 	}
 }
 
+func TestEstimateFalsePositiveProbability(t *testing.T) {
+	tests := []struct {
+		numHashes                        int
+		numEntries                       int
+		count                            int
+		expectedFalsePositiveProbability float64
+	}{
+		{ // invalid params
+			numHashes:                        0,
+			numEntries:                       2_048,
+			count:                            1,
+			expectedFalsePositiveProbability: 1,
+		},
+		{ // invalid params
+			numHashes:                        1,
+			numEntries:                       0,
+			count:                            1,
+			expectedFalsePositiveProbability: 1,
+		},
+		{
+			numHashes:                        8,
+			numEntries:                       2_048,
+			count:                            0,
+			expectedFalsePositiveProbability: 0,
+		},
+		{ // params from OptimalParameters(10_000, .01)
+			numHashes:                        7,
+			numEntries:                       11_982,
+			count:                            9_993,
+			expectedFalsePositiveProbability: .01,
+		},
+		{ // params from OptimalParameters(100_000, .001)
+			numHashes:                        10,
+			numEntries:                       179_720,
+			count:                            100_000,
+			expectedFalsePositiveProbability: .001,
+		},
+		{ // params from OptimalParameters(10_000, .01)
+			numHashes:                        7,
+			numEntries:                       11_982,
+			count:                            14_449,
+			expectedFalsePositiveProbability: .05,
+		},
+		{ // params from OptimalParameters(10_000, .01)
+			numHashes:                        7,
+			numEntries:                       11_982,
+			count:                            math.MaxInt,
+			expectedFalsePositiveProbability: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d_%d_%d", test.numHashes, test.numEntries, test.count), func(t *testing.T) {
+			falsePositiveProbability := EstimateFalsePositiveProbability(test.numHashes, test.numEntries, test.count)
+			require.InDelta(t, test.expectedFalsePositiveProbability, falsePositiveProbability, .0001)
+		})
+	}
+}
+
 func FuzzOptimalHashes(f *testing.F) {
 	f.Fuzz(func(t *testing.T, numEntries, count int) {
 		hashes := OptimalHashes(numEntries, count)
@@ -67,9 +67,11 @@ const (
 	DefaultBenchlistMinFailingDuration = 2*time.Minute + 30*time.Second
 
 	// Router
-	DefaultConsensusAppConcurrency  = 2
-	DefaultConsensusShutdownTimeout = time.Minute
-	DefaultFrontierPollFrequency    = 100 * time.Millisecond
+	DefaultConsensusAppConcurrency                = 2
+	DefaultConsensusGossipVerificationConcurrency = 0
+	DefaultConsensusMaxOrphanBytes                = 0
+	DefaultConsensusShutdownTimeout               = time.Minute
+	DefaultFrontierPollFrequency                  = 100 * time.Millisecond
 
 	// Inbound Throttling
 	DefaultInboundThrottlerAtLargeAllocSize         = 6 * units.MiB
@@ -69,6 +69,11 @@ func (lh *Hashmap[K, V]) Get(key K) (V, bool) {
 	return utils.Zero[V](), false
 }
 
+func (lh *Hashmap[K, V]) Has(key K) bool {
+	_, ok := lh.entryMap[key]
+	return ok
+}
+
 func (lh *Hashmap[K, V]) Delete(key K) bool {
 	e, ok := lh.entryMap[key]
 	if ok {
@@ -20,6 +20,7 @@ func TestHashmap(t *testing.T) {
 	key0 := ids.GenerateTestID()
 	_, exists := lh.Get(key0)
 	require.False(exists, "shouldn't have found the value")
+	require.False(lh.Has(key0), "shouldn't have found the value")
 
 	_, _, exists = lh.Oldest()
 	require.False(exists, "shouldn't have found a value")
@@ -33,6 +34,7 @@ func TestHashmap(t *testing.T) {
 	val0, exists := lh.Get(key0)
 	require.True(exists, "should have found the value")
 	require.Zero(val0, "wrong value")
+	require.True(lh.Has(key0), "should have found the value")
 
 	rkey0, val0, exists := lh.Oldest()
 	require.True(exists, "should have found the value")
@@ -5,8 +5,10 @@ package executor
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/ava-labs/avalanchego/chains/atomic"
+	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow/consensus/snowman"
 	"github.com/ava-labs/avalanchego/utils/set"
@@ -24,6 +26,10 @@ var (
 
 	ErrChainNotSynced       = errors.New("chain not synced")
 	ErrConflictingParentTxs = errors.New("block contains a transaction that conflicts with a transaction in a parent block")
+	// ErrRetryable is wrapped by verification errors that may not persist,
+	// such as if a tx spends a UTXO that isn't known yet because the tx that
+	// produces it hasn't been accepted.
+	ErrRetryable = errors.New("retryable verification failure")
 )
 
 type Manager interface {
@@ -43,6 +49,16 @@ type Manager interface {
 	// preferred state. This should *not* be used to verify transactions in a block.
 	VerifyTx(tx *txs.Tx) error
 
+	// VerifyTxs independently verifies each of the transactions as VerifyTx
+	// would, against a single snapshot of the currently preferred state. The
+	// returned errors are aligned with the transactions.
+	VerifyTxs(txs []*txs.Tx) []error
+
+	// VerifyProjectedTx verifies that the transaction can be issued on top of
+	// the currently preferred block, which is the state the next block will be
+	// built on. This should *not* be used to verify transactions in a block.
+	VerifyProjectedTx(tx *txs.Tx) error
+
 	// VerifyUniqueInputs returns nil iff no blocks in the inclusive
 	// ancestry of [blkID] consume an input in [inputs].
 	VerifyUniqueInputs(blkID ids.ID, inputs set.Set[ids.ID]) error
@@ -143,6 +159,43 @@ func (m *manager) NewBlock(blk block.Block) snowman.Block {
 }
 
 func (m *manager) VerifyTx(tx *txs.Tx) error {
+	return m.verifyTx(tx, m.lastAccepted)
+}
+
+func (m *manager) VerifyTxs(txs []*txs.Tx) []error {
+	return m.verifyTxs(txs, m.lastAccepted)
+}
+
+func (m *manager) VerifyProjectedTx(tx *txs.Tx) error {
+	return m.verifyTx(tx, m.preferred)
+}
+
+// verifyTx verifies [tx] on top of the state after [parentID] was executed.
+func (m *manager) verifyTx(tx *txs.Tx, parentID ids.ID) error {
+	return m.verifyTxs([]*txs.Tx{tx}, parentID)[0]
+}
+
+// verifyTxs independently verifies each of [txs] on top of the state after
+// [parentID] was executed. The parent state is only looked up once, so every
+// tx is verified against the same snapshot.
+func (m *manager) verifyTxs(txs []*txs.Tx, parentID ids.ID) []error {
+	parentState, ok := m.GetState(parentID)
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		errs[i] = m.verifyTxOn(tx, parentID, parentState, ok)
+	}
+	return errs
+}
+
+// verifyTxOn verifies [tx] on top of [parentState], which is the state after
+// [parentID] was executed. If [hasParentState] is false, the parent state is
+// missing.
+func (m *manager) verifyTxOn(
+	tx *txs.Tx,
+	parentID ids.ID,
+	parentState state.Chain,
+	hasParentState bool,
+) error {
 	if !m.backend.Bootstrapped {
 		return ErrChainNotSynced
 	}
@@ -155,7 +208,10 @@ func (m *manager) VerifyTx(tx *txs.Tx) error {
 		return err
 	}
 
-	stateDiff, err := state.NewDiff(m.lastAccepted, m)
+	if !hasParentState {
+		return fmt.Errorf("%w: %s", state.ErrMissingParentState, parentID)
+	}
+	stateDiff, err := state.NewDiffOn(parentState)
 	if err != nil {
 		return err
 	}
@@ -165,6 +221,12 @@ func (m *manager) VerifyTx(tx *txs.Tx) error {
 		State:   stateDiff,
 		Tx:      tx,
 	})
+	// A missing UTXO may be produced by a tx that hasn't been accepted yet,
+	// or may have already been spent. These can't be distinguished, so the tx
+	// may pass verification once the state changes.
+	if errors.Is(err, database.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrRetryable, err)
+	}
 	if err != nil {
 		return err
 	}
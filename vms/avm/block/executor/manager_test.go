@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/set"
 	"github.com/ava-labs/avalanchego/vms/avm/block"
@@ -171,6 +172,34 @@ func TestManagerVerifyTx(t *testing.T) {
 			},
 			expectedErr: errTestSemanticVerifyFail,
 		},
+		{
+			name: "spends unknown utxo",
+			txF: func(ctrl *gomock.Controller) *txs.Tx {
+				unsigned := txs.NewMockUnsignedTx(ctrl)
+				// Syntactic verification passes
+				unsigned.EXPECT().Visit(gomock.Any()).Return(nil)
+				// Semantic verification fails to find the UTXO
+				unsigned.EXPECT().Visit(gomock.Any()).Return(database.ErrNotFound)
+				return &txs.Tx{
+					Unsigned: unsigned,
+				}
+			},
+			managerF: func(ctrl *gomock.Controller) *manager {
+				lastAcceptedID := ids.GenerateTestID()
+
+				// These values don't matter for this test
+				state := state.NewMockState(ctrl)
+				state.EXPECT().GetLastAccepted().Return(lastAcceptedID)
+				state.EXPECT().GetTimestamp().Return(time.Time{})
+
+				return &manager{
+					backend:      defaultTestBackend(true, nil),
+					state:        state,
+					lastAccepted: lastAcceptedID,
+				}
+			},
+			expectedErr: ErrRetryable,
+		},
 		{
 			name: "fails execution",
 			txF: func(ctrl *gomock.Controller) *txs.Tx {
@@ -246,6 +275,52 @@ func TestManagerVerifyTx(t *testing.T) {
 	}
 }
 
+func TestManagerVerifyTxs(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+
+	newTx := func(errs ...error) *txs.Tx {
+		unsigned := txs.NewMockUnsignedTx(ctrl)
+		for _, err := range errs {
+			unsigned.EXPECT().Visit(gomock.Any()).Return(err)
+		}
+		return &txs.Tx{
+			Unsigned: unsigned,
+		}
+	}
+
+	var (
+		syntacticallyInvalidTx = newTx(errTestSyntacticVerifyFail)
+		semanticallyInvalidTx  = newTx(nil, errTestSemanticVerifyFail)
+		failedExecutionTx      = newTx(nil, nil, errTestExecutionFail)
+		validTx                = newTx(nil, nil, nil)
+	)
+
+	lastAcceptedID := ids.GenerateTestID()
+
+	// These values don't matter for this test
+	state := state.NewMockState(ctrl)
+	state.EXPECT().GetLastAccepted().Return(lastAcceptedID).Times(3)
+	state.EXPECT().GetTimestamp().Return(time.Time{}).Times(3)
+
+	m := &manager{
+		backend:      defaultTestBackend(true, nil),
+		state:        state,
+		lastAccepted: lastAcceptedID,
+	}
+	errs := m.VerifyTxs([]*txs.Tx{
+		validTx,
+		syntacticallyInvalidTx,
+		semanticallyInvalidTx,
+		failedExecutionTx,
+	})
+	require.Len(errs, 4)
+	require.NoError(errs[0])
+	require.ErrorIs(errs[1], errTestSyntacticVerifyFail)
+	require.ErrorIs(errs[2], errTestSemanticVerifyFail)
+	require.ErrorIs(errs[3], errTestExecutionFail)
+}
+
 func TestVerifyUniqueInputs(t *testing.T) {
 	require := require.New(t)
 	ctrl := gomock.NewController(t)
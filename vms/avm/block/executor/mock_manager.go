@@ -143,6 +143,20 @@ func (mr *MockManagerMockRecorder) SetPreference(blkID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreference", reflect.TypeOf((*MockManager)(nil).SetPreference), blkID)
 }
 
+// VerifyProjectedTx mocks base method.
+func (m *MockManager) VerifyProjectedTx(tx *txs.Tx) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyProjectedTx", tx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyProjectedTx indicates an expected call of VerifyProjectedTx.
+func (mr *MockManagerMockRecorder) VerifyProjectedTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyProjectedTx", reflect.TypeOf((*MockManager)(nil).VerifyProjectedTx), tx)
+}
+
 // VerifyTx mocks base method.
 func (m *MockManager) VerifyTx(tx *txs.Tx) error {
 	m.ctrl.T.Helper()
@@ -157,6 +171,20 @@ func (mr *MockManagerMockRecorder) VerifyTx(tx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTx", reflect.TypeOf((*MockManager)(nil).VerifyTx), tx)
 }
 
+// VerifyTxs mocks base method.
+func (m *MockManager) VerifyTxs(txs []*txs.Tx) []error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTxs", txs)
+	ret0, _ := ret[0].([]error)
+	return ret0
+}
+
+// VerifyTxs indicates an expected call of VerifyTxs.
+func (mr *MockManagerMockRecorder) VerifyTxs(txs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTxs", reflect.TypeOf((*MockManager)(nil).VerifyTxs), txs)
+}
+
 // VerifyUniqueInputs mocks base method.
 func (m *MockManager) VerifyUniqueInputs(blkID ids.ID, inputs set.Set[ids.ID]) error {
 	m.ctrl.T.Helper()
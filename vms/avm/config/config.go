@@ -3,7 +3,13 @@
 
 package config
 
-import "time"
+import (
+	"time"
+
+	"golang.org/x/sync/semaphore"
+
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+)
 
 // Struct collecting all the foundational parameters of the AVM
 type Config struct {
@@ -15,6 +21,16 @@ type Config struct {
 
 	// Time of the E network upgrade
 	EUpgradeTime time.Time
+
+	// Limits the number of gossiped txs that are verified concurrently. This
+	// limiter may be shared with other chains. If nil, verification is not
+	// limited.
+	GossipVerificationLimiter *semaphore.Weighted
+
+	// OnTxAdded, if non-nil, is called with every tx once it is added to the
+	// mempool, such as to notify an indexer. It must not block or call back
+	// into the mempool.
+	OnTxAdded func(*txs.Tx)
 }
 
 func (c *Config) IsEActivated(timestamp time.Time) bool {
@@ -55,6 +55,37 @@ func TestParseConfig(t *testing.T) {
 					ExpectedBloomFilterElements:                 network.DefaultConfig.ExpectedBloomFilterElements,
 					ExpectedBloomFilterFalsePositiveProbability: network.DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
 					MaxBloomFilterFalsePositiveProbability:      network.DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+					BloomChurnMultiplier:                        network.DefaultConfig.BloomChurnMultiplier,
+					DropReasonTTL:                               5 * time.Minute,
+				},
+				IndexTransactions:    DefaultConfig.IndexTransactions,
+				IndexAllowIncomplete: DefaultConfig.IndexAllowIncomplete,
+				ChecksumsEnabled:     DefaultConfig.ChecksumsEnabled,
+			},
+		},
+		{
+			name:        "manually disabled drop reason ttl",
+			configBytes: []byte(`{"network":{"drop-reason-ttl":0}}`),
+			expectedConfig: Config{
+				Network: network.Config{
+					MaxValidatorSetStaleness:                    network.DefaultConfig.MaxValidatorSetStaleness,
+					TargetGossipSize:                            network.DefaultConfig.TargetGossipSize,
+					PushGossipPercentStake:                      network.DefaultConfig.PushGossipPercentStake,
+					PushGossipNumValidators:                     network.DefaultConfig.PushGossipNumValidators,
+					PushGossipNumPeers:                          network.DefaultConfig.PushGossipNumPeers,
+					PushRegossipNumValidators:                   network.DefaultConfig.PushRegossipNumValidators,
+					PushRegossipNumPeers:                        network.DefaultConfig.PushRegossipNumPeers,
+					PushGossipDiscardedCacheSize:                network.DefaultConfig.PushGossipDiscardedCacheSize,
+					PushGossipMaxRegossipFrequency:              network.DefaultConfig.PushGossipMaxRegossipFrequency,
+					PushGossipFrequency:                         network.DefaultConfig.PushGossipFrequency,
+					PullGossipPollSize:                          network.DefaultConfig.PullGossipPollSize,
+					PullGossipFrequency:                         network.DefaultConfig.PullGossipFrequency,
+					PullGossipThrottlingPeriod:                  network.DefaultConfig.PullGossipThrottlingPeriod,
+					PullGossipThrottlingLimit:                   network.DefaultConfig.PullGossipThrottlingLimit,
+					ExpectedBloomFilterElements:                 network.DefaultConfig.ExpectedBloomFilterElements,
+					ExpectedBloomFilterFalsePositiveProbability: network.DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
+					MaxBloomFilterFalsePositiveProbability:      network.DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+					BloomChurnMultiplier:                        network.DefaultConfig.BloomChurnMultiplier,
 				},
 				IndexTransactions:    DefaultConfig.IndexTransactions,
 				IndexAllowIncomplete: DefaultConfig.IndexAllowIncomplete,
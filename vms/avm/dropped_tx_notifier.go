@@ -0,0 +1,47 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avm
+
+import (
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+)
+
+var _ mempool.Mempool = (*droppedTxNotifier)(nil)
+
+// droppedTxNotifier notifies the network of txs that are dropped or removed
+// while building, verifying and accepting blocks, so that the network can
+// track the fate of the txs it received from peers and the staleness of its
+// bloom filter.
+type droppedTxNotifier struct {
+	mempool.Mempool
+
+	vm *VM
+}
+
+func (d *droppedTxNotifier) Remove(txs ...*txs.Tx) {
+	// The network is only initialized once the chain is linearized.
+	if d.vm.network == nil {
+		d.Mempool.Remove(txs...)
+		return
+	}
+	// The network removes the txs from the mempool itself.
+	d.vm.network.Remove(txs...)
+}
+
+func (d *droppedTxNotifier) MarkDropped(txID ids.ID, reason error) {
+	d.Mempool.MarkDropped(txID, reason)
+
+	// Txs aren't dropped from a full mempool, so they aren't rejected.
+	if errors.Is(reason, mempool.ErrMempoolFull) {
+		return
+	}
+	// The network is only initialized once the chain is linearized.
+	if d.vm.network != nil {
+		d.vm.network.MarkDropped(txID)
+	}
+}
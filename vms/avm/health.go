@@ -5,7 +5,9 @@ package avm
 
 import "context"
 
-// TODO: add health checks
-func (*VM) HealthCheck(context.Context) (interface{}, error) {
-	return nil, nil
+func (vm *VM) HealthCheck(ctx context.Context) (interface{}, error) {
+	if vm.network == nil {
+		return nil, nil
+	}
+	return vm.network.HealthCheck(ctx)
 }
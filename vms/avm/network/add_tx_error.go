@@ -0,0 +1,69 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+var _ error = (*AddTxError)(nil)
+
+// AddTxFailure is the reason that a tx wasn't added to the mempool.
+type AddTxFailure byte
+
+const (
+	// AddTxDuplicate is reported if the tx is already in the mempool.
+	AddTxDuplicate AddTxFailure = iota + 1
+	// AddTxDropped is reported if the tx was previously dropped, and it isn't
+	// verified again yet.
+	AddTxDropped
+	// AddTxSpam is reported if the tx is likely spam.
+	AddTxSpam
+	// AddTxInvalid is reported if the tx failed verification.
+	AddTxInvalid
+	// AddTxRejected is reported if the mempool didn't accept the tx, such as
+	// if the tx conflicts with another tx or if the mempool is full.
+	AddTxRejected
+	// AddTxDeferred is reported if the tx failed verification with a
+	// retryable error. The tx isn't dropped, so it is verified again when it
+	// is next received or retried.
+	AddTxDeferred
+)
+
+func (f AddTxFailure) String() string {
+	switch f {
+	case AddTxDuplicate:
+		return "duplicate"
+	case AddTxDropped:
+		return "dropped"
+	case AddTxSpam:
+		return "spam"
+	case AddTxInvalid:
+		return "invalid"
+	case AddTxRejected:
+		return "rejected"
+	case AddTxDeferred:
+		return "deferred"
+	default:
+		return "unknown"
+	}
+}
+
+// AddTxError is returned when a tx isn't added to the mempool. Err is the
+// underlying reason, so errors.Is can still be used to match it.
+type AddTxError struct {
+	TxID    ids.ID
+	Failure AddTxFailure
+	Err     error
+}
+
+func (e *AddTxError) Error() string {
+	return fmt.Sprintf("failed to add %s tx %s: %s", e.Failure, e.TxID, e.Err)
+}
+
+func (e *AddTxError) Unwrap() error {
+	return e.Err
+}
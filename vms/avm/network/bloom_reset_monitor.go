@@ -0,0 +1,67 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/buffer"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+// bloomResetWindow is the duration over which bloom filter resets are counted.
+const bloomResetWindow = time.Minute
+
+var errBloomResetsTooFrequent = errors.New("bloom filter is reset too frequently")
+
+func newBloomResetMonitor(maxResets int) (*bloomResetMonitor, error) {
+	// Only the most recent resets need to be remembered to know whether more
+	// than [maxResets] happened within the window.
+	resets, err := buffer.NewBoundedQueue[time.Time](maxResets+1, nil)
+	return &bloomResetMonitor{
+		maxResets: maxResets,
+		resets:    resets,
+	}, err
+}
+
+// bloomResetMonitor tracks the number of times the bloom filter was reset
+// within the last bloomResetWindow. Frequent resets indicate that the bloom
+// filter is undersized for the churn of the mempool, so the monitor reports
+// itself as unhealthy if more than maxResets happen within the window.
+type bloomResetMonitor struct {
+	clock     mockable.Clock
+	maxResets int
+
+	lock   sync.Mutex
+	resets buffer.Queue[time.Time]
+}
+
+// observe records that the bloom filter was reset.
+func (m *bloomResetMonitor) observe() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.resets.Push(m.clock.Time())
+}
+
+// numResets returns the number of times the bloom filter was reset within the
+// last bloomResetWindow and whether it exceeds the max number of resets.
+func (m *bloomResetMonitor) numResets() (int, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.clock.Time()
+	for {
+		resetTime, ok := m.resets.Peek()
+		if !ok || now.Sub(resetTime) < bloomResetWindow {
+			break
+		}
+		m.resets.Pop()
+	}
+
+	numResets := m.resets.Len()
+	return numResets, numResets > m.maxResets
+}
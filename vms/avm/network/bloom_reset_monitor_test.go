@@ -0,0 +1,168 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+)
+
+func TestBloomResetMonitor(t *testing.T) {
+	require := require.New(t)
+
+	m, err := newBloomResetMonitor(2)
+	require.NoError(err)
+	now := time.Now()
+	m.clock.Set(now)
+
+	numResets, tooFrequent := m.numResets()
+	require.Zero(numResets)
+	require.False(tooFrequent)
+
+	for i := 0; i < 2; i++ {
+		m.observe()
+	}
+	numResets, tooFrequent = m.numResets()
+	require.Equal(2, numResets)
+	require.False(tooFrequent)
+
+	m.clock.Set(now.Add(bloomResetWindow / 2))
+	m.observe()
+	numResets, tooFrequent = m.numResets()
+	require.Equal(3, numResets)
+	require.True(tooFrequent)
+
+	// Resets leave the window once they are older than it.
+	m.clock.Set(now.Add(bloomResetWindow))
+	numResets, tooFrequent = m.numResets()
+	require.Equal(1, numResets)
+	require.False(tooFrequent)
+
+	m.clock.Set(now.Add(bloomResetWindow * 3 / 2))
+	numResets, tooFrequent = m.numResets()
+	require.Zero(numResets)
+	require.False(tooFrequent)
+}
+
+func TestNetworkHealthCheckBloomResetsTooFrequent(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.MaxBloomResetsPerMinute = 1
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.NoError(err)
+
+	now := time.Now()
+	n.mempool.bloomResetMonitor.clock.Set(now)
+
+	details, err := n.HealthCheck(context.Background())
+	require.NoError(err)
+	require.Equal(
+		map[string]interface{}{
+			"bloomResetsPerMinute": 0,
+		},
+		details,
+	)
+
+	// The bloom filter is sized for few txs, so adding many txs resets it
+	// repeatedly.
+	for i := 0; i < 1000; i++ {
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(n.mempool.AddWithoutVerification(tx))
+	}
+
+	details, err = n.HealthCheck(context.Background())
+	require.ErrorIs(err, errBloomResetsTooFrequent)
+	require.Greater(details.(map[string]interface{})["bloomResetsPerMinute"], config.MaxBloomResetsPerMinute)
+
+	// Once the resets are older than the window, the network is healthy again.
+	n.mempool.bloomResetMonitor.clock.Set(now.Add(bloomResetWindow))
+	_, err = n.HealthCheck(context.Background())
+	require.NoError(err)
+}
+
+func TestBloomResetMonitorIgnoresRemovals(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	const expectedElements = 8
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(registerer),
+		withBloomFilter(expectedElements, DefaultConfig.ExpectedBloomFilterFalsePositiveProbability, DefaultConfig.MaxBloomFilterFalsePositiveProbability),
+	)
+	gossipMempool.bloomResetMonitor, err = newBloomResetMonitor(0)
+	require.NoError(err)
+
+	txIDs := make([]ids.ID, expectedElements/bloomRebuildDivisor)
+	for i := range txIDs {
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(gossipMempool.AddWithoutVerification(tx))
+		txIDs[i] = tx.ID()
+	}
+
+	// Removing enough txs rebuilds the bloom filter, which is expected and
+	// isn't counted as a reset.
+	require.NoError(gossipMempool.RemoveTxs(txIDs...))
+	require.Zero(gossipMempool.numRemovedSinceReset)
+	numResets, tooFrequent := gossipMempool.bloomResetMonitor.numResets()
+	require.Zero(numResets)
+	require.False(tooFrequent)
+}
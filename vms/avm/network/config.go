@@ -6,9 +6,20 @@ package network
 import (
 	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
 )
 
+// SpamScorer scores how likely [tx], received from [nodeID], is to be spam. A
+// higher score is more likely to be spam. [nodeID] is ids.EmptyNodeID if the
+// tx was issued locally.
+type SpamScorer func(tx *txs.Tx, nodeID ids.NodeID) (score float64, err error)
+
+// RequestLoad reports the current load of requests being served. The scale of
+// the load is defined by the caller.
+type RequestLoad func() float64
+
 var DefaultConfig = Config{
 	MaxValidatorSetStaleness:                    time.Minute,
 	TargetGossipSize:                            20 * units.KiB,
@@ -27,6 +38,8 @@ var DefaultConfig = Config{
 	ExpectedBloomFilterElements:                 8 * 1024,
 	ExpectedBloomFilterFalsePositiveProbability: .01,
 	MaxBloomFilterFalsePositiveProbability:      .05,
+	BloomChurnMultiplier:                        defaultBloomChurnMultiplier,
+	DropReasonTTL:                               5 * time.Minute,
 }
 
 type Config struct {
@@ -60,21 +73,200 @@ type Config struct {
 	// PushGossipMaxRegossipFrequency is the limit for how frequently a
 	// transaction will be push gossiped.
 	PushGossipMaxRegossipFrequency time.Duration `json:"push-gossip-max-regossip-frequency"`
+	// PushGossipMaxLifetime is the duration after a transaction is queued to
+	// be pushed after which it will no longer be pushed, even if it remains in
+	// the mempool. The transaction can still be pulled by peers. If 0, a
+	// transaction is pushed for as long as it remains in the mempool.
+	PushGossipMaxLifetime time.Duration `json:"push-gossip-max-lifetime"`
 	// PushGossipFrequency is how frequently rounds of push gossip are
 	// performed.
 	PushGossipFrequency time.Duration `json:"push-gossip-frequency"`
+	// PushGossipFilterTTL, if non-zero, skips pushing transactions to peers
+	// that pushed them to us, or that advertised a bloom filter containing
+	// them, within the ttl.
+	PushGossipFilterTTL time.Duration `json:"push-gossip-filter-ttl"`
+	// PushGossipFilterAdvertiseFrequency is how frequently the mempool bloom
+	// filter is advertised to peers in pushed gossip, if PushGossipFilterTTL is
+	// non-zero. If 0, the bloom filter is never advertised. This should be
+	// less than the PushGossipFilterTTL of peers to be effective.
+	PushGossipFilterAdvertiseFrequency time.Duration `json:"push-gossip-filter-advertise-frequency"`
 	// PullGossipPollSize is the number of validators to sample when performing
 	// a round of pull gossip.
 	PullGossipPollSize int `json:"pull-gossip-poll-size"`
 	// PullGossipFrequency is how frequently rounds of pull gossip are
 	// performed.
 	PullGossipFrequency time.Duration `json:"pull-gossip-frequency"`
+	// GossipFrequencyJitter, if non-zero, randomizes every interval between
+	// rounds of push and pull gossip by up to this fraction of
+	// PushGossipFrequency and PullGossipFrequency, so that nodes don't gossip
+	// in synchronized bursts. Must be less than 1.
+	GossipFrequencyJitter float64 `json:"gossip-frequency-jitter"`
 	// PullGossipThrottlingPeriod is how large of a window the throttler should
 	// use.
 	PullGossipThrottlingPeriod time.Duration `json:"pull-gossip-throttling-period"`
 	// PullGossipThrottlingLimit is the number of pull querys that are allowed
 	// by a validator in every throttling window.
 	PullGossipThrottlingLimit int `json:"pull-gossip-throttling-limit"`
+	// PullGossipCompressFilter, if true, compresses the bloom filter sent in
+	// pull gossip requests. This should only be enabled once all peers
+	// support compressed filters.
+	PullGossipCompressFilter bool `json:"pull-gossip-compress-filter"`
+	// PullGossipMaxResponseSize is the size of the largest pull gossip
+	// response that is handled. Larger responses are dropped without being
+	// parsed. If 0, responses of any size are handled.
+	PullGossipMaxResponseSize int `json:"pull-gossip-max-response-size"`
+	// PullGossipResponseCompressionThreshold, if non-zero, asks peers to
+	// compress their pull gossip responses and compresses the responses that
+	// are larger than this many bytes for peers that ask for it.
+	PullGossipResponseCompressionThreshold int `json:"pull-gossip-response-compression-threshold"`
+	// MaxConcurrentPullGossipRequests, if non-zero, is the maximum number of
+	// pull gossip requests that are served concurrently. Requests received
+	// while this many requests are being served are refused as throttled.
+	MaxConcurrentPullGossipRequests int `json:"max-concurrent-pull-gossip-requests"`
+	// SmallMempoolGossipThreshold is the number of txs below which the
+	// mempool is considered small. While the mempool is small, rounds of push
+	// and pull gossip are performed at most once every
+	// SmallMempoolGossipMinInterval. If 0, gossip frequency doesn't depend on
+	// the size of the mempool.
+	SmallMempoolGossipThreshold int `json:"small-mempool-gossip-threshold"`
+	// SmallMempoolGossipMinInterval is the minimum amount of time between
+	// rounds of push or pull gossip while the mempool is small.
+	SmallMempoolGossipMinInterval time.Duration `json:"small-mempool-gossip-min-interval"`
+	// VersionedTxGossip, if true, prefixes gossiped txs with a version byte
+	// identifying the codec version they're encoded with. Versioned and
+	// unversioned txs are always accepted. This should only be enabled once
+	// all peers support versioned gossip.
+	VersionedTxGossip bool `json:"versioned-tx-gossip"`
+	// PullGossipIncludeConflicts, if true, remembers recent transactions that
+	// conflicted with a transaction in the mempool and includes them in
+	// responses to pull gossip requests alongside the transactions they
+	// conflict with.
+	PullGossipIncludeConflicts bool `json:"pull-gossip-include-conflicts"`
+	// PullGossipStatusHints, if true, annotates the transactions returned to
+	// pull gossip requests that ask for it with whether they are pending or
+	// were recently accepted.
+	PullGossipStatusHints bool `json:"pull-gossip-status-hints"`
+	// PullGossipPrioritizeByFeeRate, if true, responds to pull gossip
+	// requests with the transactions that burn the most of the fee asset per
+	// byte first, so that they are included even if the response is full.
+	PullGossipPrioritizeByFeeRate bool `json:"pull-gossip-prioritize-by-fee-rate"`
+	// PullGossipDependencyBundles, if true, responds to pull gossip requests
+	// with transactions grouped into bundles of transactions that spend each
+	// other's outputs, so that a transaction is sent before the transactions
+	// that depend on it.
+	PullGossipDependencyBundles bool `json:"pull-gossip-dependency-bundles"`
+	// PullGossipMarshalCacheSize, if non-zero, is the number of transactions
+	// whose marshalled bytes are cached to respond to pull gossip requests,
+	// so that transactions served to many peers are only marshalled once.
+	PullGossipMarshalCacheSize int `json:"pull-gossip-marshal-cache-size"`
+	// PullGossipChallengeFrequency, if non-zero, includes a challenge in one
+	// out of every PullGossipChallengeFrequency responses to pull gossip
+	// requests, which the requester must echo in its next request. Requests
+	// that fail to echo their challenge may be replays and are reported in
+	// the peer stats. Challenges received from peers are always echoed.
+	PullGossipChallengeFrequency int `json:"pull-gossip-challenge-frequency"`
+	// RejectionHintNumPeers, if non-zero, is the number of peers that are
+	// notified of a transaction that failed verification, so that they can
+	// drop it rather than gossip it. Rejections reported by peers are only
+	// applied if the transaction also fails local verification. Each peer's
+	// rejections are throttled like its pull gossip requests, and each
+	// transaction is only verified for the first rejection reported for it.
+	RejectionHintNumPeers int `json:"rejection-hint-num-peers"`
+	// PullGossipSnapshotInterval, if non-zero, serves pull gossip requests
+	// from a copy of the mempool that is refreshed once it is older than
+	// PullGossipSnapshotInterval, rather than from the mempool itself. This
+	// avoids contention between serving requests and modifying the mempool,
+	// at the cost of serving slightly stale transactions.
+	PullGossipSnapshotInterval time.Duration `json:"pull-gossip-snapshot-interval"`
+	// PullGossipMaxTxAge, if non-zero, excludes transactions that were added
+	// to the mempool more than PullGossipMaxTxAge ago from responses to pull
+	// gossip requests, as they are unlikely to ever be accepted.
+	PullGossipMaxTxAge time.Duration `json:"pull-gossip-max-tx-age"`
+	// PullGossipPruneAgedTxs, if true, removes transactions that were added
+	// to the mempool more than PullGossipMaxTxAge ago from the mempool before
+	// each pull gossip round, rather than only excluding them from responses.
+	// It has no effect if PullGossipMaxTxAge is 0.
+	PullGossipPruneAgedTxs bool `json:"pull-gossip-prune-aged-txs"`
+	// MaxDroppedTxsPerPeer is the maximum number of dropped transactions whose
+	// drop reasons are tracked for each peer. Once exceeded, the least
+	// recently dropped transaction of the peer is no longer tracked. This is
+	// independent of the transactions the mempool tracks as dropped. If 0,
+	// dropped transactions are not tracked per peer.
+	MaxDroppedTxsPerPeer int `json:"max-dropped-txs-per-peer"`
+	// MaxPeerFilters, if non-zero, is the number of peers whose bloom filter
+	// from their latest pull gossip request is remembered, to estimate how
+	// many peers already know about a transaction. Once exceeded, the filter
+	// that was least recently received is forgotten.
+	MaxPeerFilters int `json:"max-peer-filters"`
+	// MaxDeferredTxs, if non-zero, is the number of txs that failed
+	// verification with a retryable error, such as spending an unknown UTXO,
+	// that are queued to be verified again. Deferred txs are only verified
+	// again after a block is accepted, as txs are only verified against the
+	// last accepted state. Once exceeded, the oldest deferred tx is
+	// forgotten. Txs that fail with a retryable error are never marked as
+	// dropped, even if they aren't queued.
+	MaxDeferredTxs int `json:"max-deferred-txs"`
+	// DropReasonCacheSize, if non-zero, is the number of dropped txs whose
+	// drop reasons are remembered, in addition to the recently dropped txs
+	// remembered by the mempool. Once exceeded, the least recently dropped tx
+	// is forgotten and is verified again when it is next received.
+	DropReasonCacheSize int `json:"drop-reason-cache-size"`
+	// DropReasonTTL, if non-zero, is the duration after which a tx that was
+	// dropped is verified again when it is next received, as the preferred
+	// state may have changed to make it valid. If 0, dropped txs are ignored
+	// for as long as the mempool remembers that they were dropped. By default,
+	// dropped txs are verified again after 5 minutes.
+	DropReasonTTL time.Duration `json:"drop-reason-ttl"`
+	// MaxTxSize, if non-zero, is the size in bytes of the largest tx that is
+	// added to the mempool. Larger txs are dropped. This only has an effect
+	// if it is less than the max tx size of the mempool.
+	MaxTxSize int `json:"max-tx-size"`
+	// MaxGossipPeers, if non-zero, is the maximum number of distinct peers
+	// tracked across all of the per-peer gossip stats, challenges, dropped
+	// transactions and bloom filters. Once exceeded, the least recently active
+	// peer is no longer tracked by any of them.
+	MaxGossipPeers int `json:"max-gossip-peers"`
+	// StateSyncMaxQueuedGossip is the maximum number of gossip messages that
+	// are queued while state syncing, to be handled once state sync completes.
+	// Any additional gossip received while state syncing is dropped.
+	StateSyncMaxQueuedGossip int `json:"state-sync-max-queued-gossip"`
+	// MaxBloomResetsPerMinute, if non-zero, is the number of times the bloom
+	// filter may be reset within a minute before the network reports itself
+	// as unhealthy. Frequent resets indicate that the bloom filter is
+	// undersized for the churn of the mempool.
+	MaxBloomResetsPerMinute int `json:"max-bloom-resets-per-minute"`
+	// VerificationFailureWindow, if non-zero, is the number of most recent
+	// verifications of locally issued transactions over which the
+	// verification failure rate is monitored. If the failure rate exceeds
+	// VerificationFailureThreshold, a warning is logged and the network
+	// reports itself as unhealthy. Verifications older than 10 minutes aren't
+	// counted, and transactions received from peers are never counted.
+	VerificationFailureWindow int `json:"verification-failure-window"`
+	// VerificationFailureThreshold is the verification failure rate above
+	// which verification is considered to be consistently failing.
+	VerificationFailureThreshold float64 `json:"verification-failure-threshold"`
+	// ReorgDropReplayDepth, if non-zero, is the reorg depth at or above which
+	// the reasons that transactions were dropped are cleared. Transactions
+	// dropped for spending inputs that were spent by reverted blocks may be
+	// valid again, so they are re-verified when they are next received.
+	ReorgDropReplayDepth uint64 `json:"reorg-drop-replay-depth"`
+	// GossipWorkers, if non-zero, is the number of goroutines dedicated to
+	// handling tx gossip, isolating the work of handling gossip from the rest
+	// of the node.
+	GossipWorkers int `json:"gossip-workers"`
+	// GossipWorkerQueueSize is the number of tx gossip messages that wait for
+	// a gossip worker once every worker is busy. Any additional gossip is
+	// dropped and requests are refused.
+	GossipWorkerQueueSize int `json:"gossip-worker-queue-size"`
+	// MaxTrackedTxFates, if non-zero, is the maximum number of txs received
+	// from peers whose eventual fate, accepted, rejected or expired, is
+	// tracked and reported in the metrics. Once exceeded, the oldest tracked
+	// tx is considered expired.
+	MaxTrackedTxFates int `json:"max-tracked-tx-fates"`
+	// TxFateExpiry is the duration after which a tracked tx that was neither
+	// accepted nor rejected is considered expired. If 0, txs only expire to
+	// stay within MaxTrackedTxFates.
+	TxFateExpiry time.Duration `json:"tx-fate-expiry"`
 	// ExpectedBloomFilterElements is the number of elements to expect when
 	// creating a new bloom filter. The larger this number is, the larger the
 	// bloom filter will be.
@@ -90,4 +282,88 @@ type Config struct {
 	// The smaller this number is, the more frequently that the bloom filter
 	// will be regenerated.
 	MaxBloomFilterFalsePositiveProbability float64 `json:"max-bloom-filter-false-positive-probability"`
+	// MaxBloomFilterSize, if non-zero, is the maximum size in bytes of the
+	// bloom filter that is sent in every pull gossip request. If the bloom
+	// filter would otherwise be larger, it is clamped to this size at the
+	// cost of a higher false positive probability.
+	MaxBloomFilterSize int `json:"max-bloom-filter-size"`
+	// PullGossipBloomFilterElements, if non-zero, is the number of elements
+	// to expect when creating the bloom filter sent in pull gossip requests.
+	// This allows the filter that determines which txs peers respond with to
+	// be larger than the filter advertised in push gossip. If 0, a single
+	// bloom filter sized by ExpectedBloomFilterElements is used for both.
+	PullGossipBloomFilterElements int `json:"pull-gossip-bloom-filter-elements"`
+	// PullGossipBloomFilterFalsePositiveProbability overrides
+	// ExpectedBloomFilterFalsePositiveProbability for the bloom filter sent in
+	// pull gossip requests. If 0, ExpectedBloomFilterFalsePositiveProbability
+	// is used.
+	PullGossipBloomFilterFalsePositiveProbability float64 `json:"pull-gossip-bloom-filter-false-positive-probability"`
+	// PullGossipMaxBloomFilterFalsePositiveProbability overrides
+	// MaxBloomFilterFalsePositiveProbability for the bloom filter sent in pull
+	// gossip requests. If 0, MaxBloomFilterFalsePositiveProbability is used.
+	PullGossipMaxBloomFilterFalsePositiveProbability float64 `json:"pull-gossip-max-bloom-filter-false-positive-probability"`
+	// BloomChurnMultiplier is the number used to multiply the size of the
+	// mempool to determine how many elements the bloom filter is sized for
+	// when it is reset. Chains with high tx churn may increase this to reset
+	// the bloom filter less frequently. If 0, a multiplier of 3 is used.
+	BloomChurnMultiplier int `json:"bloom-churn-multiplier"`
+	// BloomSaltRotationInterval, if non-zero, is how frequently the bloom
+	// filters are rebuilt with a new salt, regardless of whether they need to
+	// be reset. Rotating the salt prevents peers from crafting txs that
+	// persistently collide with the txs in the bloom filters. If 0, the salt
+	// only changes when the bloom filters are reset.
+	BloomSaltRotationInterval time.Duration `json:"bloom-salt-rotation-interval"`
+	// VerifyProjectedState, if true, verifies gossiped transactions against
+	// the state of the currently preferred block, which the next block will
+	// be built on, rather than the last accepted state. This allows
+	// transactions that depend on processing blocks to be added to the
+	// mempool.
+	VerifyProjectedState bool `json:"verify-projected-state"`
+	// BuildBlockRequestWindow is the duration after notifying the engine that
+	// a block should be built during which additional notifications are
+	// coalesced into a single notification. If 0, the engine is notified
+	// after every transaction added to the mempool.
+	BuildBlockRequestWindow time.Duration `json:"build-block-request-window"`
+	// GossipSampleThreshold is the number of transactions in the mempool above
+	// which only a sample of the mempool is pushed to peers and served in
+	// response to pull requests. If 0, the full mempool is always gossiped.
+	GossipSampleThreshold int `json:"gossip-sample-threshold"`
+	// GossipSampleFraction is the fraction of transactions that are gossiped
+	// once the mempool exceeds GossipSampleThreshold.
+	GossipSampleFraction float64 `json:"gossip-sample-fraction"`
+	// GossipSampleTopFraction is the fraction of transactions that burn the
+	// most of the fee asset per byte that are always gossiped once the
+	// mempool exceeds GossipSampleThreshold. GossipSampleFraction of the
+	// remaining transactions are gossiped uniformly at random.
+	GossipSampleTopFraction float64 `json:"gossip-sample-top-fraction"`
+	// GossipPriorityHalfLife, if non-zero, halves the fee rate that
+	// transactions are ranked by for every GossipPriorityHalfLife since they
+	// were added to the mempool, so that fresh transactions outrank stale
+	// transactions with the same fee rate. This applies both to the top
+	// transactions of a sample, selected by GossipSampleTopFraction, and to
+	// the order of pull gossip responses with PullGossipPrioritizeByFeeRate.
+	GossipPriorityHalfLife time.Duration `json:"gossip-priority-half-life"`
+	// SpamScorer, if non-nil, is called before verifying a transaction that is
+	// being added to the mempool. Transactions that score above
+	// SpamScoreThreshold are rejected with ErrLikelySpam.
+	SpamScorer SpamScorer `json:"-"`
+	// SpamScoreThreshold is the score above which transactions are rejected
+	// as spam.
+	SpamScoreThreshold float64 `json:"spam-score-threshold"`
+	// SpamScorerFailClosed, if true, rejects transactions that SpamScorer
+	// fails to score. Otherwise, they are admitted as if they weren't spam.
+	SpamScorerFailClosed bool `json:"spam-scorer-fail-closed"`
+	// OnTxAdded, if non-nil, is called with every transaction once it is
+	// added to the mempool, such as to notify an indexer. It is called
+	// without the gossip mempool's lock held, but must not block or call back
+	// into the mempool. It can't be set in the JSON config, so the VM sets it
+	// from the OnTxAdded of its static config.
+	OnTxAdded func(*txs.Tx) `json:"-"`
+	// BloomRebuildLoad, if non-nil, is checked before the mempool bloom filter
+	// is rebuilt. While the load exceeds BloomRebuildMaxLoad, rebuilds are
+	// deferred, accepting a temporarily higher false positive probability.
+	BloomRebuildLoad RequestLoad `json:"-"`
+	// BloomRebuildMaxLoad is the load above which rebuilds of the mempool
+	// bloom filter are deferred.
+	BloomRebuildMaxLoad float64 `json:"bloom-rebuild-max-load"`
 }
@@ -0,0 +1,90 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/linked"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+)
+
+// maxConflictingTxs is the maximum number of txs that were rejected from the
+// mempool for conflicting with another tx that are remembered.
+const maxConflictingTxs = 256
+
+func newConflictSets() *conflictSets {
+	return &conflictSets{
+		txs:     linked.NewHashmap[ids.ID, *txs.Tx](),
+		byInput: make(map[ids.ID]set.Set[ids.ID]),
+	}
+}
+
+// conflictSets remembers the most recent txs that couldn't be added to the
+// mempool because they conflict with a tx in the mempool.
+//
+// It is not thread safe.
+type conflictSets struct {
+	// txs are the remembered conflicting txs, from oldest to newest
+	txs *linked.Hashmap[ids.ID, *txs.Tx]
+	// byInput maps the ID of a UTXO to the remembered txs that consume it
+	byInput map[ids.ID]set.Set[ids.ID]
+}
+
+// add remembers [tx] as conflicting with a tx in the mempool.
+func (c *conflictSets) add(tx *txs.Tx) {
+	txID := tx.ID()
+	if _, ok := c.txs.Get(txID); ok {
+		return
+	}
+
+	if c.txs.Len() >= maxConflictingTxs {
+		_, oldestTx, _ := c.txs.Oldest()
+		c.remove(oldestTx)
+	}
+
+	c.txs.Put(txID, tx)
+	for inputID := range tx.Unsigned.InputIDs() {
+		txIDs, ok := c.byInput[inputID]
+		if !ok {
+			txIDs = set.Set[ids.ID]{}
+			c.byInput[inputID] = txIDs
+		}
+		txIDs.Add(txID)
+	}
+}
+
+func (c *conflictSets) remove(tx *txs.Tx) {
+	txID := tx.ID()
+	c.txs.Delete(txID)
+	for inputID := range tx.Unsigned.InputIDs() {
+		txIDs := c.byInput[inputID]
+		txIDs.Remove(txID)
+		if txIDs.Len() == 0 {
+			delete(c.byInput, inputID)
+		}
+	}
+}
+
+// conflicts returns the remembered txs, other than [tx], that consume any of
+// the UTXOs consumed by [tx].
+func (c *conflictSets) conflicts(tx *txs.Tx) []*txs.Tx {
+	var (
+		txID      = tx.ID()
+		conflicts []*txs.Tx
+		seen      set.Set[ids.ID]
+	)
+	for inputID := range tx.Unsigned.InputIDs() {
+		for conflictID := range c.byInput[inputID] {
+			if conflictID == txID || seen.Contains(conflictID) {
+				continue
+			}
+			seen.Add(conflictID)
+
+			conflict, _ := c.txs.Get(conflictID)
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/gossip"
+	"github.com/ava-labs/avalanchego/utils/linked"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+)
+
+var (
+	_ gossip.Gossiper = (*retryingGossiper)(nil)
+
+	errInvalidMaxDeferredTxs = errors.New("max deferred txs must be positive")
+)
+
+func newDeferredTxs(maxSize int) (*deferredTxs, error) {
+	if maxSize <= 0 {
+		return nil, errInvalidMaxDeferredTxs
+	}
+	return &deferredTxs{
+		maxSize: maxSize,
+		txs:     linked.NewHashmap[ids.ID, deferredTx](),
+	}, nil
+}
+
+// deferredTxs queues the most recent txs that failed verification with a
+// retryable error, so that they can be verified again once the state they are
+// verified against changes.
+type deferredTxs struct {
+	lock    sync.Mutex
+	maxSize int
+	// txs are the deferred txs, from oldest to newest
+	txs *linked.Hashmap[ids.ID, deferredTx]
+	// changed is set if the state may have changed since the deferred txs
+	// were last retried.
+	changed bool
+}
+
+// deferredTx is a tx that was deferred, and the peer it was received from.
+type deferredTx struct {
+	nodeID ids.NodeID
+	tx     *txs.Tx
+}
+
+// push queues [tx], received from [nodeID], to be retried. If the queue is
+// full, the oldest deferred tx is forgotten.
+func (d *deferredTxs) push(nodeID ids.NodeID, tx *txs.Tx) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	txID := tx.ID()
+	if !d.txs.Has(txID) && d.txs.Len() >= d.maxSize {
+		oldestTxID, _, _ := d.txs.Oldest()
+		d.txs.Delete(oldestTxID)
+	}
+	d.txs.Put(txID, deferredTx{
+		nodeID: nodeID,
+		tx:     tx,
+	})
+}
+
+// remove stops [txID] from being retried.
+func (d *deferredTxs) remove(txID ids.ID) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.txs.Delete(txID)
+}
+
+// stateChanged records that the deferred txs may now pass verification.
+func (d *deferredTxs) stateChanged() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.changed = true
+}
+
+// popIfChanged removes and returns every deferred tx, from oldest to newest,
+// if the state may have changed since they were last popped.
+func (d *deferredTxs) popIfChanged() []deferredTx {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if !d.changed {
+		return nil
+	}
+	d.changed = false
+
+	popped := make([]deferredTx, 0, d.txs.Len())
+	for it := d.txs.NewIterator(); it.Next(); {
+		popped = append(popped, it.Value())
+	}
+	d.txs.Clear()
+	return popped
+}
+
+func (d *deferredTxs) len() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.txs.Len()
+}
+
+// retryingGossiper retries the deferred txs of the mempool before every round
+// of gossip, so that the txs that pass verification are gossiped in the same
+// round.
+type retryingGossiper struct {
+	gossip.Gossiper
+	mempool *gossipMempool
+}
+
+func (r retryingGossiper) Gossip(ctx context.Context) error {
+	r.mempool.retryDeferredTxs()
+	return r.Gossiper.Gossip(ctx)
+}
+
+// retryDeferredTxs verifies the deferred txs again, if the state may have
+// changed since they were last verified. Txs that fail verification with a
+// retryable error again are deferred again.
+func (g *gossipMempool) retryDeferredTxs() {
+	if g.deferred == nil {
+		return
+	}
+
+	for _, deferred := range g.deferred.popIfChanged() {
+		err := g.AddFromPeer(deferred.nodeID, deferred.tx)
+		g.log.Debug("retried deferred tx",
+			zap.Stringer("txID", deferred.tx.ID()),
+			zap.Stringer("nodeID", deferred.nodeID),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+)
+
+func TestNewDeferredTxsInvalidMaxSize(t *testing.T) {
+	_, err := newDeferredTxs(0)
+	require.ErrorIs(t, err, errInvalidMaxDeferredTxs)
+}
+
+func TestDeferredTxs(t *testing.T) {
+	require := require.New(t)
+
+	d, err := newDeferredTxs(2)
+	require.NoError(err)
+
+	var (
+		nodeID = ids.GenerateTestNodeID()
+		tx0    = &txs.Tx{TxID: ids.GenerateTestID()}
+		tx1    = &txs.Tx{TxID: ids.GenerateTestID()}
+		tx2    = &txs.Tx{TxID: ids.GenerateTestID()}
+	)
+	d.push(nodeID, tx0)
+	d.push(nodeID, tx1)
+	// Deferring a tx again doesn't evict another tx.
+	d.push(nodeID, tx0)
+	require.Equal(2, d.len())
+
+	// Nothing is popped until the state changes.
+	require.Empty(d.popIfChanged())
+
+	// The oldest tx is evicted once full.
+	d.push(nodeID, tx2)
+	require.Equal(2, d.len())
+
+	d.stateChanged()
+	require.Equal(
+		[]deferredTx{
+			{nodeID: nodeID, tx: tx0},
+			{nodeID: nodeID, tx: tx2},
+		},
+		d.popIfChanged(),
+	)
+	require.Zero(d.len())
+	require.Empty(d.popIfChanged())
+
+	d.push(nodeID, tx1)
+	d.remove(tx1.ID())
+	require.Zero(d.len())
+}
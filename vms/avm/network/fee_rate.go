@@ -0,0 +1,109 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/gossip"
+	"github.com/ava-labs/avalanchego/utils/math"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+)
+
+var (
+	_ gossip.Prioritizer[*txs.Tx] = feeRatePrioritizer{}
+	_ txs.Visitor                 = (*burnCalculator)(nil)
+)
+
+// feeRatePrioritizer prioritizes txs by the amount of the fee asset that they
+// burn per byte.
+type feeRatePrioritizer struct {
+	feeAssetID ids.ID
+}
+
+func (p feeRatePrioritizer) Priority(tx *txs.Tx) uint64 {
+	calculator := &burnCalculator{
+		feeAssetID: p.feeAssetID,
+	}
+	if err := tx.Unsigned.Visit(calculator); err != nil {
+		return 0
+	}
+
+	burned, err := math.Sub(calculator.consumed, calculator.produced)
+	if err != nil {
+		// The tx produces more than it consumes, so it will fail verification.
+		return 0
+	}
+	if size := len(tx.Bytes()); size > 0 {
+		return burned / uint64(size)
+	}
+	return burned
+}
+
+// burnCalculator sums the amount of the fee asset that a tx consumes and
+// produces.
+type burnCalculator struct {
+	feeAssetID ids.ID
+	consumed   uint64
+	produced   uint64
+}
+
+func (c *burnCalculator) BaseTx(tx *txs.BaseTx) error {
+	if err := c.consume(tx.Ins); err != nil {
+		return err
+	}
+	return c.produce(tx.Outs)
+}
+
+func (c *burnCalculator) CreateAssetTx(tx *txs.CreateAssetTx) error {
+	return c.BaseTx(&tx.BaseTx)
+}
+
+func (c *burnCalculator) OperationTx(tx *txs.OperationTx) error {
+	return c.BaseTx(&tx.BaseTx)
+}
+
+func (c *burnCalculator) ImportTx(tx *txs.ImportTx) error {
+	if err := c.consume(tx.ImportedIns); err != nil {
+		return err
+	}
+	return c.BaseTx(&tx.BaseTx)
+}
+
+func (c *burnCalculator) ExportTx(tx *txs.ExportTx) error {
+	if err := c.produce(tx.ExportedOuts); err != nil {
+		return err
+	}
+	return c.BaseTx(&tx.BaseTx)
+}
+
+func (c *burnCalculator) consume(ins []*avax.TransferableInput) error {
+	for _, in := range ins {
+		if in.AssetID() != c.feeAssetID {
+			continue
+		}
+
+		var err error
+		c.consumed, err = math.Add64(c.consumed, in.In.Amount())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *burnCalculator) produce(outs []*avax.TransferableOutput) error {
+	for _, out := range outs {
+		if out.AssetID() != c.feeAssetID {
+			continue
+		}
+
+		var err error
+		c.produced, err = math.Add64(c.produced, out.Out.Amount())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
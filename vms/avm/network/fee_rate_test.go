@@ -0,0 +1,161 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/gossip"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+)
+
+func TestFeeRatePrioritizer(t *testing.T) {
+	var (
+		feeAssetID   = ids.GenerateTestID()
+		otherAssetID = ids.GenerateTestID()
+	)
+	in := func(assetID ids.ID, amount uint64) *avax.TransferableInput {
+		return &avax.TransferableInput{
+			UTXOID: avax.UTXOID{
+				TxID: ids.GenerateTestID(),
+			},
+			Asset: avax.Asset{ID: assetID},
+			In: &secp256k1fx.TransferInput{
+				Amt: amount,
+			},
+		}
+	}
+	out := func(assetID ids.ID, amount uint64) *avax.TransferableOutput {
+		return &avax.TransferableOutput{
+			Asset: avax.Asset{ID: assetID},
+			Out: &secp256k1fx.TransferOutput{
+				Amt: amount,
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		unsigned         txs.UnsignedTx
+		size             int
+		expectedPriority uint64
+	}{
+		{
+			name: "base tx",
+			unsigned: &txs.BaseTx{BaseTx: avax.BaseTx{
+				Ins:  []*avax.TransferableInput{in(feeAssetID, 1500)},
+				Outs: []*avax.TransferableOutput{out(feeAssetID, 500)},
+			}},
+			size:             100,
+			expectedPriority: 10,
+		},
+		{
+			name: "other assets are ignored",
+			unsigned: &txs.BaseTx{BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{
+					in(feeAssetID, 1000),
+					in(otherAssetID, 5000),
+				},
+				Outs: []*avax.TransferableOutput{out(otherAssetID, 1000)},
+			}},
+			size:             100,
+			expectedPriority: 10,
+		},
+		{
+			name: "import tx",
+			unsigned: &txs.ImportTx{
+				ImportedIns: []*avax.TransferableInput{in(feeAssetID, 1000)},
+			},
+			size:             50,
+			expectedPriority: 20,
+		},
+		{
+			name: "export tx",
+			unsigned: &txs.ExportTx{
+				BaseTx: txs.BaseTx{BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{in(feeAssetID, 1000)},
+				}},
+				ExportedOuts: []*avax.TransferableOutput{out(feeAssetID, 500)},
+			},
+			size:             100,
+			expectedPriority: 5,
+		},
+		{
+			name: "produces more than consumed",
+			unsigned: &txs.BaseTx{BaseTx: avax.BaseTx{
+				Ins:  []*avax.TransferableInput{in(feeAssetID, 500)},
+				Outs: []*avax.TransferableOutput{out(feeAssetID, 1000)},
+			}},
+			size:             100,
+			expectedPriority: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &txs.Tx{Unsigned: tt.unsigned}
+			tx.SetBytes(nil, make([]byte, tt.size))
+
+			prioritizer := feeRatePrioritizer{
+				feeAssetID: feeAssetID,
+			}
+			require.Equal(t, tt.expectedPriority, prioritizer.Priority(tx))
+		})
+	}
+}
+
+// An older tx that burns the same fee rate as a newer tx is ranked below it.
+func TestFeeRatePrioritizerAgeDecay(t *testing.T) {
+	require := require.New(t)
+
+	const halfLife = time.Minute
+	var (
+		feeAssetID = ids.GenerateTestID()
+		mempool    = newTestGossipMempool(t)
+		now        = time.Now()
+	)
+	prioritizer, err := gossip.NewAgeDecayedPrioritizer[*txs.Tx](
+		feeRatePrioritizer{
+			feeAssetID: feeAssetID,
+		},
+		halfLife,
+		mempool.AddedTime,
+		&mempool.clock,
+	)
+	require.NoError(err)
+
+	newTx := func(i byte) *txs.Tx {
+		tx := &txs.Tx{Unsigned: &txs.BaseTx{BaseTx: avax.BaseTx{
+			Ins: []*avax.TransferableInput{{
+				UTXOID: avax.UTXOID{
+					TxID: ids.GenerateTestID(),
+				},
+				Asset: avax.Asset{ID: feeAssetID},
+				In: &secp256k1fx.TransferInput{
+					Amt: 1000,
+				},
+			}},
+		}}}
+		bytes := make([]byte, 100)
+		bytes[0] = i
+		tx.SetBytes(nil, bytes)
+		return tx
+	}
+
+	oldTx := newTx(0)
+	mempool.clock.Set(now)
+	require.NoError(mempool.Add(oldTx))
+
+	newerTx := newTx(1)
+	mempool.clock.Set(now.Add(halfLife))
+	require.NoError(mempool.Add(newerTx))
+
+	require.Equal(uint64(10), prioritizer.Priority(newerTx))
+	require.Equal(uint64(5), prioritizer.Priority(oldTx))
+}
@@ -4,36 +4,101 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/codec"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/network/p2p"
 	"github.com/ava-labs/avalanchego/network/p2p/gossip"
+	"github.com/ava-labs/avalanchego/utils"
 	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
 	"github.com/ava-labs/avalanchego/vms/avm/txs"
 	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
 )
 
 var (
-	_ p2p.Handler                = (*txGossipHandler)(nil)
-	_ gossip.Set[*txs.Tx]        = (*gossipMempool)(nil)
-	_ gossip.Marshaller[*txs.Tx] = (*txParser)(nil)
+	_ p2p.Handler                       = (*txGossipHandler)(nil)
+	_ gossip.BatchPeerAwareSet[*txs.Tx] = (*gossipMempool)(nil)
+	_ gossip.SizedSet[*txs.Tx]          = (*gossipMempool)(nil)
+	_ gossip.SnapshotSet[*txs.Tx]       = (*gossipMempool)(nil)
+	_ gossip.Set[*txs.Tx]               = (*pushGossipSet)(nil)
+	_ gossip.Marshaller[*txs.Tx]        = (*txParser)(nil)
+	_ gossip.GossipVersioner            = (*txParser)(nil)
+	_ gossip.MarshalObserver[*txs.Tx]   = (*txParser)(nil)
+	_ gossip.Gossiper                   = (*pruningGossiper)(nil)
+
+	ErrLikelySpam    = errors.New("likely spam")
+	ErrMempoolClosed = errors.New("mempool is closed")
+
+	errNonCanonicalTx              = errors.New("tx isn't canonically encoded")
+	errTxTooOld                    = errors.New("tx exceeded the max tx age")
+	errUnknownGossipVersion        = errors.New("unknown gossip version")
+	errGossipVersionMismatch       = errors.New("gossip version doesn't match codec version")
+	errNoGossipVersion             = errors.New("no gossip version for codec version")
+	errInvalidBloomChurnMultiplier = errors.New("bloom churn multiplier must be at least 1")
+	errTooManyConcurrentRequests   = fmt.Errorf("%w: too many concurrent requests", p2p.ErrThrottled)
 )
 
-// bloomChurnMultiplier is the number used to multiply the size of the mempool
-// to determine how large of a bloom filter to create.
-const bloomChurnMultiplier = 3
+const (
+	// defaultBloomChurnMultiplier is the bloom churn multiplier used if none
+	// is configured.
+	defaultBloomChurnMultiplier = 3
+
+	// bloomShrinkDivisor is the factor by which the number of elements a bloom
+	// filter is sized for must exceed the number required by the mempool
+	// before the bloom filter is shrunk when it is reset. This prevents the
+	// bloom filter from being resized every time it is reset while the
+	// mempool size hovers around a threshold.
+	bloomShrinkDivisor = 2
+
+	// bloomRebuildDivisor determines how many txs must be removed from the
+	// mempool by RemoveTxs before the bloom filter is rebuilt. Once the
+	// number of txs removed since the bloom filter was last reset reaches
+	// 1/bloomRebuildDivisor of the number of elements it is sized for, the
+	// bloom filter is rebuilt from the txs remaining in the mempool.
+	bloomRebuildDivisor = 4
+
+	// stuckTxMinGossipAttempts is the number of times a tx must have been
+	// gossiped before it can be reported as stuck.
+	stuckTxMinGossipAttempts = 3
+
+	// maxDropTimes is the minimum number of dropped txs whose drop times are
+	// remembered. This matches the number of dropped txs the mempool
+	// remembers.
+	maxDropTimes = 64
+
+	// maxRecentlyAcceptedTxs is the number of accepted txs that are
+	// remembered to report their status hints.
+	maxRecentlyAcceptedTxs = 4096
+)
 
 // txGossipHandler is the handler called when serving gossip messages
 type txGossipHandler struct {
 	p2p.NoOpHandler
 	appGossipHandler  p2p.Handler
 	appRequestHandler p2p.Handler
+	// mempool, if non-nil, is the mempool that gossip is added to. Gossip is
+	// dropped once it is closed.
+	mempool *gossipMempool
+	// requestLimiter, if non-nil, limits the number of requests that are
+	// handled concurrently. Requests beyond the limit are refused rather
+	// than waiting for a request to finish.
+	requestLimiter *semaphore.Weighted
 }
 
 func (t txGossipHandler) AppGossip(
@@ -41,6 +106,10 @@ func (t txGossipHandler) AppGossip(
 	nodeID ids.NodeID,
 	gossipBytes []byte,
 ) {
+	// Gossip can't be added to a closed mempool, so it isn't parsed.
+	if t.mempool != nil && t.mempool.isClosed() {
+		return
+	}
 	t.appGossipHandler.AppGossip(ctx, nodeID, gossipBytes)
 }
 
@@ -50,19 +119,178 @@ func (t txGossipHandler) AppRequest(
 	deadline time.Time,
 	requestBytes []byte,
 ) ([]byte, error) {
-	return t.appRequestHandler.AppRequest(ctx, nodeID, deadline, requestBytes)
+	if t.requestLimiter != nil {
+		if !t.requestLimiter.TryAcquire(1) {
+			return nil, fmt.Errorf("dropping request from %s: %w", nodeID, errTooManyConcurrentRequests)
+		}
+		defer t.requestLimiter.Release(1)
+	}
+
+	responseBytes, err := t.appRequestHandler.AppRequest(ctx, nodeID, deadline, requestBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the filters of requests that were served are recorded, so that
+	// peers that aren't served can't skew the estimates.
+	if t.mempool != nil && t.mempool.peerFilters != nil {
+		if filter, salt, err := gossip.ParseAppRequest(requestBytes); err == nil {
+			t.mempool.peerFilters.record(nodeID, filter, salt)
+		}
+	}
+	return responseBytes, nil
+}
+
+// txGossipVersions are the codec versions that txs prefixed with each version
+// byte are encoded with. Version byte i+1 is used for txGossipVersions[i].
+// Version bytes start at 1 so that unprefixed txs, which start with the zero
+// high byte of codec version 0, can still be parsed while peers upgrade.
+var txGossipVersions = []uint16{
+	txs.CodecVersion,
 }
 
+// unversionedGossip is the version label of txs that aren't prefixed with a
+// version byte.
+const unversionedGossip = "unversioned"
+
 type txParser struct {
 	parser txs.Parser
+
+	// versioned, if true, prefixes marshalled txs with the version byte of
+	// their codec version. If multiple version bytes share a codec version,
+	// the lowest is used. This should only be enabled once all peers support
+	// versioned gossip.
+	versioned bool
+	// versions are the codec versions of each version byte, as in
+	// txGossipVersions. If nil, txGossipVersions is used.
+	versions []uint16
+	// onMarshal, if non-nil, is called with the ID of every tx that is
+	// marshalled to be gossiped.
+	onMarshal func(txID ids.ID)
+	// numNonCanonical, if non-nil, counts the gossiped txs that were rejected
+	// for not being canonically encoded.
+	numNonCanonical prometheus.Counter
+}
+
+func (g *txParser) MarshalGossip(tx *txs.Tx) ([]byte, error) {
+	if g.onMarshal != nil {
+		g.onMarshal(tx.ID())
+	}
+	txBytes := tx.Bytes()
+	if !g.versioned {
+		return txBytes, nil
+	}
+
+	if len(txBytes) < codec.VersionSize {
+		return nil, fmt.Errorf("%w: %s", codec.ErrCantUnpackVersion, tx.ID())
+	}
+	codecVersion := binary.BigEndian.Uint16(txBytes)
+	i := slices.Index(g.gossipVersions(), codecVersion)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %d", errNoGossipVersion, codecVersion)
+	}
+
+	gossipBytes := make([]byte, 0, 1+len(txBytes))
+	gossipBytes = append(gossipBytes, byte(i+1))
+	return append(gossipBytes, txBytes...), nil
 }
 
-func (*txParser) MarshalGossip(tx *txs.Tx) ([]byte, error) {
-	return tx.Bytes(), nil
+// OnCachedMarshal reports [tx] to onMarshal, as it is being gossiped even
+// though it isn't being marshalled again.
+func (g *txParser) OnCachedMarshal(tx *txs.Tx) {
+	if g.onMarshal != nil {
+		g.onMarshal(tx.ID())
+	}
 }
 
-func (g *txParser) UnmarshalGossip(bytes []byte) (*txs.Tx, error) {
-	return g.parser.ParseTx(bytes)
+// UnmarshalGossip parses [gossipBytes] and rejects txs that aren't canonically
+// encoded. Txs are deduplicated by their ID, which is the hash of their bytes,
+// so equivalent txs with different encodings would otherwise be treated as
+// distinct txs.
+//
+// If [gossipBytes] is prefixed with a version byte, the tx must be encoded
+// with the codec version of that gossip version. Unprefixed txs are parsed
+// with the default codec version.
+func (g *txParser) UnmarshalGossip(gossipBytes []byte) (*txs.Tx, error) {
+	txBytes := gossipBytes
+	codecVersion := uint16(txs.CodecVersion)
+	if len(gossipBytes) > 0 && gossipBytes[0] != 0 {
+		version := gossipBytes[0]
+		versions := g.gossipVersions()
+		if int(version) > len(versions) {
+			return nil, fmt.Errorf("%w: %d", errUnknownGossipVersion, version)
+		}
+		codecVersion = versions[version-1]
+
+		txBytes = gossipBytes[1:]
+		if len(txBytes) < codec.VersionSize {
+			return nil, fmt.Errorf("%w: gossip version %d", codec.ErrCantUnpackVersion, version)
+		}
+		if txCodecVersion := binary.BigEndian.Uint16(txBytes); txCodecVersion != codecVersion {
+			return nil, fmt.Errorf("%w: gossip version %d expects codec version %d but got %d",
+				errGossipVersionMismatch,
+				version,
+				codecVersion,
+				txCodecVersion,
+			)
+		}
+	}
+
+	tx, err := g.parser.ParseTx(txBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	canonicalBytes, err := g.parser.Codec().Marshal(codecVersion, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(canonicalBytes, txBytes) {
+		if g.numNonCanonical != nil {
+			g.numNonCanonical.Inc()
+		}
+		return nil, fmt.Errorf("%w: %s", errNonCanonicalTx, tx.ID())
+	}
+	return tx, nil
+}
+
+// GossipVersion returns the version byte that [gossipBytes] is prefixed with,
+// or unversionedGossip if it isn't prefixed.
+func (*txParser) GossipVersion(gossipBytes []byte) string {
+	if len(gossipBytes) == 0 || gossipBytes[0] == 0 {
+		return unversionedGossip
+	}
+	return strconv.Itoa(int(gossipBytes[0]))
+}
+
+// gossipVersionLabels returns the versions reported by GossipVersion for the
+// txs that can be parsed.
+func (g *txParser) gossipVersionLabels() []string {
+	versions := g.gossipVersions()
+	labels := make([]string, 0, 1+len(versions))
+	labels = append(labels, unversionedGossip)
+	for i := range versions {
+		labels = append(labels, strconv.Itoa(i+1))
+	}
+	return labels
+}
+
+func (g *txParser) gossipVersions() []uint16 {
+	if g.versions != nil {
+		return g.versions
+	}
+	return txGossipVersions
+}
+
+// txDependencies returns the IDs of the txs that produced the UTXOs consumed by
+// [tx].
+func txDependencies(tx *txs.Tx) []ids.ID {
+	utxoIDs := tx.Unsigned.InputUTXOs()
+	dependencies := make([]ids.ID, len(utxoIDs))
+	for i, utxoID := range utxoIDs {
+		dependencies[i] = utxoID.TxID
+	}
+	return dependencies
 }
 
 func newGossipMempool(
@@ -74,15 +302,43 @@ func newGossipMempool(
 	minTargetElements int,
 	targetFalsePositiveProbability,
 	resetFalsePositiveProbability float64,
+	bloomChurnMultiplier int,
+	bloomOptions ...gossip.BloomFilterOption,
 ) (*gossipMempool, error) {
-	bloom, err := gossip.NewBloomFilter(registerer, "mempool_bloom_filter", minTargetElements, targetFalsePositiveProbability, resetFalsePositiveProbability)
-	return &gossipMempool{
-		Mempool:    mempool,
-		log:        log,
-		txVerifier: txVerifier,
-		parser:     parser,
-		bloom:      bloom,
-	}, err
+	if bloomChurnMultiplier < 1 {
+		return nil, fmt.Errorf("%w: %d", errInvalidBloomChurnMultiplier, bloomChurnMultiplier)
+	}
+
+	bloom, err := gossip.NewBloomFilter(registerer, "mempool_bloom_filter", minTargetElements, targetFalsePositiveProbability, resetFalsePositiveProbability, bloomOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &gossipMempool{
+		Mempool:              mempool,
+		log:                  log,
+		txVerifier:           txVerifier,
+		parser:               parser,
+		bloom:                bloom,
+		bloomElements:        minTargetElements,
+		minBloomElements:     minTargetElements,
+		bloomChurnMultiplier: bloomChurnMultiplier,
+		bloomCountMetric: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mempool_bloom_filter_elements",
+			Help: "number of txs added to the mempool bloom filter since it was last reset (n)",
+		}),
+		bloomFalsePositiveProbabilityMetric: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mempool_bloom_filter_false_positive_probability",
+			Help: "estimated false positive probability of the mempool bloom filter",
+		}),
+		tracking: make(map[ids.ID]*txTracking),
+	}
+	g.lastBloomReset = g.clock.Time()
+	err = utils.Err(
+		registerer.Register(g.bloomCountMetric),
+		registerer.Register(g.bloomFalsePositiveProbabilityMetric),
+	)
+	return g, err
 }
 
 type gossipMempool struct {
@@ -90,77 +346,1250 @@ type gossipMempool struct {
 	log        logging.Logger
 	txVerifier TxVerifier
 	parser     txs.Parser
+	clock      mockable.Clock
+
+	// verifyProjectedState, if true, verifies gossiped txs against the state
+	// the next block is projected to be built on rather than the currently
+	// accepted state.
+	verifyProjectedState bool
+
+	// spamScorer, if non-nil, scores txs before they are verified. Txs with a
+	// score above spamScoreThreshold are not added to the mempool. If
+	// spamScorer errors, the tx is only rejected if spamScorerFailClosed.
+	spamScorer           SpamScorer
+	spamScoreThreshold   float64
+	spamScorerFailClosed bool
 
+	// buildBlockRequestWindow is the duration after a build block request
+	// during which additional requests are coalesced into a single request
+	// issued at the end of the window. If it is 0, every request is issued.
+	buildBlockRequestWindow time.Duration
+	buildBlockLock          sync.Mutex
+	buildBlockTimer         *time.Timer
+	buildBlockRequested     bool
+
+	// closed is set by Close. It is checked before a tx is verified and again
+	// once it has been verified, so that adds which were in progress when the
+	// mempool was closed don't add their txs, without Close waiting for them.
+	closed utils.Atomic[bool]
+
+	// lock must not be held while acquiring the mempool lock, as the mempool
+	// lock is held while serving pull gossip, which acquires lock to look up
+	// the conflicts of the txs being served.
 	lock  sync.RWMutex
 	bloom *gossip.BloomFilter
+	// bloomElements is the number of elements that bloom is currently sized
+	// for.
+	bloomElements    int
+	minBloomElements int
+	// bloomChurnMultiplier is the number used to multiply the size of the
+	// mempool to determine how large of a bloom filter to create.
+	bloomChurnMultiplier int
+	// bloomCountMetric and bloomFalsePositiveProbabilityMetric report how
+	// saturated the bloom filter is.
+	bloomCountMetric                    prometheus.Gauge
+	bloomFalsePositiveProbabilityMetric prometheus.Gauge
+	// pullBloom, if non-nil, is sent in pull gossip requests instead of bloom,
+	// so that it can be sized independently of the filter advertised in push
+	// gossip. It holds the same txs as bloom and is reset along with it.
+	pullBloom *gossip.BloomFilter
+	// numRemovedSinceReset is the number of txs removed by RemoveTxs since the
+	// bloom filter was last reset.
+	numRemovedSinceReset int
+	// bloomRebuildLoad, if non-nil, defers rebuilding the bloom filter while
+	// it reports a load above bloomRebuildMaxLoad. bloomRebuildDeferred is
+	// true if a rebuild was deferred and hasn't been performed yet.
+	bloomRebuildLoad     RequestLoad
+	bloomRebuildMaxLoad  float64
+	bloomRebuildDeferred bool
+	// saltRotationInterval, if non-zero, is the duration after which the bloom
+	// filters are rebuilt with a new salt, even if they don't need to be
+	// reset. This bounds how long a peer that learned the salt can rely on
+	// crafted collisions to keep txs from being gossiped to us.
+	// lastBloomReset is when the bloom filters were last reset.
+	saltRotationInterval time.Duration
+	lastBloomReset       time.Time
+	// bloomRebuilding is true while the bloom filters are being rebuilt
+	// without holding lock. The txs added to the bloom filters during the
+	// rebuild are remembered in addedDuringRebuild, so that they are added to
+	// the rebuilt bloom filters before they are swapped in.
+	bloomRebuilding    bool
+	addedDuringRebuild []*txs.Tx
+
+	// trackingLock guards tracking. No other locks are acquired while it is
+	// held, so it can be acquired while iterating over the mempool, such as
+	// when txs are marshalled or filtered by age to serve pull gossip.
+	trackingLock sync.Mutex
+	tracking     map[ids.ID]*txTracking
+
+	// conflictSets, if non-nil, remembers txs that weren't added to the
+	// mempool because they conflict with another tx.
+	conflictSets *conflictSets
+
+	// marshalCache, if non-nil, caches the marshalled bytes of txs served to
+	// pull gossip requests. Txs are evicted from it once they are removed.
+	marshalCache *gossip.MarshalCache
+
+	// peerDrops, if non-nil, records why txs received from each peer were
+	// dropped.
+	peerDrops *peerDropTracker
+	// peerLRU, if non-nil, also bounds the peers tracked by peerDrops.
+	peerLRU *gossip.PeerLRU
+
+	// deferred, if non-nil, queues txs that failed verification with a
+	// retryable error to be verified again once the state changes.
+	deferred *deferredTxs
+
+	// dropReasons, if non-nil, remembers why txs were dropped in addition to
+	// the small number of recently dropped txs remembered by the mempool.
+	dropReasons *cache.LRU[ids.ID, error]
+	// dropTimes, if non-nil, records when dropped txs were dropped, so that
+	// they are verified again once dropReasonTTL has passed.
+	dropTimes     *cache.LRU[ids.ID, time.Time]
+	dropReasonTTL time.Duration
+	// maxTxSize, if non-zero, is the size of the largest tx that is added to
+	// the mempool.
+	maxTxSize int
+	// txFates, if non-nil, tracks the eventual fate of txs received from
+	// peers.
+	txFates *txFateTracker
+	// recentlyAccepted, if non-nil, remembers recently accepted txs to report
+	// their status hints.
+	recentlyAccepted *cache.LRU[ids.ID, struct{}]
+
+	// verificationMonitor, if non-nil, warns if most locally issued txs fail
+	// verification.
+	verificationMonitor *verificationMonitor
+
+	// bloomResetMonitor, if non-nil, records when the bloom filter is reset to
+	// report if it is reset too frequently.
+	bloomResetMonitor *bloomResetMonitor
+
+	// onRejected, if non-nil, is called with every tx received from a peer
+	// that fails verification.
+	onRejected func(*txs.Tx)
+
+	// onAdd, if non-nil, is called with every tx once it is added to the
+	// mempool. It is only given the tx, so that it can't re-enter the
+	// mempool while a tx is being added.
+	onAdd func(*txs.Tx)
+
+	// peerFilters, if non-nil, remembers the bloom filters recently sent by
+	// peers in pull gossip requests.
+	peerFilters *peerFilters
+
+	// numAdded and numDropped are the lifetime number of txs that were added
+	// to the mempool and marked as dropped by this gossipMempool.
+	numAdded   uint64
+	numDropped uint64
+}
+
+// txTracking records the gossip history of a tx that was added to the mempool.
+type txTracking struct {
+	addedTime      time.Time
+	gossipAttempts int
 }
 
-// Add is called by the p2p SDK when handling transactions that were pushed to
-// us and when handling transactions that were pulled from a peer. If this
-// returns a nil error while handling push gossip, the p2p SDK will queue the
-// transaction to push gossip as well.
+// Add attempts to add a tx that was issued locally to the mempool.
 func (g *gossipMempool) Add(tx *txs.Tx) error {
+	return g.AddFromPeer(ids.EmptyNodeID, tx)
+}
+
+// AddFromPeer is called by the p2p SDK when handling transactions that were
+// pushed to us and when handling transactions that were pulled from a peer. If
+// this returns a nil error while handling push gossip, the p2p SDK will queue
+// the transaction to push gossip as well.
+func (g *gossipMempool) AddFromPeer(nodeID ids.NodeID, tx *txs.Tx) error {
+	if g.isClosed() {
+		return closedError(tx)
+	}
+
+	if err := g.checkTx(nodeID, tx, false); err != nil {
+		return err
+	}
+	if err := g.addVerifiedTx(nodeID, tx, g.verifyTx(tx)); err != nil {
+		return err
+	}
+	g.requestBuildBlock()
+	return nil
+}
+
+// AddBatchFromPeer is called by the p2p SDK when handling transactions that
+// were pushed to us in a single message. The transactions are verified
+// against a single snapshot of the preferred state, rather than looking up the
+// preferred state for each transaction. A block is only requested to be built
+// once the whole batch is added.
+func (g *gossipMempool) AddBatchFromPeer(nodeID ids.NodeID, batch []*txs.Tx) []error {
+	errs := make([]error, len(batch))
+	if g.isClosed() {
+		for i, tx := range batch {
+			errs[i] = closedError(tx)
+		}
+		return errs
+	}
+
+	var (
+		toVerify = make([]*txs.Tx, 0, len(batch))
+		indices  = make([]int, 0, len(batch))
+	)
+	for i, tx := range batch {
+		errs[i] = g.checkTx(nodeID, tx, false)
+		if errs[i] == nil {
+			toVerify = append(toVerify, tx)
+			indices = append(indices, i)
+		}
+	}
+	added := false
+	for j, verifyErr := range g.verifyTxs(toVerify) {
+		i := indices[j]
+		errs[i] = g.addVerifiedTx(nodeID, batch[i], verifyErr)
+		added = added || errs[i] == nil
+	}
+	if added {
+		g.requestBuildBlock()
+	}
+	return errs
+}
+
+// WouldAccept returns the error that Add would return for [tx], without adding
+// [tx] to the mempool, marking it as dropped or otherwise modifying the state of
+// the gossip mempool. [tx] is verified against the same preferred state as Add
+// would verify it against.
+//
+// Txs that would be rejected by the mempool itself, such as txs that conflict
+// with txs in the mempool or that don't fit in a full mempool, are not
+// detected.
+func (g *gossipMempool) WouldAccept(tx *txs.Tx) error {
+	if err := g.checkTx(ids.EmptyNodeID, tx, true); err != nil {
+		return err
+	}
+	if err := g.verifyTx(tx); err != nil {
+		failure := AddTxInvalid
+		if errors.Is(err, ErrRetryable) {
+			failure = AddTxDeferred
+		}
+		return &AddTxError{
+			TxID:    tx.ID(),
+			Failure: failure,
+			Err:     err,
+		}
+	}
+	if err := g.checkTxSize(tx); err != nil {
+		return &AddTxError{
+			TxID:    tx.ID(),
+			Failure: AddTxRejected,
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// checkTx returns an error if [tx], which was received from [nodeID], should
+// not be verified. If [dryRun] is true, the time that dropped txs were first
+// seen is not recorded.
+func (g *gossipMempool) checkTx(nodeID ids.NodeID, tx *txs.Tx, dryRun bool) error {
 	txID := tx.ID()
-	if _, ok := g.Mempool.Get(txID); ok {
-		return fmt.Errorf("attempted to issue %w: %s ", mempool.ErrDuplicateTx, txID)
+	if g.Mempool.Contains(txID) {
+		return &AddTxError{
+			TxID:    txID,
+			Failure: AddTxDuplicate,
+			Err:     mempool.ErrDuplicateTx,
+		}
 	}
 
-	if reason := g.Mempool.GetDropReason(txID); reason != nil {
-		// If the tx is being dropped - just ignore it
-		//
-		// TODO: Should we allow re-verification of the transaction even if it
-		// failed previously?
-		return reason
+	// If the tx was dropped, ignore it until it may have become valid.
+	if reason := g.getDropReason(txID); reason != nil && !g.canReverify(txID, dryRun) {
+		return &AddTxError{
+			TxID:    txID,
+			Failure: AddTxDropped,
+			Err:     reason,
+		}
 	}
 
-	// Verify the tx at the currently preferred state
-	if err := g.txVerifier.VerifyTx(tx); err != nil {
-		g.Mempool.MarkDropped(txID, err)
+	// Spam is not marked as dropped, as the score of a tx may change.
+	if err := g.checkSpam(nodeID, tx); err != nil {
+		return &AddTxError{
+			TxID:    txID,
+			Failure: AddTxSpam,
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// addVerifiedTx adds [tx], which was received from [nodeID], to the mempool
+// if it passed verification with [verifyErr]. The caller is responsible for
+// requesting a block to be built if the tx is added.
+func (g *gossipMempool) addVerifiedTx(nodeID ids.NodeID, tx *txs.Tx, verifyErr error) error {
+	// Verification may have been waiting on the engine lock while the mempool
+	// was being closed.
+	if g.isClosed() {
+		return closedError(tx)
+	}
+
+	txID := tx.ID()
+	// Retryable failures don't indicate that the tx is invalid, so the tx
+	// isn't dropped and isn't observed as failing verification.
+	if errors.Is(verifyErr, ErrRetryable) {
+		if g.deferred != nil {
+			g.deferred.push(nodeID, tx)
+		}
+		return &AddTxError{
+			TxID:    txID,
+			Failure: AddTxDeferred,
+			Err:     verifyErr,
+		}
+	}
+
+	// Peers can send arbitrarily many invalid txs, so only the verification
+	// of txs issued locally indicates whether this node can verify txs.
+	if g.verificationMonitor != nil && nodeID == ids.EmptyNodeID {
+		g.verificationMonitor.observe(verifyErr)
+	}
+	if verifyErr != nil {
+		g.markDropped(txID, verifyErr)
+		g.markPeerDropped(nodeID, txID, verifyErr)
+		// Only txs received from peers are likely to be gossiped by other
+		// peers.
+		if g.onRejected != nil && nodeID != ids.EmptyNodeID {
+			g.onRejected(tx)
+		}
+		return &AddTxError{
+			TxID:    txID,
+			Failure: AddTxInvalid,
+			Err:     verifyErr,
+		}
+	}
+
+	err := g.addWithoutVerification(tx)
+	if err != nil && !errors.Is(err, mempool.ErrDuplicateTx) {
+		g.markPeerDropped(nodeID, txID, err)
+	}
+	if err == nil && g.txFates != nil && nodeID != ids.EmptyNodeID {
+		g.txFates.track(txID)
+	}
+	return err
+}
+
+// applyRejectionHint re-verifies [tx], which a peer reported as having failed
+// verification. If [tx] fails verification, it is removed from the mempool and
+// marked as dropped so that it isn't gossiped. Otherwise, the hint is ignored
+// and errFalseRejectionHint is returned.
+func (g *gossipMempool) applyRejectionHint(tx *txs.Tx) error {
+	txID := tx.ID()
+	if g.getDropReason(txID) != nil {
+		return nil
+	}
+
+	verifyErr := g.verifyTx(tx)
+	if verifyErr == nil {
+		return errFalseRejectionHint
+	}
+
+	if err := g.RemoveTxs(txID); err != nil {
 		return err
 	}
+	g.markDropped(txID, verifyErr)
+	return nil
+}
+
+// markPeerDropped records that [txID], received from [nodeID], was dropped for
+// [reason] if drops are being tracked per peer. Txs issued locally are not
+// tracked.
+func (g *gossipMempool) markPeerDropped(nodeID ids.NodeID, txID ids.ID, reason error) {
+	if g.peerDrops == nil || nodeID == ids.EmptyNodeID {
+		return
+	}
+
+	if g.peerLRU != nil {
+		g.peerLRU.Touch(nodeID)
+	}
 
-	return g.AddWithoutVerification(tx)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.peerDrops.markDropped(nodeID, txID, reason)
+}
+
+// forgetPeerDrops stops tracking the txs received from [nodeID] that were
+// dropped.
+func (g *gossipMempool) forgetPeerDrops(nodeID ids.NodeID) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.peerDrops.forget(nodeID)
+}
+
+// PeerDrops returns the number of recent txs received from [nodeID] that were
+// dropped, and how many of those conflicted with another tx. This can be used
+// to detect peers that repeatedly send conflicting txs.
+func (g *gossipMempool) PeerDrops(nodeID ids.NodeID) (numDropped int, numConflicting int) {
+	if g.peerDrops == nil {
+		return 0, 0
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.peerDrops.numDropped(nodeID)
+}
+
+// checkSpam returns ErrLikelySpam if the spamScorer scores [tx], which was
+// received from [nodeID], above the spamScoreThreshold.
+func (g *gossipMempool) checkSpam(nodeID ids.NodeID, tx *txs.Tx) error {
+	if g.spamScorer == nil {
+		return nil
+	}
+
+	score, err := g.spamScorer(tx, nodeID)
+	if err != nil {
+		if g.spamScorerFailClosed {
+			return fmt.Errorf("%w: failed to score tx: %w", ErrLikelySpam, err)
+		}
+		g.log.Debug("failed to score tx",
+			zap.Stringer("txID", tx.ID()),
+			zap.Stringer("nodeID", nodeID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if score > g.spamScoreThreshold {
+		return fmt.Errorf("%w: score %f exceeds threshold %f", ErrLikelySpam, score, g.spamScoreThreshold)
+	}
+	return nil
+}
+
+func (g *gossipMempool) verifyTx(tx *txs.Tx) error {
+	if g.verifyProjectedState {
+		return g.txVerifier.VerifyProjectedTx(tx)
+	}
+
+	// Verify the tx at the currently preferred state
+	return g.txVerifier.VerifyTx(tx)
+}
+
+// verifyTxs verifies each of [batch] as verifyTx would. The returned errors
+// are aligned with [batch].
+func (g *gossipMempool) verifyTxs(batch []*txs.Tx) []error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if !g.verifyProjectedState {
+		return g.txVerifier.VerifyTxs(batch)
+	}
+
+	errs := make([]error, len(batch))
+	for i, tx := range batch {
+		errs[i] = g.txVerifier.VerifyProjectedTx(tx)
+	}
+	return errs
 }
 
 func (g *gossipMempool) Has(txID ids.ID) bool {
-	_, ok := g.Mempool.Get(txID)
-	return ok
+	return g.Mempool.Contains(txID)
 }
 
 func (g *gossipMempool) AddWithoutVerification(tx *txs.Tx) error {
+	if g.isClosed() {
+		return closedError(tx)
+	}
+
+	if err := g.addWithoutVerification(tx); err != nil {
+		return err
+	}
+	g.requestBuildBlock()
+	return nil
+}
+
+// addWithoutVerification adds [tx] to the mempool without requesting a block
+// to be built, so that a single request can be made for many txs.
+func (g *gossipMempool) addWithoutVerification(tx *txs.Tx) error {
+	if err := g.checkTxSize(tx); err != nil {
+		g.markDropped(tx.ID(), err)
+		return &AddTxError{
+			TxID:    tx.ID(),
+			Failure: AddTxRejected,
+			Err:     err,
+		}
+	}
+
 	if err := g.Mempool.Add(tx); err != nil {
-		g.Mempool.MarkDropped(tx.ID(), err)
+		if errors.Is(err, mempool.ErrConflictsWithOtherTx) {
+			g.addConflict(tx)
+		}
+		// The tx may have been added concurrently since it was checked for in
+		// the mempool. Marking it as dropped would poison a valid tx.
+		if errors.Is(err, mempool.ErrDuplicateTx) {
+			return &AddTxError{
+				TxID:    tx.ID(),
+				Failure: AddTxDuplicate,
+				Err:     err,
+			}
+		}
+		g.markDropped(tx.ID(), err)
+		return &AddTxError{
+			TxID:    tx.ID(),
+			Failure: AddTxRejected,
+			Err:     err,
+		}
+	}
+
+	if g.dropReasons != nil {
+		g.dropReasons.Evict(tx.ID())
+	}
+	if g.deferred != nil {
+		g.deferred.remove(tx.ID())
+	}
+
+	g.trackingLock.Lock()
+	g.tracking[tx.ID()] = &txTracking{
+		addedTime: g.clock.Time(),
+	}
+	g.trackingLock.Unlock()
+
+	g.lock.Lock()
+	g.numAdded++
+
+	g.bloom.Add(tx)
+	if g.pullBloom != nil {
+		g.pullBloom.Add(tx)
+	}
+	if g.bloomRebuilding {
+		g.addedDuringRebuild = append(g.addedDuringRebuild, tx)
+	}
+	g.updateBloomMetrics()
+	g.lock.Unlock()
+
+	if g.onAdd != nil {
+		g.onAdd(tx)
+	}
+	return g.rebuildBloomFilterIfNeeded()
+}
+
+// setPullBloomFilter sends a bloom filter sized for [minTargetElements] with
+// the provided false positive probabilities in pull gossip requests, rather
+// than the bloom filter advertised in push gossip.
+func (g *gossipMempool) setPullBloomFilter(
+	registerer prometheus.Registerer,
+	minTargetElements int,
+	targetFalsePositiveProbability,
+	resetFalsePositiveProbability float64,
+	bloomOptions ...gossip.BloomFilterOption,
+) error {
+	pullBloom, err := gossip.NewBloomFilter(registerer, "mempool_pull_bloom_filter", minTargetElements, targetFalsePositiveProbability, resetFalsePositiveProbability, bloomOptions...)
+	if err != nil {
 		return err
 	}
 
+	// This is only called before the mempool is used, so no txs can be added
+	// between populating the bloom filter and setting it.
+	g.Mempool.Iterate(func(tx *txs.Tx) bool {
+		pullBloom.Add(tx)
+		return true
+	})
+
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
-	g.bloom.Add(tx)
-	reset, err := gossip.ResetBloomFilterIfNeeded(g.bloom, g.Mempool.Len()*bloomChurnMultiplier)
+	g.pullBloom = pullBloom
+	return nil
+}
+
+// checkTxSize returns an error wrapping mempool.ErrTxTooLarge if [tx] is
+// larger than the max tx size.
+func (g *gossipMempool) checkTxSize(tx *txs.Tx) error {
+	if txSize := len(tx.Bytes()); g.maxTxSize > 0 && txSize > g.maxTxSize {
+		return fmt.Errorf("%w: %s size (%d) > max size (%d)",
+			mempool.ErrTxTooLarge,
+			tx.ID(),
+			txSize,
+			g.maxTxSize,
+		)
+	}
+	return nil
+}
+
+// addConflict remembers [tx], which conflicts with a tx in the mempool, if
+// conflicts are being tracked.
+func (g *gossipMempool) addConflict(tx *txs.Tx) {
+	if g.conflictSets == nil {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.conflictSets.add(tx)
+}
+
+// Conflicts returns the remembered txs that conflict with [tx]. If conflicts
+// are not being tracked, nil is returned.
+func (g *gossipMempool) Conflicts(tx *txs.Tx) []*txs.Tx {
+	if g.conflictSets == nil {
+		return nil
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.conflictSets.conflicts(tx)
+}
+
+// MarkAccepted records that the txs with [txIDs] were accepted, if status
+// hints are being reported or the fates of txs are being tracked.
+func (g *gossipMempool) MarkAccepted(txIDs ...ids.ID) {
+	if g.txFates != nil {
+		g.txFates.accepted(txIDs...)
+	}
+	if g.recentlyAccepted == nil {
+		return
+	}
+
+	for _, txID := range txIDs {
+		g.recentlyAccepted.Put(txID, struct{}{})
+	}
+}
+
+// StatusHint returns the status of [tx] as known by this node. Txs that were
+// recently marked as accepted are accepted and other txs in the mempool are
+// pending.
+//
+// Txs are usually removed from the mempool once their block is verified.
+// However, a tx can be re-added while its block is processing, such as if a
+// peer gossips it while a different block is preferred, and a block marks its
+// txs as accepted before removing them from the mempool. Until then, the tx
+// is still served to pull gossip requests.
+func (g *gossipMempool) StatusHint(tx *txs.Tx) gossip.Status {
+	txID := tx.ID()
+	if g.recentlyAccepted != nil {
+		if _, ok := g.recentlyAccepted.Get(txID); ok {
+			return gossip.StatusAccepted
+		}
+	}
+	if g.Has(txID) {
+		return gossip.StatusPending
+	}
+	return gossip.StatusUnknown
+}
+
+// RemoveTxs removes the txs with [txIDs], and any txs that conflict with them,
+// from the mempool. If enough txs have been removed since the bloom filter was
+// last reset, the bloom filter is rebuilt so that it stops reporting the
+// removed txs.
+func (g *gossipMempool) RemoveTxs(txIDs ...ids.ID) error {
+	removed := make([]*txs.Tx, 0, len(txIDs))
+	for _, txID := range txIDs {
+		if tx, ok := g.Mempool.Get(txID); ok {
+			removed = append(removed, tx)
+		}
+	}
+	return g.remove(removed)
+}
+
+// Remove removes [txs], and any txs that conflict with them, from the mempool,
+// such as once they are included in an accepted block. Like RemoveTxs, the
+// removals are accounted for so that the bloom filter is rebuilt once enough
+// of its elements are stale.
+//
+// Removing txs indicates that the state has changed, so deferred txs are
+// retried before the next round of push gossip.
+func (g *gossipMempool) Remove(txs ...*txs.Tx) {
+	if g.deferred != nil {
+		g.deferred.stateChanged()
+	}
+	if err := g.remove(txs); err != nil {
+		g.log.Error("failed to rebuild bloom filter",
+			zap.Error(err),
+		)
+	}
+}
+
+// remove removes [txs], and any txs that conflict with them, from the mempool
+// and rebuilds the bloom filter if enough txs have been removed since it was
+// last reset.
+func (g *gossipMempool) remove(txs []*txs.Tx) error {
+	// Txs that weren't in the mempool aren't in the bloom filter, so only the
+	// txs that were actually removed are accounted for. Txs added
+	// concurrently can only cause removals to be undercounted.
+	numBefore := g.Mempool.Len()
+	g.Mempool.Remove(txs...)
+	numRemoved := max(numBefore-g.Mempool.Len(), 0)
+
+	g.trackingLock.Lock()
+	for _, tx := range txs {
+		delete(g.tracking, tx.ID())
+	}
+	g.trackingLock.Unlock()
+
+	if g.marshalCache != nil {
+		for _, tx := range txs {
+			g.marshalCache.Evict(tx.ID())
+		}
+	}
+
+	g.lock.Lock()
+	g.numRemovedSinceReset += numRemoved
+	g.lock.Unlock()
+
+	return g.rebuildBloomFilterIfNeeded()
+}
+
+// bloomRebuild describes a rebuild of the bloom filters that is in progress.
+type bloomRebuild struct {
+	targetElements int
+	// numRemoved is the number of removals accounted for by the rebuild.
+	numRemoved int
+}
+
+// rebuildBloomFilterIfNeeded rebuilds the bloom filter if its false positive
+// probability is too high or if enough txs have been removed since it was last
+// reset. If the request load is too high, the rebuild is deferred until a
+// later call.
+//
+// The bloom filters are repopulated without holding [g.lock], so that txs can
+// be added to the mempool during the rebuild.
+//
+// Assumes [g.lock] is not held.
+func (g *gossipMempool) rebuildBloomFilterIfNeeded() error {
+	numTxs := g.Mempool.Len()
+	g.lock.Lock()
+	rebuild, ok := g.startBloomRebuildIfNeeded(numTxs)
+	g.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	return g.rebuildBloomFilter(rebuild)
+}
+
+// startBloomRebuildIfNeeded returns the rebuild to perform if the bloom
+// filters should be rebuilt. Only a single rebuild is performed at a time.
+// [numTxs] is the number of txs in the mempool.
+//
+// Assumes [g.lock] is held.
+func (g *gossipMempool) startBloomRebuildIfNeeded(numTxs int) (bloomRebuild, bool) {
+	if g.bloomRebuilding {
+		return bloomRebuild{}, false
+	}
+
+	removedTooMany := g.numRemovedSinceReset*bloomRebuildDivisor >= g.bloomElements
+	needsReset := g.bloom.NeedsReset() || (g.pullBloom != nil && g.pullBloom.NeedsReset())
+	saltExpired := g.saltExpired()
+	if !removedTooMany && !needsReset && !saltExpired {
+		return bloomRebuild{}, false
+	}
+
+	if g.bloomRebuildLoad != nil {
+		if load := g.bloomRebuildLoad(); load > g.bloomRebuildMaxLoad {
+			if !g.bloomRebuildDeferred {
+				g.log.Debug("deferring bloom filter rebuild",
+					zap.Float64("load", load),
+					zap.Float64("maxLoad", g.bloomRebuildMaxLoad),
+				)
+			}
+			g.bloomRebuildDeferred = true
+			return bloomRebuild{}, false
+		}
+	}
+	g.bloomRebuildDeferred = false
+
+	targetElements := g.bloomTargetElements(numTxs)
+	if removedTooMany {
+		g.log.Debug("rebuilding bloom filter",
+			zap.Int("numRemoved", g.numRemovedSinceReset),
+			zap.Int("previousElements", g.bloomElements),
+			zap.Int("targetElements", targetElements),
+		)
+	} else if needsReset {
+		g.log.Debug("resetting bloom filter",
+			zap.Int("previousElements", g.bloomElements),
+			zap.Int("targetElements", targetElements),
+		)
+	} else {
+		g.log.Debug("rotating bloom filter salt",
+			zap.Duration("age", g.clock.Time().Sub(g.lastBloomReset)),
+			zap.Int("targetElements", targetElements),
+		)
+	}
+	// Only a false positive probability that is too high indicates that the
+	// bloom filter is undersized. Rebuilding after removals and rotating the
+	// salt are expected.
+	if g.bloomResetMonitor != nil && needsReset {
+		g.bloomResetMonitor.observe()
+	}
+
+	g.bloomRebuilding = true
+	return bloomRebuild{
+		targetElements: targetElements,
+		numRemoved:     g.numRemovedSinceReset,
+	}, true
+}
+
+// rebuildBloomFilter populates new bloom filters, sized for the target
+// elements of [rebuild], with the txs in the mempool and swaps them in. The
+// txs added to the bloom filters while they were being populated are added
+// before they are swapped in, so that no txs in the mempool are missing from
+// the swapped in bloom filters.
+//
+// Assumes [g.lock] is not held.
+func (g *gossipMempool) rebuildBloomFilter(rebuild bloomRebuild) error {
+	// The bloom filters hold the same txs, so they are reset together.
+	resets, err := g.prepareBloomFilterResets(rebuild.targetElements)
 	if err != nil {
+		g.lock.Lock()
+		g.bloomRebuilding = false
+		g.addedDuringRebuild = nil
+		g.lock.Unlock()
 		return err
 	}
 
-	if reset {
-		g.log.Debug("resetting bloom filter")
-		g.Mempool.Iterate(func(tx *txs.Tx) bool {
-			g.bloom.Add(tx)
-			return true
-		})
+	// The snapshot is populated without holding the mempool lock either, so
+	// that txs can be added to the mempool during the rebuild.
+	inMempool := set.NewSet[ids.ID](g.Mempool.Len())
+	g.IterateSnapshot(func(tx *txs.Tx) bool {
+		for _, reset := range resets {
+			reset.Add(tx)
+		}
+		inMempool.Add(tx.ID())
+		return true
+	})
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, tx := range g.addedDuringRebuild {
+		for _, reset := range resets {
+			reset.Add(tx)
+		}
+		inMempool.Add(tx.ID())
+	}
+	gossip.ApplyBloomFilterReset(g.bloom, resets[0])
+	if g.pullBloom != nil {
+		gossip.ApplyBloomFilterReset(g.pullBloom, resets[1])
 	}
 
-	g.Mempool.RequestBuildBlock()
+	g.bloomElements = rebuild.targetElements
+	g.lastBloomReset = g.clock.Time()
+	// Txs removed during the rebuild may still be in the bloom filters.
+	g.numRemovedSinceReset = max(g.numRemovedSinceReset-rebuild.numRemoved, 0)
+	g.bloomRebuilding = false
+	g.addedDuringRebuild = nil
+
+	// Drop the tracking of any txs that are no longer in the mempool.
+	g.trackingLock.Lock()
+	for txID := range g.tracking {
+		if !inMempool.Contains(txID) {
+			delete(g.tracking, txID)
+		}
+	}
+	g.trackingLock.Unlock()
+	g.updateBloomMetrics()
 	return nil
 }
 
+// prepareBloomFilterResets returns the resets of the bloom filter and, if it is
+// set, of the pull bloom filter.
+func (g *gossipMempool) prepareBloomFilterResets(targetElements int) ([]*gossip.BloomFilterReset, error) {
+	reset, err := gossip.PrepareBloomFilterReset(g.bloom, targetElements)
+	if err != nil {
+		return nil, err
+	}
+	resets := []*gossip.BloomFilterReset{reset}
+	if g.pullBloom != nil {
+		pullReset, err := gossip.PrepareBloomFilterReset(g.pullBloom, targetElements)
+		if err != nil {
+			return nil, err
+		}
+		resets = append(resets, pullReset)
+	}
+	return resets, nil
+}
+
+// saltExpired returns true if the bloom filters should be rebuilt with a new
+// salt.
+//
+// Assumes [g.lock] is held.
+func (g *gossipMempool) saltExpired() bool {
+	return g.saltRotationInterval > 0 && g.clock.Time().Sub(g.lastBloomReset) >= g.saltRotationInterval
+}
+
+// updateBloomMetrics reports the saturation of the bloom filter.
+//
+// Assumes [g.lock] is held.
+func (g *gossipMempool) updateBloomMetrics() {
+	g.bloomCountMetric.Set(float64(g.bloom.Count()))
+	g.bloomFalsePositiveProbabilityMetric.Set(g.bloom.FalsePositiveProbability())
+}
+
+// bloomTargetElements returns the number of elements the bloom filter should
+// be sized for if it is reset, given that there are [numTxs] txs in the
+// mempool. The bloom filter grows as soon as the mempool
+// requires more elements than it is sized for, but it only shrinks once the
+// mempool requires less than 1/bloomShrinkDivisor of the elements it is sized
+// for.
+//
+// Assumes [g.lock] is held.
+func (g *gossipMempool) bloomTargetElements(numTxs int) int {
+	targetElements := max(g.minBloomElements, numTxs*g.bloomChurnMultiplier)
+	if targetElements < g.bloomElements && targetElements*bloomShrinkDivisor >= g.bloomElements {
+		return g.bloomElements
+	}
+	return targetElements
+}
+
+// Close stops txs from being added to the mempool. Close doesn't wait for adds
+// that are in progress, as they may be waiting on the engine lock held by the
+// caller; those adds, and any subsequent adds, return ErrMempoolClosed.
+func (g *gossipMempool) Close() {
+	g.closed.Set(true)
+
+	// Build block requests coalesced during the current window are dropped.
+	g.buildBlockLock.Lock()
+	defer g.buildBlockLock.Unlock()
+
+	if g.buildBlockTimer != nil {
+		g.buildBlockTimer.Stop()
+		g.buildBlockTimer = nil
+	}
+	g.buildBlockRequested = false
+}
+
+func (g *gossipMempool) isClosed() bool {
+	return g.closed.Get()
+}
+
+func closedError(tx *txs.Tx) error {
+	return &AddTxError{
+		TxID:    tx.ID(),
+		Failure: AddTxRejected,
+		Err:     ErrMempoolClosed,
+	}
+}
+
+// requestBuildBlock notifies the consensus engine that a block should be
+// built, coalescing any requests made during the buildBlockRequestWindow.
+func (g *gossipMempool) requestBuildBlock() {
+	if g.buildBlockRequestWindow <= 0 {
+		g.Mempool.RequestBuildBlock()
+		return
+	}
+
+	g.buildBlockLock.Lock()
+	defer g.buildBlockLock.Unlock()
+
+	if g.buildBlockTimer != nil {
+		g.buildBlockRequested = true
+		return
+	}
+
+	g.Mempool.RequestBuildBlock()
+	g.buildBlockTimer = time.AfterFunc(g.buildBlockRequestWindow, g.flushBuildBlockRequest)
+}
+
+// flushBuildBlockRequest closes the current buildBlockRequestWindow and issues
+// a build block request if any were coalesced during the window.
+func (g *gossipMempool) flushBuildBlockRequest() {
+	g.buildBlockLock.Lock()
+	defer g.buildBlockLock.Unlock()
+
+	g.buildBlockTimer = nil
+	if g.buildBlockRequested {
+		g.buildBlockRequested = false
+		g.Mempool.RequestBuildBlock()
+	}
+}
+
+func (g *gossipMempool) markDropped(txID ids.ID, reason error) {
+	g.Mempool.MarkDropped(txID, reason)
+	if g.dropReasons != nil {
+		g.dropReasons.Put(txID, reason)
+	}
+	if g.txFates != nil {
+		g.txFates.rejected(txID)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.numDropped++
+	if g.dropTimes != nil {
+		g.dropTimes.Put(txID, g.clock.Time())
+	}
+}
+
+// getDropReason returns the reason that the tx with [txID] was dropped, or nil
+// if it isn't known to have been dropped.
+func (g *gossipMempool) getDropReason(txID ids.ID) error {
+	if reason := g.Mempool.GetDropReason(txID); reason != nil {
+		return reason
+	}
+	if g.dropReasons == nil {
+		return nil
+	}
+	reason, _ := g.dropReasons.Get(txID)
+	return reason
+}
+
+// clearDropReasons forgets why all txs were dropped, so that they are verified
+// again when they are next received.
+func (g *gossipMempool) clearDropReasons() {
+	g.Mempool.ClearDropReasons()
+	if g.dropReasons != nil {
+		g.dropReasons.Flush()
+	}
+}
+
+// canReverify returns true if [txID], which is marked as dropped, was dropped
+// at least dropReasonTTL ago, so that it should be verified again in case the
+// preferred state changed to make it valid. Txs that weren't dropped by the
+// gossip mempool, such as txs dropped while building blocks, are considered
+// to be dropped once this is first called for them. If [dryRun] is true, the
+// drop times are only read.
+func (g *gossipMempool) canReverify(txID ids.ID, dryRun bool) bool {
+	if g.dropTimes == nil {
+		return false
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := g.clock.Time()
+	droppedTime, ok := g.dropTimes.Get(txID)
+	if !ok {
+		if !dryRun {
+			g.dropTimes.Put(txID, now)
+		}
+		return false
+	}
+	if now.Sub(droppedTime) < g.dropReasonTTL {
+		return false
+	}
+
+	if !dryRun {
+		g.dropTimes.Evict(txID)
+	}
+	return true
+}
+
 func (g *gossipMempool) Iterate(f func(*txs.Tx) bool) {
 	g.Mempool.Iterate(f)
 }
 
+// IterateSnapshot iterates over the txs that were in the mempool when it was
+// called until [f] returns false. Unlike Iterate, [f] is called without holding
+// the mempool lock, so txs can be added and removed while iterating. Txs that
+// are added after the snapshot is taken aren't iterated over, and txs that are
+// removed after the snapshot is taken are still iterated over. The txs
+// themselves are shared with the mempool and must not be modified.
+func (g *gossipMempool) IterateSnapshot(f func(*txs.Tx) bool) {
+	snapshot := make([]*txs.Tx, 0, g.Mempool.Len())
+	g.Mempool.Iterate(func(tx *txs.Tx) bool {
+		snapshot = append(snapshot, tx)
+		return true
+	})
+
+	for _, tx := range snapshot {
+		if !f(tx) {
+			return
+		}
+	}
+}
+
+// MarkGossiped records that an attempt was made to gossip [txID].
+func (g *gossipMempool) MarkGossiped(txID ids.ID) {
+	g.trackingLock.Lock()
+	defer g.trackingLock.Unlock()
+
+	if tracking, ok := g.tracking[txID]; ok {
+		tracking.gossipAttempts++
+	}
+}
+
+// AddedTime returns when [tx] was added to the mempool, or false if it isn't in
+// the mempool.
+func (g *gossipMempool) AddedTime(tx *txs.Tx) (time.Time, bool) {
+	g.trackingLock.Lock()
+	defer g.trackingLock.Unlock()
+
+	tracking, ok := g.tracking[tx.ID()]
+	if !ok {
+		return time.Time{}, false
+	}
+	return tracking.addedTime, true
+}
+
+// pruneOlderThan removes the txs that were added to the mempool more than
+// [maxAge] ago, as they are unlikely to ever be accepted. The removed txs are
+// marked as dropped, so that they aren't added again when they are gossiped
+// back to us.
+func (g *gossipMempool) pruneOlderThan(maxAge time.Duration) error {
+	minAddedTime := g.clock.Time().Add(-maxAge)
+
+	var expiredTxIDs []ids.ID
+	g.trackingLock.Lock()
+	for txID, tracking := range g.tracking {
+		if tracking.addedTime.Before(minAddedTime) {
+			expiredTxIDs = append(expiredTxIDs, txID)
+		}
+	}
+	g.trackingLock.Unlock()
+
+	if len(expiredTxIDs) == 0 {
+		return nil
+	}
+	if err := g.RemoveTxs(expiredTxIDs...); err != nil {
+		return err
+	}
+	for _, txID := range expiredTxIDs {
+		g.markDropped(txID, errTxTooOld)
+	}
+	g.log.Debug("pruned txs from the mempool",
+		zap.Int("numPruned", len(expiredTxIDs)),
+		zap.Duration("maxAge", maxAge),
+	)
+	return nil
+}
+
+// pruningGossiper prunes the txs that exceeded the max tx age from the mempool
+// before each gossip round.
+type pruningGossiper struct {
+	gossip.Gossiper
+
+	mempool *gossipMempool
+	maxAge  time.Duration
+}
+
+func (p *pruningGossiper) Gossip(ctx context.Context) error {
+	if err := p.mempool.pruneOlderThan(p.maxAge); err != nil {
+		return err
+	}
+	return p.Gossiper.Gossip(ctx)
+}
+
+// StuckTxs returns the IDs of the txs in the mempool that were added at least
+// [minAge] ago and have been gossiped at least stuckTxMinGossipAttempts times,
+// without being removed from the mempool.
+func (g *gossipMempool) StuckTxs(minAge time.Duration) []ids.ID {
+	txIDs := make([]ids.ID, 0, g.Mempool.Len())
+	g.Mempool.Iterate(func(tx *txs.Tx) bool {
+		txIDs = append(txIDs, tx.ID())
+		return true
+	})
+
+	g.trackingLock.Lock()
+	defer g.trackingLock.Unlock()
+
+	var (
+		maxAddedTime = g.clock.Time().Add(-minAge)
+		stuckTxIDs   []ids.ID
+	)
+	for _, txID := range txIDs {
+		tracking, ok := g.tracking[txID]
+		if ok &&
+			!tracking.addedTime.After(maxAddedTime) &&
+			tracking.gossipAttempts >= stuckTxMinGossipAttempts {
+			stuckTxIDs = append(stuckTxIDs, txID)
+		}
+	}
+	return stuckTxIDs
+}
+
+// txGossipDiagnosis is the mempool portion of a GossipDiagnosis.
+type txGossipDiagnosis struct {
+	inMempool      bool
+	dropReason     error
+	inBloomFilter  bool
+	gossipAttempts int
+	stuck          bool
+	numPeersWithTx int
+}
+
+// diagnose returns the mempool state relevant to gossiping [txID].
+func (g *gossipMempool) diagnose(txID ids.ID) txGossipDiagnosis {
+	// The mempool is read before lock is acquired.
+	inMempool := g.Mempool.Contains(txID)
+	diagnosis := txGossipDiagnosis{
+		inMempool:  inMempool,
+		dropReason: g.getDropReason(txID),
+	}
+
+	g.trackingLock.Lock()
+	if tracking, ok := g.tracking[txID]; ok && inMempool {
+		diagnosis.gossipAttempts = tracking.gossipAttempts
+		diagnosis.stuck = tracking.gossipAttempts >= stuckTxMinGossipAttempts
+	}
+	g.trackingLock.Unlock()
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	diagnosis.inBloomFilter = g.bloom.Has(txGossipID(txID))
+	if g.peerFilters != nil {
+		diagnosis.numPeersWithTx = g.peerFilters.numPeersWith(txID)
+	}
+	return diagnosis
+}
+
+// txGossipID allows the bloom filter to be queried for a tx that may not be
+// available.
+type txGossipID ids.ID
+
+func (t txGossipID) GossipID() ids.ID {
+	return ids.ID(t)
+}
+
+// Stats returns the lifetime number of txs that were added to the mempool and
+// marked as dropped.
+func (g *gossipMempool) Stats() (numAdded uint64, numDropped uint64) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.numAdded, g.numDropped
+}
+
+// GetFilter returns the bloom filter sent in pull gossip requests.
 func (g *gossipMempool) GetFilter() (bloom []byte, salt []byte) {
+	return g.getFilter(true)
+}
+
+// GetPushFilter returns the bloom filter advertised in push gossip.
+func (g *gossipMempool) GetPushFilter() (bloom []byte, salt []byte) {
+	return g.getFilter(false)
+}
+
+// pushGossipSet is the set that txs are pushed from. It advertises the bloom
+// filter of the mempool that is meant for push gossip.
+type pushGossipSet struct {
+	*gossipMempool
+}
+
+func (p pushGossipSet) GetFilter() (bloom []byte, salt []byte) {
+	return p.GetPushFilter()
+}
+
+// getFilter returns the pull bloom filter if [pull] is true and one is set.
+// Otherwise, the bloom filter advertised in push gossip is returned.
+func (g *gossipMempool) getFilter(pull bool) ([]byte, []byte) {
+	g.lock.RLock()
+	filter := g.bloom
+	if pull && g.pullBloom != nil {
+		filter = g.pullBloom
+	}
+	if !g.bloomRebuildDeferred && !g.saltExpired() {
+		defer g.lock.RUnlock()
+		return filter.Marshal()
+	}
+	g.lock.RUnlock()
+
+	// Retry any deferred rebuild, and rotate an expired salt, so that the
+	// bloom filter is eventually rebuilt, even if the mempool isn't modified.
+	if err := g.rebuildBloomFilterIfNeeded(); err != nil {
+		g.log.Error("failed to rebuild bloom filter",
+			zap.Error(err),
+		)
+	}
+
 	g.lock.RLock()
 	defer g.lock.RUnlock()
 
-	return g.bloom.Marshal()
+	return filter.Marshal()
 }
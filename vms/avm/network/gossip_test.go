@@ -4,14 +4,31 @@
 package network
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sync/semaphore"
 
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/codec"
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/network/p2p/gossip"
 	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/bloom"
 	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/avm/block/executor"
 	"github.com/ava-labs/avalanchego/vms/avm/fxs"
 	"github.com/ava-labs/avalanchego/vms/avm/txs"
 	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
@@ -29,6 +46,118 @@ func (v testVerifier) VerifyTx(*txs.Tx) error {
 	return v.err
 }
 
+func (v testVerifier) VerifyTxs(txs []*txs.Tx) []error {
+	errs := make([]error, len(txs))
+	for i := range txs {
+		errs[i] = v.err
+	}
+	return errs
+}
+
+func (v testVerifier) VerifyProjectedTx(*txs.Tx) error {
+	return v.err
+}
+
+// testGossipMempoolConfig describes how newTestGossipMempool builds a
+// gossipMempool. Anything left unset falls back to a fresh default.
+type testGossipMempoolConfig struct {
+	mempool                        mempool.Mempool
+	registerer                     prometheus.Registerer
+	txVerifier                     TxVerifier
+	parser                         txs.Parser
+	minTargetElements              int
+	targetFalsePositiveProbability float64
+	resetFalsePositiveProbability  float64
+	bloomChurnMultiplier           int
+}
+
+type testGossipMempoolOption func(*testGossipMempoolConfig)
+
+// withMempool wraps [m] instead of a new empty mempool.
+func withMempool(m mempool.Mempool) testGossipMempoolOption {
+	return func(c *testGossipMempoolConfig) {
+		c.mempool = m
+	}
+}
+
+// withRegisterer registers the gossip metrics, and the default mempool's
+// metrics, with [r].
+func withRegisterer(r prometheus.Registerer) testGossipMempoolOption {
+	return func(c *testGossipMempoolConfig) {
+		c.registerer = r
+	}
+}
+
+func withTxVerifier(v TxVerifier) testGossipMempoolOption {
+	return func(c *testGossipMempoolConfig) {
+		c.txVerifier = v
+	}
+}
+
+func withParser(p txs.Parser) testGossipMempoolOption {
+	return func(c *testGossipMempoolConfig) {
+		c.parser = p
+	}
+}
+
+func withBloomFilter(minTargetElements int, targetFalsePositiveProbability, resetFalsePositiveProbability float64) testGossipMempoolOption {
+	return func(c *testGossipMempoolConfig) {
+		c.minTargetElements = minTargetElements
+		c.targetFalsePositiveProbability = targetFalsePositiveProbability
+		c.resetFalsePositiveProbability = resetFalsePositiveProbability
+	}
+}
+
+func withBloomChurnMultiplier(bloomChurnMultiplier int) testGossipMempoolOption {
+	return func(c *testGossipMempoolConfig) {
+		c.bloomChurnMultiplier = bloomChurnMultiplier
+	}
+}
+
+// newTestGossipMempool returns a gossipMempool over an empty mempool that
+// accepts every tx and uses the default bloom filter parameters, unless
+// overridden by [opts].
+func newTestGossipMempool(t testing.TB, opts ...testGossipMempoolOption) *gossipMempool {
+	require := require.New(t)
+
+	c := testGossipMempoolConfig{
+		registerer:                     prometheus.NewRegistry(),
+		txVerifier:                     testVerifier{},
+		minTargetElements:              DefaultConfig.ExpectedBloomFilterElements,
+		targetFalsePositiveProbability: DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
+		resetFalsePositiveProbability:  DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+		bloomChurnMultiplier:           DefaultConfig.BloomChurnMultiplier,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.mempool == nil {
+		var err error
+		c.mempool, err = mempool.New("", c.registerer, make(chan common.Message, 1))
+		require.NoError(err)
+	}
+	if c.parser == nil {
+		var err error
+		c.parser, err = txs.NewParser(nil)
+		require.NoError(err)
+	}
+
+	g, err := newGossipMempool(
+		c.mempool,
+		c.registerer,
+		logging.NoLog{},
+		c.txVerifier,
+		c.parser,
+		c.minTargetElements,
+		c.targetFalsePositiveProbability,
+		c.resetFalsePositiveProbability,
+		c.bloomChurnMultiplier,
+	)
+	require.NoError(err)
+	return g
+}
+
 func TestMarshaller(t *testing.T) {
 	require := require.New(t)
 
@@ -54,29 +183,268 @@ func TestMarshaller(t *testing.T) {
 	require.Equal(want.GossipID(), got.GossipID())
 }
 
-func TestGossipMempoolAdd(t *testing.T) {
+// paddingParser accepts txs followed by arbitrary padding, modeling a tx format
+// that allows non-canonical encodings.
+type paddingParser struct {
+	txs.Parser
+
+	txLen int
+}
+
+func (p paddingParser) ParseTx(txBytes []byte) (*txs.Tx, error) {
+	tx, err := p.Parser.ParseTx(txBytes[:p.txLen])
+	if err != nil {
+		return nil, err
+	}
+	tx.SetBytes(tx.Unsigned.Bytes(), txBytes)
+	return tx, nil
+}
+
+func TestMarshallerNonCanonicalTx(t *testing.T) {
 	require := require.New(t)
 
-	metrics := prometheus.NewRegistry()
-	toEngine := make(chan common.Message, 1)
+	parser, err := txs.NewParser(
+		[]fxs.Fx{
+			&secp256k1fx.Fx{},
+		},
+	)
+	require.NoError(err)
 
-	baseMempool, err := mempool.New("", metrics, toEngine)
+	tx := &txs.Tx{Unsigned: &txs.BaseTx{}}
+	require.NoError(tx.Initialize(parser.Codec()))
+
+	marshaller := txParser{
+		parser: paddingParser{
+			Parser: parser,
+			txLen:  len(tx.Bytes()),
+		},
+		numNonCanonical: prometheus.NewCounter(prometheus.CounterOpts{}),
+	}
+
+	got, err := marshaller.UnmarshalGossip(tx.Bytes())
 	require.NoError(err)
+	require.Equal(tx.ID(), got.ID())
 
-	parser, err := txs.NewParser(nil)
+	// The padded encoding parses to an equivalent tx with a different ID.
+	paddedBytes := append(slices.Clone(tx.Bytes()), 0)
+	_, err = marshaller.UnmarshalGossip(paddedBytes)
+	require.ErrorIs(err, errNonCanonicalTx)
+	require.Equal(1.0, testutil.ToFloat64(marshaller.numNonCanonical))
+}
+
+// multiVersionParser parses txs encoded with any of the codec versions
+// registered in [cm].
+type multiVersionParser struct {
+	txs.Parser
+
+	cm codec.Manager
+}
+
+func newMultiVersionParser(t *testing.T, codecVersions ...uint16) multiVersionParser {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(
+		[]fxs.Fx{
+			&secp256k1fx.Fx{},
+		},
+	)
 	require.NoError(err)
 
-	mempool, err := newGossipMempool(
-		baseMempool,
-		metrics,
-		logging.NoLog{},
-		testVerifier{},
-		parser,
-		DefaultConfig.ExpectedBloomFilterElements,
-		DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
-		DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+	c, ok := parser.CodecRegistry().(codec.Codec)
+	require.True(ok)
+
+	cm := codec.NewDefaultManager()
+	for _, codecVersion := range codecVersions {
+		require.NoError(cm.RegisterCodec(codecVersion, c))
+	}
+	return multiVersionParser{
+		Parser: parser,
+		cm:     cm,
+	}
+}
+
+func (p multiVersionParser) Codec() codec.Manager {
+	return p.cm
+}
+
+func (p multiVersionParser) ParseTx(txBytes []byte) (*txs.Tx, error) {
+	tx := &txs.Tx{}
+	codecVersion, err := p.cm.Unmarshal(txBytes, tx)
+	if err != nil {
+		return nil, err
+	}
+	unsignedBytesLen, err := p.cm.Size(codecVersion, &tx.Unsigned)
+	if err != nil {
+		return nil, err
+	}
+	tx.SetBytes(txBytes[:unsignedBytesLen], txBytes)
+	return tx, nil
+}
+
+// newTx returns a tx encoded with [codecVersion].
+func (p multiVersionParser) newTx(t *testing.T, codecVersion uint16) *txs.Tx {
+	require := require.New(t)
+
+	memo := ids.GenerateTestID()
+	tx := &txs.Tx{Unsigned: &txs.BaseTx{
+		BaseTx: avax.BaseTx{
+			Memo: memo[:],
+		},
+	}}
+	txBytes, err := p.cm.Marshal(codecVersion, tx)
+	require.NoError(err)
+
+	tx, err = p.ParseTx(txBytes)
+	require.NoError(err)
+	return tx
+}
+
+func TestMarshallerVersions(t *testing.T) {
+	parser := newMultiVersionParser(t, 0, 1)
+	versions := []uint16{0, 1}
+
+	tests := []struct {
+		name                string
+		versioned           bool
+		codecVersion        uint16
+		expectedVersionByte byte
+	}{
+		{
+			name:         "unversioned",
+			versioned:    false,
+			codecVersion: 0,
+		},
+		{
+			name:                "codec version 0",
+			versioned:           true,
+			codecVersion:        0,
+			expectedVersionByte: 1,
+		},
+		{
+			name:                "codec version 1",
+			versioned:           true,
+			codecVersion:        1,
+			expectedVersionByte: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			marshaller := txParser{
+				parser:    parser,
+				versioned: tt.versioned,
+				versions:  versions,
+			}
+
+			want := parser.newTx(t, tt.codecVersion)
+			gossipBytes, err := marshaller.MarshalGossip(want)
+			require.NoError(err)
+			if tt.versioned {
+				require.Equal(tt.expectedVersionByte, gossipBytes[0])
+				require.Equal(want.Bytes(), gossipBytes[1:])
+			} else {
+				require.Equal(want.Bytes(), gossipBytes)
+			}
+
+			got, err := marshaller.UnmarshalGossip(gossipBytes)
+			require.NoError(err)
+			require.Equal(want.ID(), got.ID())
+
+			// Nodes that don't marshal versioned gossip still parse it
+			marshaller.versioned = !tt.versioned
+			got, err = marshaller.UnmarshalGossip(gossipBytes)
+			require.NoError(err)
+			require.Equal(want.ID(), got.ID())
+		})
+	}
+}
+
+func TestMarshallerUnknownVersion(t *testing.T) {
+	require := require.New(t)
+
+	parser := newMultiVersionParser(t, 0, 1)
+	marshaller := txParser{
+		parser:    parser,
+		versioned: true,
+		versions:  []uint16{0},
+	}
+
+	// Txs encoded with a codec version without a gossip version can't be
+	// marshalled
+	tx := parser.newTx(t, 1)
+	_, err := marshaller.MarshalGossip(tx)
+	require.ErrorIs(err, errNoGossipVersion)
+
+	// Unknown gossip versions are rejected
+	gossipBytes := append([]byte{2}, tx.Bytes()...)
+	_, err = marshaller.UnmarshalGossip(gossipBytes)
+	require.ErrorIs(err, errUnknownGossipVersion)
+
+	// Gossip versions must match the codec version of the tx
+	gossipBytes = append([]byte{1}, tx.Bytes()...)
+	_, err = marshaller.UnmarshalGossip(gossipBytes)
+	require.ErrorIs(err, errGossipVersionMismatch)
+}
+
+func TestMarshallerSharedCodecVersion(t *testing.T) {
+	require := require.New(t)
+
+	parser := newMultiVersionParser(t, 0, 1)
+	marshaller := txParser{
+		parser:    parser,
+		versioned: true,
+		versions:  []uint16{1, 0, 1},
+	}
+
+	// The lowest version byte of the codec version is always used
+	tx := parser.newTx(t, 1)
+	for i := 0; i < 10; i++ {
+		gossipBytes, err := marshaller.MarshalGossip(tx)
+		require.NoError(err)
+		require.Equal(byte(1), gossipBytes[0])
+	}
+
+	// Txs prefixed with any of the version bytes of the codec version are
+	// parsed
+	gossipBytes := append([]byte{3}, tx.Bytes()...)
+	got, err := marshaller.UnmarshalGossip(gossipBytes)
+	require.NoError(err)
+	require.Equal(tx.ID(), got.ID())
+}
+
+func TestMarshallerGossipVersion(t *testing.T) {
+	require := require.New(t)
+
+	parser := newMultiVersionParser(t, 0, 1)
+	marshaller := txParser{
+		parser:   parser,
+		versions: []uint16{0, 1},
+	}
+	require.Equal(
+		[]string{unversionedGossip, "1", "2"},
+		marshaller.gossipVersionLabels(),
 	)
+
+	tx := parser.newTx(t, 1)
+	unversionedBytes, err := marshaller.MarshalGossip(tx)
+	require.NoError(err)
+	require.Equal(unversionedGossip, marshaller.GossipVersion(unversionedBytes))
+
+	marshaller.versioned = true
+	versionedBytes, err := marshaller.MarshalGossip(tx)
 	require.NoError(err)
+	require.Equal("2", marshaller.GossipVersion(versionedBytes))
+
+	// Versions that can't be parsed are reported as they are, and are bounded
+	// by the metrics.
+	require.Equal("3", marshaller.GossipVersion([]byte{3}))
+	require.Equal(unversionedGossip, marshaller.GossipVersion(nil))
+}
+
+func TestGossipMempoolAdd(t *testing.T) {
+	require := require.New(t)
+	mempool := newTestGossipMempool(t)
 
 	tx := &txs.Tx{
 		Unsigned: &txs.BaseTx{
@@ -93,39 +461,2530 @@ func TestGossipMempoolAdd(t *testing.T) {
 
 func TestGossipMempoolAddVerified(t *testing.T) {
 	require := require.New(t)
+	mempool := newTestGossipMempool(
+		t,
+		withTxVerifier(testVerifier{err: errTest}),
+	)
+
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+
+	require.NoError(mempool.AddWithoutVerification(tx))
+	require.True(mempool.bloom.Has(tx))
+}
+
+func TestGossipMempoolVerifyProjectedState(t *testing.T) {
+	tests := []struct {
+		name                 string
+		verifyProjectedState bool
+		txVerifierFunc       func(*gomock.Controller, *txs.Tx) TxVerifier
+	}{
+		{
+			name:                 "current state",
+			verifyProjectedState: false,
+			txVerifierFunc: func(ctrl *gomock.Controller, tx *txs.Tx) TxVerifier {
+				txVerifier := executor.NewMockManager(ctrl)
+				txVerifier.EXPECT().VerifyTx(tx).Return(nil)
+				return txVerifier
+			},
+		},
+		{
+			name:                 "projected state",
+			verifyProjectedState: true,
+			txVerifierFunc: func(ctrl *gomock.Controller, tx *txs.Tx) TxVerifier {
+				txVerifier := executor.NewMockManager(ctrl)
+				txVerifier.EXPECT().VerifyProjectedTx(tx).Return(nil)
+				return txVerifier
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctrl := gomock.NewController(t)
+
+			tx := &txs.Tx{
+				Unsigned: &txs.BaseTx{
+					BaseTx: avax.BaseTx{
+						Ins: []*avax.TransferableInput{},
+					},
+				},
+				TxID: ids.GenerateTestID(),
+			}
+			gossipMempool := newTestGossipMempool(
+				t,
+				withTxVerifier(tt.txVerifierFunc(ctrl, tx)),
+			)
+			gossipMempool.verifyProjectedState = tt.verifyProjectedState
+
+			require.NoError(gossipMempool.Add(tx))
+			require.True(gossipMempool.Has(tx.ID()))
+		})
+	}
+}
+
+// buildBlockCountingMempool counts the number of build block requests.
+type buildBlockCountingMempool struct {
+	mempool.Mempool
+	numBuildBlockRequests int
+}
+
+func (m *buildBlockCountingMempool) RequestBuildBlock() {
+	m.numBuildBlockRequests++
+	m.Mempool.RequestBuildBlock()
+}
+
+func TestGossipMempoolCoalesceBuildBlockRequests(t *testing.T) {
+	require := require.New(t)
+
+	const numTxs = 16
 
 	metrics := prometheus.NewRegistry()
 	toEngine := make(chan common.Message, 1)
 
 	baseMempool, err := mempool.New("", metrics, toEngine)
 	require.NoError(err)
+	countingMempool := &buildBlockCountingMempool{
+		Mempool: baseMempool,
+	}
 
-	parser, err := txs.NewParser(nil)
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(countingMempool),
+		withRegisterer(metrics),
+	)
+	gossipMempool.buildBlockRequestWindow = time.Hour
+
+	for i := 0; i < numTxs; i++ {
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(gossipMempool.Add(tx))
+	}
+
+	// Only the first add notifies the engine, the rest are coalesced until the
+	// end of the window.
+	require.Equal(1, countingMempool.numBuildBlockRequests)
+	require.True(gossipMempool.buildBlockRequested)
+
+	gossipMempool.buildBlockTimer.Stop()
+	gossipMempool.flushBuildBlockRequest()
+	require.Equal(2, countingMempool.numBuildBlockRequests)
+	require.False(gossipMempool.buildBlockRequested)
+}
+
+func TestGossipMempoolIterateSnapshot(t *testing.T) {
+	require := require.New(t)
+
+	const numTxs = 64
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
 	require.NoError(err)
 
-	mempool, err := newGossipMempool(
-		baseMempool,
-		metrics,
-		logging.NoLog{},
-		testVerifier{
-			err: errTest, // We shouldn't be attempting to verify the tx in this flow
-		},
-		parser,
-		DefaultConfig.ExpectedBloomFilterElements,
-		DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
-		DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	snapshotTxIDs := set.NewSet[ids.ID](numTxs)
+	for i := 0; i < numTxs; i++ {
+		tx := newTx()
+		require.NoError(gossipMempool.Add(tx))
+		snapshotTxIDs.Add(tx.ID())
+	}
+
+	// Txs are added concurrently with iterating over the snapshot
+	var (
+		wg    sync.WaitGroup
+		added = make(chan *txs.Tx, numTxs)
 	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numTxs; i++ {
+			tx := newTx()
+			if err := gossipMempool.Add(tx); err == nil {
+				added <- tx
+			}
+		}
+	}()
+
+	// The mempool isn't locked while iterating, so txs can be added by the
+	// iterator. Txs added after the snapshot was taken aren't iterated over.
+	iterated := set.NewSet[ids.ID](numTxs)
+	gossipMempool.IterateSnapshot(func(tx *txs.Tx) bool {
+		iterated.Add(tx.ID())
+		require.NoError(gossipMempool.Add(newTx()))
+		return true
+	})
+	wg.Wait()
+	close(added)
+
+	require.GreaterOrEqual(iterated.Len(), numTxs)
+	for txID := range snapshotTxIDs {
+		require.True(iterated.Contains(txID))
+	}
+	require.Equal(numTxs+iterated.Len()+len(added), gossipMempool.Len())
+}
+
+func TestGossipMempoolAddBatchRequestsBuildBlockOnce(t *testing.T) {
+	require := require.New(t)
+
+	const batchSize = 16
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
 	require.NoError(err)
+	countingMempool := &buildBlockCountingMempool{
+		Mempool: baseMempool,
+	}
 
-	tx := &txs.Tx{
-		Unsigned: &txs.BaseTx{
-			BaseTx: avax.BaseTx{
-				Ins: []*avax.TransferableInput{},
+	verifier := &testVerifier{}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(countingMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	newBatch := func() []*txs.Tx {
+		batch := make([]*txs.Tx, batchSize)
+		for i := range batch {
+			batch[i] = &txs.Tx{
+				Unsigned: &txs.BaseTx{
+					BaseTx: avax.BaseTx{
+						Ins: []*avax.TransferableInput{},
+					},
+				},
+				TxID: ids.GenerateTestID(),
+			}
+		}
+		return batch
+	}
+
+	nodeID := ids.GenerateTestNodeID()
+	for i := 1; i <= 2; i++ {
+		for _, err := range gossipMempool.AddBatchFromPeer(nodeID, newBatch()) {
+			require.NoError(err)
+		}
+		require.Equal(i, countingMempool.numBuildBlockRequests)
+	}
+
+	// A batch that doesn't add any txs doesn't request a block
+	verifier.err = errTest
+	for _, err := range gossipMempool.AddBatchFromPeer(nodeID, newBatch()) {
+		require.ErrorIs(err, errTest)
+	}
+	require.Equal(2, countingMempool.numBuildBlockRequests)
+}
+
+func TestGossipMempoolStats(t *testing.T) {
+	require := require.New(t)
+	gossipMempool := newTestGossipMempool(t)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
 			},
-		},
-		TxID: ids.GenerateTestID(),
+			TxID: ids.GenerateTestID(),
+		}
 	}
 
-	require.NoError(mempool.AddWithoutVerification(tx))
-	require.True(mempool.bloom.Has(tx))
+	tx0 := newTx()
+	require.NoError(gossipMempool.Add(tx0))
+	require.NoError(gossipMempool.Add(newTx()))
+
+	// Duplicates are neither added nor dropped
+	err := gossipMempool.AddWithoutVerification(tx0)
+	require.ErrorIs(err, mempool.ErrDuplicateTx)
+
+	gossipMempool.txVerifier = testVerifier{
+		err: errTest,
+	}
+	err = gossipMempool.Add(newTx())
+	require.ErrorIs(err, errTest)
+
+	numAdded, numDropped := gossipMempool.Stats()
+	require.Equal(uint64(2), numAdded)
+	require.Equal(uint64(1), numDropped)
+}
+
+func TestGossipMempoolStuckTxs(t *testing.T) {
+	require := require.New(t)
+	mempool := newTestGossipMempool(t)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	var (
+		startTime = time.Unix(0, 0)
+		minAge    = time.Minute
+
+		// Old and gossiped many times
+		oldGossipedTx = newTx()
+		// Old, but not gossiped enough
+		oldUngossipedTx = newTx()
+		// Gossiped many times, but too new
+		newGossipedTx = newTx()
+		// Old and gossiped many times, but removed from the mempool
+		oldRemovedTx = newTx()
+	)
+
+	mempool.clock.Set(startTime)
+	require.NoError(mempool.Add(oldGossipedTx))
+	require.NoError(mempool.Add(oldUngossipedTx))
+	require.NoError(mempool.Add(oldRemovedTx))
+
+	mempool.clock.Set(startTime.Add(minAge))
+	require.NoError(mempool.Add(newGossipedTx))
+
+	for i := 0; i < stuckTxMinGossipAttempts; i++ {
+		mempool.MarkGossiped(oldGossipedTx.ID())
+		mempool.MarkGossiped(newGossipedTx.ID())
+		mempool.MarkGossiped(oldRemovedTx.ID())
+	}
+	mempool.MarkGossiped(oldUngossipedTx.ID())
+	mempool.Remove(oldRemovedTx)
+
+	mempool.clock.Set(startTime.Add(minAge + time.Second))
+	require.Equal([]ids.ID{oldGossipedTx.ID()}, mempool.StuckTxs(minAge))
+}
+
+func TestGossipMempoolReverifyDroppedTx(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &testVerifier{
+		err: errTest,
+	}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	const ttl = time.Minute
+	gossipMempool.dropTimes = &cache.LRU[ids.ID, time.Time]{Size: maxDropTimes}
+	gossipMempool.dropReasonTTL = ttl
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	var (
+		startTime = time.Unix(0, 0)
+		tx        = newTx()
+		// Dropped without the gossip mempool observing the drop
+		blockTx = newTx()
+	)
+
+	gossipMempool.clock.Set(startTime)
+	require.ErrorIs(gossipMempool.Add(tx), errTest)
+	baseMempool.MarkDropped(blockTx.ID(), errTest)
+
+	// Once the tx becomes valid, the drop reason is still reported until the
+	// TTL passes.
+	verifier.err = nil
+	gossipMempool.clock.Set(startTime.Add(ttl - time.Second))
+	require.ErrorIs(gossipMempool.Add(tx), errTest)
+	require.ErrorIs(gossipMempool.Add(blockTx), errTest)
+	require.False(gossipMempool.Has(tx.ID()))
+
+	gossipMempool.clock.Set(startTime.Add(ttl))
+	require.NoError(gossipMempool.Add(tx))
+	require.True(gossipMempool.Has(tx.ID()))
+	require.NoError(gossipMempool.Mempool.GetDropReason(tx.ID()))
+
+	// Drops that weren't observed are timed from when they were first seen.
+	require.ErrorIs(gossipMempool.Add(blockTx), errTest)
+	gossipMempool.clock.Set(startTime.Add(2*ttl - time.Second))
+	require.NoError(gossipMempool.Add(blockTx))
+	require.True(gossipMempool.Has(blockTx.ID()))
+
+	// Txs that are still invalid are dropped again.
+	verifier.err = errTest
+	invalidTx := newTx()
+	require.ErrorIs(gossipMempool.Add(invalidTx), errTest)
+	gossipMempool.clock.Set(startTime.Add(3 * ttl))
+	require.ErrorIs(gossipMempool.Add(invalidTx), errTest)
+	require.False(gossipMempool.Has(invalidTx.ID()))
+}
+
+func TestGossipMempoolDropReasonCache(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &testVerifier{
+		err: errTest,
+	}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	// The cache is larger than the number of drop reasons remembered by the
+	// mempool, but smaller than the number of dropped txs.
+	const (
+		cacheSize = 100
+		numTxs    = 2 * cacheSize
+	)
+	gossipMempool.dropReasons = &cache.LRU[ids.ID, error]{Size: cacheSize}
+
+	droppedTxs := make([]*txs.Tx, numTxs)
+	for i := range droppedTxs {
+		droppedTxs[i] = &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.ErrorIs(gossipMempool.Add(droppedTxs[i]), errTest)
+	}
+	require.Equal(cacheSize, gossipMempool.dropReasons.Len())
+
+	// The most recently dropped txs are still ignored, even once the mempool
+	// no longer remembers that they were dropped.
+	verifier.err = nil
+	recentTx := droppedTxs[numTxs-cacheSize]
+	require.NoError(baseMempool.GetDropReason(recentTx.ID()))
+	err = gossipMempool.Add(recentTx)
+	require.ErrorIs(err, errTest)
+	var addTxErr *AddTxError
+	require.ErrorAs(err, &addTxErr)
+	require.Equal(AddTxDropped, addTxErr.Failure)
+	require.False(gossipMempool.Has(recentTx.ID()))
+
+	// Evicted txs are verified again.
+	evictedTx := droppedTxs[numTxs-cacheSize-1]
+	require.NoError(gossipMempool.Add(evictedTx))
+	require.True(gossipMempool.Has(evictedTx.ID()))
+
+	// Clearing the drop reasons allows all txs to be verified again.
+	gossipMempool.clearDropReasons()
+	require.Zero(gossipMempool.dropReasons.Len())
+	require.NoError(gossipMempool.Add(recentTx))
+	require.True(gossipMempool.Has(recentTx.ID()))
+}
+
+func TestGossipMempoolMaxTxSize(t *testing.T) {
+	const maxTxSize = 128
+
+	tests := []struct {
+		name        string
+		maxTxSize   int
+		txSize      int
+		expectedErr error
+	}{
+		{
+			name:      "no limit",
+			maxTxSize: 0,
+			txSize:    maxTxSize + 1,
+		},
+		{
+			name:      "under limit",
+			maxTxSize: maxTxSize,
+			txSize:    maxTxSize,
+		},
+		{
+			name:        "over limit",
+			maxTxSize:   maxTxSize,
+			txSize:      maxTxSize + 1,
+			expectedErr: mempool.ErrTxTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			metrics := prometheus.NewRegistry()
+			baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+			require.NoError(err)
+
+			gossipMempool := newTestGossipMempool(
+				t,
+				withMempool(baseMempool),
+				withRegisterer(metrics),
+			)
+			gossipMempool.maxTxSize = tt.maxTxSize
+
+			txBytes := make([]byte, tt.txSize)
+			_, _ = rand.Read(txBytes)
+			tx := &txs.Tx{
+				Unsigned: &txs.BaseTx{
+					BaseTx: avax.BaseTx{
+						Ins: []*avax.TransferableInput{},
+					},
+				},
+			}
+			tx.SetBytes(nil, txBytes)
+
+			err = gossipMempool.AddFromPeer(ids.GenerateTestNodeID(), tx)
+			require.ErrorIs(err, tt.expectedErr)
+			require.Equal(tt.expectedErr == nil, gossipMempool.Has(tx.ID()))
+			if tt.expectedErr == nil {
+				return
+			}
+
+			var addErr *AddTxError
+			require.ErrorAs(err, &addErr)
+			require.Equal(AddTxRejected, addErr.Failure)
+			require.ErrorIs(gossipMempool.GetDropReason(tx.ID()), tt.expectedErr)
+			require.False(gossipMempool.bloom.Has(tx))
+		})
+	}
+}
+
+func TestGossipMempoolAddTxError(t *testing.T) {
+	newTx := func(inputTxID ids.ID) *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{
+						{
+							UTXOID: avax.UTXOID{
+								TxID: inputTxID,
+							},
+						},
+					},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		setup           func(*require.Assertions, *gossipMempool, *testVerifier, *txs.Tx)
+		expectedFailure AddTxFailure
+		expectedErr     error
+	}{
+		{
+			name: "duplicate",
+			setup: func(require *require.Assertions, g *gossipMempool, _ *testVerifier, tx *txs.Tx) {
+				require.NoError(g.Add(tx))
+			},
+			expectedFailure: AddTxDuplicate,
+			expectedErr:     mempool.ErrDuplicateTx,
+		},
+		{
+			name: "dropped",
+			setup: func(_ *require.Assertions, g *gossipMempool, _ *testVerifier, tx *txs.Tx) {
+				g.Mempool.MarkDropped(tx.ID(), errTest)
+			},
+			expectedFailure: AddTxDropped,
+			expectedErr:     errTest,
+		},
+		{
+			name: "spam",
+			setup: func(_ *require.Assertions, g *gossipMempool, _ *testVerifier, _ *txs.Tx) {
+				g.spamScorer = func(*txs.Tx, ids.NodeID) (float64, error) {
+					return 1, nil
+				}
+			},
+			expectedFailure: AddTxSpam,
+			expectedErr:     ErrLikelySpam,
+		},
+		{
+			name: "invalid",
+			setup: func(_ *require.Assertions, _ *gossipMempool, verifier *testVerifier, _ *txs.Tx) {
+				verifier.err = errTest
+			},
+			expectedFailure: AddTxInvalid,
+			expectedErr:     errTest,
+		},
+		{
+			name: "deferred",
+			setup: func(_ *require.Assertions, _ *gossipMempool, verifier *testVerifier, _ *txs.Tx) {
+				verifier.err = fmt.Errorf("%w: %w", ErrRetryable, errTest)
+			},
+			expectedFailure: AddTxDeferred,
+			expectedErr:     ErrRetryable,
+		},
+		{
+			name: "rejected",
+			setup: func(require *require.Assertions, g *gossipMempool, _ *testVerifier, tx *txs.Tx) {
+				// Spends the same input as tx
+				conflictingTx := &txs.Tx{
+					Unsigned: tx.Unsigned,
+					TxID:     ids.GenerateTestID(),
+				}
+				require.NoError(g.Add(conflictingTx))
+			},
+			expectedFailure: AddTxRejected,
+			expectedErr:     mempool.ErrConflictsWithOtherTx,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			metrics := prometheus.NewRegistry()
+			baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+			require.NoError(err)
+
+			verifier := &testVerifier{}
+			gossipMempool := newTestGossipMempool(
+				t,
+				withMempool(baseMempool),
+				withRegisterer(metrics),
+				withTxVerifier(verifier),
+			)
+
+			tx := newTx(ids.GenerateTestID())
+			tt.setup(require, gossipMempool, verifier, tx)
+
+			err = gossipMempool.Add(tx)
+			require.ErrorIs(err, tt.expectedErr)
+
+			var addTxErr *AddTxError
+			require.ErrorAs(err, &addTxErr)
+			require.Equal(tx.ID(), addTxErr.TxID)
+			require.Equal(tt.expectedFailure, addTxErr.Failure)
+		})
+	}
+}
+
+func TestGossipMempoolWouldAccept(t *testing.T) {
+	tests := []struct {
+		name            string
+		setup           func(*require.Assertions, *gossipMempool, *testVerifier, *txs.Tx)
+		expectedFailure AddTxFailure
+		expectedErr     error
+	}{
+		{
+			name:  "accepted",
+			setup: func(*require.Assertions, *gossipMempool, *testVerifier, *txs.Tx) {},
+		},
+		{
+			name: "duplicate",
+			setup: func(require *require.Assertions, g *gossipMempool, _ *testVerifier, tx *txs.Tx) {
+				require.NoError(g.Add(tx))
+			},
+			expectedFailure: AddTxDuplicate,
+			expectedErr:     mempool.ErrDuplicateTx,
+		},
+		{
+			name: "dropped",
+			setup: func(_ *require.Assertions, g *gossipMempool, _ *testVerifier, tx *txs.Tx) {
+				g.Mempool.MarkDropped(tx.ID(), errTest)
+			},
+			expectedFailure: AddTxDropped,
+			expectedErr:     errTest,
+		},
+		{
+			name: "spam",
+			setup: func(_ *require.Assertions, g *gossipMempool, _ *testVerifier, _ *txs.Tx) {
+				g.spamScorer = func(*txs.Tx, ids.NodeID) (float64, error) {
+					return 1, nil
+				}
+			},
+			expectedFailure: AddTxSpam,
+			expectedErr:     ErrLikelySpam,
+		},
+		{
+			name: "invalid",
+			setup: func(_ *require.Assertions, _ *gossipMempool, verifier *testVerifier, _ *txs.Tx) {
+				verifier.err = errTest
+			},
+			expectedFailure: AddTxInvalid,
+			expectedErr:     errTest,
+		},
+		{
+			name: "deferred",
+			setup: func(_ *require.Assertions, _ *gossipMempool, verifier *testVerifier, _ *txs.Tx) {
+				verifier.err = fmt.Errorf("%w: %w", ErrRetryable, errTest)
+			},
+			expectedFailure: AddTxDeferred,
+			expectedErr:     ErrRetryable,
+		},
+		{
+			name: "too large",
+			setup: func(_ *require.Assertions, g *gossipMempool, _ *testVerifier, tx *txs.Tx) {
+				g.maxTxSize = len(tx.Bytes()) - 1
+			},
+			expectedFailure: AddTxRejected,
+			expectedErr:     mempool.ErrTxTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			metrics := prometheus.NewRegistry()
+			baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+			require.NoError(err)
+
+			verifier := &testVerifier{}
+			gossipMempool := newTestGossipMempool(
+				t,
+				withMempool(baseMempool),
+				withRegisterer(metrics),
+				withTxVerifier(verifier),
+			)
+			gossipMempool.dropTimes = &cache.LRU[ids.ID, time.Time]{Size: maxDropTimes}
+			gossipMempool.dropReasonTTL = time.Minute
+
+			tx := &txs.Tx{
+				Unsigned: &txs.BaseTx{
+					BaseTx: avax.BaseTx{
+						Ins: []*avax.TransferableInput{},
+					},
+				},
+			}
+			txBytes := make([]byte, 64)
+			_, _ = rand.Read(txBytes)
+			tx.SetBytes(nil, txBytes)
+			tt.setup(require, gossipMempool, verifier, tx)
+
+			var (
+				wasAdded     = gossipMempool.Has(tx.ID())
+				dropReason   = gossipMempool.GetDropReason(tx.ID())
+				numDropTimes = gossipMempool.dropTimes.Len()
+			)
+			numAdded, numDropped := gossipMempool.Stats()
+			wouldAcceptErr := gossipMempool.WouldAccept(tx)
+			require.ErrorIs(wouldAcceptErr, tt.expectedErr)
+
+			// The mempool is not modified
+			require.Equal(wasAdded, gossipMempool.Has(tx.ID()))
+			require.Equal(wasAdded, gossipMempool.bloom.Has(tx))
+			require.Equal(dropReason, gossipMempool.GetDropReason(tx.ID()))
+			require.Equal(numDropTimes, gossipMempool.dropTimes.Len())
+			newNumAdded, newNumDropped := gossipMempool.Stats()
+			require.Equal(numAdded, newNumAdded)
+			require.Equal(numDropped, newNumDropped)
+
+			addErr := gossipMempool.Add(tx)
+			require.ErrorIs(addErr, tt.expectedErr)
+			if tt.expectedErr == nil {
+				require.True(gossipMempool.Has(tx.ID()))
+				return
+			}
+
+			var wouldAcceptTxErr, addTxErr *AddTxError
+			require.ErrorAs(wouldAcceptErr, &wouldAcceptTxErr)
+			require.ErrorAs(addErr, &addTxErr)
+			require.Equal(addTxErr.TxID, wouldAcceptTxErr.TxID)
+			require.Equal(addTxErr.Failure, wouldAcceptTxErr.Failure)
+			require.Equal(tt.expectedFailure, wouldAcceptTxErr.Failure)
+		})
+	}
+}
+
+// batchVerifier fails the verification of the txs in errs and records the
+// batches of txs it verifies.
+type batchVerifier struct {
+	errs    map[ids.ID]error
+	batches [][]ids.ID
+}
+
+func (v *batchVerifier) VerifyTx(tx *txs.Tx) error {
+	return v.errs[tx.ID()]
+}
+
+func (v *batchVerifier) VerifyTxs(batch []*txs.Tx) []error {
+	var (
+		txIDs = make([]ids.ID, len(batch))
+		errs  = make([]error, len(batch))
+	)
+	for i, tx := range batch {
+		txIDs[i] = tx.ID()
+		errs[i] = v.VerifyTx(tx)
+	}
+	v.batches = append(v.batches, txIDs)
+	return errs
+}
+
+func (v *batchVerifier) VerifyProjectedTx(tx *txs.Tx) error {
+	return v.VerifyTx(tx)
+}
+
+func TestGossipMempoolAddBatchFromPeer(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &batchVerifier{
+		errs: make(map[ids.ID]error),
+	}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	var (
+		validTx     = newTx()
+		invalidTx   = newTx()
+		duplicateTx = newTx()
+		droppedTx   = newTx()
+		otherTx     = newTx()
+	)
+	verifier.errs[invalidTx.ID()] = errTest
+	require.NoError(gossipMempool.Add(duplicateTx))
+	gossipMempool.Mempool.MarkDropped(droppedTx.ID(), errTest)
+	verifier.batches = nil
+
+	nodeID := ids.GenerateTestNodeID()
+	errs := gossipMempool.AddBatchFromPeer(nodeID, []*txs.Tx{
+		validTx,
+		invalidTx,
+		duplicateTx,
+		droppedTx,
+		otherTx,
+	})
+	require.Len(errs, 5)
+	require.NoError(errs[0])
+	require.ErrorIs(errs[1], errTest)
+	require.ErrorIs(errs[2], mempool.ErrDuplicateTx)
+	require.ErrorIs(errs[3], errTest)
+	require.NoError(errs[4])
+
+	var addTxErr *AddTxError
+	require.ErrorAs(errs[1], &addTxErr)
+	require.Equal(AddTxInvalid, addTxErr.Failure)
+	require.ErrorAs(errs[3], &addTxErr)
+	require.Equal(AddTxDropped, addTxErr.Failure)
+
+	// Only the txs that passed the initial checks are verified, in a single
+	// batch.
+	require.Equal(
+		[][]ids.ID{
+			{validTx.ID(), invalidTx.ID(), otherTx.ID()},
+		},
+		verifier.batches,
+	)
+	require.True(gossipMempool.Has(validTx.ID()))
+	require.False(gossipMempool.Has(invalidTx.ID()))
+	require.True(gossipMempool.Has(otherTx.ID()))
+	require.ErrorIs(gossipMempool.GetDropReason(invalidTx.ID()), errTest)
+}
+
+func BenchmarkGossipMempoolAdd(b *testing.B) {
+	const batchSize = 64
+	benchmarks := []struct {
+		name string
+		add  func(*gossipMempool, ids.NodeID, []*txs.Tx)
+	}{
+		{
+			name: "per tx",
+			add: func(g *gossipMempool, nodeID ids.NodeID, batch []*txs.Tx) {
+				for _, tx := range batch {
+					_ = g.AddFromPeer(nodeID, tx)
+				}
+			},
+		},
+		{
+			name: "batch",
+			add: func(g *gossipMempool, nodeID ids.NodeID, batch []*txs.Tx) {
+				_ = g.AddBatchFromPeer(nodeID, batch)
+			},
+		},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			metrics := prometheus.NewRegistry()
+			baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+			require.NoError(b, err)
+
+			// The verifier is locked as it is by the VM, and every tx fails
+			// verification so that the mempool doesn't fill up.
+			gossipMempool := newTestGossipMempool(
+				b,
+				withMempool(baseMempool),
+				withRegisterer(metrics),
+				withTxVerifier(NewLockedTxVerifier(&sync.Mutex{}, testVerifier{err: errTest})),
+			)
+
+			var (
+				nodeID = ids.GenerateTestNodeID()
+				batch  = make([]*txs.Tx, batchSize)
+			)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				for j := range batch {
+					batch[j] = &txs.Tx{
+						Unsigned: &txs.BaseTx{
+							BaseTx: avax.BaseTx{
+								Ins: []*avax.TransferableInput{},
+							},
+						},
+						TxID: ids.GenerateTestID(),
+					}
+				}
+				b.StartTimer()
+
+				bm.add(gossipMempool, nodeID, batch)
+			}
+		})
+	}
+}
+
+func TestGossipMempoolSpamScorer(t *testing.T) {
+	const threshold = .5
+	tests := []struct {
+		name        string
+		score       float64
+		scoreErr    error
+		failClosed  bool
+		expectedErr error
+	}{
+		{
+			name:  "below threshold",
+			score: threshold / 2,
+		},
+		{
+			name:  "at threshold",
+			score: threshold,
+		},
+		{
+			name:        "above threshold",
+			score:       threshold * 2,
+			expectedErr: ErrLikelySpam,
+		},
+		{
+			name:     "scorer error fail open",
+			scoreErr: errTest,
+		},
+		{
+			name:        "scorer error fail closed",
+			scoreErr:    errTest,
+			failClosed:  true,
+			expectedErr: ErrLikelySpam,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			mempool := newTestGossipMempool(t)
+
+			var (
+				nodeID       = ids.GenerateTestNodeID()
+				scoredNodeID ids.NodeID
+			)
+			mempool.spamScorer = func(_ *txs.Tx, nodeID ids.NodeID) (float64, error) {
+				scoredNodeID = nodeID
+				return tt.score, tt.scoreErr
+			}
+			mempool.spamScoreThreshold = threshold
+			mempool.spamScorerFailClosed = tt.failClosed
+
+			tx := &txs.Tx{
+				Unsigned: &txs.BaseTx{
+					BaseTx: avax.BaseTx{
+						Ins: []*avax.TransferableInput{},
+					},
+				},
+				TxID: ids.GenerateTestID(),
+			}
+
+			err := mempool.AddFromPeer(nodeID, tx)
+			require.ErrorIs(err, tt.expectedErr)
+			require.Equal(nodeID, scoredNodeID)
+			require.Equal(tt.expectedErr == nil, mempool.Has(tx.ID()))
+
+			// Spam isn't marked as dropped, so it can be re-scored later.
+			require.NoError(mempool.GetDropReason(tx.ID()))
+		})
+	}
+}
+
+func TestGossipMempoolBloomFilterResetHysteresis(t *testing.T) {
+	require := require.New(t)
+	const minTargetElements = 10
+	mempool := newTestGossipMempool(
+		t,
+		withBloomFilter(minTargetElements, DefaultConfig.ExpectedBloomFilterFalsePositiveProbability, DefaultConfig.MaxBloomFilterFalsePositiveProbability),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	const amplitude = 5
+	var pending []*txs.Tx
+	add := func() {
+		tx := newTx()
+		require.NoError(mempool.Add(tx))
+		pending = append(pending, tx)
+	}
+	remove := func() {
+		mempool.Remove(pending[0])
+		pending = pending[1:]
+	}
+
+	// oscillate keeps the mempool size hovering within [amplitude] txs below
+	// its current size, returning the number of times the bloom filter was reset and the sizes
+	// it was reset to.
+	oscillate := func() (int, set.Set[int]) {
+		var (
+			numResets int
+			sizes     set.Set[int]
+		)
+		// Removals account for the staleness of the bloom filter, so the
+		// bloom filter may be reset by either removals or adds.
+		churn := func(f func()) {
+			_, salt := mempool.GetFilter()
+			f()
+			if _, newSalt := mempool.GetFilter(); !bytes.Equal(salt, newSalt) {
+				numResets++
+				sizes.Add(mempool.bloomElements)
+			}
+		}
+		for i := 0; i < 100; i++ {
+			for j := 0; j < amplitude; j++ {
+				churn(remove)
+			}
+			for j := 0; j < amplitude; j++ {
+				churn(add)
+			}
+		}
+		return numResets, sizes
+	}
+
+	const numTxs = 100
+	for i := 0; i < numTxs; i++ {
+		add()
+	}
+	_, _ = oscillate()
+
+	// The bloom filter is still reset as txs churn, but it isn't resized while
+	// the mempool size hovers around the same size.
+	bloomElements := mempool.bloomElements
+	require.GreaterOrEqual(bloomElements, (numTxs-amplitude)*DefaultConfig.BloomChurnMultiplier)
+	numResets, sizes := oscillate()
+	require.Positive(numResets)
+	require.Equal(set.Of(bloomElements), sizes)
+
+	// The bloom filter shrinks once the mempool is much smaller.
+	const numShrunkTxs = numTxs / (2 * bloomShrinkDivisor)
+	for len(pending) > numShrunkTxs {
+		remove()
+	}
+	_, _ = oscillate()
+	require.Less(mempool.bloomElements, bloomElements/bloomShrinkDivisor)
+}
+
+func TestGossipMempoolBloomChurnMultiplier(t *testing.T) {
+	// numResets returns the number of times the bloom filter is reset while
+	// adding txs to a mempool with [bloomChurnMultiplier].
+	numResets := func(t *testing.T, bloomChurnMultiplier int) int {
+		require := require.New(t)
+
+		metrics := prometheus.NewRegistry()
+		baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+		require.NoError(err)
+
+		mempool := newTestGossipMempool(
+			t,
+			withMempool(baseMempool),
+			withRegisterer(metrics),
+			withBloomFilter(1, DefaultConfig.ExpectedBloomFilterFalsePositiveProbability, DefaultConfig.MaxBloomFilterFalsePositiveProbability),
+			withBloomChurnMultiplier(bloomChurnMultiplier),
+		)
+
+		var numResets int
+		for i := 0; i < 1000; i++ {
+			tx := &txs.Tx{
+				Unsigned: &txs.BaseTx{
+					BaseTx: avax.BaseTx{
+						Ins: []*avax.TransferableInput{},
+					},
+				},
+				TxID: ids.GenerateTestID(),
+			}
+
+			_, salt := mempool.GetFilter()
+			require.NoError(mempool.Add(tx))
+			if _, newSalt := mempool.GetFilter(); !bytes.Equal(salt, newSalt) {
+				numResets++
+			}
+		}
+		return numResets
+	}
+
+	// A higher multiplier sizes the bloom filter for more txs, so it is reset
+	// less frequently.
+	require.Less(t, numResets(t, 10), numResets(t, 1))
+}
+
+func TestNewGossipMempoolInvalidBloomChurnMultiplier(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	_, err = newGossipMempool(
+		baseMempool,
+		metrics,
+		logging.NoLog{},
+		testVerifier{},
+		parser,
+		DefaultConfig.ExpectedBloomFilterElements,
+		DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
+		DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+		0,
+	)
+	require.ErrorIs(err, errInvalidBloomChurnMultiplier)
+}
+
+func TestGossipMempoolBloomMetrics(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	mempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+	)
+	require.Zero(testutil.ToFloat64(mempool.bloomCountMetric))
+	require.Zero(testutil.ToFloat64(mempool.bloomFalsePositiveProbabilityMetric))
+
+	const numTxs = 100
+	for i := 0; i < numTxs; i++ {
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(mempool.Add(tx))
+	}
+
+	require.Equal(float64(numTxs), testutil.ToFloat64(mempool.bloomCountMetric))
+	falsePositiveProbability := testutil.ToFloat64(mempool.bloomFalsePositiveProbabilityMetric)
+	require.Positive(falsePositiveProbability)
+	require.Less(falsePositiveProbability, DefaultConfig.ExpectedBloomFilterFalsePositiveProbability)
+}
+
+func TestGossipMempoolPullBloomFilter(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	g := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	// Txs added before the pull bloom filter is set are included in it.
+	var (
+		addedBefore = newTx()
+		addedAfter  = newTx()
+		removed     = newTx()
+	)
+	require.NoError(g.Add(addedBefore))
+
+	const (
+		pullElements                 = 4 * 8 * 1024
+		pullFalsePositiveProbability = .001
+		pullMaxFalsePositive         = .005
+	)
+	require.NoError(g.setPullBloomFilter(
+		metrics,
+		pullElements,
+		pullFalsePositiveProbability,
+		pullMaxFalsePositive,
+	))
+	require.NoError(g.Add(addedAfter))
+	require.NoError(g.Add(removed))
+
+	// The pull bloom filter is sized by the pull specific parameters, while
+	// the push bloom filter is unchanged.
+	expectedPullBloom, err := gossip.NewBloomFilter(prometheus.NewRegistry(), "", pullElements, pullFalsePositiveProbability, pullMaxFalsePositive)
+	require.NoError(err)
+	expectedPushBloom, err := gossip.NewBloomFilter(
+		prometheus.NewRegistry(),
+		"",
+		DefaultConfig.ExpectedBloomFilterElements,
+		DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
+		DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+	)
+	require.NoError(err)
+
+	requireFilters := func() {
+		expectedPullBytes, _ := expectedPullBloom.Marshal()
+		expectedPushBytes, _ := expectedPushBloom.Marshal()
+		pullBytes, _ := g.GetFilter()
+		pushBytes, _ := g.GetPushFilter()
+		require.Len(pullBytes, len(expectedPullBytes))
+		require.Len(pushBytes, len(expectedPushBytes))
+
+		// The push gossiper advertises the push bloom filter
+		pushGossipBytes, _ := pushGossipSet{g}.GetFilter()
+		require.Equal(pushBytes, pushGossipBytes)
+	}
+	requireFilters()
+	require.True(g.pullBloom.Has(addedBefore))
+	require.True(g.pullBloom.Has(addedAfter))
+	require.True(g.pullBloom.Has(removed))
+
+	// The bloom filters are rebuilt together, and the pull bloom filter keeps
+	// its size.
+	g.Mempool.Remove(removed)
+	g.lock.Lock()
+	g.numRemovedSinceReset = g.bloomElements
+	g.lock.Unlock()
+	require.NoError(g.rebuildBloomFilterIfNeeded())
+
+	requireFilters()
+	for _, filter := range []*gossip.BloomFilter{g.bloom, g.pullBloom} {
+		require.True(filter.Has(addedBefore))
+		require.True(filter.Has(addedAfter))
+		require.False(filter.Has(removed))
+	}
+}
+
+func TestGossipMempoolBloomSaltRotation(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	g := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+	)
+	require.NoError(g.setPullBloomFilter(
+		metrics,
+		DefaultConfig.ExpectedBloomFilterElements,
+		DefaultConfig.ExpectedBloomFilterFalsePositiveProbability,
+		DefaultConfig.MaxBloomFilterFalsePositiveProbability,
+	))
+
+	const rotationInterval = time.Minute
+	g.saltRotationInterval = rotationInterval
+	start := g.lastBloomReset
+	g.clock.Set(start)
+
+	mempoolTxs := make([]*txs.Tx, 10)
+	for i := range mempoolTxs {
+		mempoolTxs[i] = &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(g.Add(mempoolTxs[i]))
+	}
+
+	// requireConsistentFilters requires that every tx in the mempool is in the
+	// filters returned with their salts, and returns the salts.
+	requireConsistentFilters := func() (pullSalt []byte, pushSalt []byte) {
+		for _, getFilter := range []func() ([]byte, []byte){g.GetFilter, g.GetPushFilter} {
+			filterBytes, salt := getFilter()
+			filter, err := bloom.Parse(filterBytes)
+			require.NoError(err)
+			for _, tx := range mempoolTxs {
+				txID := tx.ID()
+				require.True(bloom.Contains(filter, txID[:], salt))
+			}
+			if pullSalt == nil {
+				pullSalt = salt
+			} else {
+				pushSalt = salt
+			}
+		}
+		return pullSalt, pushSalt
+	}
+
+	pullSalt, pushSalt := requireConsistentFilters()
+
+	// The salts aren't rotated before the interval elapses.
+	g.clock.Set(start.Add(rotationInterval - time.Nanosecond))
+	newPullSalt, newPushSalt := requireConsistentFilters()
+	require.Equal(pullSalt, newPullSalt)
+	require.Equal(pushSalt, newPushSalt)
+
+	// Once the interval elapses, the filters are rebuilt with new salts,
+	// even though the mempool wasn't modified.
+	rotationTime := start.Add(rotationInterval)
+	g.clock.Set(rotationTime)
+	newPullSalt, newPushSalt = requireConsistentFilters()
+	require.NotEqual(pullSalt, newPullSalt)
+	require.NotEqual(pushSalt, newPushSalt)
+	require.Equal(rotationTime, g.lastBloomReset)
+
+	// The interval restarts from the rotation.
+	pullSalt, pushSalt = newPullSalt, newPushSalt
+	g.clock.Set(rotationTime.Add(rotationInterval - time.Nanosecond))
+	newPullSalt, newPushSalt = requireConsistentFilters()
+	require.Equal(pullSalt, newPullSalt)
+	require.Equal(pushSalt, newPushSalt)
+}
+
+// pausingMempool pauses the first iteration over the mempool, after all of
+// its txs were iterated over, until resume is closed.
+type pausingMempool struct {
+	mempool.Mempool
+
+	once   sync.Once
+	paused chan struct{}
+	resume chan struct{}
+}
+
+func (m *pausingMempool) Iterate(f func(*txs.Tx) bool) {
+	m.Mempool.Iterate(f)
+	m.once.Do(func() {
+		close(m.paused)
+		<-m.resume
+	})
+}
+
+func TestGossipMempoolAddDuringBloomRebuild(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	pausingMempool := &pausingMempool{
+		Mempool: baseMempool,
+		paused:  make(chan struct{}),
+		resume:  make(chan struct{}),
+	}
+	g := newTestGossipMempool(
+		t,
+		withMempool(pausingMempool),
+		withRegisterer(metrics),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	const numTxs = 10
+	added := make([]*txs.Tx, 0, 2*numTxs)
+	for i := 0; i < numTxs; i++ {
+		tx := newTx()
+		require.NoError(g.Add(tx))
+		added = append(added, tx)
+	}
+	_, saltBefore := g.GetFilter()
+
+	// Force a rebuild, which is paused once it took a snapshot of the mempool.
+	g.lock.Lock()
+	g.numRemovedSinceReset = g.bloomElements
+	g.lock.Unlock()
+
+	rebuildErr := make(chan error, 1)
+	go func() {
+		rebuildErr <- g.rebuildBloomFilterIfNeeded()
+	}()
+	<-pausingMempool.paused
+
+	// Txs can be added while the bloom filter is being rebuilt.
+	for i := 0; i < numTxs; i++ {
+		tx := newTx()
+		require.NoError(g.Add(tx))
+		added = append(added, tx)
+	}
+
+	close(pausingMempool.resume)
+	require.NoError(<-rebuildErr)
+
+	_, saltAfter := g.GetFilter()
+	require.NotEqual(saltBefore, saltAfter)
+	for _, tx := range added {
+		require.True(g.bloom.Has(tx))
+		require.Contains(g.tracking, tx.ID())
+	}
+	require.False(g.bloomRebuilding)
+	require.Empty(g.addedDuringRebuild)
+}
+
+func TestGossipMempoolRemoveTxs(t *testing.T) {
+	require := require.New(t)
+	// The false positive probability is small enough that the bloom filter
+	// reports exactly the txs that were added to it.
+	mempool := newTestGossipMempool(
+		t,
+		withBloomFilter(10, 0.000001, 0.00001),
+	)
+
+	const numTxs = 100
+	addedTxs := make([]*txs.Tx, numTxs)
+	for i := range addedTxs {
+		addedTxs[i] = &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(mempool.Add(addedTxs[i]))
+	}
+
+	txIDs := func(txsToRemove []*txs.Tx) []ids.ID {
+		txIDs := make([]ids.ID, len(txsToRemove))
+		for i, tx := range txsToRemove {
+			txIDs[i] = tx.ID()
+		}
+		return txIDs
+	}
+
+	// Removing fewer txs than the threshold leaves the bloom filter intact.
+	numBeforeRebuild := (mempool.bloomElements+bloomRebuildDivisor-1)/bloomRebuildDivisor - 1
+	require.Less(numBeforeRebuild, numTxs)
+	_, salt := mempool.GetFilter()
+	require.NoError(mempool.RemoveTxs(txIDs(addedTxs[:numBeforeRebuild])...))
+	_, newSalt := mempool.GetFilter()
+	require.Equal(salt, newSalt)
+	for _, tx := range addedTxs[:numBeforeRebuild] {
+		require.False(mempool.Has(tx.ID()))
+		require.True(mempool.bloom.Has(tx))
+	}
+
+	// Removing one more tx rebuilds the bloom filter from the remaining txs.
+	require.NoError(mempool.RemoveTxs(addedTxs[numBeforeRebuild].ID(), ids.GenerateTestID()))
+	_, newSalt = mempool.GetFilter()
+	require.NotEqual(salt, newSalt)
+	require.Zero(mempool.numRemovedSinceReset)
+
+	removed, remaining := addedTxs[:numBeforeRebuild+1], addedTxs[numBeforeRebuild+1:]
+	require.Equal(len(remaining), mempool.Len())
+	for _, tx := range removed {
+		require.False(mempool.Has(tx.ID()))
+		require.False(mempool.bloom.Has(tx))
+		require.NotContains(mempool.tracking, tx.ID())
+	}
+	for _, tx := range remaining {
+		require.True(mempool.Has(tx.ID()))
+		require.True(mempool.bloom.Has(tx))
+		require.Contains(mempool.tracking, tx.ID())
+	}
+}
+
+func TestGossipMempoolRemove(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	// The false positive probability is small enough that the bloom filter
+	// reports exactly the txs that were added to it.
+	mempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withBloomFilter(10, 0.000001, 0.00001),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	const numTxs = 100
+	addedTxs := make([]*txs.Tx, numTxs)
+	for i := range addedTxs {
+		addedTxs[i] = newTx()
+		require.NoError(mempool.Add(addedTxs[i]))
+	}
+
+	// Txs that weren't in the mempool, such as txs accepted in blocks built by
+	// peers, don't make the bloom filter stale.
+	numBeforeRebuild := (mempool.bloomElements+bloomRebuildDivisor-1)/bloomRebuildDivisor - 1
+	require.Less(numBeforeRebuild, numTxs)
+	unknownTxs := make([]*txs.Tx, numTxs)
+	for i := range unknownTxs {
+		unknownTxs[i] = newTx()
+	}
+	_, salt := mempool.GetFilter()
+	mempool.Remove(unknownTxs...)
+	require.Zero(mempool.numRemovedSinceReset)
+
+	// Removing fewer txs than the threshold leaves the bloom filter intact.
+	mempool.Remove(addedTxs[:numBeforeRebuild]...)
+	_, newSalt := mempool.GetFilter()
+	require.Equal(salt, newSalt)
+	require.Equal(numBeforeRebuild, mempool.numRemovedSinceReset)
+	for _, tx := range addedTxs[:numBeforeRebuild] {
+		require.False(mempool.Has(tx.ID()))
+		require.True(mempool.bloom.Has(tx))
+	}
+
+	// Removing one more tx makes enough of the bloom filter stale for it to
+	// be rebuilt from the remaining txs.
+	mempool.Remove(addedTxs[numBeforeRebuild])
+	_, newSalt = mempool.GetFilter()
+	require.NotEqual(salt, newSalt)
+	require.Zero(mempool.numRemovedSinceReset)
+
+	removed, remaining := addedTxs[:numBeforeRebuild+1], addedTxs[numBeforeRebuild+1:]
+	for _, tx := range removed {
+		require.False(mempool.bloom.Has(tx))
+		require.NotContains(mempool.tracking, tx.ID())
+	}
+	for _, tx := range remaining {
+		require.True(mempool.bloom.Has(tx))
+	}
+}
+
+func TestGossipMempoolDeferBloomRebuild(t *testing.T) {
+	require := require.New(t)
+	gossipMempool := newTestGossipMempool(
+		t,
+		withBloomFilter(10, 0.000001, 0.00001),
+	)
+
+	const maxLoad = 1
+	load := float64(maxLoad + 1)
+	gossipMempool.bloomRebuildLoad = func() float64 {
+		return load
+	}
+	gossipMempool.bloomRebuildMaxLoad = maxLoad
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	// Under high load, the bloom filter isn't reset even though it exceeds
+	// its false positive probability.
+	_, salt := gossipMempool.GetFilter()
+	const numTxs = 100
+	addedTxs := make([]*txs.Tx, numTxs)
+	for i := range addedTxs {
+		addedTxs[i] = newTx()
+		require.NoError(gossipMempool.Add(addedTxs[i]))
+	}
+	_, newSalt := gossipMempool.GetFilter()
+	require.Equal(salt, newSalt)
+	require.True(gossipMempool.bloomRebuildDeferred)
+	require.True(gossipMempool.bloom.NeedsReset())
+
+	// Once the load drops, the deferred reset is performed.
+	load = maxLoad
+	_, newSalt = gossipMempool.GetFilter()
+	require.NotEqual(salt, newSalt)
+	require.False(gossipMempool.bloomRebuildDeferred)
+	require.False(gossipMempool.bloom.NeedsReset())
+	for _, tx := range addedTxs {
+		require.True(gossipMempool.bloom.Has(tx))
+	}
+
+	// Under high load, removing txs doesn't rebuild the bloom filter.
+	load = maxLoad + 1
+	salt = newSalt
+	numToRemove := (gossipMempool.bloomElements + bloomRebuildDivisor - 1) / bloomRebuildDivisor
+	require.Less(numToRemove, numTxs)
+	removed, remaining := addedTxs[:numToRemove], addedTxs[numToRemove:]
+	for _, tx := range removed {
+		require.NoError(gossipMempool.RemoveTxs(tx.ID()))
+	}
+	_, newSalt = gossipMempool.GetFilter()
+	require.Equal(salt, newSalt)
+	require.True(gossipMempool.bloomRebuildDeferred)
+	for _, tx := range removed {
+		require.True(gossipMempool.bloom.Has(tx))
+	}
+
+	// Once the load drops, the next modification of the mempool performs the
+	// deferred rebuild.
+	load = maxLoad
+	tx := newTx()
+	require.NoError(gossipMempool.Add(tx))
+	require.False(gossipMempool.bloomRebuildDeferred)
+	require.Zero(gossipMempool.numRemovedSinceReset)
+	for _, tx := range removed {
+		require.False(gossipMempool.bloom.Has(tx))
+	}
+	for _, tx := range append(remaining, tx) {
+		require.True(gossipMempool.bloom.Has(tx))
+	}
+}
+
+func TestGossipMempoolStatusHint(t *testing.T) {
+	require := require.New(t)
+	gossipMempool := newTestGossipMempool(t)
+	gossipMempool.recentlyAccepted = &cache.LRU[ids.ID, struct{}]{Size: maxRecentlyAcceptedTxs}
+
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+	require.Equal(gossip.StatusUnknown, gossipMempool.StatusHint(tx))
+
+	require.NoError(gossipMempool.Add(tx))
+	require.Equal(gossip.StatusPending, gossipMempool.StatusHint(tx))
+
+	// Txs are marked as accepted before they are removed from the mempool.
+	gossipMempool.MarkAccepted(tx.ID())
+	require.Equal(gossip.StatusAccepted, gossipMempool.StatusHint(tx))
+
+	require.NoError(gossipMempool.RemoveTxs(tx.ID()))
+	require.Equal(gossip.StatusAccepted, gossipMempool.StatusHint(tx))
+}
+
+func TestGossipMempoolStatusHintDisabled(t *testing.T) {
+	require := require.New(t)
+	gossipMempool := newTestGossipMempool(t)
+
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+	require.NoError(gossipMempool.Add(tx))
+
+	// Accepted txs are not remembered unless status hints are reported.
+	gossipMempool.MarkAccepted(tx.ID())
+	require.Equal(gossip.StatusPending, gossipMempool.StatusHint(tx))
+}
+
+func TestGossipMempoolConflicts(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(
+		[]fxs.Fx{
+			&secp256k1fx.Fx{},
+		},
+	)
+	require.NoError(err)
+
+	newGossipMempoolWithConflicts := func() *gossipMempool {
+		metrics := prometheus.NewRegistry()
+		baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+		require.NoError(err)
+
+		mempool := newTestGossipMempool(
+			t,
+			withMempool(baseMempool),
+			withRegisterer(metrics),
+			withParser(parser),
+		)
+		mempool.conflictSets = newConflictSets()
+		return mempool
+	}
+
+	// newTx returns a tx that consumes the UTXOs produced by [utxoTxIDs]. The
+	// memo distinguishes txs that consume the same UTXOs.
+	newTx := func(memo byte, utxoTxIDs ...ids.ID) *txs.Tx {
+		ins := make([]*avax.TransferableInput, len(utxoTxIDs))
+		for i, utxoTxID := range utxoTxIDs {
+			ins[i] = &avax.TransferableInput{
+				UTXOID: avax.UTXOID{
+					TxID: utxoTxID,
+				},
+				Asset: avax.Asset{
+					ID: ids.Empty,
+				},
+				In: &secp256k1fx.TransferInput{
+					Amt: 1,
+				},
+			}
+		}
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins:  ins,
+					Memo: []byte{memo},
+				},
+			},
+		}
+		require.NoError(tx.Initialize(parser.Codec()))
+		return tx
+	}
+
+	var (
+		utxo0     = ids.GenerateTestID()
+		utxo1     = ids.GenerateTestID()
+		tx        = newTx(0, utxo0, utxo1)
+		conflict0 = newTx(1, utxo0)
+		conflict1 = newTx(2, utxo1)
+		// conflict01 conflicts with tx on both UTXOs
+		conflict01 = newTx(3, utxo0, utxo1)
+		unrelated  = newTx(4, ids.GenerateTestID())
+	)
+
+	server := newGossipMempoolWithConflicts()
+	require.NoError(server.Add(tx))
+	require.NoError(server.Add(unrelated))
+	for _, conflict := range []*txs.Tx{conflict0, conflict1, conflict01} {
+		err := server.Add(conflict)
+		require.ErrorIs(err, mempool.ErrConflictsWithOtherTx)
+	}
+	require.ElementsMatch([]*txs.Tx{conflict0, conflict1, conflict01}, server.Conflicts(tx))
+	// Only the txs that are not in the mempool are remembered as conflicts
+	require.ElementsMatch([]*txs.Tx{conflict01}, server.Conflicts(conflict0))
+	require.Empty(server.Conflicts(unrelated))
+
+	// Serve a pull request from a peer that doesn't know about any txs
+	metrics, err := gossip.NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := gossip.NewHandler[*txs.Tx](
+		logging.NoLog{},
+		&txParser{
+			parser: parser,
+		},
+		server,
+		metrics,
+		DefaultConfig.TargetGossipSize,
+		gossip.WithDependencyBundles(txDependencies),
+		gossip.WithConflicts(server.Conflicts),
+	)
+
+	client := newGossipMempoolWithConflicts()
+	filter, salt := client.GetFilter()
+	requestBytes, err := gossip.MarshalAppRequest(filter, salt)
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	bundles, err := gossip.ParseAppResponseBundles(responseBytes)
+	require.NoError(err)
+	require.Len(bundles, 5)
+	for _, bundle := range bundles {
+		for _, txBytes := range bundle {
+			tx, err := parser.ParseTx(txBytes)
+			require.NoError(err)
+			_ = client.AddFromPeer(ids.EmptyNodeID, tx)
+		}
+	}
+
+	// The client learns the full conflict set of tx
+	require.True(client.Has(tx.ID()))
+	require.True(client.Has(unrelated.ID()))
+	clientConflictIDs := make([]ids.ID, 0, 3)
+	for _, conflict := range client.Conflicts(tx) {
+		clientConflictIDs = append(clientConflictIDs, conflict.ID())
+	}
+	require.ElementsMatch([]ids.ID{conflict0.ID(), conflict1.ID(), conflict01.ID()}, clientConflictIDs)
+}
+
+func TestGossipMempoolPeerDrops(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &testVerifier{}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	const maxPerPeer = 4
+	gossipMempool.peerDrops, err = newPeerDropTracker(maxPerPeer, metrics)
+	require.NoError(err)
+
+	newTx := func(inputTxID ids.ID) *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{
+						{
+							UTXOID: avax.UTXOID{
+								TxID: inputTxID,
+							},
+						},
+					},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	var (
+		spammer = ids.GenerateTestNodeID()
+		peer    = ids.GenerateTestNodeID()
+		utxoID  = ids.GenerateTestID()
+	)
+
+	// A peer that sends a conflicting tx has it tracked as conflicting
+	require.NoError(gossipMempool.Add(newTx(utxoID)))
+	err = gossipMempool.AddFromPeer(peer, newTx(utxoID))
+	require.ErrorIs(err, mempool.ErrConflictsWithOtherTx)
+	numDropped, numConflicting := gossipMempool.PeerDrops(peer)
+	require.Equal(1, numDropped)
+	require.Equal(1, numConflicting)
+
+	// A peer that sends many invalid txs can't exceed its limit
+	verifier.err = errTest
+	const numSpam = 3 * maxPerPeer
+	for i := 0; i < numSpam; i++ {
+		err := gossipMempool.AddFromPeer(spammer, newTx(ids.GenerateTestID()))
+		require.ErrorIs(err, errTest)
+	}
+	numDropped, numConflicting = gossipMempool.PeerDrops(spammer)
+	require.Equal(maxPerPeer, numDropped)
+	require.Zero(numConflicting)
+	require.Equal(float64(numSpam-maxPerPeer), testutil.ToFloat64(gossipMempool.peerDrops.evictions))
+
+	// Other peers are unaffected
+	numDropped, numConflicting = gossipMempool.PeerDrops(peer)
+	require.Equal(1, numDropped)
+	require.Equal(1, numConflicting)
+
+	// Locally issued txs are not tracked
+	err = gossipMempool.Add(newTx(ids.GenerateTestID()))
+	require.ErrorIs(err, errTest)
+	numDropped, _ = gossipMempool.PeerDrops(ids.EmptyNodeID)
+	require.Zero(numDropped)
+}
+
+// concurrencyVerifier records the maximum number of concurrent calls to
+// VerifyTx.
+type concurrencyVerifier struct {
+	lock          sync.Mutex
+	current       int
+	maxConcurrent int
+}
+
+func (v *concurrencyVerifier) VerifyTx(*txs.Tx) error {
+	v.lock.Lock()
+	v.current++
+	v.maxConcurrent = max(v.maxConcurrent, v.current)
+	v.lock.Unlock()
+
+	// Give other verifications the chance to run concurrently.
+	time.Sleep(time.Millisecond)
+
+	v.lock.Lock()
+	v.current--
+	v.lock.Unlock()
+	return nil
+}
+
+func (v *concurrencyVerifier) VerifyTxs(txs []*txs.Tx) []error {
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		errs[i] = v.VerifyTx(tx)
+	}
+	return errs
+}
+
+func (v *concurrencyVerifier) VerifyProjectedTx(tx *txs.Tx) error {
+	return v.VerifyTx(tx)
+}
+
+func TestGossipMempoolSharedVerificationLimiter(t *testing.T) {
+	require := require.New(t)
+
+	const (
+		maxConcurrentVerifications = 2
+		numTxsPerMempool           = 16
+	)
+
+	var (
+		limiter  = semaphore.NewWeighted(maxConcurrentVerifications)
+		verifier = &concurrencyVerifier{}
+		mempools = make([]*gossipMempool, 2)
+	)
+	for i := range mempools {
+		mempools[i] = newTestGossipMempool(
+			t,
+			withTxVerifier(NewLimitedTxVerifier(limiter, verifier)),
+		)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, len(mempools)*numTxsPerMempool)
+	)
+	for _, mempool := range mempools {
+		for i := 0; i < numTxsPerMempool; i++ {
+			wg.Add(1)
+			go func(mempool *gossipMempool) {
+				defer wg.Done()
+
+				errs <- mempool.Add(&txs.Tx{
+					Unsigned: &txs.BaseTx{
+						BaseTx: avax.BaseTx{
+							Ins: []*avax.TransferableInput{},
+						},
+					},
+					TxID: ids.GenerateTestID(),
+				})
+			}(mempool)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(err)
+	}
+	require.LessOrEqual(verifier.maxConcurrent, maxConcurrentVerifications)
+}
+
+// signalingLocker signals on [locking] every time Lock is called, before it
+// waits to acquire the lock.
+type signalingLocker struct {
+	sync.Mutex
+	locking chan struct{}
+}
+
+func (l *signalingLocker) Lock() {
+	l.locking <- struct{}{}
+	l.Mutex.Lock()
+}
+
+// A chain whose lock is held must not occupy the verification slots shared
+// with other chains.
+func TestSharedVerificationLimiterChainLockHeld(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		limiter = semaphore.NewWeighted(1)
+		tx      = &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+
+		blockedLock = &signalingLocker{
+			locking: make(chan struct{}, 1),
+		}
+		blockedVerifier = NewLockedTxVerifier(
+			blockedLock,
+			NewLimitedTxVerifier(limiter, testVerifier{}),
+		)
+		verifier = NewLockedTxVerifier(
+			&sync.Mutex{},
+			NewLimitedTxVerifier(limiter, testVerifier{}),
+		)
+	)
+
+	// Hold the lock of the first chain while it attempts to verify a tx.
+	blockedLock.Mutex.Lock()
+	blockedErr := make(chan error, 1)
+	go func() {
+		blockedErr <- blockedVerifier.VerifyTx(tx)
+	}()
+	<-blockedLock.locking
+
+	// The second chain can still verify txs.
+	verified := make(chan error, 1)
+	go func() {
+		verified <- verifier.VerifyTx(tx)
+	}()
+	select {
+	case err := <-verified:
+		require.NoError(err)
+	case <-time.After(time.Minute):
+		require.FailNow("verification was blocked by another chain's lock")
+	}
+
+	blockedLock.Mutex.Unlock()
+	require.NoError(<-blockedErr)
+}
+
+// A tx that is added concurrently should not be marked as dropped
+func TestGossipMempoolAddConcurrentDuplicate(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+	txID := tx.ID()
+
+	// Simulate the tx being added between the Contains check and the Add.
+	baseMempool := mempool.NewMockMempool(ctrl)
+	baseMempool.EXPECT().Contains(txID).Return(false)
+	baseMempool.EXPECT().GetDropReason(txID).Return(nil)
+	baseMempool.EXPECT().Add(tx).Return(mempool.ErrDuplicateTx)
+
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+	)
+
+	err := gossipMempool.Add(tx)
+	require.ErrorIs(err, mempool.ErrDuplicateTx)
+	require.False(gossipMempool.bloom.Has(tx))
+}
+
+func TestGossipMempoolAddRace(t *testing.T) {
+	require := require.New(t)
+
+	const numAdds = 16
+	mempool := newTestGossipMempool(t)
+
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numAdds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_ = mempool.Add(tx)
+		}()
+	}
+	wg.Wait()
+
+	require.True(mempool.Has(tx.ID()))
+	require.True(mempool.bloom.Has(tx))
+	require.NoError(mempool.GetDropReason(tx.ID()))
+}
+
+// blockingVerifier signals [verifying] when a tx is being verified and blocks
+// until [release] is closed.
+type blockingVerifier struct {
+	testVerifier
+	verifying chan struct{}
+	release   chan struct{}
+}
+
+func (v *blockingVerifier) VerifyTx(tx *txs.Tx) error {
+	v.verifying <- struct{}{}
+	<-v.release
+	return v.testVerifier.VerifyTx(tx)
+}
+
+func TestGossipMempoolClose(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &blockingVerifier{
+		verifying: make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	// Close while a tx is being added.
+	inFlightTx := newTx()
+	addErr := make(chan error)
+	go func() {
+		addErr <- gossipMempool.Add(inFlightTx)
+	}()
+	<-verifier.verifying
+
+	// Close doesn't wait for the in-flight add, which may be waiting on the
+	// engine lock held by the caller of Close.
+	gossipMempool.Close()
+
+	// Once verified, the in-flight tx isn't added.
+	close(verifier.release)
+	require.ErrorIs(<-addErr, ErrMempoolClosed)
+	require.False(gossipMempool.Has(inFlightTx.ID()))
+
+	// Subsequent adds are rejected.
+	tx := newTx()
+	err = gossipMempool.Add(tx)
+	require.ErrorIs(err, ErrMempoolClosed)
+	var addTxErr *AddTxError
+	require.ErrorAs(err, &addTxErr)
+	require.Equal(AddTxRejected, addTxErr.Failure)
+
+	require.ErrorIs(gossipMempool.AddWithoutVerification(tx), ErrMempoolClosed)
+	for _, err := range gossipMempool.AddBatchFromPeer(ids.GenerateTestNodeID(), []*txs.Tx{tx, newTx()}) {
+		require.ErrorIs(err, ErrMempoolClosed)
+	}
+	require.False(gossipMempool.Has(tx.ID()))
+
+	// Closing again is a no-op.
+	gossipMempool.Close()
+}
+
+func TestTxGossipHandlerRequestLimit(t *testing.T) {
+	require := require.New(t)
+
+	const maxConcurrentRequests = 2
+
+	var (
+		handling = make(chan struct{})
+		release  = make(chan struct{})
+	)
+	handler := txGossipHandler{
+		appRequestHandler: p2p.TestHandler{
+			AppRequestF: func(context.Context, ids.NodeID, time.Time, []byte) ([]byte, error) {
+				handling <- struct{}{}
+				<-release
+				return nil, nil
+			},
+		},
+		requestLimiter: semaphore.NewWeighted(maxConcurrentRequests),
+	}
+
+	// Saturate the limiter.
+	errs := make(chan error, maxConcurrentRequests)
+	for i := 0; i < maxConcurrentRequests; i++ {
+		go func() {
+			_, err := handler.AppRequest(context.Background(), ids.GenerateTestNodeID(), time.Time{}, nil)
+			errs <- err
+		}()
+		<-handling
+	}
+
+	// Excess requests are refused without waiting for a request to finish.
+	_, err := handler.AppRequest(context.Background(), ids.GenerateTestNodeID(), time.Time{}, nil)
+	require.ErrorIs(err, p2p.ErrThrottled)
+
+	close(release)
+	for i := 0; i < maxConcurrentRequests; i++ {
+		require.NoError(<-errs)
+	}
+
+	// Requests are served again once the in-flight requests finish.
+	go func() {
+		<-handling
+	}()
+	_, err = handler.AppRequest(context.Background(), ids.GenerateTestNodeID(), time.Time{}, nil)
+	require.NoError(err)
+}
+
+func TestGossipMempoolRetryableVerification(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &testVerifier{}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+	const maxDeferredTxs = 2
+	gossipMempool.deferred, err = newDeferredTxs(maxDeferredTxs)
+	require.NoError(err)
+	gossiper := retryingGossiper{
+		Gossiper: gossip.NoOpGossiper{},
+		mempool:  gossipMempool,
+	}
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+	nodeID := ids.GenerateTestNodeID()
+
+	// Txs that fail with a retryable error are deferred rather than dropped.
+	retryableErr := fmt.Errorf("%w: %w", ErrRetryable, errTest)
+	verifier.err = retryableErr
+	deferredTx := newTx()
+	err = gossipMempool.AddFromPeer(nodeID, deferredTx)
+	require.ErrorIs(err, ErrRetryable)
+	require.NoError(gossipMempool.getDropReason(deferredTx.ID()))
+	require.Equal(1, gossipMempool.deferred.len())
+
+	// Txs that fail with a permanent error are dropped rather than deferred.
+	verifier.err = errTest
+	invalidTx := newTx()
+	err = gossipMempool.AddFromPeer(nodeID, invalidTx)
+	require.ErrorIs(err, errTest)
+	require.NotErrorIs(err, ErrRetryable)
+	require.ErrorIs(gossipMempool.getDropReason(invalidTx.ID()), errTest)
+	require.Equal(1, gossipMempool.deferred.len())
+
+	// Deferred txs aren't retried until the state changes.
+	verifier.err = nil
+	require.NoError(gossiper.Gossip(context.Background()))
+	require.False(gossipMempool.Has(deferredTx.ID()))
+	require.Equal(1, gossipMempool.deferred.len())
+
+	gossipMempool.Remove(invalidTx)
+	require.NoError(gossiper.Gossip(context.Background()))
+	require.True(gossipMempool.Has(deferredTx.ID()))
+	require.Zero(gossipMempool.deferred.len())
+
+	// Txs that fail with a retryable error again are deferred again.
+	verifier.err = retryableErr
+	retriedTxs := []*txs.Tx{newTx(), newTx()}
+	for _, tx := range retriedTxs {
+		require.ErrorIs(gossipMempool.AddFromPeer(nodeID, tx), ErrRetryable)
+	}
+	gossipMempool.Remove()
+	require.NoError(gossiper.Gossip(context.Background()))
+	require.Equal(len(retriedTxs), gossipMempool.deferred.len())
+
+	// The oldest deferred tx is forgotten once the queue is full.
+	require.ErrorIs(gossipMempool.AddFromPeer(nodeID, newTx()), ErrRetryable)
+	require.Equal(maxDeferredTxs, gossipMempool.deferred.len())
+
+	verifier.err = nil
+	gossipMempool.Remove()
+	require.NoError(gossiper.Gossip(context.Background()))
+	require.False(gossipMempool.Has(retriedTxs[0].ID()))
+	require.True(gossipMempool.Has(retriedTxs[1].ID()))
+}
+
+func TestGossipMempoolOnAdd(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	verifier := &testVerifier{}
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withTxVerifier(verifier),
+	)
+
+	var added []ids.ID
+	gossipMempool.onAdd = func(tx *txs.Tx) {
+		// The callback is called without the lock held.
+		require.True(gossipMempool.lock.TryLock())
+		gossipMempool.lock.Unlock()
+
+		added = append(added, tx.ID())
+	}
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	// Txs added from peers and without verification are reported.
+	gossipedTx := newTx()
+	require.NoError(gossipMempool.AddFromPeer(ids.GenerateTestNodeID(), gossipedTx))
+	issuedTx := newTx()
+	require.NoError(gossipMempool.AddWithoutVerification(issuedTx))
+	require.Equal([]ids.ID{gossipedTx.ID(), issuedTx.ID()}, added)
+
+	// Duplicates aren't reported again.
+	err = gossipMempool.AddFromPeer(ids.GenerateTestNodeID(), gossipedTx)
+	require.ErrorIs(err, mempool.ErrDuplicateTx)
+	err = gossipMempool.AddWithoutVerification(issuedTx)
+	require.ErrorIs(err, mempool.ErrDuplicateTx)
+
+	// Dropped txs aren't reported.
+	verifier.err = errTest
+	err = gossipMempool.AddFromPeer(ids.GenerateTestNodeID(), newTx())
+	require.ErrorIs(err, errTest)
+
+	require.Equal([]ids.ID{gossipedTx.ID(), issuedTx.ID()}, added)
+}
+
+func TestGossipMempoolMaxAge(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withParser(parser),
+	)
+
+	// newTx returns a tx that is distinguished from other txs by [memo].
+	newTx := func(memo byte) *txs.Tx {
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins:  []*avax.TransferableInput{},
+					Memo: []byte{memo},
+				},
+			},
+		}
+		require.NoError(tx.Initialize(parser.Codec()))
+		return tx
+	}
+
+	const maxAge = time.Hour
+	now := time.Now()
+	staleTx := newTx(0)
+	gossipMempool.clock.Set(now.Add(-2 * maxAge))
+	require.NoError(gossipMempool.Add(staleTx))
+	freshTx := newTx(1)
+	gossipMempool.clock.Set(now)
+	require.NoError(gossipMempool.Add(freshTx))
+
+	addedTime, ok := gossipMempool.AddedTime(staleTx)
+	require.True(ok)
+	require.Equal(now.Add(-2*maxAge), addedTime)
+	_, ok = gossipMempool.AddedTime(newTx(2))
+	require.False(ok)
+
+	gossipMetrics, err := gossip.NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := gossip.NewHandler[*txs.Tx](
+		logging.NoLog{},
+		&txParser{
+			parser: parser,
+		},
+		gossipMempool,
+		gossipMetrics,
+		DefaultConfig.TargetGossipSize,
+		gossip.WithMaxAge(gossipMempool.AddedTime, maxAge),
+	)
+
+	requesterBloom, err := gossip.NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := gossip.MarshalAppRequest(requesterBloom.Marshal())
+	require.NoError(err)
+	responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+	require.NoError(err)
+
+	// Only the tx that was added within the max age is served.
+	gossipBytes, err := gossip.ParseAppResponse(responseBytes)
+	require.NoError(err)
+	require.Equal([][]byte{freshTx.Bytes()}, gossipBytes)
+
+	// Pruning removes the stale tx from the mempool and stops it from being
+	// added again.
+	var numGossiped int
+	pruner := &pruningGossiper{
+		Gossiper: &gossip.TestGossiper{
+			GossipF: func(context.Context) error {
+				numGossiped++
+				return nil
+			},
+		},
+		mempool: gossipMempool,
+		maxAge:  maxAge,
+	}
+	require.NoError(pruner.Gossip(context.Background()))
+	require.Equal(1, numGossiped)
+	require.False(gossipMempool.Has(staleTx.ID()))
+	require.True(gossipMempool.Has(freshTx.ID()))
+	require.ErrorIs(gossipMempool.Add(staleTx), errTxTooOld)
+}
+
+func TestGossipMempoolMarshalCache(t *testing.T) {
+	require := require.New(t)
+
+	metrics := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", metrics, make(chan common.Message, 1))
+	require.NoError(err)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	gossipMempool := newTestGossipMempool(
+		t,
+		withMempool(baseMempool),
+		withRegisterer(metrics),
+		withParser(parser),
+	)
+	gossipMempool.marshalCache, err = gossip.NewMarshalCache(1)
+	require.NoError(err)
+
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+	}
+	require.NoError(tx.Initialize(parser.Codec()))
+	require.NoError(gossipMempool.Add(tx))
+
+	gossipMetrics, err := gossip.NewMetrics(prometheus.NewRegistry(), "")
+	require.NoError(err)
+	handler := gossip.NewHandler[*txs.Tx](
+		logging.NoLog{},
+		&txParser{
+			parser:    parser,
+			onMarshal: gossipMempool.MarkGossiped,
+		},
+		gossipMempool,
+		gossipMetrics,
+		DefaultConfig.TargetGossipSize,
+		gossip.WithMarshalCache[*txs.Tx](gossipMempool.marshalCache),
+	)
+
+	requesterBloom, err := gossip.NewBloomFilter(prometheus.NewRegistry(), "", 1000, 0.01, 0.05)
+	require.NoError(err)
+	requestBytes, err := gossip.MarshalAppRequest(requesterBloom.Marshal())
+	require.NoError(err)
+	for i := 0; i < 2; i++ {
+		responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, requestBytes)
+		require.NoError(err)
+		gossipBytes, err := gossip.ParseAppResponse(responseBytes)
+		require.NoError(err)
+		require.Equal([][]byte{tx.Bytes()}, gossipBytes)
+	}
+
+	// Serving the cached bytes is still recorded as a gossip attempt.
+	require.Equal(1, gossipMempool.marshalCache.Len())
+	require.Equal(2, gossipMempool.tracking[tx.ID()].gossipAttempts)
+
+	// Removing the tx evicts it from the cache.
+	gossipMempool.Remove(tx)
+	require.Zero(gossipMempool.marshalCache.Len())
 }
@@ -0,0 +1,74 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/utils/wrappers"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+)
+
+const mempoolSummaryLen = wrappers.IntLen + wrappers.LongLen
+
+var (
+	_ p2p.Handler = (*mempoolSummaryHandler)(nil)
+
+	errInvalidMempoolSummaryLen = errors.New("invalid mempool summary length")
+)
+
+// MempoolSummary describes the contents of a mempool without including any of
+// the txs.
+type MempoolSummary struct {
+	// NumTxs is the number of txs in the mempool.
+	NumTxs uint32
+	// NumBytes is the total size of the txs in the mempool.
+	NumBytes uint64
+}
+
+func MarshalMempoolSummary(summary MempoolSummary) []byte {
+	bytes := make([]byte, mempoolSummaryLen)
+	binary.BigEndian.PutUint32(bytes, summary.NumTxs)
+	binary.BigEndian.PutUint64(bytes[wrappers.IntLen:], summary.NumBytes)
+	return bytes
+}
+
+func ParseMempoolSummary(bytes []byte) (MempoolSummary, error) {
+	if len(bytes) != mempoolSummaryLen {
+		return MempoolSummary{}, fmt.Errorf("%w: expected %d bytes but got %d",
+			errInvalidMempoolSummaryLen,
+			mempoolSummaryLen,
+			len(bytes),
+		)
+	}
+	return MempoolSummary{
+		NumTxs:   binary.BigEndian.Uint32(bytes),
+		NumBytes: binary.BigEndian.Uint64(bytes[wrappers.IntLen:]),
+	}, nil
+}
+
+// mempoolSummaryHandler serves a summary of the mempool so that peers can
+// cheaply decide whether a full pull of the mempool is worthwhile.
+type mempoolSummaryHandler struct {
+	p2p.NoOpHandler
+	mempool mempool.Mempool
+}
+
+func (h *mempoolSummaryHandler) AppRequest(
+	context.Context,
+	ids.NodeID,
+	time.Time,
+	[]byte,
+) ([]byte, error) {
+	return MarshalMempoolSummary(MempoolSummary{
+		NumTxs:   uint32(h.mempool.Len()),
+		NumBytes: uint64(h.mempool.Size()),
+	}), nil
+}
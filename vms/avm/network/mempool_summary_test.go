@@ -0,0 +1,65 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+)
+
+func TestMempoolSummaryHandler(t *testing.T) {
+	require := require.New(t)
+
+	mempool, err := mempool.New("", prometheus.NewRegistry(), nil)
+	require.NoError(err)
+
+	handler := &mempoolSummaryHandler{
+		mempool: mempool,
+	}
+
+	numBytes := 0
+	for i := 0; i < 3; i++ {
+		tx := &txs.Tx{Unsigned: &txs.BaseTx{BaseTx: avax.BaseTx{
+			Ins: []*avax.TransferableInput{{
+				UTXOID: avax.UTXOID{
+					OutputIndex: uint32(i),
+				},
+			}},
+		}}}
+		tx.SetBytes(utils.RandomBytes(32), utils.RandomBytes(64))
+		require.NoError(mempool.Add(tx))
+		numBytes += len(tx.Bytes())
+
+		responseBytes, err := handler.AppRequest(context.Background(), ids.EmptyNodeID, time.Time{}, nil)
+		require.NoError(err)
+
+		// The summary must not include the contents of the mempool.
+		require.Len(responseBytes, mempoolSummaryLen)
+
+		summary, err := ParseMempoolSummary(responseBytes)
+		require.NoError(err)
+		require.Equal(
+			MempoolSummary{
+				NumTxs:   uint32(i + 1),
+				NumBytes: uint64(numBytes),
+			},
+			summary,
+		)
+	}
+}
+
+func TestParseMempoolSummaryInvalidLength(t *testing.T) {
+	_, err := ParseMempoolSummary(make([]byte, mempoolSummaryLen+1))
+	require.ErrorIs(t, err, errInvalidMempoolSummaryLen)
+}
@@ -5,13 +5,20 @@ package network
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 
+	"github.com/ava-labs/avalanchego/cache"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/network/p2p"
 	"github.com/ava-labs/avalanchego/network/p2p/gossip"
+	"github.com/ava-labs/avalanchego/snow"
 	"github.com/ava-labs/avalanchego/snow/engine/common"
 	"github.com/ava-labs/avalanchego/snow/validators"
 	"github.com/ava-labs/avalanchego/utils/logging"
@@ -19,7 +26,14 @@ import (
 	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
 )
 
-const txGossipHandlerID = 0
+const (
+	txGossipHandlerID       = 0
+	mempoolSummaryHandlerID = 1
+	rejectionHintHandlerID  = 2
+
+	// maxPeerStats is the maximum number of peers to report gossip stats for.
+	maxPeerStats = 1024
+)
 
 var (
 	_ common.AppHandler    = (*Network)(nil)
@@ -30,14 +44,24 @@ type Network struct {
 	*p2p.Network
 
 	log       logging.Logger
+	config    Config
 	parser    txs.Parser
 	mempool   *gossipMempool
 	appSender common.AppSender
 
+	gossipGate            *gossip.Gate
+	txGossipWorkers       *gossip.PooledHandler
+	txGossipMetrics       gossip.Metrics
+	txGossipPeerStats     *gossip.PeerStatsTracker
 	txPushGossiper        *gossip.PushGossiper[*txs.Tx]
 	txPushGossipFrequency time.Duration
 	txPullGossiper        gossip.Gossiper
 	txPullGossipFrequency time.Duration
+
+	// txPushGossipInterval and txPullGossipInterval are non-nil if the
+	// frequency of gossip is jittered.
+	txPushGossipInterval *gossip.JitteredInterval
+	txPullGossipInterval *gossip.JitteredInterval
 }
 
 func New(
@@ -46,6 +70,7 @@ func New(
 	subnetID ids.ID,
 	vdrs validators.State,
 	parser txs.Parser,
+	feeAssetID ids.ID,
 	txVerifier TxVerifier,
 	mempool mempool.Mempool,
 	appSender common.AppSender,
@@ -58,7 +83,15 @@ func New(
 	}
 
 	marshaller := &txParser{
-		parser: parser,
+		parser:    parser,
+		versioned: config.VersionedTxGossip,
+		numNonCanonical: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gossip_non_canonical_txs",
+			Help: "number of gossiped txs rejected for not being canonically encoded (n)",
+		}),
+	}
+	if err := registerer.Register(marshaller.numNonCanonical); err != nil {
+		return nil, err
 	}
 	validators := p2p.NewValidators(
 		p2pNetwork.Peers,
@@ -71,11 +104,19 @@ func New(
 		txGossipHandlerID,
 		p2p.WithValidatorSampling(validators),
 	)
-	txGossipMetrics, err := gossip.NewMetrics(registerer, "tx")
+	txGossipMetrics, err := gossip.NewVersionedMetrics(registerer, "tx", marshaller.gossipVersionLabels()...)
 	if err != nil {
 		return nil, err
 	}
 
+	bloomChurnMultiplier := config.BloomChurnMultiplier
+	if bloomChurnMultiplier == 0 {
+		bloomChurnMultiplier = defaultBloomChurnMultiplier
+	}
+	var bloomOptions []gossip.BloomFilterOption
+	if config.MaxBloomFilterSize > 0 {
+		bloomOptions = append(bloomOptions, gossip.WithMaxBloomFilterSize(log, config.MaxBloomFilterSize))
+	}
 	gossipMempool, err := newGossipMempool(
 		mempool,
 		registerer,
@@ -85,14 +126,221 @@ func New(
 		config.ExpectedBloomFilterElements,
 		config.ExpectedBloomFilterFalsePositiveProbability,
 		config.MaxBloomFilterFalsePositiveProbability,
+		bloomChurnMultiplier,
+		bloomOptions...,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if config.PullGossipBloomFilterElements > 0 {
+		targetFalsePositiveProbability := config.PullGossipBloomFilterFalsePositiveProbability
+		if targetFalsePositiveProbability == 0 {
+			targetFalsePositiveProbability = config.ExpectedBloomFilterFalsePositiveProbability
+		}
+		resetFalsePositiveProbability := config.PullGossipMaxBloomFilterFalsePositiveProbability
+		if resetFalsePositiveProbability == 0 {
+			resetFalsePositiveProbability = config.MaxBloomFilterFalsePositiveProbability
+		}
+		if err := gossipMempool.setPullBloomFilter(
+			registerer,
+			config.PullGossipBloomFilterElements,
+			targetFalsePositiveProbability,
+			resetFalsePositiveProbability,
+			bloomOptions...,
+		); err != nil {
+			return nil, err
+		}
+	}
+	gossipMempool.verifyProjectedState = config.VerifyProjectedState
+	gossipMempool.buildBlockRequestWindow = config.BuildBlockRequestWindow
+	gossipMempool.spamScorer = config.SpamScorer
+	gossipMempool.spamScoreThreshold = config.SpamScoreThreshold
+	gossipMempool.spamScorerFailClosed = config.SpamScorerFailClosed
+	gossipMempool.bloomRebuildLoad = config.BloomRebuildLoad
+	gossipMempool.bloomRebuildMaxLoad = config.BloomRebuildMaxLoad
+	gossipMempool.saltRotationInterval = config.BloomSaltRotationInterval
+	gossipMempool.maxTxSize = config.MaxTxSize
+	gossipMempool.onAdd = config.OnTxAdded
+	if config.MaxDeferredTxs > 0 {
+		gossipMempool.deferred, err = newDeferredTxs(config.MaxDeferredTxs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.DropReasonCacheSize > 0 {
+		gossipMempool.dropReasons = &cache.LRU[ids.ID, error]{Size: config.DropReasonCacheSize}
+	}
+	if config.DropReasonTTL > 0 {
+		// Every tx whose drop reason is remembered needs its drop time, or it
+		// would be timed from when it is next received.
+		gossipMempool.dropTimes = &cache.LRU[ids.ID, time.Time]{
+			Size: max(maxDropTimes, config.DropReasonCacheSize),
+		}
+		gossipMempool.dropReasonTTL = config.DropReasonTTL
+	}
+	if config.MaxBloomResetsPerMinute > 0 {
+		gossipMempool.bloomResetMonitor, err = newBloomResetMonitor(config.MaxBloomResetsPerMinute)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.VerificationFailureWindow > 0 {
+		gossipMempool.verificationMonitor, err = newVerificationMonitor(
+			log,
+			config.VerificationFailureWindow,
+			config.VerificationFailureThreshold,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.MaxTrackedTxFates > 0 {
+		gossipMempool.txFates, err = newTxFateTracker(
+			config.MaxTrackedTxFates,
+			config.TxFateExpiry,
+			registerer,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var peerLRU *gossip.PeerLRU
+	if config.MaxGossipPeers > 0 {
+		peerLRU, err = gossip.NewPeerLRU(config.MaxGossipPeers, registerer, "tx")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.MaxDroppedTxsPerPeer > 0 {
+		gossipMempool.peerDrops, err = newPeerDropTracker(config.MaxDroppedTxsPerPeer, registerer)
+		if err != nil {
+			return nil, err
+		}
+		if peerLRU != nil {
+			gossipMempool.peerLRU = peerLRU
+			peerLRU.OnEvict(gossipMempool.forgetPeerDrops)
+		}
+	}
+	if config.MaxPeerFilters > 0 {
+		gossipMempool.peerFilters, err = newPeerFilters(config.MaxPeerFilters)
+		if err != nil {
+			return nil, err
+		}
+		if peerLRU != nil {
+			peerLRU.OnEvict(gossipMempool.peerFilters.remove)
+		}
+	}
+
+	// Track gossip attempts so that poorly propagating txs can be reported.
+	marshaller.onMarshal = gossipMempool.MarkGossiped
+
+	txGossipPeerStats := gossip.NewPeerStatsTrackerWithPeers(maxPeerStats, peerLRU)
+	var (
+		pushGossiperOptions = []gossip.PushGossiperOption[*txs.Tx]{
+			gossip.WithMaxGossipLifetime[*txs.Tx](config.PushGossipMaxLifetime),
+			gossip.WithConnectedPeers[*txs.Tx](p2pNetwork.Peers),
+		}
+		handlerOptions = []gossip.HandlerOption[*txs.Tx]{
+			gossip.WithPeerStats[*txs.Tx](txGossipPeerStats),
+		}
+	)
+	var prioritizer gossip.Prioritizer[*txs.Tx] = feeRatePrioritizer{
+		feeAssetID: feeAssetID,
+	}
+	if config.GossipPriorityHalfLife != 0 {
+		prioritizer, err = gossip.NewAgeDecayedPrioritizer(
+			prioritizer,
+			config.GossipPriorityHalfLife,
+			gossipMempool.AddedTime,
+			&gossipMempool.clock,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gossip priority config: %w", err)
+		}
+	}
+	if config.GossipSampleThreshold > 0 {
+		// AVM txs don't pay priority fees, so unless the top txs are
+		// configured to be selected by their fee rate, the sample is chosen
+		// uniformly at random.
+		samplingParams := gossip.SamplingParams[*txs.Tx]{
+			Size:           mempool.Len,
+			Threshold:      config.GossipSampleThreshold,
+			TopFraction:    config.GossipSampleTopFraction,
+			RandomFraction: config.GossipSampleFraction,
+		}
+		if config.GossipSampleTopFraction > 0 {
+			samplingParams.Priority = prioritizer.Priority
+		}
+		if err := samplingParams.Verify(); err != nil {
+			return nil, fmt.Errorf("invalid gossip sampling config: %w", err)
+		}
+		pushGossiperOptions = append(pushGossiperOptions, gossip.WithPushSampling(samplingParams))
+		handlerOptions = append(handlerOptions, gossip.WithServeSampling(samplingParams))
+	}
+	if config.PullGossipPrioritizeByFeeRate {
+		handlerOptions = append(handlerOptions, gossip.WithPrioritizer(prioritizer))
+	}
+	if config.PullGossipDependencyBundles {
+		handlerOptions = append(handlerOptions, gossip.WithDependencyBundles(txDependencies))
+	}
+	if config.PullGossipMarshalCacheSize > 0 {
+		gossipMempool.marshalCache, err = gossip.NewMarshalCache(config.PullGossipMarshalCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		handlerOptions = append(handlerOptions, gossip.WithMarshalCache[*txs.Tx](gossipMempool.marshalCache))
+	}
+	if config.PullGossipIncludeConflicts {
+		gossipMempool.conflictSets = newConflictSets()
+		handlerOptions = append(handlerOptions, gossip.WithConflicts(gossipMempool.Conflicts))
+	}
+	if config.PullGossipChallengeFrequency > 0 {
+		challengeParams := gossip.ReplayChallengeParams{
+			MaxPeers:  maxPeerStats,
+			Frequency: config.PullGossipChallengeFrequency,
+			Peers:     peerLRU,
+		}
+		if err := challengeParams.Verify(); err != nil {
+			return nil, fmt.Errorf("invalid pull gossip challenge config: %w", err)
+		}
+		handlerOptions = append(handlerOptions, gossip.WithReplayChallenges[*txs.Tx](challengeParams))
+	}
+	if config.PullGossipSnapshotInterval > 0 {
+		snapshot, err := gossip.NewSnapshot[*txs.Tx](gossipMempool, config.PullGossipSnapshotInterval, registerer, "tx")
+		if err != nil {
+			return nil, err
+		}
+		handlerOptions = append(handlerOptions, gossip.WithSnapshot(snapshot))
+	}
+	if config.PullGossipMaxTxAge > 0 {
+		handlerOptions = append(handlerOptions, gossip.WithMaxAge(gossipMempool.AddedTime, config.PullGossipMaxTxAge))
+	}
+	if config.PushGossipFilterTTL > 0 {
+		pushFilters, err := gossip.NewPushFilters(
+			maxPeerStats,
+			config.PushGossipFilterTTL,
+			config.ExpectedBloomFilterElements,
+			registerer,
+			"tx",
+		)
+		if err != nil {
+			return nil, err
+		}
+		pushGossiperOptions = append(pushGossiperOptions, gossip.WithPushFilters[*txs.Tx](pushFilters, config.PushGossipFilterAdvertiseFrequency))
+		handlerOptions = append(handlerOptions, gossip.WithReceivedPushFilters[*txs.Tx](pushFilters))
+	}
+	if config.PullGossipResponseCompressionThreshold > 0 {
+		handlerOptions = append(handlerOptions, gossip.WithResponseCompression[*txs.Tx](config.PullGossipResponseCompressionThreshold))
+	}
+	if config.PullGossipStatusHints {
+		gossipMempool.recentlyAccepted = &cache.LRU[ids.ID, struct{}]{Size: maxRecentlyAcceptedTxs}
+		handlerOptions = append(handlerOptions, gossip.WithStatusHints(gossipMempool.StatusHint))
+	}
+
 	txPushGossiper, err := gossip.NewPushGossiper[*txs.Tx](
 		marshaller,
-		gossipMempool,
+		pushGossipSet{gossipMempool},
 		validators,
 		txGossipClient,
 		txGossipMetrics,
@@ -108,11 +356,27 @@ func New(
 		config.PushGossipDiscardedCacheSize,
 		config.TargetGossipSize,
 		config.PushGossipMaxRegossipFrequency,
+		pushGossiperOptions...,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	pullGossiperOptions := []gossip.PullGossiperOption[*txs.Tx]{
+		gossip.WithChallengeEcho[*txs.Tx](maxPeerStats),
+	}
+	if peerLRU != nil {
+		pullGossiperOptions = append(pullGossiperOptions, gossip.WithPeerLRU[*txs.Tx](peerLRU))
+	}
+	if config.PullGossipCompressFilter {
+		pullGossiperOptions = append(pullGossiperOptions, gossip.WithCompressedFilter[*txs.Tx]())
+	}
+	if config.PullGossipMaxResponseSize > 0 {
+		pullGossiperOptions = append(pullGossiperOptions, gossip.WithMaxResponseSize[*txs.Tx](config.PullGossipMaxResponseSize))
+	}
+	if config.PullGossipResponseCompressionThreshold > 0 {
+		pullGossiperOptions = append(pullGossiperOptions, gossip.WithCompressedResponses[*txs.Tx]())
+	}
 	var txPullGossiper gossip.Gossiper = gossip.NewPullGossiper[*txs.Tx](
 		log,
 		marshaller,
@@ -120,8 +384,18 @@ func New(
 		txGossipClient,
 		txGossipMetrics,
 		config.PullGossipPollSize,
+		pullGossiperOptions...,
 	)
 
+	if config.SmallMempoolGossipThreshold > 0 {
+		txPullGossiper = gossip.NewMinIntervalGossiper[*txs.Tx](
+			txPullGossiper,
+			gossipMempool,
+			config.SmallMempoolGossipThreshold,
+			config.SmallMempoolGossipMinInterval,
+		)
+	}
+
 	// Gossip requests are only served if a node is a validator
 	txPullGossiper = gossip.ValidatorGossiper{
 		Gossiper:   txPullGossiper,
@@ -129,20 +403,40 @@ func New(
 		Validators: validators,
 	}
 
+	// Gossip is neither sent nor handled while state syncing, as txs can't be
+	// verified against incomplete state.
+	gossipGate := &gossip.Gate{}
+	txPullGossiper = gossip.GatedGossiper{
+		Gossiper: txPullGossiper,
+		Gate:     gossipGate,
+	}
+
+	if config.PullGossipMaxTxAge > 0 && config.PullGossipPruneAgedTxs {
+		txPullGossiper = &pruningGossiper{
+			Gossiper: txPullGossiper,
+			mempool:  gossipMempool,
+			maxAge:   config.PullGossipMaxTxAge,
+		}
+	}
+
 	handler := gossip.NewHandler[*txs.Tx](
 		log,
 		marshaller,
 		gossipMempool,
 		txGossipMetrics,
 		config.TargetGossipSize,
+		handlerOptions...,
 	)
 
 	validatorHandler := p2p.NewValidatorHandler(
 		p2p.NewThrottlerHandler(
 			handler,
-			p2p.NewSlidingWindowThrottler(
-				config.PullGossipThrottlingPeriod,
-				config.PullGossipThrottlingLimit,
+			gossip.NewPeerStatsThrottler(
+				p2p.NewSlidingWindowThrottler(
+					config.PullGossipThrottlingPeriod,
+					config.PullGossipThrottlingLimit,
+				),
+				txGossipPeerStats,
 			),
 			log,
 		),
@@ -155,40 +449,338 @@ func New(
 	txGossipHandler := txGossipHandler{
 		appGossipHandler:  handler,
 		appRequestHandler: validatorHandler,
+		mempool:           gossipMempool,
+	}
+	if config.MaxConcurrentPullGossipRequests > 0 {
+		txGossipHandler.requestLimiter = semaphore.NewWeighted(int64(config.MaxConcurrentPullGossipRequests))
+	}
+
+	var (
+		pooledTxGossipHandler *gossip.PooledHandler
+		appTxGossipHandler    p2p.Handler = txGossipHandler
+	)
+	if config.GossipWorkers > 0 {
+		pooledTxGossipHandler, err = gossip.NewPooledHandler(
+			log,
+			txGossipHandler,
+			config.GossipWorkers,
+			config.GossipWorkerQueueSize,
+			registerer,
+			"tx",
+		)
+		if err != nil {
+			return nil, err
+		}
+		appTxGossipHandler = pooledTxGossipHandler
+	}
+
+	gatedTxGossipHandler := gossip.NewGatedHandler(
+		log,
+		appTxGossipHandler,
+		gossipGate,
+		config.StateSyncMaxQueuedGossip,
+	)
+	if err := p2pNetwork.AddHandler(txGossipHandlerID, gatedTxGossipHandler); err != nil {
+		return nil, err
 	}
 
-	if err := p2pNetwork.AddHandler(txGossipHandlerID, txGossipHandler); err != nil {
+	mempoolSummaryHandler := p2p.NewThrottlerHandler(
+		&mempoolSummaryHandler{
+			mempool: gossipMempool,
+		},
+		p2p.NewSlidingWindowThrottler(
+			config.PullGossipThrottlingPeriod,
+			config.PullGossipThrottlingLimit,
+		),
+		log,
+	)
+	gatedMempoolSummaryHandler := gossip.NewGatedHandler(log, mempoolSummaryHandler, gossipGate, 0)
+	if err := p2pNetwork.AddHandler(mempoolSummaryHandlerID, gatedMempoolSummaryHandler); err != nil {
 		return nil, err
 	}
 
+	if config.RejectionHintNumPeers > 0 {
+		rejectionHints := &rejectionHintSender{
+			log:    log,
+			client: p2pNetwork.NewClient(rejectionHintHandlerID),
+			sendConfig: common.SendConfig{
+				Peers: config.RejectionHintNumPeers,
+			},
+		}
+		gossipMempool.onRejected = rejectionHints.send
+
+		// Each peer is limited in how many txs it can make us verify.
+		rejectionHintHandler := p2p.NewThrottlerHandler(
+			&rejectionHintHandler{
+				log:     log,
+				parser:  parser,
+				mempool: gossipMempool,
+				hinted:  &cache.LRU[ids.ID, struct{}]{Size: maxHintedTxs},
+			},
+			p2p.NewSlidingWindowThrottler(
+				config.PullGossipThrottlingPeriod,
+				config.PullGossipThrottlingLimit,
+			),
+			log,
+		)
+		gatedRejectionHintHandler := gossip.NewGatedHandler(log, rejectionHintHandler, gossipGate, 0)
+		if err := p2pNetwork.AddHandler(rejectionHintHandlerID, gatedRejectionHintHandler); err != nil {
+			return nil, err
+		}
+	}
+
+	var txPushGossipInterval, txPullGossipInterval *gossip.JitteredInterval
+	if config.GossipFrequencyJitter > 0 {
+		seed := time.Now().UnixNano()
+		txPushGossipInterval, err = gossip.NewJitteredInterval(
+			config.PushGossipFrequency,
+			config.GossipFrequencyJitter,
+			rand.NewSource(seed),
+		)
+		if err != nil {
+			return nil, err
+		}
+		txPullGossipInterval, err = gossip.NewJitteredInterval(
+			config.PullGossipFrequency,
+			config.GossipFrequencyJitter,
+			rand.NewSource(seed+1),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Network{
 		Network:               p2pNetwork,
 		log:                   log,
+		config:                config,
 		parser:                parser,
 		mempool:               gossipMempool,
 		appSender:             appSender,
+		gossipGate:            gossipGate,
+		txGossipWorkers:       pooledTxGossipHandler,
+		txGossipMetrics:       txGossipMetrics,
+		txGossipPeerStats:     txGossipPeerStats,
 		txPushGossiper:        txPushGossiper,
 		txPushGossipFrequency: config.PushGossipFrequency,
 		txPullGossiper:        txPullGossiper,
 		txPullGossipFrequency: config.PullGossipFrequency,
+		txPushGossipInterval:  txPushGossipInterval,
+		txPullGossipInterval:  txPullGossipInterval,
 	}, nil
 }
 
 func (n *Network) PushGossip(ctx context.Context) {
-	gossip.Every(ctx, n.log, n.txPushGossiper, n.txPushGossipFrequency)
+	var txPushGossiper gossip.Gossiper = n.txPushGossiper
+	if n.config.SmallMempoolGossipThreshold > 0 {
+		txPushGossiper = gossip.NewMinIntervalGossiper[*txs.Tx](
+			txPushGossiper,
+			n.mempool,
+			n.config.SmallMempoolGossipThreshold,
+			n.config.SmallMempoolGossipMinInterval,
+		)
+	}
+	if n.mempool.deferred != nil {
+		txPushGossiper = retryingGossiper{
+			Gossiper: txPushGossiper,
+			mempool:  n.mempool,
+		}
+	}
+	txPushGossiper = gossip.GatedGossiper{
+		Gossiper: txPushGossiper,
+		Gate:     n.gossipGate,
+	}
+	if n.txPushGossipInterval != nil {
+		gossip.EveryWithJitter(ctx, n.log, txPushGossiper, n.txPushGossipInterval)
+		return
+	}
+	gossip.Every(ctx, n.log, txPushGossiper, n.txPushGossipFrequency)
 }
 
 func (n *Network) PullGossip(ctx context.Context) {
+	if n.txPullGossipInterval != nil {
+		gossip.EveryWithJitter(ctx, n.log, n.txPullGossiper, n.txPullGossipInterval)
+		return
+	}
 	gossip.Every(ctx, n.log, n.txPullGossiper, n.txPullGossipFrequency)
 }
 
+// SetState stops sending and handling gossip while the chain is state
+// syncing, and resumes once it isn't. Gossip received while state syncing is
+// queued, up to the configured limit, and handled once state sync completes.
+func (n *Network) SetState(state snow.State) {
+	if state == snow.StateSyncing {
+		n.gossipGate.Close()
+	} else {
+		n.gossipGate.Open()
+	}
+}
+
+// Reorged is called when the preferred chain changes, where [depth] is the
+// number of previously preferred blocks that are no longer preferred. Once
+// [depth] reaches the configured replay depth, the reasons that txs were
+// dropped are cleared so that txs which spent inputs of reverted blocks are
+// re-verified rather than ignored when they are next received.
+//
+// Drop reasons are cleared for all txs, as the mempool doesn't track the
+// inputs of dropped txs.
+func (n *Network) Reorged(depth uint64) {
+	if n.config.ReorgDropReplayDepth == 0 || depth < n.config.ReorgDropReplayDepth {
+		return
+	}
+
+	n.log.Debug("clearing dropped txs after reorg",
+		zap.Uint64("depth", depth),
+	)
+	n.mempool.clearDropReasons()
+}
+
+// HealthCheck reports the network as unhealthy if verification of txs is
+// consistently failing or if the bloom filter is reset too frequently.
+func (n *Network) HealthCheck(context.Context) (interface{}, error) {
+	if n.mempool.verificationMonitor == nil && n.mempool.bloomResetMonitor == nil {
+		return nil, nil
+	}
+
+	var (
+		details = make(map[string]interface{})
+		errs    []error
+	)
+	if n.mempool.verificationMonitor != nil {
+		failureRate, failing := n.mempool.verificationMonitor.failureRate()
+		details["verificationFailureRate"] = failureRate
+		if failing {
+			errs = append(errs, errVerificationFailing)
+		}
+	}
+	if n.mempool.bloomResetMonitor != nil {
+		numResets, tooFrequent := n.mempool.bloomResetMonitor.numResets()
+		details["bloomResetsPerMinute"] = numResets
+		if tooFrequent {
+			errs = append(errs, errBloomResetsTooFrequent)
+		}
+	}
+	return details, errors.Join(errs...)
+}
+
+// GossipDiagnosis explains the gossip state of a single tx.
+type GossipDiagnosis struct {
+	// InMempool is true if the tx is currently in the mempool. Only txs in the
+	// mempool are gossiped.
+	InMempool bool
+	// DropReason is the reason the tx was most recently dropped from the
+	// mempool, if any.
+	DropReason error
+	// InBloomFilter is true if the tx is reported to peers as known when
+	// pulling gossip. This may be a false positive.
+	InBloomFilter bool
+	// Queued is true if the tx is tracked to be pushed to peers.
+	Queued bool
+	// InCooldown is true if the tx was pushed too recently to be regossiped.
+	InCooldown bool
+	// Discarded is true if the tx was recently dropped after being pushed, so
+	// it will not be pushed as a new tx if it is re-issued.
+	Discarded bool
+	// Expired is true if the tx was pushed for longer than the max gossip
+	// lifetime, so it will not be pushed again.
+	Expired bool
+	// GossipAttempts is the number of times the tx was marshalled to be
+	// gossiped since it was added to the mempool.
+	GossipAttempts int
+	// Stuck is true if the tx has been gossiped enough times that it is
+	// reported as stuck.
+	Stuck bool
+	// NumPeersWithTx is the estimated number of peers that already know about
+	// the tx, based on the bloom filters they most recently sent in pull
+	// gossip requests. It is only tracked if MaxPeerFilters is non-zero.
+	NumPeersWithTx int
+}
+
+// WhyNotGossiped returns the state of every subsystem that determines whether
+// [txID] is gossiped.
+func (n *Network) WhyNotGossiped(txID ids.ID) GossipDiagnosis {
+	var (
+		mempoolDiagnosis = n.mempool.diagnose(txID)
+		pushStatus       = n.txPushGossiper.Status(txID)
+	)
+	return GossipDiagnosis{
+		InMempool:      mempoolDiagnosis.inMempool,
+		DropReason:     mempoolDiagnosis.dropReason,
+		InBloomFilter:  mempoolDiagnosis.inBloomFilter,
+		Queued:         pushStatus.Tracked,
+		InCooldown:     pushStatus.InCooldown,
+		Discarded:      pushStatus.Discarded,
+		Expired:        pushStatus.Expired,
+		GossipAttempts: mempoolDiagnosis.gossipAttempts,
+		Stuck:          mempoolDiagnosis.stuck,
+		NumPeersWithTx: mempoolDiagnosis.numPeersWithTx,
+	}
+}
+
+// PeerStats returns the tx gossip activity of the most recently active peers.
+func (n *Network) PeerStats() map[ids.NodeID]gossip.GossipPeerStats {
+	return n.txGossipPeerStats.PeerStats()
+}
+
+// MarkAccepted records that the txs with [txIDs] were accepted, so that their
+// fates can be reported and they can be reported as accepted to peers that
+// ask for status hints.
+func (n *Network) MarkAccepted(txIDs ...ids.ID) {
+	n.mempool.MarkAccepted(txIDs...)
+}
+
+// Remove removes [txs], and any txs that conflict with them, from the mempool,
+// such as once they are included in an accepted block, so that the bloom
+// filter stops reporting them once enough txs have been removed.
+func (n *Network) Remove(txs ...*txs.Tx) {
+	n.mempool.Remove(txs...)
+}
+
+// MarkDropped records that the txs with [txIDs] were dropped from the mempool
+// outside of the network, such as while building or verifying blocks, if the
+// fates of txs are being tracked.
+func (n *Network) MarkDropped(txIDs ...ids.ID) {
+	if n.mempool.txFates != nil {
+		n.mempool.txFates.rejected(txIDs...)
+	}
+}
+
+// Close stops txs from being added to the mempool, without waiting for adds
+// that are in progress, stops the gossip workers, if any, and logs a summary of the
+// lifetime gossip activity of the network, so that it is available even if the
+// metrics are no longer being scraped.
+func (n *Network) Close() {
+	n.mempool.Close()
+	if n.txGossipWorkers != nil {
+		n.txGossipWorkers.Close()
+	}
+
+	summary, err := n.txGossipMetrics.Summary()
+	if err != nil {
+		n.log.Warn("failed to summarize tx gossip metrics",
+			zap.Error(err),
+		)
+		return
+	}
+
+	numAdded, numDropped := n.mempool.Stats()
+	n.log.Info("tx gossip summary",
+		zap.Uint64("sentCount", summary.SentCount),
+		zap.Uint64("sentBytes", summary.SentBytes),
+		zap.Uint64("receivedCount", summary.ReceivedCount),
+		zap.Uint64("receivedBytes", summary.ReceivedBytes),
+		zap.Uint64("numAdded", numAdded),
+		zap.Uint64("numDropped", numDropped),
+	)
+}
+
 // IssueTxFromRPC attempts to add a tx to the mempool, after verifying it. If
 // the tx is added to the mempool, it will attempt to push gossip the tx to
 // random peers in the network.
 //
 // If the tx is already in the mempool, mempool.ErrDuplicateTx will be
 // returned.
-// If the tx is not added to the mempool, an error will be returned.
+// If the tx is not added to the mempool, an *AddTxError will be returned.
 func (n *Network) IssueTxFromRPC(tx *txs.Tx) error {
 	if err := n.mempool.Add(tx); err != nil {
 		return err
@@ -203,7 +795,7 @@ func (n *Network) IssueTxFromRPC(tx *txs.Tx) error {
 //
 // If the tx is already in the mempool, mempool.ErrDuplicateTx will be
 // returned.
-// If the tx is not added to the mempool, an error will be returned.
+// If the tx is not added to the mempool, an *AddTxError will be returned.
 func (n *Network) IssueTxFromRPCWithoutVerification(tx *txs.Tx) error {
 	if err := n.mempool.AddWithoutVerification(tx); err != nil {
 		return err
@@ -5,6 +5,7 @@ package network
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/gossip"
 	"github.com/ava-labs/avalanchego/snow/engine/common"
 	"github.com/ava-labs/avalanchego/snow/validators"
 	"github.com/ava-labs/avalanchego/utils/logging"
@@ -21,6 +23,7 @@ import (
 	"github.com/ava-labs/avalanchego/vms/avm/fxs"
 	"github.com/ava-labs/avalanchego/vms/avm/txs"
 	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
 	"github.com/ava-labs/avalanchego/vms/nftfx"
 	"github.com/ava-labs/avalanchego/vms/propertyfx"
 	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
@@ -63,7 +66,7 @@ func TestNetworkIssueTxFromRPC(t *testing.T) {
 			name: "mempool has transaction",
 			mempoolFunc: func(ctrl *gomock.Controller) mempool.Mempool {
 				mempool := mempool.NewMockMempool(ctrl)
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, true)
+				mempool.EXPECT().Contains(gomock.Any()).Return(true)
 				return mempool
 			},
 			expectedErr: mempool.ErrDuplicateTx,
@@ -72,7 +75,7 @@ func TestNetworkIssueTxFromRPC(t *testing.T) {
 			name: "transaction marked as dropped in mempool",
 			mempoolFunc: func(ctrl *gomock.Controller) mempool.Mempool {
 				mempool := mempool.NewMockMempool(ctrl)
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, false)
+				mempool.EXPECT().Contains(gomock.Any()).Return(false)
 				mempool.EXPECT().GetDropReason(gomock.Any()).Return(errTest)
 				return mempool
 			},
@@ -82,7 +85,7 @@ func TestNetworkIssueTxFromRPC(t *testing.T) {
 			name: "transaction invalid",
 			mempoolFunc: func(ctrl *gomock.Controller) mempool.Mempool {
 				mempool := mempool.NewMockMempool(ctrl)
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, false)
+				mempool.EXPECT().Contains(gomock.Any()).Return(false)
 				mempool.EXPECT().GetDropReason(gomock.Any()).Return(nil)
 				mempool.EXPECT().MarkDropped(gomock.Any(), gomock.Any())
 				return mempool
@@ -98,7 +101,7 @@ func TestNetworkIssueTxFromRPC(t *testing.T) {
 			name: "can't add transaction to mempool",
 			mempoolFunc: func(ctrl *gomock.Controller) mempool.Mempool {
 				mempool := mempool.NewMockMempool(ctrl)
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, false)
+				mempool.EXPECT().Contains(gomock.Any()).Return(false)
 				mempool.EXPECT().GetDropReason(gomock.Any()).Return(nil)
 				mempool.EXPECT().Add(gomock.Any()).Return(errTest)
 				mempool.EXPECT().MarkDropped(gomock.Any(), gomock.Any())
@@ -115,12 +118,12 @@ func TestNetworkIssueTxFromRPC(t *testing.T) {
 			name: "happy path",
 			mempoolFunc: func(ctrl *gomock.Controller) mempool.Mempool {
 				mempool := mempool.NewMockMempool(ctrl)
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, false)
+				mempool.EXPECT().Contains(gomock.Any()).Return(false)
 				mempool.EXPECT().GetDropReason(gomock.Any()).Return(nil)
 				mempool.EXPECT().Add(gomock.Any()).Return(nil)
-				mempool.EXPECT().Len().Return(0)
+				mempool.EXPECT().Len().Return(1)
 				mempool.EXPECT().RequestBuildBlock()
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, true).Times(2)
+				mempool.EXPECT().Contains(gomock.Any()).Return(true).Times(2)
 				return mempool
 			},
 			txVerifierFunc: func(ctrl *gomock.Controller) TxVerifier {
@@ -185,6 +188,7 @@ func TestNetworkIssueTxFromRPC(t *testing.T) {
 					},
 				},
 				parser,
+				ids.Empty,
 				txVerifierFunc(ctrl),
 				mempoolFunc(ctrl),
 				appSenderFunc(ctrl),
@@ -223,9 +227,9 @@ func TestNetworkIssueTxFromRPCWithoutVerification(t *testing.T) {
 			name: "happy path",
 			mempoolFunc: func(ctrl *gomock.Controller) mempool.Mempool {
 				mempool := mempool.NewMockMempool(ctrl)
-				mempool.EXPECT().Get(gomock.Any()).Return(nil, true).Times(2)
+				mempool.EXPECT().Contains(gomock.Any()).Return(true).Times(2)
 				mempool.EXPECT().Add(gomock.Any()).Return(nil)
-				mempool.EXPECT().Len().Return(0)
+				mempool.EXPECT().Len().Return(1)
 				mempool.EXPECT().RequestBuildBlock()
 				return mempool
 			},
@@ -279,6 +283,7 @@ func TestNetworkIssueTxFromRPCWithoutVerification(t *testing.T) {
 					},
 				},
 				parser,
+				ids.Empty,
 				executor.NewMockManager(ctrl), // Should never verify a tx
 				mempoolFunc(ctrl),
 				appSenderFunc(ctrl),
@@ -293,3 +298,377 @@ func TestNetworkIssueTxFromRPCWithoutVerification(t *testing.T) {
 		})
 	}
 }
+
+func TestNetworkWhyNotGossiped(t *testing.T) {
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	tests := []struct {
+		name              string
+		setup             func(*require.Assertions, *Network, mempool.Mempool, *txs.Tx)
+		expectedDiagnosis GossipDiagnosis
+	}{
+		{
+			name:  "unknown",
+			setup: func(*require.Assertions, *Network, mempool.Mempool, *txs.Tx) {},
+		},
+		{
+			name: "dropped",
+			setup: func(_ *require.Assertions, _ *Network, mempool mempool.Mempool, tx *txs.Tx) {
+				mempool.MarkDropped(tx.ID(), errTest)
+			},
+			expectedDiagnosis: GossipDiagnosis{
+				DropReason: errTest,
+			},
+		},
+		{
+			name: "queued",
+			setup: func(require *require.Assertions, n *Network, _ mempool.Mempool, tx *txs.Tx) {
+				require.NoError(n.IssueTxFromRPC(tx))
+			},
+			expectedDiagnosis: GossipDiagnosis{
+				InMempool:     true,
+				InBloomFilter: true,
+				Queued:        true,
+			},
+		},
+		{
+			name: "in cooldown",
+			setup: func(require *require.Assertions, n *Network, _ mempool.Mempool, tx *txs.Tx) {
+				require.NoError(n.IssueTxFromRPC(tx))
+				require.NoError(n.txPushGossiper.Gossip(context.Background()))
+			},
+			expectedDiagnosis: GossipDiagnosis{
+				InMempool:      true,
+				InBloomFilter:  true,
+				Queued:         true,
+				InCooldown:     true,
+				GossipAttempts: 1,
+			},
+		},
+		{
+			name: "stuck",
+			setup: func(require *require.Assertions, n *Network, _ mempool.Mempool, tx *txs.Tx) {
+				require.NoError(n.IssueTxFromRPC(tx))
+				for i := 0; i < stuckTxMinGossipAttempts; i++ {
+					n.mempool.MarkGossiped(tx.ID())
+				}
+			},
+			expectedDiagnosis: GossipDiagnosis{
+				InMempool:      true,
+				InBloomFilter:  true,
+				Queued:         true,
+				GossipAttempts: stuckTxMinGossipAttempts,
+				Stuck:          true,
+			},
+		},
+		{
+			name: "discarded",
+			setup: func(require *require.Assertions, n *Network, mempool mempool.Mempool, tx *txs.Tx) {
+				require.NoError(n.IssueTxFromRPC(tx))
+				require.NoError(n.txPushGossiper.Gossip(context.Background()))
+				mempool.Remove(tx)
+				require.NoError(n.txPushGossiper.Gossip(context.Background()))
+			},
+			expectedDiagnosis: GossipDiagnosis{
+				InBloomFilter: true,
+				Discarded:     true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			parser, err := txs.NewParser(nil)
+			require.NoError(err)
+
+			registerer := prometheus.NewRegistry()
+			baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+			require.NoError(err)
+
+			config := testConfig
+			config.PushGossipMaxRegossipFrequency = time.Hour
+			n, err := New(
+				logging.NoLog{},
+				ids.EmptyNodeID,
+				ids.Empty,
+				&validators.TestState{
+					GetCurrentHeightF: func(context.Context) (uint64, error) {
+						return 0, nil
+					},
+					GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+						return nil, nil
+					},
+				},
+				parser,
+				ids.Empty,
+				testVerifier{},
+				baseMempool,
+				&common.FakeSender{},
+				registerer,
+				config,
+			)
+			require.NoError(err)
+
+			tx := newTx()
+			tt.setup(require, n, baseMempool, tx)
+			require.Equal(tt.expectedDiagnosis, n.WhyNotGossiped(tx.ID()))
+		})
+	}
+}
+
+func TestNetworkReverifyManyDroppedTxs(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	const (
+		numDropped = 2 * maxDropTimes
+		ttl        = time.Minute
+	)
+	config := testConfig
+	config.DropReasonCacheSize = numDropped
+	config.DropReasonTTL = ttl
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.NoError(err)
+
+	verifier := &testVerifier{
+		err: errTest,
+	}
+	n.mempool.txVerifier = verifier
+
+	startTime := time.Unix(0, 0)
+	n.mempool.clock.Set(startTime)
+	droppedTxs := make([]*txs.Tx, numDropped)
+	for i := range droppedTxs {
+		droppedTxs[i] = &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.ErrorIs(n.mempool.Add(droppedTxs[i]), errTest)
+	}
+
+	// Every tx whose drop reason is remembered is verified again once the TTL
+	// has passed since it was dropped, including the least recently dropped.
+	verifier.err = nil
+	n.mempool.clock.Set(startTime.Add(ttl))
+	require.NoError(n.mempool.Add(droppedTxs[0]))
+	require.True(n.mempool.Has(droppedTxs[0].ID()))
+}
+
+func TestNetworkSupportBundle(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.MaxDroppedTxsPerPeer = 1
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.NoError(err)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	require.NoError(n.IssueTxFromRPC(newTx()))
+
+	nodeID := ids.GenerateTestNodeID()
+	tx := newTx()
+	n.mempool.txVerifier = testVerifier{
+		err: errTest,
+	}
+	err = n.mempool.AddFromPeer(nodeID, tx)
+	require.ErrorIs(err, errTest)
+
+	bundleBytes, err := n.SupportBundle()
+	require.NoError(err)
+
+	var sections map[string]json.RawMessage
+	require.NoError(json.Unmarshal(bundleBytes, &sections))
+	for _, section := range []string{"config", "metrics", "peers", "recentDrops", "bloomFilter", "mempool"} {
+		require.Contains(sections, section)
+	}
+
+	var bundle supportBundle
+	require.NoError(json.Unmarshal(bundleBytes, &bundle))
+	require.Equal(1, bundle.Mempool.Len)
+	require.Equal(1, bundle.BloomFilter.Count)
+	require.Equal(
+		[]droppedTx{
+			{
+				NodeID: nodeID,
+				TxID:   tx.ID(),
+				Reason: errTest.Error(),
+			},
+		},
+		bundle.RecentDrops,
+	)
+}
+
+func TestNetworkReorgedClearsDropReasons(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.ReorgDropReplayDepth = 3
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{
+			err: errTest,
+		},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.NoError(err)
+
+	// The tx is dropped because its inputs were spent by a block that will
+	// be reverted.
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+	nodeID := ids.GenerateTestNodeID()
+	err = n.mempool.AddFromPeer(nodeID, tx)
+	require.ErrorIs(err, errTest)
+
+	n.mempool.txVerifier = testVerifier{}
+
+	// Shallow reorgs don't make dropped txs eligible again.
+	n.Reorged(config.ReorgDropReplayDepth - 1)
+	err = n.mempool.AddFromPeer(nodeID, tx)
+	require.ErrorIs(err, errTest)
+
+	n.Reorged(config.ReorgDropReplayDepth)
+	require.NoError(n.mempool.Mempool.GetDropReason(tx.ID()))
+	require.NoError(n.mempool.AddFromPeer(nodeID, tx))
+
+	_, ok := n.mempool.Get(tx.ID())
+	require.True(ok)
+}
+
+func TestNetworkInvalidGossipPriorityHalfLife(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.GossipPriorityHalfLife = -time.Second
+	_, err = New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.ErrorIs(err, gossip.ErrInvalidPriorityHalfLife)
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/linked"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+)
+
+// maxDropTrackedPeers is the maximum number of peers whose dropped txs are
+// tracked. Once exceeded, the peer that least recently had a tx dropped is no
+// longer tracked.
+const maxDropTrackedPeers = 1024
+
+func newPeerDropTracker(maxPerPeer int, registerer prometheus.Registerer) (*peerDropTracker, error) {
+	p := &peerDropTracker{
+		maxPerPeer: maxPerPeer,
+		peers:      linked.NewHashmap[ids.NodeID, *linked.Hashmap[ids.ID, error]](),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mempool_peer_dropped_tx_evictions",
+			Help: "number of dropped txs that were no longer tracked for a peer to stay within the per-peer limit (n)",
+		}),
+	}
+	return p, registerer.Register(p.evictions)
+}
+
+// peerDropTracker records the reasons that the most recent txs received from
+// each peer were dropped, independently of the dropped txs tracked by the
+// mempool. Each peer is limited to maxPerPeer txs so that a single peer can't
+// grow the tracker by sending unique invalid txs.
+//
+// It is not thread safe.
+type peerDropTracker struct {
+	maxPerPeer int
+	// peers maps a peer to its dropped txs, from oldest to newest
+	peers     *linked.Hashmap[ids.NodeID, *linked.Hashmap[ids.ID, error]]
+	evictions prometheus.Counter
+}
+
+// markDropped records that [txID], received from [nodeID], was dropped for
+// [reason].
+func (p *peerDropTracker) markDropped(nodeID ids.NodeID, txID ids.ID, reason error) {
+	drops, ok := p.peers.Get(nodeID)
+	if !ok {
+		if p.peers.Len() >= maxDropTrackedPeers {
+			oldestNodeID, _, _ := p.peers.Oldest()
+			p.peers.Delete(oldestNodeID)
+		}
+		drops = linked.NewHashmap[ids.ID, error]()
+	}
+	// Mark the peer as the most recently active
+	p.peers.Put(nodeID, drops)
+
+	if _, ok := drops.Get(txID); !ok && drops.Len() >= p.maxPerPeer {
+		oldestTxID, _, _ := drops.Oldest()
+		drops.Delete(oldestTxID)
+		p.evictions.Inc()
+	}
+	drops.Put(txID, reason)
+}
+
+// forget stops tracking the dropped txs of [nodeID].
+func (p *peerDropTracker) forget(nodeID ids.NodeID) {
+	p.peers.Delete(nodeID)
+}
+
+// numDropped returns the number of tracked txs from [nodeID] that were dropped
+// and the number of those that were dropped for conflicting with another tx.
+func (p *peerDropTracker) numDropped(nodeID ids.NodeID) (int, int) {
+	drops, ok := p.peers.Get(nodeID)
+	if !ok {
+		return 0, 0
+	}
+
+	numConflicting := 0
+	it := drops.NewIterator()
+	for it.Next() {
+		if errors.Is(it.Value(), mempool.ErrConflictsWithOtherTx) {
+			numConflicting++
+		}
+	}
+	return drops.Len(), numConflicting
+}
+
+// droppedTx is a tx received from a peer that was dropped
+type droppedTx struct {
+	NodeID ids.NodeID `json:"nodeID"`
+	TxID   ids.ID     `json:"txID"`
+	Reason string     `json:"reason"`
+}
+
+// recentDrops returns up to [limit] of the dropped txs of the peers that most
+// recently had a tx dropped, from the most recently active peer to the least.
+func (p *peerDropTracker) recentDrops(limit int) []droppedTx {
+	var (
+		peers = make([]ids.NodeID, 0, p.peers.Len())
+		it    = p.peers.NewIterator()
+	)
+	for it.Next() {
+		peers = append(peers, it.Key())
+	}
+
+	var drops []droppedTx
+	for i := len(peers) - 1; i >= 0 && len(drops) < limit; i-- {
+		nodeID := peers[i]
+		peerDrops, _ := p.peers.Get(nodeID)
+		it := peerDrops.NewIterator()
+		for it.Next() && len(drops) < limit {
+			drops = append(drops, droppedTx{
+				NodeID: nodeID,
+				TxID:   it.Key(),
+				Reason: it.Value().Error(),
+			})
+		}
+	}
+	return drops
+}
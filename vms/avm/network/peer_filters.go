@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/bloom"
+	"github.com/ava-labs/avalanchego/utils/linked"
+)
+
+var errInvalidMaxPeerFilters = errors.New("max peer filters must be positive")
+
+func newPeerFilters(maxPeers int) (*peerFilters, error) {
+	if maxPeers <= 0 {
+		return nil, errInvalidMaxPeerFilters
+	}
+	return &peerFilters{
+		maxPeers: maxPeers,
+		filters:  linked.NewHashmap[ids.NodeID, peerFilter](),
+	}, nil
+}
+
+// peerFilters remembers the bloom filter that each of the most recently
+// active peers sent in its latest pull gossip request, to estimate how widely
+// a tx has propagated.
+type peerFilters struct {
+	lock     sync.Mutex
+	maxPeers int
+	// filters are the filters of each peer, from least to most recently
+	// received
+	filters *linked.Hashmap[ids.NodeID, peerFilter]
+}
+
+// peerFilter is a bloom filter of the txs a peer knows about.
+type peerFilter struct {
+	filter *bloom.ReadFilter
+	salt   ids.ID
+}
+
+// record replaces the filter of [nodeID] with [filter] and [salt]. If this
+// exceeds the maximum number of peers, the filter of the peer whose filter was
+// least recently recorded is forgotten.
+func (p *peerFilters) record(nodeID ids.NodeID, filter *bloom.ReadFilter, salt ids.ID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.filters.Has(nodeID) && p.filters.Len() >= p.maxPeers {
+		oldestNodeID, _, _ := p.filters.Oldest()
+		p.filters.Delete(oldestNodeID)
+	}
+	p.filters.Put(nodeID, peerFilter{
+		filter: filter,
+		salt:   salt,
+	})
+}
+
+// remove forgets the filter of [nodeID].
+func (p *peerFilters) remove(nodeID ids.NodeID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.filters.Delete(nodeID)
+}
+
+// numPeersWith returns the number of peers whose filter contains [txID]. As
+// bloom filters have false positives, and peers may have learned about [txID]
+// since they sent their filter, this is only an estimate.
+func (p *peerFilters) numPeersWith(txID ids.ID) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var numPeers int
+	for it := p.filters.NewIterator(); it.Next(); {
+		filter := it.Value()
+		if bloom.Contains(filter.filter, txID[:], filter.salt[:]) {
+			numPeers++
+		}
+	}
+	return numPeers
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/network/p2p/gossip"
+	"github.com/ava-labs/avalanchego/utils/bloom"
+)
+
+// newPeerFilter returns a filter that contains [txIDs].
+func newPeerFilter(t *testing.T, txIDs ...ids.ID) (*bloom.ReadFilter, ids.ID) {
+	require := require.New(t)
+
+	filter, err := bloom.New(bloom.OptimalParameters(1000, 0.0001))
+	require.NoError(err)
+	salt := ids.GenerateTestID()
+	for _, txID := range txIDs {
+		bloom.Add(filter, txID[:], salt[:])
+	}
+
+	readFilter, err := bloom.Parse(filter.Marshal())
+	require.NoError(err)
+	return readFilter, salt
+}
+
+func TestNewPeerFiltersInvalidMaxPeers(t *testing.T) {
+	_, err := newPeerFilters(0)
+	require.ErrorIs(t, err, errInvalidMaxPeerFilters)
+}
+
+func TestPeerFilters(t *testing.T) {
+	require := require.New(t)
+
+	p, err := newPeerFilters(2)
+	require.NoError(err)
+
+	var (
+		tx0   = ids.GenerateTestID()
+		tx1   = ids.GenerateTestID()
+		node0 = ids.GenerateTestNodeID()
+		node1 = ids.GenerateTestNodeID()
+		node2 = ids.GenerateTestNodeID()
+	)
+	record := func(nodeID ids.NodeID, txIDs ...ids.ID) {
+		filter, salt := newPeerFilter(t, txIDs...)
+		p.record(nodeID, filter, salt)
+	}
+	record(node0, tx0, tx1)
+	record(node1, tx0)
+	require.Equal(2, p.numPeersWith(tx0))
+	require.Equal(1, p.numPeersWith(tx1))
+	require.Zero(p.numPeersWith(ids.GenerateTestID()))
+
+	// A newer filter from a peer replaces its previous filter.
+	record(node0, tx0)
+	require.Equal(2, p.numPeersWith(tx0))
+	require.Zero(p.numPeersWith(tx1))
+
+	// Only the filters of the most recent peers are kept.
+	record(node2, tx1)
+	require.Equal(1, p.numPeersWith(tx0))
+	require.Equal(1, p.numPeersWith(tx1))
+
+	p.remove(node2)
+	require.Zero(p.numPeersWith(tx1))
+}
+
+func TestTxGossipHandlerRecordsPeerFilters(t *testing.T) {
+	require := require.New(t)
+
+	peerFilters, err := newPeerFilters(10)
+	require.NoError(err)
+
+	var serveErr error
+	handler := txGossipHandler{
+		appRequestHandler: p2p.TestHandler{
+			AppRequestF: func(context.Context, ids.NodeID, time.Time, []byte) ([]byte, error) {
+				return nil, serveErr
+			},
+		},
+		mempool: &gossipMempool{
+			peerFilters: peerFilters,
+		},
+	}
+
+	txID := ids.GenerateTestID()
+	request := func() error {
+		filter, salt := newPeerFilter(t, txID)
+		requestBytes, err := gossip.MarshalAppRequest(filter.Marshal(), salt[:])
+		require.NoError(err)
+		_, err = handler.AppRequest(context.Background(), ids.GenerateTestNodeID(), time.Time{}, requestBytes)
+		return err
+	}
+
+	// The filters of requests that aren't served aren't recorded.
+	serveErr = errTest
+	require.ErrorIs(request(), errTest)
+	require.Zero(peerFilters.numPeersWith(txID))
+
+	serveErr = nil
+	require.NoError(request())
+	require.NoError(request())
+	require.Equal(2, peerFilters.numPeersWith(txID))
+}
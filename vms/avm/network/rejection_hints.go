@@ -0,0 +1,92 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+)
+
+// maxHintedTxs is the number of txs that rejection hints were received for
+// that are remembered, so that each tx is only verified once for a hint.
+const maxHintedTxs = 4096
+
+var (
+	_ p2p.Handler = (*rejectionHintHandler)(nil)
+
+	errFalseRejectionHint = errors.New("rejected tx passed verification")
+)
+
+// rejectionHintSender notifies peers of txs that failed verification, so that
+// they don't need to gossip them.
+type rejectionHintSender struct {
+	log        logging.Logger
+	client     *p2p.Client
+	sendConfig common.SendConfig
+}
+
+func (s *rejectionHintSender) send(tx *txs.Tx) {
+	if err := s.client.AppGossip(context.TODO(), s.sendConfig, tx.Bytes()); err != nil {
+		s.log.Error("failed to send rejection hint",
+			zap.Stringer("txID", tx.ID()),
+			zap.Error(err),
+		)
+	}
+}
+
+// rejectionHintHandler handles txs that peers report as having failed
+// verification. The claim of the peer isn't trusted, the tx is only dropped if
+// it also fails local verification. As verifying a tx is expensive, hints for
+// a tx are ignored once a hint for it was handled, while it is remembered in
+// hinted.
+type rejectionHintHandler struct {
+	p2p.NoOpHandler
+	log     logging.Logger
+	parser  txs.Parser
+	mempool *gossipMempool
+	hinted  *cache.LRU[ids.ID, struct{}]
+}
+
+func (h *rejectionHintHandler) AppGossip(
+	_ context.Context,
+	nodeID ids.NodeID,
+	gossipBytes []byte,
+) {
+	tx, err := h.parser.ParseTx(gossipBytes)
+	if err != nil {
+		h.log.Debug("failed to parse rejection hint",
+			zap.Stringer("nodeID", nodeID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	txID := tx.ID()
+	if _, ok := h.hinted.Get(txID); ok {
+		h.log.Debug("ignoring rejection hint",
+			zap.Stringer("nodeID", nodeID),
+			zap.Stringer("txID", txID),
+			zap.String("reason", "tx was already hinted"),
+		)
+		return
+	}
+	h.hinted.Put(txID, struct{}{})
+
+	if err := h.mempool.applyRejectionHint(tx); err != nil {
+		h.log.Debug("ignoring rejection hint",
+			zap.Stringer("nodeID", nodeID),
+			zap.Stringer("txID", txID),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/avm/block/executor"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+)
+
+func newRejectionHintTestNetwork(t *testing.T, sender *common.FakeSender) *Network {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.RejectionHintNumPeers = 1
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{},
+		baseMempool,
+		sender,
+		registerer,
+		config,
+	)
+	require.NoError(err)
+	return n
+}
+
+func newRejectionHintTestTx(t *testing.T, n *Network) *txs.Tx {
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				BlockchainID: ids.GenerateTestID(),
+				Ins:          []*avax.TransferableInput{},
+			},
+		},
+	}
+	require.NoError(t, tx.Initialize(n.parser.Codec()))
+	return tx
+}
+
+func TestRejectionHintPropagated(t *testing.T) {
+	require := require.New(t)
+
+	sender := &common.FakeSender{
+		SentAppGossip: make(chan []byte, 1),
+	}
+	n := newRejectionHintTestNetwork(t, sender)
+	n.mempool.txVerifier = testVerifier{
+		err: errTest,
+	}
+
+	// Txs issued locally aren't reported to peers.
+	tx := newRejectionHintTestTx(t, n)
+	err := n.IssueTxFromRPC(tx)
+	require.ErrorIs(err, errTest)
+	require.Empty(sender.SentAppGossip)
+
+	tx = newRejectionHintTestTx(t, n)
+	err = n.mempool.AddFromPeer(ids.GenerateTestNodeID(), tx)
+	require.ErrorIs(err, errTest)
+	require.Equal(
+		p2p.PrefixMessage(p2p.ProtocolPrefix(rejectionHintHandlerID), tx.Bytes()),
+		<-sender.SentAppGossip,
+	)
+}
+
+func TestRejectionHintHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		verifyErr          error
+		expectedInMempool  bool
+		expectedDropReason error
+	}{
+		{
+			name:               "invalid tx",
+			verifyErr:          errTest,
+			expectedInMempool:  false,
+			expectedDropReason: errTest,
+		},
+		{
+			name:               "false claim",
+			verifyErr:          nil,
+			expectedInMempool:  true,
+			expectedDropReason: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			n := newRejectionHintTestNetwork(t, &common.FakeSender{})
+			tx := newRejectionHintTestTx(t, n)
+			require.NoError(n.IssueTxFromRPC(tx))
+
+			// The tx is re-verified after the peer claims it is invalid.
+			n.mempool.txVerifier = testVerifier{
+				err: tt.verifyErr,
+			}
+			handler := &rejectionHintHandler{
+				log:     logging.NoLog{},
+				parser:  n.parser,
+				mempool: n.mempool,
+				hinted:  &cache.LRU[ids.ID, struct{}]{Size: maxHintedTxs},
+			}
+			handler.AppGossip(context.Background(), ids.GenerateTestNodeID(), tx.Bytes())
+
+			txID := tx.ID()
+			_, inMempool := n.mempool.Get(txID)
+			require.Equal(tt.expectedInMempool, inMempool)
+			require.Equal(tt.expectedDropReason, n.mempool.GetDropReason(txID))
+		})
+	}
+}
+
+func TestRejectionHintHandlerVerifiesOnce(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+
+	n := newRejectionHintTestNetwork(t, &common.FakeSender{})
+	tx := newRejectionHintTestTx(t, n)
+	require.NoError(n.IssueTxFromRPC(tx))
+
+	// Repeated false hints for a tx, even from different peers, only cause it
+	// to be verified once.
+	txVerifier := executor.NewMockManager(ctrl)
+	txVerifier.EXPECT().VerifyTx(gomock.Any()).Return(nil)
+	n.mempool.txVerifier = txVerifier
+	handler := &rejectionHintHandler{
+		log:     logging.NoLog{},
+		parser:  n.parser,
+		mempool: n.mempool,
+		hinted:  &cache.LRU[ids.ID, struct{}]{Size: maxHintedTxs},
+	}
+	for i := 0; i < 3; i++ {
+		handler.AppGossip(context.Background(), ids.GenerateTestNodeID(), tx.Bytes())
+	}
+	require.True(n.mempool.Has(tx.ID()))
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"encoding/json"
+
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+// maxSupportBundleDrops is the maximum number of dropped txs included in a
+// support bundle.
+const maxSupportBundleDrops = 256
+
+// supportBundle is the gossip state of the network that is needed to triage
+// gossip issues.
+type supportBundle struct {
+	Config      Config                           `json:"config"`
+	Metrics     supportBundleMetrics             `json:"metrics"`
+	Peers       map[ids.NodeID]supportBundlePeer `json:"peers"`
+	RecentDrops []droppedTx                      `json:"recentDrops"`
+	BloomFilter supportBundleBloomFilter         `json:"bloomFilter"`
+	Mempool     supportBundleMempool             `json:"mempool"`
+}
+
+type supportBundleMetrics struct {
+	SentCount     uint64 `json:"sentCount"`
+	SentBytes     uint64 `json:"sentBytes"`
+	ReceivedCount uint64 `json:"receivedCount"`
+	ReceivedBytes uint64 `json:"receivedBytes"`
+	CurrentFanout int    `json:"currentFanout"`
+}
+
+type supportBundlePeer struct {
+	ReceivedCount        uint64 `json:"receivedCount"`
+	ReceivedBytes        uint64 `json:"receivedBytes"`
+	SentCount            uint64 `json:"sentCount"`
+	SentBytes            uint64 `json:"sentBytes"`
+	NumAdded             uint64 `json:"numAdded"`
+	NumDropped           uint64 `json:"numDropped"`
+	NumThrottled         uint64 `json:"numThrottled"`
+	NumChallengeFailures uint64 `json:"numChallengeFailures"`
+	// NumTrackedDrops and NumTrackedConflicts are only reported if dropped
+	// txs are tracked per peer.
+	NumTrackedDrops     int `json:"numTrackedDrops"`
+	NumTrackedConflicts int `json:"numTrackedConflicts"`
+}
+
+type supportBundleBloomFilter struct {
+	Count                int     `json:"count"`
+	MaxCount             int     `json:"maxCount"`
+	Saturation           float64 `json:"saturation"`
+	Elements             int     `json:"elements"`
+	NumRemovedSinceReset int     `json:"numRemovedSinceReset"`
+	RebuildDeferred      bool    `json:"rebuildDeferred"`
+}
+
+type supportBundleMempool struct {
+	Len        int    `json:"len"`
+	NumAdded   uint64 `json:"numAdded"`
+	NumDropped uint64 `json:"numDropped"`
+}
+
+// SupportBundle returns a JSON document of the gossip config and the current
+// gossip state, including metrics, per-peer stats, recently dropped txs and the
+// saturation of the bloom filter.
+func (n *Network) SupportBundle() ([]byte, error) {
+	summary, err := n.txGossipMetrics.Summary()
+	if err != nil {
+		return nil, err
+	}
+
+	peerStats := n.txGossipPeerStats.PeerStats()
+	bundle := supportBundle{
+		Config: n.config,
+		Metrics: supportBundleMetrics{
+			SentCount:     summary.SentCount,
+			SentBytes:     summary.SentBytes,
+			ReceivedCount: summary.ReceivedCount,
+			ReceivedBytes: summary.ReceivedBytes,
+			CurrentFanout: n.txPushGossiper.CurrentFanout(),
+		},
+		Peers: make(map[ids.NodeID]supportBundlePeer, len(peerStats)),
+	}
+	for nodeID, stats := range peerStats {
+		bundle.Peers[nodeID] = supportBundlePeer{
+			ReceivedCount:        stats.ReceivedCount,
+			ReceivedBytes:        stats.ReceivedBytes,
+			SentCount:            stats.SentCount,
+			SentBytes:            stats.SentBytes,
+			NumAdded:             stats.NumAdded,
+			NumDropped:           stats.NumDropped,
+			NumThrottled:         stats.NumThrottled,
+			NumChallengeFailures: stats.NumChallengeFailures,
+		}
+	}
+	n.mempool.addToSupportBundle(&bundle)
+	return json.Marshal(bundle)
+}
+
+// addToSupportBundle adds the state of the mempool to [bundle].
+func (g *gossipMempool) addToSupportBundle(bundle *supportBundle) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var (
+		count    = g.bloom.Count()
+		maxCount = g.bloom.MaxCount()
+	)
+	bundle.BloomFilter = supportBundleBloomFilter{
+		Count:                count,
+		MaxCount:             maxCount,
+		Elements:             g.bloomElements,
+		NumRemovedSinceReset: g.numRemovedSinceReset,
+		RebuildDeferred:      g.bloomRebuildDeferred,
+	}
+	if maxCount > 0 {
+		bundle.BloomFilter.Saturation = float64(count) / float64(maxCount)
+	}
+	bundle.Mempool = supportBundleMempool{
+		Len:        g.Mempool.Len(),
+		NumAdded:   g.numAdded,
+		NumDropped: g.numDropped,
+	}
+
+	if g.peerDrops == nil {
+		return
+	}
+	for nodeID, peer := range bundle.Peers {
+		peer.NumTrackedDrops, peer.NumTrackedConflicts = g.peerDrops.numDropped(nodeID)
+		bundle.Peers[nodeID] = peer
+	}
+	bundle.RecentDrops = g.peerDrops.recentDrops(maxSupportBundleDrops)
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/linked"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+const (
+	fateLabel = "fate"
+
+	txFateAccepted = "accepted"
+	txFateRejected = "rejected"
+	txFateExpired  = "expired"
+)
+
+func newTxFateTracker(
+	maxTracked int,
+	expiry time.Duration,
+	registerer prometheus.Registerer,
+) (*txFateTracker, error) {
+	t := &txFateTracker{
+		maxTracked: maxTracked,
+		expiry:     expiry,
+		pending:    linked.NewHashmap[ids.ID, time.Time](),
+		fates: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gossip_tx_fates",
+				Help: "number of txs received from peers that were eventually accepted, rejected or expired (n)",
+			},
+			[]string{fateLabel},
+		),
+	}
+	return t, registerer.Register(t.fates)
+}
+
+// txFateTracker tracks the eventual fate of the txs that were added to the
+// mempool after being received from peers. Each tx is counted exactly once,
+// as either:
+//   - accepted, if it was accepted.
+//   - rejected, if it was dropped from the mempool.
+//   - expired, if its fate wasn't known within the expiry, or if it was no
+//     longer tracked to stay within the limit of tracked txs.
+type txFateTracker struct {
+	clock      mockable.Clock
+	maxTracked int
+	expiry     time.Duration
+	fates      *prometheus.CounterVec
+
+	lock sync.Mutex
+	// pending maps the txs whose fate isn't known yet to the time they were
+	// tracked, from oldest to newest
+	pending *linked.Hashmap[ids.ID, time.Time]
+}
+
+// track starts tracking the fate of [txID].
+func (t *txFateTracker) track(txID ids.ID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.expire()
+	if _, ok := t.pending.Get(txID); ok {
+		return
+	}
+	if t.pending.Len() >= t.maxTracked {
+		oldestTxID, _, _ := t.pending.Oldest()
+		t.resolve(oldestTxID, txFateExpired)
+	}
+	t.pending.Put(txID, t.clock.Time())
+}
+
+// accepted records that [txIDs] were accepted.
+func (t *txFateTracker) accepted(txIDs ...ids.ID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.expire()
+	for _, txID := range txIDs {
+		t.resolve(txID, txFateAccepted)
+	}
+}
+
+// rejected records that [txIDs] were dropped from the mempool.
+func (t *txFateTracker) rejected(txIDs ...ids.ID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.expire()
+	for _, txID := range txIDs {
+		t.resolve(txID, txFateRejected)
+	}
+}
+
+// expire resolves the pending txs that have been tracked for longer than the
+// expiry as expired.
+//
+// Assumes [t.lock] is held.
+func (t *txFateTracker) expire() {
+	if t.expiry <= 0 {
+		return
+	}
+
+	now := t.clock.Time()
+	for {
+		txID, trackedTime, ok := t.pending.Oldest()
+		if !ok || now.Sub(trackedTime) < t.expiry {
+			return
+		}
+		t.resolve(txID, txFateExpired)
+	}
+}
+
+// resolve records [fate] for [txID] if its fate wasn't already known.
+//
+// Assumes [t.lock] is held.
+func (t *txFateTracker) resolve(txID ids.ID, fate string) {
+	if !t.pending.Delete(txID) {
+		return
+	}
+	t.fates.With(prometheus.Labels{
+		fateLabel: fate,
+	}).Inc()
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+)
+
+func TestNetworkTxFates(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.MaxTrackedTxFates = 2
+	config.TxFateExpiry = time.Minute
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.NoError(err)
+
+	now := time.Now()
+	fates := n.mempool.txFates
+	fates.clock.Set(now)
+
+	nodeID := ids.GenerateTestNodeID()
+	addFromPeer := func() ids.ID {
+		tx := &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+		require.NoError(n.mempool.AddFromPeer(nodeID, tx))
+		return tx.ID()
+	}
+	requireFates := func(accepted, rejected, expired int) {
+		require.Equal(float64(accepted), testutil.ToFloat64(fates.fates.WithLabelValues(txFateAccepted)))
+		require.Equal(float64(rejected), testutil.ToFloat64(fates.fates.WithLabelValues(txFateRejected)))
+		require.Equal(float64(expired), testutil.ToFloat64(fates.fates.WithLabelValues(txFateExpired)))
+	}
+
+	// Each fate is only counted once per tx.
+	acceptedTxID := addFromPeer()
+	n.MarkAccepted(acceptedTxID)
+	n.MarkAccepted(acceptedTxID)
+	n.MarkDropped(acceptedTxID)
+	requireFates(1, 0, 0)
+
+	rejectedTxID := addFromPeer()
+	n.MarkDropped(rejectedTxID)
+	n.MarkDropped(rejectedTxID)
+	n.MarkAccepted(rejectedTxID)
+	requireFates(1, 1, 0)
+
+	// Txs that are neither accepted nor rejected within the expiry are
+	// expired.
+	expiredTxID := addFromPeer()
+	fates.clock.Set(now.Add(config.TxFateExpiry))
+	n.MarkAccepted(expiredTxID)
+	requireFates(1, 1, 1)
+
+	// Txs that are no longer tracked to stay within the limit are expired.
+	for i := 0; i < config.MaxTrackedTxFates+1; i++ {
+		addFromPeer()
+	}
+	requireFates(1, 1, 2)
+
+	// Txs issued locally aren't tracked.
+	tx := &txs.Tx{
+		Unsigned: &txs.BaseTx{
+			BaseTx: avax.BaseTx{
+				Ins: []*avax.TransferableInput{},
+			},
+		},
+		TxID: ids.GenerateTestID(),
+	}
+	require.NoError(n.mempool.AddFromPeer(ids.EmptyNodeID, tx))
+	n.MarkAccepted(tx.ID())
+	requireFates(1, 1, 2)
+}
@@ -4,16 +4,38 @@
 package network
 
 import (
+	"context"
 	"sync"
 
+	"golang.org/x/sync/semaphore"
+
+	"github.com/ava-labs/avalanchego/vms/avm/block/executor"
 	"github.com/ava-labs/avalanchego/vms/avm/txs"
 )
 
-var _ TxVerifier = (*LockedTxVerifier)(nil)
+var (
+	_ TxVerifier = (*LockedTxVerifier)(nil)
+	_ TxVerifier = (*LimitedTxVerifier)(nil)
+
+	// ErrRetryable is wrapped by verification errors that may not persist,
+	// such as if a tx spends a UTXO that isn't known yet. Txs that fail
+	// verification with a retryable error aren't marked as dropped.
+	ErrRetryable = executor.ErrRetryable
+)
 
 type TxVerifier interface {
 	// VerifyTx verifies that the transaction should be issued into the mempool.
 	VerifyTx(tx *txs.Tx) error
+
+	// VerifyTxs verifies each of the transactions as VerifyTx would, against
+	// a single snapshot of the state. The returned errors are aligned with
+	// the transactions.
+	VerifyTxs(txs []*txs.Tx) []error
+
+	// VerifyProjectedTx verifies that the transaction should be issued into
+	// the mempool based on the state the next block is projected to be built
+	// on.
+	VerifyProjectedTx(tx *txs.Tx) error
 }
 
 type LockedTxVerifier struct {
@@ -28,9 +50,84 @@ func (l *LockedTxVerifier) VerifyTx(tx *txs.Tx) error {
 	return l.txVerifier.VerifyTx(tx)
 }
 
+func (l *LockedTxVerifier) VerifyTxs(txs []*txs.Tx) []error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.txVerifier.VerifyTxs(txs)
+}
+
+func (l *LockedTxVerifier) VerifyProjectedTx(tx *txs.Tx) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.txVerifier.VerifyProjectedTx(tx)
+}
+
 func NewLockedTxVerifier(lock sync.Locker, txVerifier TxVerifier) *LockedTxVerifier {
 	return &LockedTxVerifier{
 		lock:       lock,
 		txVerifier: txVerifier,
 	}
 }
+
+// LimitedTxVerifier bounds the number of concurrent calls to VerifyTx by
+// acquiring a slot of a, possibly shared, limiter.
+type LimitedTxVerifier struct {
+	limiter    *semaphore.Weighted
+	txVerifier TxVerifier
+}
+
+func (l *LimitedTxVerifier) VerifyTx(tx *txs.Tx) error {
+	if l.limiter == nil {
+		return l.txVerifier.VerifyTx(tx)
+	}
+
+	// Acquire can only fail if the context is cancelled.
+	_ = l.limiter.Acquire(context.Background(), 1)
+	defer l.limiter.Release(1)
+
+	return l.txVerifier.VerifyTx(tx)
+}
+
+// VerifyTxs verifies the transactions while holding a single slot of the
+// limiter.
+func (l *LimitedTxVerifier) VerifyTxs(txs []*txs.Tx) []error {
+	if l.limiter == nil {
+		return l.txVerifier.VerifyTxs(txs)
+	}
+
+	// Acquire can only fail if the context is cancelled.
+	_ = l.limiter.Acquire(context.Background(), 1)
+	defer l.limiter.Release(1)
+
+	return l.txVerifier.VerifyTxs(txs)
+}
+
+func (l *LimitedTxVerifier) VerifyProjectedTx(tx *txs.Tx) error {
+	if l.limiter == nil {
+		return l.txVerifier.VerifyProjectedTx(tx)
+	}
+
+	// Acquire can only fail if the context is cancelled.
+	_ = l.limiter.Acquire(context.Background(), 1)
+	defer l.limiter.Release(1)
+
+	return l.txVerifier.VerifyProjectedTx(tx)
+}
+
+// NewLimitedTxVerifier returns a TxVerifier that limits the number of
+// concurrent verifications using [limiter]. If [limiter] is nil, verification
+// is not limited.
+//
+// A slot is held for as long as [txVerifier] runs. If [limiter] is shared
+// between chains, [txVerifier] must not wait on a chain's lock, otherwise a
+// chain whose lock is held would occupy slots without verifying anything.
+// The chain's LockedTxVerifier should wrap the LimitedTxVerifier instead, so
+// that slots are only acquired once the lock is held.
+func NewLimitedTxVerifier(limiter *semaphore.Weighted, txVerifier TxVerifier) *LimitedTxVerifier {
+	return &LimitedTxVerifier{
+		limiter:    limiter,
+		txVerifier: txVerifier,
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/utils/buffer"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+)
+
+const (
+	// verificationFailureWarnFrequency is the minimum duration between
+	// warnings that verification is consistently failing.
+	verificationFailureWarnFrequency = time.Minute
+
+	// verificationResultTTL is the duration after which the result of a
+	// verification no longer counts towards the failure rate, so that the
+	// monitor recovers once txs stop failing, even if few txs are verified.
+	verificationResultTTL = 10 * time.Minute
+)
+
+var (
+	errInvalidVerificationFailureThreshold = errors.New("verification failure threshold must be in the range (0, 1]")
+	errVerificationFailing                 = errors.New("verification of txs is consistently failing")
+)
+
+func newVerificationMonitor(
+	log logging.Logger,
+	window int,
+	threshold float64,
+) (*verificationMonitor, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("%w: %f", errInvalidVerificationFailureThreshold, threshold)
+	}
+
+	m := &verificationMonitor{
+		log:       log,
+		window:    window,
+		threshold: threshold,
+	}
+	results, err := buffer.NewBoundedQueue(window, m.forget)
+	m.results = results
+	return m, err
+}
+
+// verificationMonitor tracks the results of the most recent verifications of
+// txs issued locally. If the rate of failures exceeds the threshold, the node
+// may be far behind or its verifier may be misconfigured, so a warning is
+// logged and the monitor reports itself as unhealthy. Txs received from peers
+// aren't monitored, as peers can send arbitrarily many invalid txs.
+type verificationMonitor struct {
+	log       logging.Logger
+	clock     mockable.Clock
+	window    int
+	threshold float64
+
+	lock       sync.Mutex
+	results    buffer.Queue[verificationResult]
+	numFailed  int
+	lastWarned time.Time
+}
+
+type verificationResult struct {
+	failed bool
+	time   time.Time
+}
+
+// observe records the result of verifying a tx.
+func (m *verificationMonitor) observe(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.clock.Time()
+	m.expire(now)
+
+	failed := err != nil
+	m.results.Push(verificationResult{
+		failed: failed,
+		time:   now,
+	})
+	if failed {
+		m.numFailed++
+	}
+
+	failureRate, failing := m.failing()
+	if !failing {
+		return
+	}
+
+	if now.Sub(m.lastWarned) < verificationFailureWarnFrequency {
+		return
+	}
+	m.lastWarned = now
+	m.log.Warn("verification of txs is consistently failing",
+		zap.Float64("failureRate", failureRate),
+		zap.Float64("threshold", m.threshold),
+		zap.Int("window", m.window),
+		zap.Error(err),
+	)
+}
+
+// failureRate returns the rate of failures over the most recent verifications
+// and whether it exceeds the threshold.
+func (m *verificationMonitor) failureRate() (float64, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.expire(m.clock.Time())
+	return m.failing()
+}
+
+// failing returns the rate of failures over the results in the window and
+// whether it exceeds the threshold. The failure rate isn't reported as
+// failing until the window is full, so that a few failures don't mark the
+// node as unhealthy.
+//
+// Assumes [m.lock] is held.
+func (m *verificationMonitor) failing() (float64, bool) {
+	numResults := m.results.Len()
+	if numResults == 0 {
+		return 0, false
+	}
+	failureRate := float64(m.numFailed) / float64(numResults)
+	return failureRate, numResults >= m.window && failureRate > m.threshold
+}
+
+// expire forgets the results that are older than verificationResultTTL.
+//
+// Assumes [m.lock] is held.
+func (m *verificationMonitor) expire(now time.Time) {
+	minTime := now.Add(-verificationResultTTL)
+	for {
+		result, ok := m.results.Peek()
+		if !ok || !result.time.Before(minTime) {
+			return
+		}
+		_, _ = m.results.Pop()
+		m.forget(result)
+	}
+}
+
+// forget removes [result] from the failure count.
+func (m *verificationMonitor) forget(result verificationResult) {
+	if result.failed {
+		m.numFailed--
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/avm/txs"
+	"github.com/ava-labs/avalanchego/vms/avm/txs/mempool"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+)
+
+func TestVerificationMonitor(t *testing.T) {
+	require := require.New(t)
+
+	m, err := newVerificationMonitor(logging.NoLog{}, 4, .5)
+	require.NoError(err)
+	now := time.Now()
+	m.clock.Set(now)
+
+	// The failure rate isn't reported as failing until the window is full.
+	for i := 0; i < 3; i++ {
+		m.observe(errTest)
+	}
+	failureRate, failing := m.failureRate()
+	require.Equal(1.0, failureRate)
+	require.False(failing)
+	require.True(m.lastWarned.IsZero())
+
+	m.observe(errTest)
+	_, failing = m.failureRate()
+	require.True(failing)
+	require.Equal(now, m.lastWarned)
+
+	// Warnings are rate limited.
+	m.clock.Set(now.Add(verificationFailureWarnFrequency - 1))
+	m.observe(errTest)
+	require.Equal(now, m.lastWarned)
+
+	m.clock.Set(now.Add(verificationFailureWarnFrequency))
+	m.observe(errTest)
+	require.Equal(now.Add(verificationFailureWarnFrequency), m.lastWarned)
+
+	// Once verification succeeds again, the oldest failures leave the window.
+	for i := 0; i < 2; i++ {
+		m.observe(nil)
+	}
+	failureRate, failing = m.failureRate()
+	require.Equal(.5, failureRate)
+	require.False(failing)
+
+	// Old results are forgotten, even if no txs are verified.
+	for i := 0; i < 3; i++ {
+		m.observe(errTest)
+	}
+	_, failing = m.failureRate()
+	require.True(failing)
+
+	m.clock.Set(now.Add(verificationFailureWarnFrequency + verificationResultTTL + 1))
+	failureRate, failing = m.failureRate()
+	require.Zero(failureRate)
+	require.False(failing)
+}
+
+func TestNewVerificationMonitorInvalidThreshold(t *testing.T) {
+	_, err := newVerificationMonitor(logging.NoLog{}, 1, 0)
+	require.ErrorIs(t, err, errInvalidVerificationFailureThreshold)
+}
+
+func TestNetworkHealthCheckVerificationFailing(t *testing.T) {
+	require := require.New(t)
+
+	parser, err := txs.NewParser(nil)
+	require.NoError(err)
+
+	registerer := prometheus.NewRegistry()
+	baseMempool, err := mempool.New("", registerer, make(chan common.Message, 1))
+	require.NoError(err)
+
+	config := testConfig
+	config.VerificationFailureWindow = 10
+	config.VerificationFailureThreshold = .9
+	n, err := New(
+		logging.NoLog{},
+		ids.EmptyNodeID,
+		ids.Empty,
+		&validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) {
+				return 0, nil
+			},
+			GetValidatorSetF: func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				return nil, nil
+			},
+		},
+		parser,
+		ids.Empty,
+		testVerifier{
+			err: errTest,
+		},
+		baseMempool,
+		&common.FakeSender{},
+		registerer,
+		config,
+	)
+	require.NoError(err)
+
+	_, err = n.HealthCheck(context.Background())
+	require.NoError(err)
+
+	newTx := func() *txs.Tx {
+		return &txs.Tx{
+			Unsigned: &txs.BaseTx{
+				BaseTx: avax.BaseTx{
+					Ins: []*avax.TransferableInput{},
+				},
+			},
+			TxID: ids.GenerateTestID(),
+		}
+	}
+
+	// Invalid txs sent by peers don't make the node unhealthy.
+	nodeID := ids.GenerateTestNodeID()
+	for i := 0; i < config.VerificationFailureWindow; i++ {
+		require.ErrorIs(n.mempool.AddFromPeer(nodeID, newTx()), errTest)
+	}
+	_, err = n.HealthCheck(context.Background())
+	require.NoError(err)
+
+	for i := 0; i < config.VerificationFailureWindow; i++ {
+		require.ErrorIs(n.IssueTxFromRPC(newTx()), errTest)
+	}
+
+	details, err := n.HealthCheck(context.Background())
+	require.ErrorIs(err, errVerificationFailing)
+	require.Equal(
+		map[string]interface{}{
+			"verificationFailureRate": 1.0,
+		},
+		details,
+	)
+}
@@ -45,6 +45,8 @@ var (
 type Mempool interface {
 	Add(tx *txs.Tx) error
 	Get(txID ids.ID) (*txs.Tx, bool)
+	// Contains returns true if the tx with [txID] is in the mempool.
+	Contains(txID ids.ID) bool
 	// Remove [txs] and any conflicts of [txs] from the mempool.
 	Remove(txs ...*txs.Tx)
 
@@ -63,9 +65,15 @@ type Mempool interface {
 	// unissued. This allows previously dropped txs to be possibly reissued.
 	MarkDropped(txID ids.ID, reason error)
 	GetDropReason(txID ids.ID) error
+	// ClearDropReasons forgets the reasons that txs were dropped, allowing
+	// them to be reissued.
+	ClearDropReasons()
 
 	// Len returns the number of txs in the mempool.
 	Len() int
+
+	// Size returns the number of bytes of txs in the mempool.
+	Size() int
 }
 
 type mempool struct {
@@ -166,6 +174,13 @@ func (m *mempool) Get(txID ids.ID) (*txs.Tx, bool) {
 	return m.unissuedTxs.Get(txID)
 }
 
+func (m *mempool) Contains(txID ids.ID) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.unissuedTxs.Has(txID)
+}
+
 func (m *mempool) Remove(txs ...*txs.Tx) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -245,9 +260,20 @@ func (m *mempool) GetDropReason(txID ids.ID) error {
 	return err
 }
 
+func (m *mempool) ClearDropReasons() {
+	m.droppedTxIDs.Flush()
+}
+
 func (m *mempool) Len() int {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
 	return m.unissuedTxs.Len()
 }
+
+func (m *mempool) Size() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return maxMempoolSize - m.bytesAvailable
+}
@@ -121,6 +121,59 @@ func TestGet(t *testing.T) {
 	require.False(exists)
 }
 
+func TestContains(t *testing.T) {
+	require := require.New(t)
+
+	mempool, err := New(
+		"mempool",
+		prometheus.NewRegistry(),
+		nil,
+	)
+	require.NoError(err)
+
+	tx := newTx(0, 32)
+	txID := tx.ID()
+
+	// Contains always agrees with Get.
+	requireContains := func(expected bool) {
+		_, exists := mempool.Get(txID)
+		require.Equal(expected, exists)
+		require.Equal(expected, mempool.Contains(txID))
+	}
+
+	requireContains(false)
+
+	require.NoError(mempool.Add(tx))
+	requireContains(true)
+
+	mempool.Remove(tx)
+	requireContains(false)
+}
+
+func BenchmarkContains(b *testing.B) {
+	mempool, err := New(
+		"mempool",
+		prometheus.NewRegistry(),
+		nil,
+	)
+	require.NoError(b, err)
+
+	tx := newTx(0, 32)
+	require.NoError(b, mempool.Add(tx))
+	txID := tx.ID()
+
+	b.Run("get", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = mempool.Get(txID)
+		}
+	})
+	b.Run("contains", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = mempool.Contains(txID)
+		}
+	})
+}
+
 func TestPeek(t *testing.T) {
 	require := require.New(t)
 
@@ -316,3 +369,24 @@ func newTx(index uint32, size int) *txs.Tx {
 	tx.SetBytes(utils.RandomBytes(size), utils.RandomBytes(size))
 	return tx
 }
+
+func TestSize(t *testing.T) {
+	require := require.New(t)
+
+	mempool, err := New(
+		"mempool",
+		prometheus.NewRegistry(),
+		nil,
+	)
+	require.NoError(err)
+	require.Zero(mempool.Size())
+
+	txs := newTxs(2, 32)
+	for _, tx := range txs {
+		require.NoError(mempool.Add(tx))
+	}
+	require.Equal(len(txs[0].Bytes())+len(txs[1].Bytes()), mempool.Size())
+
+	mempool.Remove(txs[0])
+	require.Equal(len(txs[1].Bytes()), mempool.Size())
+}
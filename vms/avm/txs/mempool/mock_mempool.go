@@ -54,6 +54,32 @@ func (mr *MockMempoolMockRecorder) Add(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockMempool)(nil).Add), arg0)
 }
 
+// ClearDropReasons mocks base method.
+func (m *MockMempool) ClearDropReasons() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClearDropReasons")
+}
+
+// ClearDropReasons indicates an expected call of ClearDropReasons.
+func (mr *MockMempoolMockRecorder) ClearDropReasons() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearDropReasons", reflect.TypeOf((*MockMempool)(nil).ClearDropReasons))
+}
+
+// Contains mocks base method.
+func (m *MockMempool) Contains(arg0 ids.ID) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Contains", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Contains indicates an expected call of Contains.
+func (mr *MockMempoolMockRecorder) Contains(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Contains", reflect.TypeOf((*MockMempool)(nil).Contains), arg0)
+}
+
 // Get mocks base method.
 func (m *MockMempool) Get(arg0 ids.ID) (*txs.Tx, bool) {
 	m.ctrl.T.Helper()
@@ -163,3 +189,17 @@ func (mr *MockMempoolMockRecorder) RequestBuildBlock() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestBuildBlock", reflect.TypeOf((*MockMempool)(nil).RequestBuildBlock))
 }
+
+// Size mocks base method.
+func (m *MockMempool) Size() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Size")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Size indicates an expected call of Size.
+func (mr *MockMempoolMockRecorder) Size() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Size", reflect.TypeOf((*MockMempool)(nil).Size))
+}
@@ -274,6 +274,7 @@ func (vm *VM) Initialize(
 
 	vm.onShutdownCtx, vm.onShutdownCtxCancel = context.WithCancel(context.Background())
 	vm.networkConfig = avmConfig.Network
+	vm.networkConfig.OnTxAdded = vm.OnTxAdded
 	return vm.state.Commit()
 }
 
@@ -301,6 +302,10 @@ func (vm *VM) onNormalOperationsStarted() error {
 }
 
 func (vm *VM) SetState(_ context.Context, state snow.State) error {
+	if vm.network != nil {
+		vm.network.SetState(state)
+	}
+
 	switch state {
 	case snow.Bootstrapping:
 		return vm.onBootstrapStarted()
@@ -319,6 +324,10 @@ func (vm *VM) Shutdown(context.Context) error {
 	vm.onShutdownCtxCancel()
 	vm.awaitShutdown.Wait()
 
+	if vm.network != nil {
+		vm.network.Close()
+	}
+
 	return utils.Err(
 		vm.state.Close(),
 		vm.baseDB.Close(),
@@ -376,10 +385,63 @@ func (vm *VM) ParseBlock(_ context.Context, blkBytes []byte) (snowman.Block, err
 }
 
 func (vm *VM) SetPreference(_ context.Context, blkID ids.ID) error {
+	previouslyPreferred := vm.chainManager.Preferred()
 	vm.chainManager.SetPreference(blkID)
+	// The depth of a reorg is only used to replay dropped txs, so the
+	// ancestry isn't walked unless that is enabled.
+	if vm.network == nil || vm.networkConfig.ReorgDropReplayDepth == 0 || previouslyPreferred == blkID {
+		return nil
+	}
+
+	depth, err := vm.reorgDepth(previouslyPreferred, blkID)
+	if err != nil {
+		// The previously preferred block may have been rejected or evicted
+		// since it was preferred, in which case it isn't treated as a reorg.
+		vm.ctx.Log.Debug("failed to calculate reorg depth",
+			zap.Stringer("previouslyPreferredID", previouslyPreferred),
+			zap.Stringer("preferredID", blkID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if depth > 0 {
+		vm.network.Reorged(depth)
+	}
 	return nil
 }
 
+// reorgDepth returns the number of blocks in the ancestry of [oldBlkID] that
+// aren't in the ancestry of [newBlkID].
+func (vm *VM) reorgDepth(oldBlkID, newBlkID ids.ID) (uint64, error) {
+	oldBlk, err := vm.chainManager.GetStatelessBlock(oldBlkID)
+	if err != nil {
+		return 0, err
+	}
+	newBlk, err := vm.chainManager.GetStatelessBlock(newBlkID)
+	if err != nil {
+		return 0, err
+	}
+
+	var depth uint64
+	for oldBlk.ID() != newBlk.ID() {
+		oldHeight, newHeight := oldBlk.Height(), newBlk.Height()
+		if oldHeight >= newHeight {
+			oldBlk, err = vm.chainManager.GetStatelessBlock(oldBlk.Parent())
+			if err != nil {
+				return 0, err
+			}
+			depth++
+		}
+		if newHeight >= oldHeight {
+			newBlk, err = vm.chainManager.GetStatelessBlock(newBlk.Parent())
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return depth, nil
+}
+
 func (vm *VM) LastAccepted(context.Context) (ids.ID, error) {
 	return vm.chainManager.LastAccepted(), nil
 }
@@ -401,10 +463,14 @@ func (vm *VM) Linearize(ctx context.Context, stopVertexID ids.ID, toEngine chan<
 		return err
 	}
 
-	mempool, err := mempool.New("mempool", vm.registerer, toEngine)
+	baseMempool, err := mempool.New("mempool", vm.registerer, toEngine)
 	if err != nil {
 		return fmt.Errorf("failed to create mempool: %w", err)
 	}
+	mempool := &droppedTxNotifier{
+		Mempool: baseMempool,
+		vm:      vm,
+	}
 
 	vm.chainManager = blockexecutor.NewManager(
 		mempool,
@@ -429,11 +495,17 @@ func (vm *VM) Linearize(ctx context.Context, stopVertexID ids.ID, toEngine chan<
 		vm.ctx.SubnetID,
 		vm.ctx.ValidatorState,
 		vm.parser,
+		vm.feeAssetID,
+		// The verification slot is only acquired once the context lock is
+		// held, so that slots aren't held while waiting for the lock.
 		network.NewLockedTxVerifier(
 			&vm.ctx.Lock,
-			vm.chainManager,
+			network.NewLimitedTxVerifier(
+				vm.GossipVerificationLimiter,
+				vm.chainManager,
+			),
 		),
-		mempool,
+		baseMempool,
 		vm.appSender,
 		vm.registerer,
 		vm.networkConfig,
@@ -683,5 +755,10 @@ func (vm *VM) onAccept(tx *txs.Tx) error {
 
 	vm.pubsub.Publish(NewPubSubFilterer(tx))
 	vm.walletService.decided(txID)
+
+	// The network is only initialized once the chain is linearized.
+	if vm.network != nil {
+		vm.network.MarkAccepted(txID)
+	}
 	return nil
 }
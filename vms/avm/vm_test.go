@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 
 	"github.com/ava-labs/avalanchego/chains/atomic"
 	"github.com/ava-labs/avalanchego/codec"
@@ -19,7 +20,10 @@ import (
 	"github.com/ava-labs/avalanchego/snow/snowtest"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/vms/avm/block"
+	"github.com/ava-labs/avalanchego/vms/avm/block/executor"
 	"github.com/ava-labs/avalanchego/vms/avm/fxs"
+	"github.com/ava-labs/avalanchego/vms/avm/network"
 	"github.com/ava-labs/avalanchego/vms/avm/txs"
 	"github.com/ava-labs/avalanchego/vms/components/avax"
 	"github.com/ava-labs/avalanchego/vms/components/verify"
@@ -128,6 +132,75 @@ func TestIssueTx(t *testing.T) {
 	issueAndAccept(require, env.vm, env.issuer, tx)
 }
 
+func TestIssueTxOnTxAdded(t *testing.T) {
+	require := require.New(t)
+
+	var added []ids.ID
+	vmStaticConfig := staticConfig(t, latest)
+	vmStaticConfig.OnTxAdded = func(tx *txs.Tx) {
+		added = append(added, tx.ID())
+	}
+	env := setup(t, &envConfig{
+		fork:           latest,
+		vmStaticConfig: &vmStaticConfig,
+	})
+	env.vm.ctx.Lock.Unlock()
+	defer func() {
+		env.vm.ctx.Lock.Lock()
+		require.NoError(env.vm.Shutdown(context.Background()))
+		env.vm.ctx.Lock.Unlock()
+	}()
+
+	tx := newTx(t, env.genesisBytes, env.vm.ctx.ChainID, env.vm.parser, "AVAX")
+	txID, err := env.vm.issueTxFromRPC(tx)
+	require.NoError(err)
+	require.Equal([]ids.ID{txID}, added)
+}
+
+func TestIssueTxSpendingUnknownUTXO(t *testing.T) {
+	require := require.New(t)
+
+	env := setup(t, &envConfig{
+		fork: latest,
+	})
+	env.vm.ctx.Lock.Unlock()
+	defer func() {
+		env.vm.ctx.Lock.Lock()
+		require.NoError(env.vm.Shutdown(context.Background()))
+		env.vm.ctx.Lock.Unlock()
+	}()
+
+	// The UTXO may be produced by a tx that hasn't been accepted yet, so the tx
+	// is deferred rather than dropped.
+	tx := &txs.Tx{Unsigned: &txs.BaseTx{
+		BaseTx: avax.BaseTx{
+			NetworkID:    constants.UnitTestID,
+			BlockchainID: env.vm.ctx.XChainID,
+			Ins: []*avax.TransferableInput{{
+				UTXOID: avax.UTXOID{
+					TxID: ids.GenerateTestID(),
+				},
+				Asset: avax.Asset{ID: env.genesisTx.ID()},
+				In: &secp256k1fx.TransferInput{
+					Amt: startBalance,
+					Input: secp256k1fx.Input{
+						SigIndices: []uint32{
+							0,
+						},
+					},
+				},
+			}},
+		},
+	}}
+	require.NoError(tx.SignSECP256K1Fx(env.vm.parser.Codec(), [][]*secp256k1.PrivateKey{{keys[0]}}))
+
+	_, err := env.vm.issueTxFromRPC(tx)
+	require.ErrorIs(err, network.ErrRetryable)
+	var addTxErr *network.AddTxError
+	require.ErrorAs(err, &addTxErr)
+	require.Equal(network.AddTxDeferred, addTxErr.Failure)
+}
+
 // Test issuing a transaction that creates an NFT family
 func TestIssueNFT(t *testing.T) {
 	require := require.New(t)
@@ -851,3 +924,126 @@ func TestClearForceAcceptedExportTx(t *testing.T) {
 	assertIndexedTX(t, env.vm.db, 0, key.PublicKey().Address(), assetID.AssetID(), tx.ID())
 	assertLatestIdx(t, env.vm.db, key.PublicKey().Address(), assetID.AssetID(), 1)
 }
+
+func TestVMReorgDepth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	blks := map[ids.ID]block.Block{}
+	newBlock := func(parentID ids.ID, height uint64) ids.ID {
+		blkID := ids.GenerateTestID()
+		blk := block.NewMockBlock(ctrl)
+		blk.EXPECT().ID().Return(blkID).AnyTimes()
+		blk.EXPECT().Parent().Return(parentID).AnyTimes()
+		blk.EXPECT().Height().Return(height).AnyTimes()
+		blks[blkID] = blk
+		return blkID
+	}
+
+	// genesis <- a1 <- a2 <- a3
+	//         <- b1
+	genesisID := newBlock(ids.Empty, 0)
+	a1ID := newBlock(genesisID, 1)
+	a2ID := newBlock(a1ID, 2)
+	a3ID := newBlock(a2ID, 3)
+	b1ID := newBlock(genesisID, 1)
+
+	chainManager := executor.NewMockManager(ctrl)
+	chainManager.EXPECT().GetStatelessBlock(gomock.Any()).DoAndReturn(
+		func(blkID ids.ID) (block.Block, error) {
+			return blks[blkID], nil
+		},
+	).AnyTimes()
+	vm := &VM{
+		chainManager: chainManager,
+	}
+
+	tests := []struct {
+		name          string
+		oldBlkID      ids.ID
+		newBlkID      ids.ID
+		expectedDepth uint64
+	}{
+		{
+			name:          "same block",
+			oldBlkID:      a2ID,
+			newBlkID:      a2ID,
+			expectedDepth: 0,
+		},
+		{
+			name:          "extends preferred chain",
+			oldBlkID:      a2ID,
+			newBlkID:      a3ID,
+			expectedDepth: 0,
+		},
+		{
+			name:          "reverts to shorter chain",
+			oldBlkID:      a3ID,
+			newBlkID:      b1ID,
+			expectedDepth: 3,
+		},
+		{
+			name:          "reverts to longer chain",
+			oldBlkID:      b1ID,
+			newBlkID:      a3ID,
+			expectedDepth: 1,
+		},
+		{
+			name:          "reverts to ancestor",
+			oldBlkID:      a3ID,
+			newBlkID:      a1ID,
+			expectedDepth: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+
+			depth, err := vm.reorgDepth(test.oldBlkID, test.newBlkID)
+			require.NoError(err)
+			require.Equal(test.expectedDepth, depth)
+		})
+	}
+}
+
+func TestVMSetPreferenceMissingAncestor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	var (
+		rejectedBlkID  = ids.GenerateTestID()
+		preferredBlkID = ids.GenerateTestID()
+	)
+	tests := []struct {
+		name                 string
+		reorgDropReplayDepth uint64
+		expectWalk           bool
+	}{
+		{
+			name:                 "replay disabled",
+			reorgDropReplayDepth: 0,
+			expectWalk:           false,
+		},
+		{
+			name:                 "previously preferred block was rejected",
+			reorgDropReplayDepth: 1,
+			expectWalk:           true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chainManager := executor.NewMockManager(ctrl)
+			chainManager.EXPECT().Preferred().Return(rejectedBlkID)
+			chainManager.EXPECT().SetPreference(preferredBlkID)
+			if test.expectWalk {
+				chainManager.EXPECT().GetStatelessBlock(rejectedBlkID).Return(nil, database.ErrNotFound)
+			}
+
+			vm := &VM{
+				ctx:          snowtest.Context(t, snowtest.XChainID),
+				chainManager: chainManager,
+				network:      &network.Network{},
+			}
+			vm.networkConfig.ReorgDropReplayDepth = test.reorgDropReplayDepth
+			require.NoError(t, vm.SetPreference(context.Background(), preferredBlkID))
+		})
+	}
+}
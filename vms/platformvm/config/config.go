@@ -6,6 +6,8 @@ package config
 import (
 	"time"
 
+	"golang.org/x/sync/semaphore"
+
 	"github.com/ava-labs/avalanchego/chains"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow/uptime"
@@ -118,6 +120,11 @@ type Config struct {
 	// on recently created subnets (without this, users need to wait for
 	// [recentlyAcceptedWindowTTL] to pass for activation to occur).
 	UseCurrentHeight bool
+
+	// Limits the number of gossiped txs that are verified concurrently. This
+	// limiter may be shared with other chains. If nil, verification is not
+	// limited.
+	GossipVerificationLimiter *semaphore.Weighted
 }
 
 func (c *Config) IsApricotPhase3Activated(timestamp time.Time) bool {
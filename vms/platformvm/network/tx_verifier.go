@@ -4,12 +4,18 @@
 package network
 
 import (
+	"context"
 	"sync"
 
+	"golang.org/x/sync/semaphore"
+
 	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
 )
 
-var _ TxVerifier = (*LockedTxVerifier)(nil)
+var (
+	_ TxVerifier = (*LockedTxVerifier)(nil)
+	_ TxVerifier = (*LimitedTxVerifier)(nil)
+)
 
 type TxVerifier interface {
 	// VerifyTx verifies that the transaction should be issued into the mempool.
@@ -34,3 +40,38 @@ func NewLockedTxVerifier(lock sync.Locker, txVerifier TxVerifier) *LockedTxVerif
 		txVerifier: txVerifier,
 	}
 }
+
+// LimitedTxVerifier bounds the number of concurrent calls to VerifyTx by
+// acquiring a slot of a, possibly shared, limiter.
+type LimitedTxVerifier struct {
+	limiter    *semaphore.Weighted
+	txVerifier TxVerifier
+}
+
+func (l *LimitedTxVerifier) VerifyTx(tx *txs.Tx) error {
+	if l.limiter == nil {
+		return l.txVerifier.VerifyTx(tx)
+	}
+
+	// Acquire can only fail if the context is cancelled.
+	_ = l.limiter.Acquire(context.Background(), 1)
+	defer l.limiter.Release(1)
+
+	return l.txVerifier.VerifyTx(tx)
+}
+
+// NewLimitedTxVerifier returns a TxVerifier that limits the number of
+// concurrent verifications using [limiter]. If [limiter] is nil, verification
+// is not limited.
+//
+// A slot is held for as long as [txVerifier] runs. If [limiter] is shared
+// between chains, [txVerifier] must not wait on a chain's lock, otherwise a
+// chain whose lock is held would occupy slots without verifying anything.
+// The chain's LockedTxVerifier should wrap the LimitedTxVerifier instead, so
+// that slots are only acquired once the lock is held.
+func NewLimitedTxVerifier(limiter *semaphore.Weighted, txVerifier TxVerifier) *LimitedTxVerifier {
+	return &LimitedTxVerifier{
+		limiter:    limiter,
+		txVerifier: txVerifier,
+	}
+}
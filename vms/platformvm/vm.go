@@ -179,7 +179,12 @@ func (vm *VM) Initialize(
 		validatorManager,
 	)
 
-	txVerifier := network.NewLockedTxVerifier(&txExecutorBackend.Ctx.Lock, vm.manager)
+	// The verification slot is only acquired once the context lock is held,
+	// so that slots aren't held while waiting for the lock.
+	txVerifier := network.NewLockedTxVerifier(
+		&txExecutorBackend.Ctx.Lock,
+		network.NewLimitedTxVerifier(vm.GossipVerificationLimiter, vm.manager),
+	)
 	vm.Network, err = network.New(
 		chainCtx.Log,
 		chainCtx.NodeID,